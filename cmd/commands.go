@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/migrations"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/usecase"
+	"github.com/SawitProRecruitment/UserService/utils"
+)
+
+// runCommand executes one of the administrative subcommands of the service
+// binary instead of starting the HTTP server.
+func runCommand(name string, args []string) error {
+	switch name {
+	case "bootstrap-admin":
+		return bootstrapAdmin(args)
+	case "purge-deleted-users":
+		return purgeDeletedUsers()
+	case "migrate":
+		return migrate(args)
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// bootstrapAdmin creates the first admin user. It refuses to run once any
+// admin exists, further admins are granted through the admin API.
+func bootstrapAdmin(args []string) error {
+	payload := generated.RegisterUserJSONRequestBody{}
+	var email string
+
+	flags := flag.NewFlagSet("bootstrap-admin", flag.ContinueOnError)
+	flags.StringVar(&payload.FullName, "full-name", "", "admin's full name")
+	flags.StringVar(&payload.PhoneNumber, "phone-number", "", "admin's phone number")
+	flags.StringVar(&email, "email", "", "admin's email address, optional")
+	flags.StringVar(&payload.Password, "password", "", "admin's password")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if email != "" {
+		payload.Email = &email
+	}
+
+	phone, err := utils.InitPhone(conf.Phone)
+	if err != nil {
+		return err
+	}
+
+	if phoneNumber, err := phone.Normalize(payload.PhoneNumber); err == nil {
+		payload.PhoneNumber = phoneNumber
+	}
+
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		return err
+	}
+
+	// The payload is not sent through the API, so it is checked against its
+	// schema here.
+	if validationErrors := utils.ValidateSchema(swagger.Components.Schemas["RegisterUserRequest"].Value, payload); len(validationErrors) > 0 {
+		return validationErrors
+	}
+
+	if isPayloadValid, validationErrors := utils.IsRegisterUserPayloadValid(payload, phone); !isPayloadValid {
+		return validationErrors
+	}
+
+	DB, err := utils.InitDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	mailer, err := utils.InitMailer(conf.Mailer)
+	if err != nil {
+		return err
+	}
+
+	userRepo := repository.NewUserRepository(repository.UserRepositoryOptions{DB: DB})
+	emailUsecase := usecase.NewEmailUsecase(usecase.EmailUsecaseOptions{
+		UserRepository:              userRepo,
+		EmailVerificationRepository: repository.NewEmailVerificationRepository(repository.EmailVerificationRepositoryOptions{DB: DB}),
+		Mailer:                      mailer,
+		VerificationURL:             conf.EmailVerification.URL,
+		VerificationTokenDuration:   conf.EmailVerification.TokenDuration,
+	})
+
+	userUsecase := usecase.NewUserUsecase(usecase.UserUsecaseOptions{
+		UserRepository: userRepo,
+		EmailUsecase:   emailUsecase,
+		CryptUtil:      utils.InitCrypt(),
+	})
+
+	user, err := userUsecase.BootstrapAdmin(context.Background(), payload)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin user with id %d\n", user.Id)
+
+	return nil
+}
+
+// purgeDeletedUsers runs the purge job once, for deployments that schedule it
+// externally instead of relying on the server's background job.
+func purgeDeletedUsers() error {
+	DB, err := utils.InitDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	userUsecase := usecase.NewUserUsecase(usecase.UserUsecaseOptions{
+		UserRepository:      repository.NewUserRepository(repository.UserRepositoryOptions{DB: DB}),
+		DeletionGracePeriod: conf.Deletion.GracePeriod,
+	})
+
+	purged, err := userUsecase.PurgeDeletedUsers(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("purged %d deleted users\n", purged)
+
+	return nil
+}
+
+// migrate applies, reverts or lists the migrations embedded from migrations/,
+// or creates a new one there. Concurrent runs wait for each other, so every
+// instance can migrate on start-up.
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|create")
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "up", "down":
+		defaultSteps := 0
+		if args[0] == "down" {
+			defaultSteps = 1
+		}
+		steps := flags.Int("steps", defaultSteps, "number of migrations to run, 0 for all")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		return runMigrations(args[0], *steps)
+	case "status":
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		return migrationStatus()
+	case "create":
+		dir := flags.String("dir", "migrations", "directory to write the scripts into")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return errors.New("usage: migrate create [-dir migrations] <name>")
+		}
+
+		paths, err := utils.CreateMigration(*dir, flags.Arg(0), time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			fmt.Printf("created %s\n", path)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func runMigrations(direction string, steps int) error {
+	DB, err := utils.InitDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	migrator, err := utils.InitMigrator(utils.MigratorOptions{DB: DB, FS: migrations.FS})
+	if err != nil {
+		return err
+	}
+
+	run, verb := migrator.Up, "applied"
+	if direction == "down" {
+		run, verb = migrator.Down, "reverted"
+	}
+
+	done, err := run(context.Background(), steps)
+	for _, migration := range done {
+		fmt.Printf("%s %d_%s\n", verb, migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(done) == 0 {
+		fmt.Println("no migrations to run")
+	}
+
+	return nil
+}
+
+func migrationStatus() error {
+	DB, err := utils.InitDB(conf.Database)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	migrator, err := utils.InitMigrator(utils.MigratorOptions{DB: DB, FS: migrations.FS})
+	if err != nil {
+		return err
+	}
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt.Valid {
+			appliedAt = status.AppliedAt.Time.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
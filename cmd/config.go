@@ -1,9 +1,14 @@
 package main
 
 import (
+	"fmt"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/SawitProRecruitment/UserService/utils"
 	"github.com/joho/godotenv"
 )
@@ -12,15 +17,110 @@ var (
 	conf Config
 )
 
+const (
+	environmentProduction = "production"
+	// environmentDevelopment and environmentTest allow fakes such as the
+	// fake CAPTCHA provider.
+	environmentDevelopment = "development"
+	// environmentTest checks responses against api.yml as well.
+	environmentTest = "test"
+)
+
 type Config struct {
-	Environment string
-	Database    utils.DBOptions
-	Auth        utils.AuthOptions
+	Environment       string
+	TrustedProxies    []*net.IPNet
+	Database          utils.DBOptions
+	Auth              utils.AuthOptions
+	Mailer            utils.MailerOptions
+	EmailVerification EmailVerificationConfig
+	Reauthentication  ReauthenticationConfig
+	Devices           DevicesConfig
+	Captcha           CaptchaConfig
+	Phone             utils.PhoneOptions
+	Deletion          DeletionConfig
+	Export            ExportConfig
+	Impersonation     ImpersonationConfig
+	OIDC              OIDCConfig
+	LDAP              utils.LDAPOptions
+	SAML              utils.SAMLOptions
+	WebAuthn          utils.WebAuthnOptions
+	DPoP              utils.DPoPOptions
+	TLS               utils.TLSOptions
+}
+
+// EmailVerificationConfig controls the verification links mailed to users.
+// The token is appended to URL as the `token` query parameter and expires
+// after TokenDuration.
+type EmailVerificationConfig struct {
+	URL           string
+	TokenDuration time.Duration
+}
+
+// ReauthenticationConfig controls how recently a user must have entered their
+// password to perform sensitive operations, such as changing it.
+type ReauthenticationConfig struct {
+	Window time.Duration
+}
+
+// DevicesConfig controls the alerts sent on logins from new devices. The
+// alert links to RevokeURL with the device's revoke token appended as the
+// `token` query parameter.
+type DevicesConfig struct {
+	RevokeURL string
+}
+
+// CaptchaConfig controls when a CAPTCHA is demanded for logins and
+// registrations: after IdentifierThreshold failed logins for an account or
+// IPThreshold failed attempts from an IP address within Window. CAPTCHAs are
+// never demanded without a Verifier provider.
+type CaptchaConfig struct {
+	Verifier            utils.CaptchaOptions
+	IdentifierThreshold int64
+	IPThreshold         int64
+	Window              time.Duration
+}
+
+// DeletionConfig controls how long soft-deleted accounts can be reactivated
+// and how often the purge job looks for accounts past that period.
+type DeletionConfig struct {
+	GracePeriod   time.Duration
+	PurgeInterval time.Duration
+}
+
+// ExportConfig controls personal data exports. Download links are signed
+// with SigningKey and stay valid for LinkDuration, archives are kept for
+// Retention. Pending exports are processed every JobInterval, exports still
+// processing after ClaimTimeout are processed again.
+type ExportConfig struct {
+	SigningKey   string
+	LinkDuration time.Duration
+	Retention    time.Duration
+	JobInterval  time.Duration
+	ClaimTimeout time.Duration
+}
+
+// ImpersonationConfig controls how long impersonation tokens stay valid.
+type ImpersonationConfig struct {
+	TokenDuration time.Duration
+}
+
+// OIDCConfig lists the external identity providers users can log in with.
+// Redirects to a provider, OpenID Connect or SAML, have to come back within
+// AuthRequestDuration.
+type OIDCConfig struct {
+	Providers           []utils.OIDCProviderOptions
+	AuthRequestDuration time.Duration
 }
 
 func loadConfig() (err error) {
 	godotenv.Load()
 
+	conf.Environment = getEnv("ENVIRONMENT", environmentProduction)
+	conf.TrustedProxies, err = getCIDRListEnv("TRUSTED_PROXIES")
+	if err != nil {
+		return err
+	}
+
 	conf.Database.DSN = os.Getenv("DATABASE_DSN")
 	conf.Auth.JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
 	jwtExpiryDurationVar := os.Getenv("JWT_EXPIRY_DURATION")
@@ -30,5 +130,305 @@ func loadConfig() (err error) {
 		return err
 	}
 
+	conf.Mailer.Provider = getEnv("MAIL_PROVIDER", utils.MailerProviderSMTP)
+	conf.Mailer.Addr = os.Getenv("SMTP_ADDR")
+	conf.Mailer.Username = os.Getenv("SMTP_USERNAME")
+	conf.Mailer.Password = os.Getenv("SMTP_PASSWORD")
+	conf.Mailer.From = os.Getenv("MAIL_FROM")
+
+	conf.EmailVerification.URL = os.Getenv("EMAIL_VERIFICATION_URL")
+	conf.EmailVerification.TokenDuration, err = getDurationEnv("EMAIL_VERIFICATION_TOKEN_DURATION", 24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	conf.Reauthentication.Window, err = getDurationEnv("REAUTHENTICATION_WINDOW", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.Devices.RevokeURL = os.Getenv("NEW_DEVICE_REVOKE_URL")
+
+	conf.Captcha.Verifier.Provider = os.Getenv("CAPTCHA_PROVIDER")
+	conf.Captcha.Verifier.VerifyURL = os.Getenv("CAPTCHA_VERIFY_URL")
+	conf.Captcha.Verifier.Secret = os.Getenv("CAPTCHA_SECRET")
+	conf.Captcha.Verifier.FakeToken = os.Getenv("CAPTCHA_FAKE_TOKEN")
+	conf.Captcha.Verifier.AllowFake = conf.Environment == environmentDevelopment || conf.Environment == environmentTest
+	conf.Captcha.Verifier.Timeout, err = getDurationEnv("CAPTCHA_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return err
+	}
+
+	conf.Captcha.IdentifierThreshold, err = getIntEnv("CAPTCHA_LOGIN_THRESHOLD", 3)
+	if err != nil {
+		return err
+	}
+
+	conf.Captcha.IPThreshold, err = getIntEnv("CAPTCHA_IP_THRESHOLD", 10)
+	if err != nil {
+		return err
+	}
+
+	conf.Captcha.Window, err = getDurationEnv("CAPTCHA_WINDOW", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.Deletion.GracePeriod, err = getDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	conf.Deletion.PurgeInterval, err = getDurationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return err
+	}
+
+	conf.Export.SigningKey = os.Getenv("EXPORT_SIGNING_KEY")
+	conf.Export.LinkDuration, err = getDurationEnv("EXPORT_LINK_DURATION", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.Export.Retention, err = getDurationEnv("EXPORT_RETENTION", 7*24*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	conf.Export.JobInterval, err = getDurationEnv("EXPORT_JOB_INTERVAL", 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	conf.Export.ClaimTimeout, err = getDurationEnv("EXPORT_CLAIM_TIMEOUT", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.Impersonation.TokenDuration, err = getDurationEnv("IMPERSONATION_TOKEN_DURATION", 15*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.OIDC.Providers, err = getOIDCProviders()
+	if err != nil {
+		return err
+	}
+
+	conf.OIDC.AuthRequestDuration, err = getDurationEnv("OIDC_AUTH_REQUEST_DURATION", 10*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.Phone.AllowedCountries = getListEnv("PHONE_ALLOWED_COUNTRIES")
+	if len(conf.Phone.AllowedCountries) == 0 {
+		conf.Phone.AllowedCountries = []string{"ID"}
+	}
+
+	conf.LDAP.URL = os.Getenv("LDAP_URL")
+	conf.LDAP.StartTLS = os.Getenv("LDAP_START_TLS") == "true"
+	conf.LDAP.BindDN = os.Getenv("LDAP_BIND_DN")
+	conf.LDAP.BindPassword = os.Getenv("LDAP_BIND_PASSWORD")
+	conf.LDAP.BaseDN = os.Getenv("LDAP_BASE_DN")
+	conf.LDAP.UserFilter = getEnv("LDAP_USER_FILTER", "(mail=%s)")
+	conf.LDAP.Attributes.ID = os.Getenv("LDAP_ATTR_ID")
+	conf.LDAP.Attributes.FullName = getEnv("LDAP_ATTR_FULL_NAME", "cn")
+	conf.LDAP.Attributes.Email = getEnv("LDAP_ATTR_EMAIL", "mail")
+	conf.LDAP.Attributes.PhoneNumber = getEnv("LDAP_ATTR_PHONE_NUMBER", "telephoneNumber")
+	conf.LDAP.Timeout, err = getDurationEnv("LDAP_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return err
+	}
+
+	conf.SAML.BaseURL = os.Getenv("SAML_SP_BASE_URL")
+	conf.SAML.Key = os.Getenv("SAML_SP_KEY")
+	conf.SAML.Certificate = os.Getenv("SAML_SP_CERTIFICATE")
+	conf.SAML.Providers, err = getSAMLProviders()
+	if err != nil {
+		return err
+	}
+
+	conf.WebAuthn.RPID = os.Getenv("WEBAUTHN_RP_ID")
+	conf.WebAuthn.RPName = os.Getenv("WEBAUTHN_RP_NAME")
+	conf.WebAuthn.Origins = getListEnv("WEBAUTHN_ORIGINS")
+	conf.WebAuthn.Timeout, err = getDurationEnv("WEBAUTHN_TIMEOUT", 5*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.DPoP.ProofLifetime, err = getDurationEnv("DPOP_PROOF_LIFETIME", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.TLS.CertFile = os.Getenv("TLS_CERT_FILE")
+	conf.TLS.KeyFile = os.Getenv("TLS_KEY_FILE")
+	conf.TLS.MinVersion = getEnv("TLS_MIN_VERSION", "1.2")
+	conf.TLS.CipherSuites = getListEnv("TLS_CIPHER_SUITES")
+	conf.TLS.ReloadInterval, err = getDurationEnv("TLS_RELOAD_INTERVAL", time.Minute)
+	if err != nil {
+		return err
+	}
+
+	conf.TLS.ClientCAFile = os.Getenv("MTLS_CLIENT_CA_FILE")
+	conf.TLS.RequireClientCert = os.Getenv("MTLS_REQUIRE_CLIENT_CERT") == "true"
+	conf.TLS.Services, err = getMTLSServices()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// getOIDCProviders reads the providers named in the comma separated
+// OIDC_PROVIDERS. Each is configured with OIDC_<NAME>_ISSUER_URL,
+// OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and
+// OIDC_<NAME>_REDIRECT_URL.
+func getOIDCProviders() ([]utils.OIDCProviderOptions, error) {
+	providers := make([]utils.OIDCProviderOptions, 0)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := utils.OIDCProviderOptions{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+
+		if provider.IssuerURL == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("oidc provider %s needs %sISSUER_URL, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// getSAMLProviders reads the providers named in the comma separated
+// SAML_PROVIDERS. Each is configured with SAML_<NAME>_IDP_METADATA and the
+// optional SAML_<NAME>_ATTR_FULL_NAME, SAML_<NAME>_ATTR_EMAIL and
+// SAML_<NAME>_ATTR_PHONE_NUMBER. Names share the identities of OpenID Connect
+// providers and the LDAP directory, so they must differ from theirs.
+func getSAMLProviders() ([]utils.SAMLProviderOptions, error) {
+	taken := map[string]bool{model.IdentityProviderLDAP: true}
+	for _, provider := range conf.OIDC.Providers {
+		taken[provider.Name] = true
+	}
+
+	providers := make([]utils.SAMLProviderOptions, 0)
+	for _, name := range strings.Split(os.Getenv("SAML_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if taken[name] {
+			return nil, fmt.Errorf("saml provider %s is also configured as another identity provider", name)
+		}
+
+		prefix := "SAML_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := utils.SAMLProviderOptions{
+			Name:        name,
+			IDPMetadata: os.Getenv(prefix + "IDP_METADATA"),
+			Attributes: utils.SAMLAttributes{
+				FullName:    getEnv(prefix+"ATTR_FULL_NAME", "urn:oid:2.16.840.1.113730.3.1.241"),
+				Email:       getEnv(prefix+"ATTR_EMAIL", "urn:oid:0.9.2342.19200300.100.1.3"),
+				PhoneNumber: getEnv(prefix+"ATTR_PHONE_NUMBER", "urn:oid:2.5.4.20"),
+			},
+		}
+
+		if provider.IDPMetadata == "" {
+			return nil, fmt.Errorf("saml provider %s needs %sIDP_METADATA", name, prefix)
+		}
+
+		taken[name] = true
+		providers = append(providers, provider)
+	}
+
+	if len(providers) > 0 && (conf.SAML.BaseURL == "" || conf.SAML.Key == "" || conf.SAML.Certificate == "") {
+		return nil, fmt.Errorf("saml providers need SAML_SP_BASE_URL, SAML_SP_KEY and SAML_SP_CERTIFICATE")
+	}
+
+	return providers, nil
+}
+
+// getMTLSServices reads the comma separated MTLS_SERVICES, each item mapping
+// a service identity onto the URI SAN, DNS SAN or common name of its client
+// certificate as `<service>=<name>`. A service may be listed more than once.
+func getMTLSServices() (map[string]string, error) {
+	services := make(map[string]string)
+	for _, item := range getListEnv("MTLS_SERVICES") {
+		service, name, ok := strings.Cut(item, "=")
+		service, name = strings.TrimSpace(service), strings.TrimSpace(name)
+		if !ok || service == "" || name == "" {
+			return nil, fmt.Errorf("mtls service %q is not of the form <service>=<certificate name>", item)
+		}
+
+		if other, taken := services[name]; taken && other != service {
+			return nil, fmt.Errorf("certificate name %s is mapped to both %s and %s", name, other, service)
+		}
+
+		services[name] = service
+	}
+
+	return services, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+func getDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+func getIntEnv(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// getCIDRListEnv parses the comma separated IP ranges in CIDR notation of key.
+func getCIDRListEnv(key string) ([]*net.IPNet, error) {
+	ranges := make([]*net.IPNet, 0)
+	for _, value := range getListEnv(key) {
+		_, ipRange, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		ranges = append(ranges, ipRange)
+	}
+
+	return ranges, nil
+}
+
+// getListEnv splits the comma separated value of key, dropping empty items.
+func getListEnv(key string) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
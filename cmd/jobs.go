@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/usecase"
+
+	"github.com/labstack/gommon/log"
+)
+
+// runPurgeJob periodically hard-deletes accounts whose deletion grace period
+// has passed. It returns when ctx is done.
+func runPurgeJob(ctx context.Context, userUsecase usecase.UserUsecaseInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := userUsecase.PurgeDeletedUsers(ctx)
+			if err != nil {
+				log.Error(err)
+				continue
+			}
+
+			if purged > 0 {
+				log.Infof("purged %d deleted users", purged)
+			}
+		}
+	}
+}
+
+// runExportJob periodically assembles the pending personal data exports and
+// removes the ones past their retention. It returns when ctx is done.
+func runExportJob(ctx context.Context, exportUsecase usecase.ExportUsecaseInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				processed, err := exportUsecase.ProcessPendingExport(ctx)
+				if err != nil {
+					log.Error(err)
+				}
+
+				if !processed || err != nil {
+					break
+				}
+			}
+
+			if _, err := exportUsecase.PurgeExpiredExports(ctx); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
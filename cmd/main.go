@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"net/http"
+	"os"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/handler"
@@ -10,6 +14,7 @@ import (
 	"github.com/SawitProRecruitment/UserService/utils"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 )
 
 func init() {
@@ -19,14 +24,49 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	e := echo.New()
 	server, err := newServer()
 	if err != nil {
 		panic(err)
 	}
 
+	go runPurgeJob(context.Background(), server.UserUsecase, conf.Deletion.PurgeInterval)
+	go runExportJob(context.Background(), server.ExportUsecase, conf.Export.JobInterval)
+
+	e.HTTPErrorHandler = server.HTTPErrorHandler
+	e.IPExtractor = ipExtractor(conf.TrustedProxies)
+	e.Use(middleware.RequestID())
+	e.Use(server.Authorize)
+	e.Use(server.ValidateRequest)
 	generated.RegisterHandlers(e, server)
-	e.Logger.Fatal(e.Start(":1323"))
+	e.Logger.Fatal(e.StartServer(&http.Server{
+		Addr:      ":1323",
+		TLSConfig: server.TLSUtil.Config(),
+	}))
+}
+
+// ipExtractor tells the client's IP address, which CAPTCHAs and security
+// alerts rely on. Without trusted proxies it is the peer address, as any
+// client can send X-Forwarded-For. Behind proxies it is the first address in
+// X-Forwarded-For not belonging to one of them.
+func ipExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, trustedProxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(trustedProxy))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...)
 }
 
 func newServer() (*handler.Server, error) {
@@ -40,24 +80,207 @@ func newServer() (*handler.Server, error) {
 		return nil, err
 	}
 
+	swagger, err := generated.GetSwagger()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := utils.InitSigner(utils.SignerOptions{SecretKey: conf.Export.SigningKey})
+	if err != nil {
+		return nil, err
+	}
+
+	mailer, err := utils.InitMailer(conf.Mailer)
+	if err != nil {
+		return nil, err
+	}
+
+	oidc, err := utils.InitOIDC(context.Background(), conf.OIDC.Providers)
+	if err != nil {
+		return nil, err
+	}
+
+	saml, err := utils.InitSAML(context.Background(), conf.SAML)
+	if err != nil {
+		return nil, err
+	}
+
+	webAuthn, err := utils.InitWebAuthn(conf.WebAuthn)
+	if err != nil {
+		return nil, err
+	}
+
+	dpop, err := utils.InitDPoP(conf.DPoP)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsUtil, err := utils.InitTLS(conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	phone, err := utils.InitPhone(conf.Phone)
+	if err != nil {
+		return nil, err
+	}
+
+	var captcha utils.CaptchaVerifierInterface
+	if conf.Captcha.Verifier.Provider != "" {
+		captcha, err = utils.InitCaptcha(conf.Captcha.Verifier)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	crypt := utils.InitCrypt()
 
 	userRepo := repository.NewUserRepository(repository.UserRepositoryOptions{DB: DB})
-	authUsecase := usecase.NewAuthUsecase(usecase.AuthUsecaseOptions{
+	roleRepo := repository.NewRoleRepository(repository.RoleRepositoryOptions{DB: DB})
+	exportRepo := repository.NewExportRepository(repository.ExportRepositoryOptions{DB: DB})
+	impersonationRepo := repository.NewImpersonationRepository(repository.ImpersonationRepositoryOptions{DB: DB})
+	apiKeyRepo := repository.NewAPIKeyRepository(repository.APIKeyRepositoryOptions{DB: DB})
+	emailVerificationRepo := repository.NewEmailVerificationRepository(repository.EmailVerificationRepositoryOptions{DB: DB})
+	identityRepo := repository.NewIdentityRepository(repository.IdentityRepositoryOptions{DB: DB})
+	webAuthnRepo := repository.NewWebAuthnRepository(repository.WebAuthnRepositoryOptions{DB: DB})
+	deviceRepo := repository.NewDeviceRepository(repository.DeviceRepositoryOptions{DB: DB})
+	authFailureRepo := repository.NewAuthFailureRepository(repository.AuthFailureRepositoryOptions{DB: DB})
+	authenticators := []usecase.AuthenticatorInterface{usecase.NewPasswordAuthenticator(usecase.PasswordAuthenticatorOptions{
 		UserRepository: userRepo,
-		AuthUtil:       auth,
 		CryptUtil:      crypt,
+	})}
+
+	if conf.LDAP.URL != "" {
+		ldap, err := utils.InitLDAP(conf.LDAP)
+		if err != nil {
+			return nil, err
+		}
+
+		authenticators = append(authenticators, usecase.NewLDAPAuthenticator(usecase.LDAPAuthenticatorOptions{
+			UserRepository:     userRepo,
+			IdentityRepository: identityRepo,
+			LDAPUtil:           ldap,
+			PhoneUtil:          phone,
+		}))
+	}
+
+	emailUsecase := usecase.NewEmailUsecase(usecase.EmailUsecaseOptions{
+		UserRepository:              userRepo,
+		EmailVerificationRepository: emailVerificationRepo,
+		Mailer:                      mailer,
+		VerificationURL:             conf.EmailVerification.URL,
+		VerificationTokenDuration:   conf.EmailVerification.TokenDuration,
+	})
+
+	deviceRecognizer := usecase.NewDeviceRecognizer(usecase.DeviceRecognizerOptions{
+		DeviceRepository: deviceRepo,
+		Notifier:         emailUsecase,
+		DeviceRevokeURL:  conf.Devices.RevokeURL,
+	})
+
+	authUsecase := usecase.NewAuthUsecase(usecase.AuthUsecaseOptions{
+		UserRepository:      userRepo,
+		RoleRepository:      roleRepo,
+		APIKeyRepository:    apiKeyRepo,
+		DeviceRepository:    deviceRepo,
+		AuthUtil:            auth,
+		CryptUtil:           crypt,
+		Authenticators:      authenticators,
+		DeviceRecognizer:    deviceRecognizer,
+		DeletionGracePeriod: conf.Deletion.GracePeriod,
+	})
+
+	captchaUsecase := usecase.NewCaptchaUsecase(usecase.CaptchaUsecaseOptions{
+		AuthFailureRepository: authFailureRepo,
+		Verifier:              captcha,
+		IdentifierThreshold:   conf.Captcha.IdentifierThreshold,
+		IPThreshold:           conf.Captcha.IPThreshold,
+		Window:                conf.Captcha.Window,
 	})
 
 	userUsecase := usecase.NewUserUsecase(usecase.UserUsecaseOptions{
+		UserRepository:         userRepo,
+		EmailUsecase:           emailUsecase,
+		CryptUtil:              crypt,
+		DeletionGracePeriod:    conf.Deletion.GracePeriod,
+		ReauthenticationWindow: conf.Reauthentication.Window,
+	})
+
+	adminUsecase := usecase.NewAdminUsecase(usecase.AdminUsecaseOptions{
 		UserRepository: userRepo,
-		CryptUtil:      crypt,
+		RoleRepository: roleRepo,
+	})
+
+	exportUsecase := usecase.NewExportUsecase(usecase.ExportUsecaseOptions{
+		ExportRepository:            exportRepo,
+		UserRepository:              userRepo,
+		APIKeyRepository:            apiKeyRepo,
+		IdentityRepository:          identityRepo,
+		WebAuthnRepository:          webAuthnRepo,
+		DeviceRepository:            deviceRepo,
+		ImpersonationRepository:     impersonationRepo,
+		AuthFailureRepository:       authFailureRepo,
+		EmailVerificationRepository: emailVerificationRepo,
+		Signer:                      signer,
+		LinkDuration:                conf.Export.LinkDuration,
+		Retention:                   conf.Export.Retention,
+		ClaimTimeout:                conf.Export.ClaimTimeout,
+	})
+
+	impersonationUsecase := usecase.NewImpersonationUsecase(usecase.ImpersonationUsecaseOptions{
+		UserRepository:          userRepo,
+		RoleRepository:          roleRepo,
+		ImpersonationRepository: impersonationRepo,
+		AuthUtil:                auth,
+		TokenDuration:           conf.Impersonation.TokenDuration,
+	})
+
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(usecase.APIKeyUsecaseOptions{
+		APIKeyRepository: apiKeyRepo,
+		RoleRepository:   roleRepo,
+	})
+
+	identityUsecase := usecase.NewIdentityUsecase(usecase.IdentityUsecaseOptions{
+		UserRepository:         userRepo,
+		RoleRepository:         roleRepo,
+		IdentityRepository:     identityRepo,
+		AuthUtil:               auth,
+		OIDCUtil:               oidc,
+		SAMLUtil:               saml,
+		PhoneUtil:              phone,
+		DeviceRecognizer:       deviceRecognizer,
+		AuthRequestDuration:    conf.OIDC.AuthRequestDuration,
+		ReauthenticationWindow: conf.Reauthentication.Window,
+	})
+
+	webAuthnUsecase := usecase.NewWebAuthnUsecase(usecase.WebAuthnUsecaseOptions{
+		UserRepository:         userRepo,
+		RoleRepository:         roleRepo,
+		WebAuthnRepository:     webAuthnRepo,
+		AuthUtil:               auth,
+		WebAuthnUtil:           webAuthn,
+		DeviceRecognizer:       deviceRecognizer,
+		ChallengeDuration:      conf.WebAuthn.Timeout,
+		ReauthenticationWindow: conf.Reauthentication.Window,
 	})
 
 	opts := handler.NewServerOptions{
-		AuthUsecase: authUsecase,
-		UserUsecase: userUsecase,
-		AuthUtil:    auth,
+		AdminUsecase:         adminUsecase,
+		APIKeyUsecase:        apiKeyUsecase,
+		AuthUsecase:          authUsecase,
+		CaptchaUsecase:       captchaUsecase,
+		EmailUsecase:         emailUsecase,
+		ExportUsecase:        exportUsecase,
+		IdentityUsecase:      identityUsecase,
+		ImpersonationUsecase: impersonationUsecase,
+		UserUsecase:          userUsecase,
+		WebAuthnUsecase:      webAuthnUsecase,
+		AuthUtil:             auth,
+		DPoPUtil:             dpop,
+		PhoneUtil:            phone,
+		TLSUtil:              tlsUtil,
+		Swagger:              swagger,
+		ValidateResponses:    conf.Environment == environmentTest,
 	}
 
 	return handler.NewServer(opts), nil
@@ -1,22 +0,0 @@
-/**
-  This is the SQL script that will be used to initialize the database schema.
-  We will evaluate you based on how well you design your database.
-  1. How you design the tables.
-  2. How you choose the data types and keys.
-  3. How you name the fields.
-  In this assignment we will use PostgreSQL as the database.
-  */
-
-/** This is test table. Remove this table and replace with your own tables. */
-CREATE TABLE IF NOT EXISTS users (
-	"id" serial PRIMARY KEY,
-	"full_name" VARCHAR(100) NOT NULL,
-    "phone_number" VARCHAR(25) NOT NULL UNIQUE,
-    "password" TEXT NOT NULL,
-    "login_count" INTEGER NOT NULL DEFAULT 0,
-    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
-    "updated_at" TIMESTAMP,
-    "deleted_at" TIMESTAMP
-);
-
-CREATE INDEX IF NOT EXISTS idx_users_phone_number ON users(phone_number);
\ No newline at end of file
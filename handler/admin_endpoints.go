@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/guregu/null/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const defaultListUsersLimit = 20
+
+func (s *Server) ListUsers(ctx echo.Context, params generated.ListUsersParams) error {
+	if isParamsValid, validationErrors := utils.IsListUsersParamsValid(params); !isParamsValid {
+		return validationErrors
+	}
+
+	filter := model.UserFilter{
+		CreatedFrom: null.TimeFromPtr(params.CreatedFrom),
+		CreatedTo:   null.TimeFromPtr(params.CreatedTo),
+		Verified:    null.BoolFromPtr(params.Verified),
+		Limit:       defaultListUsersLimit,
+	}
+
+	if params.Limit != nil {
+		filter.Limit = *params.Limit
+	}
+
+	if params.Cursor != nil {
+		filter.AfterId, _ = utils.DecodeCursor(*params.Cursor)
+	}
+
+	if params.Q != nil {
+		filter.Search = *params.Q
+	}
+
+	if params.Status != nil {
+		filter.Status = string(*params.Status)
+	}
+
+	users, nextCursor, err := s.AdminUsecase.ListUsers(ctx.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+
+	data := &generated.ListUsersResponseData{Users: make([]generated.AdminUser, 0, len(users))}
+	for _, user := range users {
+		data.Users = append(data.Users, toAdminUser(user))
+	}
+
+	if nextCursor != "" {
+		data.NextCursor = &nextCursor
+	}
+
+	resp := generated.ListUsersResponse{
+		Success: true,
+		Message: "successfully list users",
+		Data:    data,
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) GetUser(ctx echo.Context, id int64) error {
+	user, err := s.AdminUsecase.GetUser(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	adminUser := toAdminUser(user)
+	resp := generated.GetUserResponse{
+		Success: true,
+		Message: "successfully get user",
+		Data:    &adminUser,
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) UpdateUser(ctx echo.Context, id int64) error {
+	req := generated.UpdateUserJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	s.normalizePhoneNumber(req.PhoneNumber)
+	if isPayloadValid, validationErrors := utils.IsAdminUpdateUserPayloadValid(req, s.PhoneUtil); !isPayloadValid {
+		return validationErrors
+	}
+
+	if err := s.AdminUsecase.UpdateUser(ctx.Request().Context(), id, req); err != nil {
+		return err
+	}
+
+	resp := generated.UpdateUserResponse{
+		Success: true,
+		Message: "successfully update user",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ChangeUserStatus(ctx echo.Context, id int64) error {
+	req := generated.ChangeUserStatusJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	actorId, _ := ctx.Get(userIdContextKey).(int64)
+	if err := s.AdminUsecase.ChangeUserStatus(ctx.Request().Context(), actorId, id, req); err != nil {
+		return err
+	}
+
+	resp := generated.ChangeUserStatusResponse{
+		Success: true,
+		Message: "successfully change user status",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func toAdminUser(user model.User) generated.AdminUser {
+	return generated.AdminUser{
+		Id:            user.Id,
+		FullName:      user.FullName,
+		PhoneNumber:   user.PhoneNumber,
+		Email:         user.Email.Ptr(),
+		Roles:         user.Roles,
+		Status:        generated.UserStatus(user.Status),
+		StatusReason:  user.StatusReason.Ptr(),
+		Verified:      user.PhoneVerifiedAt.Valid,
+		LoginCount:    user.LoginCount,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt.Ptr(),
+		EmailVerified: user.EmailVerifiedAt.Valid,
+	}
+}
+
+func (s *Server) ImpersonateUser(ctx echo.Context, id int64) error {
+	req := generated.ImpersonateUserJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	actorId, _ := ctx.Get(userIdContextKey).(int64)
+	jwt, impersonation, err := s.ImpersonationUsecase.StartImpersonation(ctx.Request().Context(), actorId, id, req)
+	if err != nil {
+		return err
+	}
+
+	resp := generated.ImpersonateUserResponse{
+		Success: true,
+		Message: "successfully impersonate user",
+		Data: &generated.ImpersonateUserResponseData{
+			Jwt:       jwt,
+			ExpiresAt: impersonation.ExpiresAt,
+		},
+	}
+
+	return ctx.JSON(http.StatusCreated, resp)
+}
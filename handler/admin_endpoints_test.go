@@ -0,0 +1,456 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_ListUsers(t *testing.T) {
+	users := []model.User{
+		{
+			Id:          1,
+			FullName:    "John Doe",
+			PhoneNumber: "+6285912345678",
+			Roles:       []string{model.RoleUser},
+			Status:      model.UserStatusActive,
+			CreatedAt:   time.Now(),
+		},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		q := "john"
+		limit := 1
+		cursor := utils.EncodeCursor(5)
+		verified := false
+		params := generated.ListUsersParams{Q: &q, Limit: &limit, Cursor: &cursor, Verified: &verified}
+		filter := model.UserFilter{Search: q, Limit: limit, AfterId: 5, Verified: null.BoolFrom(false)}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().ListUsers(gomock.Any(), filter).Times(1).Return(users, utils.EncodeCursor(1), nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.ListUsers(c, params))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.ListUsersResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Len(t, response.Data.Users, 1)
+		require.Equal(t, users[0].Id, response.Data.Users[0].Id)
+		require.Equal(t, model.UserStatusActive, string(response.Data.Users[0].Status))
+		require.NotNil(t, response.Data.NextCursor)
+	})
+
+	t.Run("failed - invalid params", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		limit := 1000
+		cursor := "not-a-cursor"
+		params := generated.ListUsersParams{Limit: &limit, Cursor: &cursor}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{})
+		render(s, c, s.ListUsers(c, params))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - list users return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().ListUsers(gomock.Any(), gomock.Any()).
+			Times(1).Return(nil, "", utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.ListUsers(c, generated.ListUsersParams{}))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_GetUser(t *testing.T) {
+	id := int64(1)
+	user := model.User{
+		Id:              id,
+		FullName:        "John Doe",
+		PhoneNumber:     "+6285912345678",
+		Roles:           []string{model.RoleAdmin},
+		Status:          model.UserStatusActive,
+		PhoneVerifiedAt: null.TimeFrom(time.Now()),
+		CreatedAt:       time.Now(),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users/1", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().GetUser(gomock.Any(), id).Times(1).Return(user, nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.GetUser(c, id))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.GetUserResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Equal(t, id, response.Data.Id)
+		require.Equal(t, user.Roles, response.Data.Roles)
+		require.True(t, response.Data.Verified)
+	})
+
+	t.Run("failed - get user return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users/1", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().GetUser(gomock.Any(), id).
+			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusNotFound, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.GetUser(c, id))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+}
+
+func TestHandler_UpdateUser(t *testing.T) {
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	id := int64(1)
+	fullName := "John Doe"
+	roles := []string{model.RoleAdmin}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.UpdateUserJSONRequestBody{FullName: &fullName, Roles: &roles}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/admin/users/1", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().UpdateUser(gomock.Any(), id, payload).Times(1).Return(nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase, PhoneUtil: phone})
+		render(s, c, s.UpdateUser(c, id))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.UpdateUserResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotEmpty(t, response.Message)
+	})
+
+	t.Run("failed - invalid fields", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "085912345678"
+		emptyRoles := []string{}
+		payload := generated.UpdateUserJSONRequestBody{PhoneNumber: &phoneNumber, Roles: &emptyRoles}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/admin/users/1", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.UpdateUser(c, id))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - update user return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.UpdateUserJSONRequestBody{FullName: &fullName}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPatch, "/v1/admin/users/1", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().UpdateUser(gomock.Any(), id, payload).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusConflict, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase, PhoneUtil: phone})
+		render(s, c, s.UpdateUser(c, id))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_ChangeUserStatus(t *testing.T) {
+	actorId := int64(1)
+	id := int64(10)
+
+	payload := generated.ChangeUserStatusJSONRequestBody{
+		Status: generated.UserStatus(model.UserStatusSuspended),
+		Reason: "spamming other users",
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/users/10/status", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().ChangeUserStatus(gomock.Any(), actorId, id, payload).Times(1).Return(nil)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, actorId)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.ChangeUserStatus(c, id))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.ChangeUserStatusResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotEmpty(t, response.Message)
+	})
+
+	t.Run("failed - invalid fields", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		invalidPayload := generated.ChangeUserStatusJSONRequestBody{Status: "banned"}
+		payloadJSON, err := json.Marshal(invalidPayload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/users/10/status", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		swagger, err := generated.GetSwagger()
+		require.NoError(t, err)
+
+		c := e.NewContext(req, rec)
+		c.SetPath("/v1/admin/users/:id/status")
+		c.SetParamNames("id")
+		c.SetParamValues("10")
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		render(s, c, s.ValidateRequest(func(c echo.Context) error { return s.ChangeUserStatus(c, id) })(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid status transition", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, "/v1/admin/users/10/status", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().ChangeUserStatus(gomock.Any(), actorId, id, payload).
+			Times(1).Return(utils.WrapWithKey(errors.New("invalid"), utils.ErrorCode(http.StatusConflict), "INVALID_STATUS_TRANSITION", ""))
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, actorId)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.ChangeUserStatus(c, id))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_STATUS_TRANSITION", *response.Code)
+	})
+}
+
+func TestHandler_ImpersonateUser(t *testing.T) {
+	actorId := int64(1)
+	id := int64(10)
+
+	payload := generated.ImpersonateUserJSONRequestBody{Reason: "debugging checkout"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/10/impersonate", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		impersonation := model.Impersonation{Id: 5, ActorId: null.IntFrom(actorId), UserId: id, ExpiresAt: time.Now().Add(15 * time.Minute)}
+		mockImpersonationUsecase := mocks.NewMockImpersonationUsecaseInterface(ctrl)
+		mockImpersonationUsecase.EXPECT().StartImpersonation(gomock.Any(), actorId, id, payload).
+			Times(1).Return("thisisjwt", impersonation, nil)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, actorId)
+		s := NewServer(NewServerOptions{ImpersonationUsecase: mockImpersonationUsecase})
+		render(s, c, s.ImpersonateUser(c, id))
+
+		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+
+		var response generated.ImpersonateUserResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotNil(t, response.Data)
+		require.Equal(t, "thisisjwt", response.Data.Jwt)
+	})
+
+	t.Run("failed - invalid fields", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payloadJSON, err := json.Marshal(generated.ImpersonateUserJSONRequestBody{Reason: "a"})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/10/impersonate", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		swagger, err := generated.GetSwagger()
+		require.NoError(t, err)
+
+		c := e.NewContext(req, rec)
+		c.SetPath("/v1/admin/users/:id/impersonate")
+		c.SetParamNames("id")
+		c.SetParamValues("10")
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		render(s, c, s.ValidateRequest(func(c echo.Context) error { return s.ImpersonateUser(c, id) })(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - target is an admin", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/10/impersonate", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockImpersonationUsecase := mocks.NewMockImpersonationUsecaseInterface(ctrl)
+		mockImpersonationUsecase.EXPECT().StartImpersonation(gomock.Any(), actorId, id, payload).
+			Times(1).Return("", model.Impersonation{}, utils.NewErrorWithCode(http.StatusForbidden, "Admins Cannot Be Impersonated."))
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, actorId)
+		s := NewServer(NewServerOptions{ImpersonationUsecase: mockImpersonationUsecase})
+		render(s, c, s.ImpersonateUser(c, id))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+}
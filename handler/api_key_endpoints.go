@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) ListApiKeys(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	apiKeys, err := s.APIKeyUsecase.ListAPIKeys(ctx.Request().Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	data := make([]generated.ApiKey, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		data = append(data, toApiKey(apiKey, ""))
+	}
+
+	resp := generated.ListApiKeysResponse{
+		Success: true,
+		Message: "successfully list api keys",
+		Data:    &generated.ListApiKeysResponseData{ApiKeys: data},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) CreateApiKey(ctx echo.Context) error {
+	req := generated.CreateApiKeyJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	if isPayloadValid, validationErrors := utils.IsCreateAPIKeyPayloadValid(req); !isPayloadValid {
+		return validationErrors
+	}
+
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	apiKey, key, err := s.APIKeyUsecase.CreateAPIKey(ctx.Request().Context(), userId, req)
+	if err != nil {
+		return err
+	}
+
+	data := toApiKey(apiKey, key)
+	resp := generated.CreateApiKeyResponse{
+		Success: true,
+		Message: "successfully create api key",
+		Data:    &data,
+	}
+
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) RevokeApiKey(ctx echo.Context, id int64) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	if err := s.APIKeyUsecase.RevokeAPIKey(ctx.Request().Context(), userId, id); err != nil {
+		return err
+	}
+
+	resp := generated.RevokeApiKeyResponse{
+		Success: true,
+		Message: "successfully revoke api key",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func toApiKey(apiKey model.APIKey, key string) generated.ApiKey {
+	data := generated.ApiKey{
+		Id:         apiKey.Id,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt.Ptr(),
+		LastUsedAt: apiKey.LastUsedAt.Ptr(),
+		CreatedAt:  apiKey.CreatedAt,
+	}
+
+	if key != "" {
+		data.Key = &key
+	}
+
+	return data
+}
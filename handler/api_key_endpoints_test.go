@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_ListApiKeys(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/api-keys", nil)
+
+		apiKeys := []model.APIKey{{Id: 3, UserId: userId, Name: "backup script", Prefix: "usk_01234567", Scopes: []string{}, CreatedAt: time.Now()}}
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().ListAPIKeys(gomock.Any(), userId).Times(1).Return(apiKeys, nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.ListApiKeys(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.ListApiKeysResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Len(t, response.Data.ApiKeys, 1)
+		require.Equal(t, "usk_01234567", response.Data.ApiKeys[0].Prefix)
+		require.Nil(t, response.Data.ApiKeys[0].Key)
+	})
+
+	t.Run("failed - list api keys return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/api-keys", nil)
+
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().ListAPIKeys(gomock.Any(), userId).
+			Times(1).Return(nil, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.ListApiKeys(c))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_CreateApiKey(t *testing.T) {
+	userId := int64(10)
+	payload := generated.CreateApiKeyJSONRequestBody{Name: "backup script"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/api-keys", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		apiKey := model.APIKey{Id: 3, UserId: userId, Name: payload.Name, Prefix: "usk_01234567", Scopes: []string{}, CreatedAt: time.Now()}
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().CreateAPIKey(gomock.Any(), userId, payload).Times(1).Return(apiKey, "usk_0123456789", nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.CreateApiKey(c))
+
+		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+
+		var response generated.CreateApiKeyResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotNil(t, response.Data.Key)
+		require.Equal(t, "usk_0123456789", *response.Data.Key)
+	})
+
+	t.Run("failed - invalid fields", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		payloadJSON, err := json.Marshal(generated.CreateApiKeyJSONRequestBody{Name: "a", ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/api-keys", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{})
+		render(s, c, s.CreateApiKey(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - scope is not granted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/api-keys", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().CreateAPIKey(gomock.Any(), userId, payload).
+			Times(1).Return(model.APIKey{}, "", utils.WrapWithKey(sql.ErrNoRows, utils.ErrorCode(http.StatusBadRequest), "INVALID_API_KEY_SCOPE", ""))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.CreateApiKey(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_API_KEY_SCOPE", *response.Code)
+	})
+}
+
+func TestHandler_RevokeApiKey(t *testing.T) {
+	userId, id := int64(10), int64(3)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/users/profile/api-keys/3", nil)
+
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().RevokeAPIKey(gomock.Any(), userId, id).Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.RevokeApiKey(c, id))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - api key not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/users/profile/api-keys/3", nil)
+
+		mockAPIKeyUsecase := mocks.NewMockAPIKeyUsecaseInterface(ctrl)
+		mockAPIKeyUsecase.EXPECT().RevokeAPIKey(gomock.Any(), userId, id).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusNotFound, ""))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{APIKeyUsecase: mockAPIKeyUsecase})
+		render(s, c, s.RevokeApiKey(c, id))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
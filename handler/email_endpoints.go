@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) SendEmailVerification(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	if err := s.EmailUsecase.SendVerificationEmail(ctx.Request().Context(), userId); err != nil {
+		return err
+	}
+
+	resp := generated.SendEmailVerificationResponse{
+		Success: true,
+		Message: "successfully send email verification",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) VerifyEmail(ctx echo.Context) error {
+	req := generated.VerifyEmailJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil || req.Token == "" {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	if err := s.EmailUsecase.VerifyEmail(ctx.Request().Context(), req.Token); err != nil {
+		return err
+	}
+
+	resp := generated.VerifyEmailResponse{
+		Success: true,
+		Message: "successfully verify email",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_SendEmailVerification(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/email/verification", nil)
+
+		mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
+		mockEmailUsecase.EXPECT().SendVerificationEmail(gomock.Any(), userId).Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{EmailUsecase: mockEmailUsecase})
+		render(s, c, s.SendEmailVerification(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.SendEmailVerificationResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.True(t, response.Success)
+	})
+
+	t.Run("failed - send verification email return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/email/verification", nil)
+
+		mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
+		mockEmailUsecase.EXPECT().SendVerificationEmail(gomock.Any(), userId).
+			Times(1).Return(utils.WrapWithKey(errors.New("usecase error"), utils.ErrorCode(http.StatusConflict), "EMAIL_NOT_SET", "User Has No Email Address."))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{EmailUsecase: mockEmailUsecase})
+		render(s, c, s.SendEmailVerification(c))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, "EMAIL_NOT_SET", *response.Code)
+	})
+}
+
+func TestHandler_VerifyEmail(t *testing.T) {
+	payload := generated.VerifyEmailJSONRequestBody{Token: "token"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/email/verify", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
+		mockEmailUsecase.EXPECT().VerifyEmail(gomock.Any(), payload.Token).Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{EmailUsecase: mockEmailUsecase})
+		render(s, c, s.VerifyEmail(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - empty token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/email/verify", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{EmailUsecase: mocks.NewMockEmailUsecaseInterface(ctrl)})
+		render(s, c, s.VerifyEmail(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - verify email return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/email/verify", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
+		mockEmailUsecase.EXPECT().VerifyEmail(gomock.Any(), payload.Token).
+			Times(1).Return(utils.WrapWithKey(errors.New("usecase error"), utils.ErrorCode(http.StatusBadRequest), "INVALID_VERIFICATION_TOKEN", "Invalid Verification Token."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{EmailUsecase: mockEmailUsecase})
+		render(s, c, s.VerifyEmail(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+}
@@ -2,38 +2,60 @@ package handler
 
 import (
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/SawitProRecruitment/UserService/utils"
 
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
 )
 
+// deviceIdHeader carries the id a client gives the device it runs on, to
+// recognize the device across logins.
+const deviceIdHeader = "X-Device-Id"
+
 func (s *Server) AuthLogin(ctx echo.Context) error {
 	req := generated.AuthLoginJSONRequestBody{}
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid Input.",
-		})
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
 	}
 
-	if isPayloadValid, errorMessage := utils.IsAuthLoginPayloadValid(req); !isPayloadValid {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: errorMessage,
-		})
+	// Numbers that can not be normalized are looked up as given.
+	s.normalizePhoneNumber(req.PhoneNumber)
+	if isPayloadValid, validationErrors := utils.IsAuthLoginPayloadValid(req); !isPayloadValid {
+		return validationErrors
 	}
 
-	user, jwt, err := s.AuthUsecase.LoginUser(ctx.Request().Context(), req)
+	keyThumbprint, err := s.verifyDPoPProof(ctx, "")
 	if err != nil {
-		return ctx.JSON(int(utils.GetCode(err)), generated.ErrorResponse{
-			Success: false,
-			Message: utils.GetMessage(err),
-		})
+		log.Error(err)
+		return invalidDPoPProofError(err, http.StatusBadRequest)
+	}
+
+	attempt := model.AuthAttempt{
+		Action:     model.AuthActionLogin,
+		Identifier: loginIdentifier(req),
+		IPAddress:  ctx.RealIP(),
 	}
 
+	if err := s.CaptchaUsecase.Challenge(ctx.Request().Context(), attempt, stringValue(req.CaptchaToken)); err != nil {
+		return err
+	}
+
+	user, jwt, err := s.AuthUsecase.LoginUser(ctx.Request().Context(), req, keyThumbprint, loginClient(ctx))
+	if err != nil {
+		if isFailedAttempt(err) {
+			s.CaptchaUsecase.RecordFailure(ctx.Request().Context(), attempt)
+		}
+
+		return err
+	}
+
+	s.CaptchaUsecase.ResetFailures(ctx.Request().Context(), attempt)
+
 	resp := generated.AuthLoginResponse{
 		Success: true,
 		Message: "successfully logged-in user",
@@ -46,28 +68,96 @@ func (s *Server) AuthLogin(ctx echo.Context) error {
 	return ctx.JSON(http.StatusOK, resp)
 }
 
+func (s *Server) Reauthenticate(ctx echo.Context) error {
+	req := generated.ReauthenticateJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil || req.Password == "" {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	keyThumbprint, _ := ctx.Get(keyThumbprintContextKey).(string)
+	deviceId, _ := ctx.Get(deviceIdContextKey).(int64)
+
+	// A stolen token must not allow guessing the password either.
+	attempt := model.AuthAttempt{
+		Action:     model.AuthActionReauthenticate,
+		Identifier: strconv.FormatInt(userId, 10),
+		IPAddress:  ctx.RealIP(),
+	}
+
+	if err := s.CaptchaUsecase.Challenge(ctx.Request().Context(), attempt, stringValue(req.CaptchaToken)); err != nil {
+		return err
+	}
+
+	jwt, err := s.AuthUsecase.Reauthenticate(ctx.Request().Context(), userId, req, keyThumbprint, deviceId)
+	if err != nil {
+		if utils.GetCode(err) == http.StatusUnauthorized {
+			s.CaptchaUsecase.RecordFailure(ctx.Request().Context(), attempt)
+		}
+
+		return err
+	}
+
+	s.CaptchaUsecase.ResetFailures(ctx.Request().Context(), attempt)
+
+	resp := generated.AuthLoginResponse{
+		Success: true,
+		Message: "successfully re-authenticated user",
+		Data: &generated.AuthLoginResponseData{
+			Id:  int(userId),
+			Jwt: jwt,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) RevokeDevice(ctx echo.Context) error {
+	req := generated.RevokeDeviceJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil || req.Token == "" {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	if err := s.AuthUsecase.RevokeDevice(ctx.Request().Context(), req.Token); err != nil {
+		return err
+	}
+
+	resp := generated.RevokeDeviceResponse{
+		Success: true,
+		Message: "successfully revoke device",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
 func (s *Server) RegisterUser(ctx echo.Context) error {
 	req := generated.RegisterUserJSONRequestBody{}
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid Input.",
-		})
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
 	}
 
-	if isPayloadValid, errorMessage := utils.IsRegisterUserPayloadValid(req); !isPayloadValid {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: errorMessage,
-		})
+	s.normalizePhoneNumber(&req.PhoneNumber)
+	if isPayloadValid, validationErrors := utils.IsRegisterUserPayloadValid(req, s.PhoneUtil); !isPayloadValid {
+		return validationErrors
+	}
+
+	attempt := model.AuthAttempt{
+		Action:     model.AuthActionRegister,
+		Identifier: req.PhoneNumber,
+		IPAddress:  ctx.RealIP(),
+	}
+
+	if err := s.CaptchaUsecase.Challenge(ctx.Request().Context(), attempt, stringValue(req.CaptchaToken)); err != nil {
+		return err
 	}
 
 	user, err := s.UserUsecase.CreateUser(ctx.Request().Context(), req)
 	if err != nil {
-		return ctx.JSON(int(utils.GetCode(err)), generated.ErrorResponse{
-			Success: false,
-			Message: utils.GetMessage(err),
-		})
+		if isFailedAttempt(err) {
+			s.CaptchaUsecase.RecordFailure(ctx.Request().Context(), attempt)
+		}
+
+		return err
 	}
 
 	resp := generated.RegisterUserResponse{
@@ -79,47 +169,76 @@ func (s *Server) RegisterUser(ctx echo.Context) error {
 	return ctx.JSON(http.StatusCreated, resp)
 }
 
-func (s *Server) GetUserProfile(ctx echo.Context) error {
-	tokenStr := ctx.Request().Header.Get("authorization")
-	idx := strings.Index(tokenStr, " ")
-	if tokenStr == "" || idx < 0 {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+// loginIdentifier returns the phone number or email address a login names
+// its account by.
+func loginIdentifier(req generated.AuthLoginJSONRequestBody) string {
+	if req.PhoneNumber != nil {
+		return *req.PhoneNumber
 	}
 
-	tokenStr = tokenStr[idx+1:]
-	if err := s.AuthUtil.ValidateJWTToken(tokenStr); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+	return utils.NormalizeEmail(stringValue(req.Email))
+}
+
+// loginClient describes the client logging in, so the device it logs in from
+// is recognized whichever way the user authenticates.
+func loginClient(ctx echo.Context) model.LoginClient {
+	return model.LoginClient{
+		DeviceId:  ctx.Request().Header.Get(deviceIdHeader),
+		UserAgent: ctx.Request().UserAgent(),
+		IPAddress: ctx.RealIP(),
 	}
+}
 
-	userId, err := s.AuthUtil.GetUserId(tokenStr)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+// isFailedAttempt reports whether a login or registration failed because of
+// what the client sent, such as a wrong password or a taken phone number,
+// rather than the server.
+func isFailedAttempt(err error) bool {
+	switch utils.GetCode(err) {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict:
+		return true
+	default:
+		return false
 	}
+}
 
+// normalizePhoneNumber brings a valid phone number into its E.164 form
+// before the payload is validated, invalid ones are left as they are for the
+// validation to report.
+func (s *Server) normalizePhoneNumber(phoneNumber *string) {
+	if phoneNumber == nil {
+		return
+	}
+
+	if normalized, err := s.PhoneUtil.Normalize(*phoneNumber); err == nil {
+		*phoneNumber = normalized
+	}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func (s *Server) GetUserProfile(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
 	user, err := s.UserUsecase.GetUserProfile(ctx.Request().Context(), userId)
 	if err != nil {
-		return ctx.JSON(int(utils.GetCode(err)), generated.ErrorResponse{
-			Success: false,
-			Message: utils.GetMessage(err),
-		})
+		return err
 	}
 
 	resp := generated.GetUserProfileResponse{
 		Success: true,
 		Message: "successfully get user profile",
 		Data: &generated.GetUserProfileResponseData{
-			Id:          int(user.Id),
-			FullName:    user.FullName,
-			PhoneNumber: user.PhoneNumber,
+			Id:            int(user.Id),
+			FullName:      user.FullName,
+			PhoneNumber:   user.PhoneNumber,
+			Email:         user.Email.Ptr(),
+			EmailVerified: user.EmailVerifiedAt.Valid,
+			Locale:        (*generated.Locale)(user.Locale.Ptr()),
 		},
 	}
 
@@ -127,56 +246,64 @@ func (s *Server) GetUserProfile(ctx echo.Context) error {
 }
 
 func (s *Server) UpdateUserProfile(ctx echo.Context) error {
-	tokenStr := ctx.Request().Header.Get("authorization")
-	idx := strings.Index(tokenStr, " ")
-	if tokenStr == "" || idx < 0 {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+	req := generated.UpdateUserProfileJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
 	}
 
-	tokenStr = tokenStr[idx+1:]
-	if err := s.AuthUtil.ValidateJWTToken(tokenStr); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+	s.normalizePhoneNumber(&req.PhoneNumber)
+	if isPayloadValid, validationErrors := utils.IsUpdateUserProfilePayloadValid(req, s.PhoneUtil); !isPayloadValid {
+		return validationErrors
 	}
 
-	userId, err := s.AuthUtil.GetUserId(tokenStr)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid JWT Token",
-		})
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	authTime, _ := ctx.Get(authTimeContextKey).(time.Time)
+	if err := s.UserUsecase.UpdateUserProfile(ctx.Request().Context(), userId, authTime, req); err != nil {
+		return err
 	}
 
-	req := generated.UpdateUserProfileJSONRequestBody{}
+	resp := generated.UpdateUserProfileResponse{
+		Success: true,
+		Message: "successfully update user profile",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DeleteUserProfile(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	authTime, _ := ctx.Get(authTimeContextKey).(time.Time)
+	if err := s.UserUsecase.DeleteUser(ctx.Request().Context(), userId, authTime); err != nil {
+		return err
+	}
+
+	resp := generated.DeleteUserProfileResponse{
+		Success: true,
+		Message: "successfully delete user account",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ChangeUserPassword(ctx echo.Context) error {
+	req := generated.ChangeUserPasswordJSONRequestBody{}
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: "Invalid Input.",
-		})
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
 	}
 
-	if isPayloadValid, errorMessage := utils.IsUpdateUserProfilePayloadValid(req); !isPayloadValid {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Success: false,
-			Message: errorMessage,
-		})
+	if isPayloadValid, validationErrors := utils.IsChangePasswordPayloadValid(req); !isPayloadValid {
+		return validationErrors
 	}
 
-	if err = s.UserUsecase.UpdateUserProfile(ctx.Request().Context(), userId, req); err != nil {
-		return ctx.JSON(int(utils.GetCode(err)), generated.ErrorResponse{
-			Success: false,
-			Message: utils.GetMessage(err),
-		})
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	authTime, _ := ctx.Get(authTimeContextKey).(time.Time)
+	if err := s.UserUsecase.ChangePassword(ctx.Request().Context(), userId, authTime, req); err != nil {
+		return err
 	}
 
-	resp := generated.UpdateUserProfileResponse{
+	resp := generated.ChangePasswordResponse{
 		Success: true,
-		Message: "successfully update user profile",
+		Message: "successfully change user password",
 	}
 
 	return ctx.JSON(http.StatusOK, resp)
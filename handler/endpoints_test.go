@@ -3,7 +3,7 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -13,6 +13,7 @@ import (
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
 	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/SawitProRecruitment/UserService/utils"
 	"github.com/labstack/echo/v4"
@@ -52,6 +53,9 @@ bfvg0Qvy85i0uVFanyEWKZRYAUWabp+WtBoNaVHZKuW/kteQDuTExvp4IEecoPoa
 )
 
 func TestHandler_AuthLogin(t *testing.T) {
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
 	t.Run("success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -70,7 +74,7 @@ func TestHandler_AuthLogin(t *testing.T) {
 		}
 
 		payload := generated.AuthLoginJSONRequestBody{
-			PhoneNumber: phoneNumber,
+			PhoneNumber: &phoneNumber,
 			Password:    password,
 		}
 
@@ -79,13 +83,21 @@ func TestHandler_AuthLogin(t *testing.T) {
 
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(deviceIdHeader, "device")
+		req.Header.Set("User-Agent", "agent")
+
+		client := model.LoginClient{DeviceId: "device", UserAgent: "agent", IPAddress: "192.0.2.1"}
+		attempt := model.AuthAttempt{Action: model.AuthActionLogin, Identifier: phoneNumber, IPAddress: "192.0.2.1"}
 
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), attempt).Times(1)
 		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
-		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload).Times(1).Return(user, "jwt", nil)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload, "", client).Times(1).Return(user, "jwt", nil)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase})
-		s.AuthLogin(c)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
 
 		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 
@@ -99,6 +111,269 @@ func TestHandler_AuthLogin(t *testing.T) {
 		require.NotEmpty(t, response.Data.Jwt)
 	})
 
+	t.Run("success - dpop proof", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "+628123456782"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		mockDPoPUtil := mockUtils.NewMockDPoPInterface(ctrl)
+		mockDPoPUtil.EXPECT().VerifyProof("proof", http.MethodPost, "http://example.com/v1/auth/login", "").Times(1).Return("jkt", nil)
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Times(1)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload, "jkt", gomock.Any()).Times(1).Return(model.User{Id: 1}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, DPoPUtil: mockDPoPUtil, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("success - phone number that can not be normalized", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "085912345678"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Times(1)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload, "", gomock.Any()).Times(1).Return(model.User{Id: 1}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("success - normalized phone number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		payload := strings.NewReader(`{"phone_number":"+62 812-3456-782","password":"password"}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", payload)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		phoneNumber := "+628123456782"
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), gomock.Any()).Times(1)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}, "", gomock.Any()).
+			Times(1).Return(model.User{Id: 1}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("success - captcha token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		email := "John@Example.com"
+		captchaToken := "token"
+		payload := generated.AuthLoginJSONRequestBody{Email: &email, Password: "password", CaptchaToken: &captchaToken}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		attempt := model.AuthAttempt{Action: model.AuthActionLogin, Identifier: "john@example.com", IPAddress: "192.0.2.1"}
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, captchaToken).Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), attempt).Times(1)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload, "", gomock.Any()).Times(1).Return(model.User{Id: 1}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - captcha required", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "+628123456782"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").
+			Times(1).Return(utils.WrapWithKey(errors.New("captcha is required"), utils.ErrorCode(http.StatusForbidden), "CAPTCHA_REQUIRED", "Captcha Required."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mocks.NewMockAuthUsecaseInterface(ctrl), CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "CAPTCHA_REQUIRED", *response.Code)
+	})
+
+	t.Run("failed - message in accepted language", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "+628123456782"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Accept-Language", "id-ID,id;q=0.9,en;q=0.8")
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").
+			Times(1).Return(utils.WrapWithKey(errors.New("captcha is required"), utils.ErrorCode(http.StatusForbidden), "CAPTCHA_REQUIRED", "Captcha Required."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mocks.NewMockAuthUsecaseInterface(ctrl), CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "Captcha Diperlukan.", response.Detail)
+	})
+
+	t.Run("failed - invalid fields in accepted language", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		payload := strings.NewReader(`{"email":"john","password":"password"}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", payload)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set("Accept-Language", "id")
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "email harus berupa alamat email yang valid", response.Detail)
+		require.Equal(t, utils.ValidationCodeInvalidEmail, (*response.Errors)[0].Code)
+		require.Equal(t, response.Detail, (*response.Errors)[0].Message)
+	})
+
+	t.Run("failed - wrong password is recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "+628123456782"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		attempt := model.AuthAttempt{Action: model.AuthActionLogin, Identifier: phoneNumber, IPAddress: "192.0.2.1"}
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().RecordFailure(gomock.Any(), attempt).Times(1)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), payload, "", gomock.Any()).
+			Times(1).Return(model.User{}, "", utils.NewErrorWithCode(http.StatusUnauthorized, "wrong password"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid dpop proof", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		phoneNumber := "+628123456782"
+		payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		mockDPoPUtil := mockUtils.NewMockDPoPInterface(ctrl)
+		mockDPoPUtil.EXPECT().VerifyProof("proof", http.MethodPost, gomock.Any(), "").Times(1).Return("", errors.New("dpop proof is for another method"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mocks.NewMockAuthUsecaseInterface(ctrl), DPoPUtil: mockDPoPUtil, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_DPOP_PROOF", *response.Code)
+	})
+
 	t.Run("failed - missing required fields", func(t *testing.T) {
 		e := echo.New()
 		rec := httptest.NewRecorder()
@@ -112,17 +387,16 @@ func TestHandler_AuthLogin(t *testing.T) {
 		req.Header.Set("content-type", "application/x-www-form-urlencoded")
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.AuthLogin(c)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
 
 		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err := json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Detail)
 	})
 
 	t.Run("failed - invalid fields", func(t *testing.T) {
@@ -130,7 +404,7 @@ func TestHandler_AuthLogin(t *testing.T) {
 		rec := httptest.NewRecorder()
 
 		form := make(url.Values)
-		form.Add("phone_number", "085912345678")
+		form.Add("phone_number", " ")
 		form.Add("password", "password")
 
 		payload := strings.NewReader(form.Encode())
@@ -139,17 +413,37 @@ func TestHandler_AuthLogin(t *testing.T) {
 		req.Header.Set("content-type", "application/x-www-form-urlencoded")
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.AuthLogin(c)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
 
 		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err := json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - both phone number and email", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		form := make(url.Values)
+		form.Add("phone_number", "+6285912345678")
+		form.Add("email", "john@example.com")
+		form.Add("password", "password")
+
+		payload := strings.NewReader(form.Encode())
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", payload)
+		req.Header.Set("content-type", "application/x-www-form-urlencoded")
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
 	})
 
 	t.Run("failed - login user return error", func(t *testing.T) {
@@ -159,8 +453,9 @@ func TestHandler_AuthLogin(t *testing.T) {
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
+		phoneNumber := "+628123456782"
 		payload := generated.AuthLoginJSONRequestBody{
-			PhoneNumber: "+628123456782",
+			PhoneNumber: &phoneNumber,
 			Password:    "password",
 		}
 
@@ -170,26 +465,99 @@ func TestHandler_AuthLogin(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").Times(1).Return(nil)
 		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
-		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), gomock.Any()).
+		mockAuthUsecase.EXPECT().LoginUser(gomock.Any(), gomock.Any(), "", gomock.Any()).
 			Times(1).Return(model.User{}, "", utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase})
-		s.AuthLogin(c)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
 
 		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Detail)
+	})
+}
+
+func TestHandler_RevokeDevice(t *testing.T) {
+	payload := generated.RevokeDeviceJSONRequestBody{Token: "token"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/devices/revoke", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().RevokeDevice(gomock.Any(), payload.Token).Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase})
+		render(s, c, s.RevokeDevice(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - empty token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/devices/revoke", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mocks.NewMockAuthUsecaseInterface(ctrl)})
+		render(s, c, s.RevokeDevice(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - revoke device return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/devices/revoke", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().RevokeDevice(gomock.Any(), payload.Token).
+			Times(1).Return(utils.WrapWithKey(errors.New("usecase error"), utils.ErrorCode(http.StatusBadRequest), "INVALID_REVOKE_TOKEN", "Invalid Revoke Token."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase})
+		render(s, c, s.RevokeDevice(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_REVOKE_TOKEN", *response.Code)
 	})
 }
 
 func TestHandler_RegisterUser(t *testing.T) {
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
 	t.Run("success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -220,12 +588,16 @@ func TestHandler_RegisterUser(t *testing.T) {
 		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+		attempt := model.AuthAttempt{Action: model.AuthActionRegister, Identifier: phoneNumber, IPAddress: "192.0.2.1"}
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
 		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
 		mockUserUsecase.EXPECT().CreateUser(gomock.Any(), payload).Times(1).Return(user, nil)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
-		s.RegisterUser(c)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
 
 		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
 
@@ -233,136 +605,460 @@ func TestHandler_RegisterUser(t *testing.T) {
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.True(t, response.Success)
-		require.NotEmpty(t, response.Message)
-		require.NotEmpty(t, response.Data.Id)
-	})
+		require.True(t, response.Success)
+		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Data.Id)
+	})
+
+	t.Run("success - normalized phone number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:    "John Doe",
+			PhoneNumber: "+62 (0)812-3456-782",
+			Password:    "passworD!1",
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		normalized := payload
+		normalized.PhoneNumber = "+628123456782"
+		attempt := model.AuthAttempt{Action: model.AuthActionRegister, Identifier: normalized.PhoneNumber, IPAddress: "192.0.2.1"}
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().CreateUser(gomock.Any(), normalized).Times(1).Return(model.User{Id: 1, PhoneNumber: normalized.PhoneNumber}, nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - phone number of another country", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{FullName: "John Doe", PhoneNumber: "+447911123456", Password: "passworD!1"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - missing required fields", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{FullName: "fullName"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.AuthLogin(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - invalid fields", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:    "fullName",
+			PhoneNumber: "phoneNumber",
+			Password:    "password",
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - field errors", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:    "John Doe",
+			PhoneNumber: "+628123456782",
+			Password:    "passworD1",
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "VALIDATION_FAILED", *response.Code)
+		require.Equal(t, []generated.FieldError{{
+			Field:   "password",
+			Code:    utils.ValidationCodeMissingSpecial,
+			Message: "password must contain 1 special character",
+			Params:  &map[string]interface{}{"min": float64(1)},
+		}}, *response.Errors)
+	})
+
+	t.Run("failed - create user return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		fullName := "John Doe"
+		phoneNumber := "+628123456782"
+		password := "passworD!1"
+
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:    fullName,
+			PhoneNumber: phoneNumber,
+			Password:    password,
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), "").Times(1).Return(nil)
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().CreateUser(gomock.Any(), payload).
+			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - conflict is recorded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:    "John Doe",
+			PhoneNumber: "+628123456782",
+			Password:    "passworD!1",
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		attempt := model.AuthAttempt{Action: model.AuthActionRegister, Identifier: payload.PhoneNumber, IPAddress: "192.0.2.1"}
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().RecordFailure(gomock.Any(), attempt).Times(1)
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().CreateUser(gomock.Any(), payload).
+			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusConflict, "phone number is taken"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid captcha", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+
+		captchaToken := "token"
+		payload := generated.RegisterUserJSONRequestBody{
+			FullName:     "John Doe",
+			PhoneNumber:  "+628123456782",
+			Password:     "passworD!1",
+			CaptchaToken: &captchaToken,
+		}
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), gomock.Any(), captchaToken).
+			Times(1).Return(utils.WrapWithKey(utils.ErrCaptchaRejected, utils.ErrorCode(http.StatusForbidden), "INVALID_CAPTCHA", "Invalid Captcha."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{UserUsecase: mocks.NewMockUserUsecaseInterface(ctrl), CaptchaUsecase: mockCaptchaUsecase, PhoneUtil: phone})
+		render(s, c, s.RegisterUser(c))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_CAPTCHA", *response.Code)
+	})
+}
+
+func TestHandler_GetUserProfile(t *testing.T) {
+	id := int64(10)
+	fullName := "John Doe"
+	phoneNumber := "+6285912345678"
+
+	user := model.User{
+		Id:          id,
+		FullName:    fullName,
+		PhoneNumber: phoneNumber,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
+
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().GetUserProfile(gomock.Any(), id).Times(1).Return(user, nil)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
+		render(s, c, s.GetUserProfile(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.GetUserProfileResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Data.Id)
+		require.NotEmpty(t, response.Data.FullName)
+		require.NotEmpty(t, response.Data.PhoneNumber)
+	})
+
+	t.Run("failed - get user profile return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
+
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().GetUserProfile(gomock.Any(), id).
+			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
+
+		render(s, c, s.GetUserProfile(c))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+}
+
+func TestHandler_UpdateUserProfile(t *testing.T) {
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	id := int64(10)
+	authTime := time.Now()
+	fullName := "John Doe"
+	phoneNumber := "+6285912345678"
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-	t.Run("failed - missing required fields", func(t *testing.T) {
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.RegisterUserJSONRequestBody{FullName: "fullName"}
+		payload := generated.UpdateUserProfileJSONRequestBody{
+			FullName:    fullName,
+			PhoneNumber: phoneNumber,
+		}
+
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().UpdateUserProfile(gomock.Any(), id, authTime, payload).Times(1).Return(nil)
+
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.AuthLogin(c)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, PhoneUtil: phone})
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		render(s, c, s.UpdateUserProfile(c))
 
-		var response generated.ErrorResponse
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.UpdateUserProfileResponse
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
+		require.True(t, response.Success)
 		require.NotEmpty(t, response.Message)
 	})
 
-	t.Run("failed - invalid fields", func(t *testing.T) {
+	t.Run("failed - invalid request body", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.RegisterUserJSONRequestBody{
-			FullName:    "fullName",
-			PhoneNumber: "phoneNumber",
-			Password:    "password",
+		payload := generated.UpdateUserProfileJSONRequestBody{
+			FullName:    fullName,
+			PhoneNumber: "0825",
 		}
 
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.AuthLogin(c)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{PhoneUtil: phone})
+
+		render(s, c, s.UpdateUserProfile(c))
 
 		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Detail)
 	})
 
-	t.Run("failed - create user return error", func(t *testing.T) {
+	t.Run("failed - get user profile return error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		fullName := "John Doe"
-		phoneNumber := "+628123456782"
-		password := "passworD!1"
-
-		payload := generated.RegisterUserJSONRequestBody{
+		payload := generated.UpdateUserProfileJSONRequestBody{
 			FullName:    fullName,
 			PhoneNumber: phoneNumber,
-			Password:    password,
 		}
 
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
-		mockUserUsecase.EXPECT().CreateUser(gomock.Any(), payload).
-			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+		mockUserUsecase.EXPECT().UpdateUserProfile(gomock.Any(), id, authTime, payload).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
-		s.RegisterUser(c)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase, PhoneUtil: phone})
+
+		render(s, c, s.UpdateUserProfile(c))
 
 		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.NotEmpty(t, response.Detail)
 	})
 }
 
-func TestHandler_GetUserProfile(t *testing.T) {
-	jwtDuration, err := time.ParseDuration(jwtDurationStr)
-	require.NoError(t, err)
-
-	auth, err := utils.InitAuth(utils.AuthOptions{
-		JWTSecretKey:      jwtSecretKey,
-		JWTExpiryDuration: jwtDuration,
-	})
-	require.NoError(t, err)
-
+func TestHandler_DeleteUserProfile(t *testing.T) {
 	id := int64(10)
-	fullName := "John Doe"
-	phoneNumber := "+6285912345678"
-
-	user := model.User{
-		Id:          id,
-		FullName:    fullName,
-		PhoneNumber: phoneNumber,
-	}
-
-	jwt, err := auth.GenerateJWTToken(user)
-	require.NoError(t, err)
-
-	authUtil, err := utils.InitAuth(utils.AuthOptions{
-		JWTSecretKey:      jwtSecretKey,
-		JWTExpiryDuration: jwtDuration,
-	})
-	require.NoError(t, err)
+	authTime := time.Now()
 
 	t.Run("success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -371,309 +1067,321 @@ func TestHandler_GetUserProfile(t *testing.T) {
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+		req := httptest.NewRequest(http.MethodDelete, "/users/profile", nil)
 
 		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
-		mockUserUsecase.EXPECT().GetUserProfile(gomock.Any(), id).Times(1).Return(user, nil)
+		mockUserUsecase.EXPECT().DeleteUser(gomock.Any(), id, authTime).Times(1).Return(nil)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{
-			UserUsecase: mockUserUsecase,
-			AuthUtil:    authUtil,
-		})
-		s.GetUserProfile(c)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
+
+		render(s, c, s.DeleteUserProfile(c))
 
 		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 
-		var response generated.GetUserProfileResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
+		var response generated.DeleteUserProfileResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
 		require.True(t, response.Success)
 		require.NotEmpty(t, response.Message)
-		require.NotEmpty(t, response.Data.Id)
-		require.NotEmpty(t, response.Data.FullName)
-		require.NotEmpty(t, response.Data.PhoneNumber)
 	})
 
-	t.Run("failed - missing jwt token", func(t *testing.T) {
+	t.Run("failed - delete user return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
-		req.Header.Set("authorization", "Bearer")
+		req := httptest.NewRequest(http.MethodDelete, "/users/profile", nil)
 
-		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.GetUserProfile(c)
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().DeleteUser(gomock.Any(), id, authTime).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
 
-		var response generated.ErrorResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		require.NoError(t, err)
+		render(s, c, s.DeleteUserProfile(c))
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
 	})
+}
+
+func TestHandler_Reauthenticate(t *testing.T) {
+	id := int64(10)
+	attempt := model.AuthAttempt{Action: model.AuthActionReauthenticate, Identifier: "10", IPAddress: "192.0.2.1"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
 
-	t.Run("failed - invalid jwt token", func(t *testing.T) {
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", dummyJwt))
+		payload := generated.ReauthenticateJSONRequestBody{Password: "Password1!"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().Reauthenticate(gomock.Any(), id, payload, "", int64(0)).Times(1).Return("jwt", nil)
+
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), attempt).Times(1)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{AuthUtil: authUtil})
-		s.GetUserProfile(c)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase})
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		render(s, c, s.Reauthenticate(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.AuthLoginResponse
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.True(t, response.Success)
+		require.Equal(t, int(id), response.Data.Id)
+		require.Equal(t, "jwt", response.Data.Jwt)
 	})
 
-	t.Run("failed - get user profile return error", func(t *testing.T) {
+	t.Run("success - with captcha", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodGet, "/users/profile", nil)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+		captchaToken := "captcha-passed"
+		payload := generated.ReauthenticateJSONRequestBody{Password: "Password1!", CaptchaToken: &captchaToken}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
 
-		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
-		mockUserUsecase.EXPECT().GetUserProfile(gomock.Any(), id).
-			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
-		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{
-			UserUsecase: mockUserUsecase,
-			AuthUtil:    authUtil,
-		})
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().Reauthenticate(gomock.Any(), id, payload, "", int64(0)).Times(1).Return("jwt", nil)
 
-		s.GetUserProfile(c)
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, captchaToken).Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().ResetFailures(gomock.Any(), attempt).Times(1)
 
-		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase})
 
-		var response generated.ErrorResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		require.NoError(t, err)
+		render(s, c, s.Reauthenticate(c))
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 	})
-}
 
-func TestHandler_UpdateUserProfile(t *testing.T) {
-	jwtDuration, err := time.ParseDuration(jwtDurationStr)
-	require.NoError(t, err)
-
-	auth, err := utils.InitAuth(utils.AuthOptions{
-		JWTSecretKey:      jwtSecretKey,
-		JWTExpiryDuration: jwtDuration,
-	})
-	require.NoError(t, err)
+	t.Run("failed - missing password", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
 
-	id := int64(10)
-	fullName := "John Doe"
-	phoneNumber := "+6285912345678"
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", strings.NewReader("{}"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
-	user := model.User{
-		Id:          id,
-		FullName:    fullName,
-		PhoneNumber: phoneNumber,
-	}
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{})
 
-	jwt, err := auth.GenerateJWTToken(user)
-	require.NoError(t, err)
+		render(s, c, s.Reauthenticate(c))
 
-	authUtil, err := utils.InitAuth(utils.AuthOptions{
-		JWTSecretKey:      jwtSecretKey,
-		JWTExpiryDuration: jwtDuration,
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
 	})
 
-	require.NoError(t, err)
-
-	t.Run("success", func(t *testing.T) {
+	t.Run("failed - captcha required", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.UpdateUserProfileJSONRequestBody{
-			FullName:    fullName,
-			PhoneNumber: phoneNumber,
-		}
-
+		payload := generated.ReauthenticateJSONRequestBody{Password: "Password1!"}
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
 
-		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
-		mockUserUsecase.EXPECT().UpdateUserProfile(gomock.Any(), id, payload).Times(1).Return(nil)
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).
+			Return(utils.NewErrorWithKey(http.StatusForbidden, "CAPTCHA_REQUIRED", "Captcha Required."))
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{
-			UserUsecase: mockUserUsecase,
-			AuthUtil:    authUtil,
-		})
-
-		s.UpdateUserProfile(c)
-
-		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{CaptchaUsecase: mockCaptchaUsecase})
 
-		var response generated.UpdateUserProfileResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		require.NoError(t, err)
+		render(s, c, s.Reauthenticate(c))
 
-		require.True(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+		require.Contains(t, rec.Body.String(), "CAPTCHA_REQUIRED")
 	})
 
-	t.Run("failed - missing jwt token", func(t *testing.T) {
+	t.Run("failed - wrong password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.UpdateUserProfileJSONRequestBody{
-			FullName:    fullName,
-			PhoneNumber: phoneNumber,
-		}
-
+		payload := generated.ReauthenticateJSONRequestBody{Password: "wrong"}
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set("authorization", "Bearer")
 
-		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{})
-		s.UpdateUserProfile(c)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().Reauthenticate(gomock.Any(), id, payload, "", int64(0)).
+			Times(1).Return("", utils.NewErrorWithCode(http.StatusUnauthorized, "wrong password"))
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
+		mockCaptchaUsecase.EXPECT().RecordFailure(gomock.Any(), attempt).Times(1)
 
-		var response generated.ErrorResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		require.NoError(t, err)
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase})
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		render(s, c, s.Reauthenticate(c))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
 	})
 
-	t.Run("failed - invalid jwt token", func(t *testing.T) {
+	t.Run("failed - inactive account", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.UpdateUserProfileJSONRequestBody{
-			FullName:    fullName,
-			PhoneNumber: phoneNumber,
-		}
-
+		payload := generated.ReauthenticateJSONRequestBody{Password: "Password1!"}
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPost, "/auth/reauthenticate", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", dummyJwt))
 
-		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{AuthUtil: authUtil})
-		s.UpdateUserProfile(c)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().Reauthenticate(gomock.Any(), id, payload, "", int64(0)).
+			Times(1).Return("", utils.NewErrorWithCode(http.StatusForbidden, "account suspended"))
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		mockCaptchaUsecase := mocks.NewMockCaptchaUsecaseInterface(ctrl)
+		mockCaptchaUsecase.EXPECT().Challenge(gomock.Any(), attempt, "").Times(1).Return(nil)
 
-		var response generated.ErrorResponse
-		err = json.Unmarshal(rec.Body.Bytes(), &response)
-		require.NoError(t, err)
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		s := NewServer(NewServerOptions{AuthUsecase: mockAuthUsecase, CaptchaUsecase: mockCaptchaUsecase})
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		render(s, c, s.Reauthenticate(c))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
 	})
+}
 
-	t.Run("failed - invalid request body", func(t *testing.T) {
+func TestHandler_ChangeUserPassword(t *testing.T) {
+	id := int64(10)
+	authTime := time.Now()
+
+	t.Run("success", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.UpdateUserProfileJSONRequestBody{
-			FullName:    fullName,
-			PhoneNumber: "0825",
-		}
-
+		payload := generated.ChangeUserPasswordJSONRequestBody{Password: "N3wPassword!"}
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPut, "/users/profile/password", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
+		mockUserUsecase.EXPECT().ChangePassword(gomock.Any(), id, authTime, payload).Times(1).Return(nil)
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{AuthUtil: authUtil})
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
 
-		s.UpdateUserProfile(c)
+		render(s, c, s.ChangeUserPassword(c))
 
-		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.ChangePasswordResponse
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
+		require.True(t, response.Success)
 		require.NotEmpty(t, response.Message)
 	})
 
-	t.Run("failed - get user profile return error", func(t *testing.T) {
+	t.Run("failed - weak password", func(t *testing.T) {
+		e := echo.New()
+		rec := httptest.NewRecorder()
+
+		payload := generated.ChangeUserPasswordJSONRequestBody{Password: "weak"}
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, "/users/profile/password", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{})
+
+		render(s, c, s.ChangeUserPassword(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - reauthentication required", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		e := echo.New()
 		rec := httptest.NewRecorder()
 
-		payload := generated.UpdateUserProfileJSONRequestBody{
-			FullName:    fullName,
-			PhoneNumber: phoneNumber,
-		}
-
+		payload := generated.ChangeUserPasswordJSONRequestBody{Password: "N3wPassword!"}
 		payloadJSON, err := json.Marshal(payload)
 		require.NoError(t, err)
 
-		req := httptest.NewRequest(http.MethodPatch, "/users/profile", bytes.NewReader(payloadJSON))
+		req := httptest.NewRequest(http.MethodPut, "/users/profile/password", bytes.NewReader(payloadJSON))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
 
 		mockUserUsecase := mocks.NewMockUserUsecaseInterface(ctrl)
-		mockUserUsecase.EXPECT().UpdateUserProfile(gomock.Any(), id, payload).
-			Times(1).Return(utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+		mockUserUsecase.EXPECT().ChangePassword(gomock.Any(), id, authTime, payload).
+			Times(1).Return(utils.WrapWithKey(utils.NewErrorWithCode(http.StatusForbidden, "stale auth"), utils.ErrorCode(http.StatusForbidden), "REAUTHENTICATION_REQUIRED", "Please Confirm Your Password To Continue."))
 
 		c := e.NewContext(req, rec)
-		s := NewServer(NewServerOptions{
-			UserUsecase: mockUserUsecase,
-			AuthUtil:    authUtil,
-		})
+		c.Set(userIdContextKey, id)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{UserUsecase: mockUserUsecase})
 
-		s.UpdateUserProfile(c)
+		render(s, c, s.ChangeUserPassword(c))
 
-		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
 
-		var response generated.ErrorResponse
+		var response generated.Problem
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		require.NoError(t, err)
 
-		require.False(t, response.Success)
-		require.NotEmpty(t, response.Message)
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", *response.Code)
 	})
 }
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "/problems/"
+	blankProblemType   = "about:blank"
+)
+
+// HTTPErrorHandler answers requests whose handler returned an error with its
+// problem details (RFC 9457). Errors carrying a code are answered with its
+// status, their message rendered in the language of the request, validation
+// errors with every failed rule. Any other error is answered with a 500.
+// Errors answered with a 5xx are logged with their stack.
+func (s *Server) HTTPErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+
+	problem := newProblem(ctx, err)
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(problem.Status)
+	} else {
+		var data []byte
+		if data, err = json.Marshal(problem); err == nil {
+			err = ctx.Blob(problem.Status, problemContentType, data)
+		}
+	}
+
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// newProblem describes err as the problem details of the request.
+func newProblem(ctx echo.Context, err error) generated.Problem {
+	status, detail, code := http.StatusInternalServerError, "", ""
+	var fieldErrors []generated.FieldError
+
+	var validationErrors utils.ValidationErrors
+	var httpError *echo.HTTPError
+	switch {
+	case errors.As(err, &validationErrors):
+		status, code = http.StatusBadRequest, validationFailedCode
+		detail = validationErrors.LocalizedError(language(ctx))
+		fieldErrors = newFieldErrors(ctx, validationErrors)
+	case errors.As(err, &httpError):
+		status = httpError.Code
+		detail = translate(ctx, fmt.Sprint(httpError.Message))
+	case utils.GetCode(err) != utils.NoCode:
+		status, code = int(utils.GetCode(err)), utils.GetKey(err)
+		detail = errorMessage(ctx, err)
+	}
+
+	if detail == "" {
+		detail = translate(ctx, http.StatusText(status))
+	}
+
+	problem := generated.Problem{
+		Type:     problemType(code),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request().URL.Path,
+	}
+
+	if requestId := getRequestId(ctx); requestId != "" {
+		problem.RequestId = &requestId
+	}
+
+	if status >= http.StatusInternalServerError {
+		fields := utils.LogFields(err)
+		fields["instance"] = problem.Instance
+		if problem.RequestId != nil {
+			fields["request_id"] = *problem.RequestId
+		}
+		log.Errorj(fields)
+	}
+
+	if code != "" {
+		problem.Code = &code
+	}
+
+	if fieldErrors != nil {
+		problem.Errors = &fieldErrors
+	}
+
+	return problem
+}
+
+// errorStatus is the status a request failing with err is answered with.
+func errorStatus(err error) int {
+	var validationErrors utils.ValidationErrors
+	var httpError *echo.HTTPError
+	switch {
+	case errors.As(err, &validationErrors):
+		return http.StatusBadRequest
+	case errors.As(err, &httpError):
+		return httpError.Code
+	case utils.GetCode(err) != utils.NoCode:
+		return int(utils.GetCode(err))
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// problemType turns an error code like ACCOUNT_SUSPENDED into the problem
+// type /problems/account-suspended.
+func problemType(code string) string {
+	if code == "" {
+		return blankProblemType
+	}
+
+	return problemTypePrefix + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+func getRequestId(ctx echo.Context) string {
+	if requestId := ctx.Response().Header().Get(echo.HeaderXRequestID); requestId != "" {
+		return requestId
+	}
+
+	return ctx.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// newFieldErrors lists every failed rule of a request, so clients can point
+// out each offending field instead of parsing the detail.
+func newFieldErrors(ctx echo.Context, validationErrors utils.ValidationErrors) []generated.FieldError {
+	lang := language(ctx)
+	fieldErrors := make([]generated.FieldError, 0, len(validationErrors))
+	for _, validationError := range validationErrors {
+		fieldError := generated.FieldError{
+			Field:   validationError.Field,
+			Code:    validationError.Code,
+			Message: validationError.LocalizedMessage(lang),
+		}
+		if len(validationError.Params) > 0 {
+			params := validationError.Params
+			fieldError.Params = &params
+		}
+
+		fieldErrors = append(fieldErrors, fieldError)
+	}
+
+	return fieldErrors
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// render answers c like echo does when a handler returns err, so tests can
+// check the response of failed requests.
+func render(s *Server, c echo.Context, err error) {
+	if err != nil {
+		s.HTTPErrorHandler(err, c)
+	}
+}
+
+func TestHandler_HTTPErrorHandler(t *testing.T) {
+	s := NewServer(NewServerOptions{})
+
+	newContext := func(rec *httptest.ResponseRecorder, acceptLanguage string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set(echo.HeaderXRequestID, "request")
+		req.Header.Set("Accept-Language", acceptLanguage)
+		return echo.New().NewContext(req, rec)
+	}
+
+	t.Run("success - error with key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		err := utils.WrapWithKey(errors.New("account is suspended"), utils.ErrorCode(http.StatusForbidden), "ACCOUNT_SUSPENDED",
+			"Account Is Suspended. Please Contact Administrator.")
+
+		s.HTTPErrorHandler(err, newContext(rec, "en"))
+
+		require.Equal(t, http.StatusForbidden, rec.Code)
+		require.Equal(t, problemContentType, rec.Header().Get(echo.HeaderContentType))
+
+		var problem generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		require.Equal(t, "/problems/account-suspended", problem.Type)
+		require.Equal(t, "Forbidden", problem.Title)
+		require.Equal(t, http.StatusForbidden, problem.Status)
+		require.Equal(t, "Account Is Suspended. Please Contact Administrator.", problem.Detail)
+		require.Equal(t, "/v1/users/profile", problem.Instance)
+		require.Equal(t, "request", *problem.RequestId)
+		require.Equal(t, "ACCOUNT_SUSPENDED", *problem.Code)
+		require.Nil(t, problem.Errors)
+	})
+
+	t.Run("success - error without key", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		err := utils.WrapWithCode(errors.New("not found"), utils.ErrorCode(http.StatusNotFound), "")
+
+		s.HTTPErrorHandler(err, newContext(rec, "id"))
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+
+		var problem generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		require.Equal(t, blankProblemType, problem.Type)
+		require.Equal(t, "Data Tidak Ditemukan. Silakan Periksa Masukan Anda Atau Hubungi Administrator.", problem.Detail)
+		require.Nil(t, problem.Code)
+	})
+
+	t.Run("success - validation errors", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		cursor := "cursor"
+		_, err := utils.IsListUsersParamsValid(generated.ListUsersParams{Cursor: &cursor})
+
+		s.HTTPErrorHandler(err, newContext(rec, "en"))
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		require.Equal(t, "/problems/validation-failed", problem.Type)
+		require.Equal(t, "cursor is invalid", problem.Detail)
+		require.Equal(t, validationFailedCode, *problem.Code)
+		require.Equal(t, []generated.FieldError{{Field: "cursor", Code: utils.ValidationCodeInvalidFormat, Message: "cursor is invalid"}}, *problem.Errors)
+	})
+
+	t.Run("success - echo error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		s.HTTPErrorHandler(echo.ErrMethodNotAllowed, newContext(rec, "en"))
+
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+		var problem generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		require.Equal(t, "Method Not Allowed", problem.Detail)
+	})
+
+	t.Run("success - unexpected error", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+
+		s.HTTPErrorHandler(errors.New("db error"), newContext(rec, "en"))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var problem generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		require.Equal(t, "Internal Server Error", problem.Detail)
+		require.NotContains(t, rec.Body.String(), "db error")
+	})
+
+	t.Run("success - response already sent", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c := newContext(rec, "en")
+		require.NoError(t, c.NoContent(http.StatusNoContent))
+
+		s.HTTPErrorHandler(errors.New("db error"), c)
+
+		require.Equal(t, http.StatusNoContent, rec.Code)
+		require.Empty(t, rec.Body.String())
+	})
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) RequestUserDataExport(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	export, err := s.ExportUsecase.RequestExport(ctx.Request().Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	resp := generated.UserDataExportResponse{
+		Success: true,
+		Message: "successfully request user data export",
+		Data:    toUserDataExport(export, ""),
+	}
+
+	return ctx.JSON(http.StatusAccepted, resp)
+}
+
+func (s *Server) GetUserDataExport(ctx echo.Context, id int64) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	export, downloadURL, err := s.ExportUsecase.GetExport(ctx.Request().Context(), userId, id)
+	if err != nil {
+		return err
+	}
+
+	resp := generated.UserDataExportResponse{
+		Success: true,
+		Message: "successfully get user data export",
+		Data:    toUserDataExport(export, downloadURL),
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DownloadUserDataExport(ctx echo.Context, id int64, params generated.DownloadUserDataExportParams) error {
+	archive, err := s.ExportUsecase.DownloadExport(ctx.Request().Context(), id, time.Unix(params.Expires, 0), params.Signature)
+	if err != nil {
+		return err
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"user-data-export-%d.zip\"", id))
+
+	return ctx.Blob(http.StatusOK, "application/zip", archive)
+}
+
+func toUserDataExport(export model.UserExport, downloadURL string) *generated.UserDataExport {
+	data := &generated.UserDataExport{
+		Id:          export.Id,
+		Status:      generated.UserDataExportStatus(export.Status),
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt.Ptr(),
+		ExpiresAt:   export.ExpiresAt.Ptr(),
+	}
+
+	if downloadURL != "" {
+		data.DownloadUrl = &downloadURL
+	}
+
+	return data
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_RequestUserDataExport(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/export", nil)
+
+		export := model.UserExport{Id: 1, UserId: userId, Status: model.ExportStatusPending, CreatedAt: time.Now()}
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().RequestExport(gomock.Any(), userId).Times(1).Return(export, nil)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.RequestUserDataExport(c))
+
+		require.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+
+		var response generated.UserDataExportResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Equal(t, export.Id, response.Data.Id)
+		require.Equal(t, generated.UserDataExportStatusPending, response.Data.Status)
+		require.Nil(t, response.Data.DownloadUrl)
+	})
+
+	t.Run("failed - export in progress", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/export", nil)
+
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().RequestExport(gomock.Any(), userId).
+			Times(1).Return(model.UserExport{}, utils.NewErrorWithCode(http.StatusConflict, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.RequestUserDataExport(c))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_GetUserDataExport(t *testing.T) {
+	userId := int64(10)
+	exportId := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/export/1", nil)
+
+		export := model.UserExport{Id: exportId, UserId: userId, Status: model.ExportStatusReady, CreatedAt: time.Now()}
+		link := "/v1/exports/1/download?expires=1&signature=signature"
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().GetExport(gomock.Any(), userId, exportId).Times(1).Return(export, link, nil)
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.GetUserDataExport(c, exportId))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.UserDataExportResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.NotNil(t, response.Data.DownloadUrl)
+		require.Equal(t, link, *response.Data.DownloadUrl)
+	})
+
+	t.Run("failed - export not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/export/1", nil)
+
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().GetExport(gomock.Any(), userId, exportId).
+			Times(1).Return(model.UserExport{}, "", utils.NewErrorWithCode(http.StatusNotFound, "usecase error"))
+
+		c := e.NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.GetUserDataExport(c, exportId))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_DownloadUserDataExport(t *testing.T) {
+	exportId := int64(1)
+	params := generated.DownloadUserDataExportParams{Expires: 1700000000, Signature: "signature"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/exports/1/download?expires=1700000000&signature=signature", nil)
+
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().DownloadExport(gomock.Any(), exportId, time.Unix(params.Expires, 0), params.Signature).
+			Times(1).Return([]byte("archive"), nil)
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.DownloadUserDataExport(c, exportId, params))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, "application/zip", rec.Header().Get(echo.HeaderContentType))
+		require.Equal(t, "archive", rec.Body.String())
+	})
+
+	t.Run("failed - link expired", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		e := echo.New()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/exports/1/download?expires=1700000000&signature=signature", nil)
+
+		mockExportUsecase := mocks.NewMockExportUsecaseInterface(ctrl)
+		mockExportUsecase.EXPECT().DownloadExport(gomock.Any(), exportId, time.Unix(params.Expires, 0), params.Signature).
+			Times(1).Return(nil, utils.WrapWithKey(errors.New("expired"), utils.ErrorCode(http.StatusGone), "LINK_EXPIRED", ""))
+
+		c := e.NewContext(req, rec)
+		s := NewServer(NewServerOptions{ExportUsecase: mockExportUsecase})
+		render(s, c, s.DownloadUserDataExport(c, exportId, params))
+
+		require.Equal(t, http.StatusGone, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "LINK_EXPIRED", *response.Code)
+	})
+}
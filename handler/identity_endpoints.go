@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+// oidcBindingCookie holds the token tying a redirect to an identity provider
+// to the browser that started it.
+const oidcBindingCookie = "oidc_binding"
+
+func (s *Server) AuthorizeOidc(ctx echo.Context, provider string) error {
+	authURL, binding, err := s.IdentityUsecase.StartOIDCLogin(ctx.Request().Context(), provider)
+	if err != nil {
+		return err
+	}
+
+	setOIDCBindingCookie(ctx, binding)
+
+	return ctx.Redirect(http.StatusFound, authURL)
+}
+
+func (s *Server) OidcCallback(ctx echo.Context, provider string, params generated.OidcCallbackParams) error {
+	if params.Code == nil || *params.Code == "" {
+		if params.Error != nil {
+			return utils.NewErrorWithKey(http.StatusBadRequest, "IDENTITY_PROVIDER_ERROR", "Identity Provider Did Not Authenticate The User. %s", *params.Error)
+		}
+
+		return utils.NewErrorWithKey(http.StatusBadRequest, "IDENTITY_PROVIDER_ERROR", "Identity Provider Did Not Authenticate The User.")
+	}
+
+	// The binding is good for one callback, whatever its outcome.
+	setOIDCBindingCookie(ctx, "")
+
+	binding := stringValue(params.OidcBinding)
+	user, jwt, err := s.IdentityUsecase.HandleOIDCCallback(ctx.Request().Context(), provider, *params.Code, params.State, binding, loginClient(ctx))
+	if err != nil {
+		return err
+	}
+
+	resp := generated.AuthLoginResponse{
+		Success: true,
+		Message: "successfully logged-in user",
+		Data: &generated.AuthLoginResponseData{
+			Id:  int(user.Id),
+			Jwt: jwt,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+// setOIDCBindingCookie sets the binding cookie for the callback, which is
+// reached by a top-level redirect from the provider, or clears it when binding
+// is empty.
+func setOIDCBindingCookie(ctx echo.Context, binding string) {
+	cookie := &http.Cookie{
+		Name:     oidcBindingCookie,
+		Value:    binding,
+		Path:     "/v1/auth/oidc/",
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if binding == "" {
+		cookie.MaxAge = -1
+	}
+
+	ctx.SetCookie(cookie)
+}
+
+func (s *Server) ListIdentities(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	identities, err := s.IdentityUsecase.ListIdentities(ctx.Request().Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	data := make([]generated.UserIdentity, 0, len(identities))
+	for _, identity := range identities {
+		data = append(data, generated.UserIdentity{
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email.Ptr(),
+			LastLoginAt: identity.LastLoginAt.Ptr(),
+			CreatedAt:   identity.CreatedAt,
+		})
+	}
+
+	resp := generated.ListIdentitiesResponse{
+		Success: true,
+		Message: "successfully list identities",
+		Data:    &generated.ListIdentitiesResponseData{Identities: data},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) LinkIdentity(ctx echo.Context, provider string) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	authTime, _ := ctx.Get(authTimeContextKey).(time.Time)
+	authURL, binding, err := s.IdentityUsecase.StartIdentityLink(ctx.Request().Context(), userId, authTime, provider)
+	if err != nil {
+		return err
+	}
+
+	setOIDCBindingCookie(ctx, binding)
+
+	resp := generated.LinkIdentityResponse{
+		Success: true,
+		Message: "successfully start linking identity",
+		Data:    &generated.LinkIdentityResponseData{AuthorizationUrl: authURL},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) UnlinkIdentity(ctx echo.Context, provider string) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	if err := s.IdentityUsecase.UnlinkIdentity(ctx.Request().Context(), userId, provider); err != nil {
+		return err
+	}
+
+	resp := generated.UnlinkIdentityResponse{
+		Success: true,
+		Message: "successfully unlink identity",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) SamlMetadata(ctx echo.Context, provider string) error {
+	metadata, err := s.IdentityUsecase.SAMLMetadata(ctx.Request().Context(), provider)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Blob(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+func (s *Server) SamlLogin(ctx echo.Context, provider string) error {
+	redirectURL, err := s.IdentityUsecase.StartSAMLLogin(ctx.Request().Context(), provider)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Redirect(http.StatusFound, redirectURL)
+}
+
+func (s *Server) SamlAcs(ctx echo.Context, provider string) error {
+	samlResponse := ctx.FormValue("SAMLResponse")
+	if samlResponse == "" {
+		return utils.NewErrorWithKey(http.StatusBadRequest, "INVALID_SAML_RESPONSE", "Missing SAML Response.")
+	}
+
+	user, jwt, err := s.IdentityUsecase.HandleSAMLResponse(ctx.Request().Context(), provider, samlResponse, loginClient(ctx))
+	if err != nil {
+		return err
+	}
+
+	resp := generated.AuthLoginResponse{
+		Success: true,
+		Message: "successfully logged-in user",
+		Data: &generated.AuthLoginResponseData{
+			Id:  int(user.Id),
+			Jwt: jwt,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,409 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_AuthorizeOidc(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/authorize", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartOIDCLogin(gomock.Any(), "google").Times(1).Return("https://accounts.example.com/authorize", "binding", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.AuthorizeOidc(c, "google"))
+
+		require.Equal(t, http.StatusFound, rec.Result().StatusCode)
+		require.Equal(t, "https://accounts.example.com/authorize", rec.Header().Get(echo.HeaderLocation))
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, oidcBindingCookie, cookies[0].Name)
+		require.Equal(t, "binding", cookies[0].Value)
+		require.True(t, cookies[0].HttpOnly)
+		require.True(t, cookies[0].Secure)
+		require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/unknown/authorize", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartOIDCLogin(gomock.Any(), "unknown").
+			Times(1).Return("", "", utils.WrapWithKey(utils.ErrUnknownOIDCProvider, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.AuthorizeOidc(c, "unknown"))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_OidcCallback(t *testing.T) {
+	code := "code"
+	binding := "binding"
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?code=code&state=state", nil)
+		req.Header.Set(deviceIdHeader, "device")
+		req.Header.Set("User-Agent", "agent")
+
+		client := model.LoginClient{DeviceId: "device", UserAgent: "agent", IPAddress: "192.0.2.1"}
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().HandleOIDCCallback(gomock.Any(), "google", code, "state", binding, client).Times(1).Return(model.User{Id: 10}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.OidcCallback(c, "google", generated.OidcCallbackParams{Code: &code, State: "state", OidcBinding: &binding}))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, oidcBindingCookie, cookies[0].Name)
+		require.Equal(t, -1, cookies[0].MaxAge)
+
+		var response generated.AuthLoginResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, 10, response.Data.Id)
+		require.Equal(t, "jwt", response.Data.Jwt)
+	})
+
+	t.Run("failed - provider returned an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?error=access_denied&state=state", nil)
+
+		providerErr := "access_denied"
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mocks.NewMockIdentityUsecaseInterface(ctrl)})
+		render(s, c, s.OidcCallback(c, "google", generated.OidcCallbackParams{Error: &providerErr, State: "state"}))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, "IDENTITY_PROVIDER_ERROR", *response.Code)
+	})
+
+	t.Run("failed - handle callback return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/oidc/google/callback?code=code&state=state", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().HandleOIDCCallback(gomock.Any(), "google", code, "state", "", gomock.Any()).
+			Times(1).Return(model.User{}, "", utils.NewErrorWithCode(http.StatusForbidden, "identity not linked"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.OidcCallback(c, "google", generated.OidcCallbackParams{Code: &code, State: "state"}))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_ListIdentities(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/identities", nil)
+
+		identities := []model.UserIdentity{{Id: 4, UserId: userId, Provider: "google", Subject: "1234", CreatedAt: time.Now()}}
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().ListIdentities(gomock.Any(), userId).Times(1).Return(identities, nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.ListIdentities(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.ListIdentitiesResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Len(t, response.Data.Identities, 1)
+		require.Equal(t, "google", response.Data.Identities[0].Provider)
+		require.Nil(t, response.Data.Identities[0].Email)
+	})
+}
+
+func TestHandler_LinkIdentity(t *testing.T) {
+	userId := int64(10)
+	authTime := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/identities/google", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartIdentityLink(gomock.Any(), userId, authTime, "google").Times(1).Return("https://accounts.example.com/authorize", "binding", nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.LinkIdentity(c, "google"))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.LinkIdentityResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, "https://accounts.example.com/authorize", response.Data.AuthorizationUrl)
+
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, oidcBindingCookie, cookies[0].Name)
+		require.Equal(t, "binding", cookies[0].Value)
+	})
+
+	t.Run("failed - start identity link return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/identities/google", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartIdentityLink(gomock.Any(), userId, authTime, "google").
+			Times(1).Return("", "", utils.NewErrorWithCode(http.StatusForbidden, "reauthentication required"))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.LinkIdentity(c, "google"))
+
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_UnlinkIdentity(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/users/profile/identities/google", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().UnlinkIdentity(gomock.Any(), userId, "google").Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.UnlinkIdentity(c, "google"))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - identity not linked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/users/profile/identities/google", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().UnlinkIdentity(gomock.Any(), userId, "google").
+			Times(1).Return(utils.NewErrorWithCode(http.StatusNotFound, "identity not linked"))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.UnlinkIdentity(c, "google"))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_SamlMetadata(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/saml/acme/metadata", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().SAMLMetadata(gomock.Any(), "acme").Times(1).Return([]byte("<EntityDescriptor/>"), nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlMetadata(c, "acme"))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, "application/samlmetadata+xml", rec.Header().Get(echo.HeaderContentType))
+		require.Equal(t, "<EntityDescriptor/>", rec.Body.String())
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/saml/unknown/metadata", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().SAMLMetadata(gomock.Any(), "unknown").
+			Times(1).Return(nil, utils.WrapWithKey(utils.ErrUnknownSAMLProvider, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlMetadata(c, "unknown"))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_SamlLogin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/saml/acme/login", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartSAMLLogin(gomock.Any(), "acme").Times(1).Return("https://idp.example.com/sso?SAMLRequest=request", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlLogin(c, "acme"))
+
+		require.Equal(t, http.StatusFound, rec.Result().StatusCode)
+		require.Equal(t, "https://idp.example.com/sso?SAMLRequest=request", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/saml/unknown/login", nil)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().StartSAMLLogin(gomock.Any(), "unknown").
+			Times(1).Return("", utils.WrapWithKey(utils.ErrUnknownSAMLProvider, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlLogin(c, "unknown"))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_SamlAcs(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/saml/acme/acs", strings.NewReader("SAMLResponse=response&RelayState="))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().HandleSAMLResponse(gomock.Any(), "acme", "response", gomock.Any()).Times(1).Return(model.User{Id: 10}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlAcs(c, "acme"))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.AuthLoginResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, 10, response.Data.Id)
+		require.Equal(t, "jwt", response.Data.Jwt)
+	})
+
+	t.Run("failed - missing response", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/saml/acme/acs", strings.NewReader("RelayState="))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlAcs(c, "acme"))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid assertion", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/saml/acme/acs", strings.NewReader("SAMLResponse=response"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+		mockIdentityUsecase := mocks.NewMockIdentityUsecaseInterface(ctrl)
+		mockIdentityUsecase.EXPECT().HandleSAMLResponse(gomock.Any(), "acme", "response", gomock.Any()).
+			Times(1).Return(model.User{}, "", utils.WrapWithKey(errors.New("bad signature"), utils.ErrorCode(http.StatusUnauthorized), "INVALID_SAML_ASSERTION", "Could Not Verify The Identity Provider's Response."))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{IdentityUsecase: mockIdentityUsecase})
+		render(s, c, s.SamlAcs(c, "acme"))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Equal(t, "INVALID_SAML_ASSERTION", *response.Code)
+	})
+}
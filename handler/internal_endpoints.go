@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (s *Server) GetInternalUser(ctx echo.Context, id int64) error {
+	user, err := s.AdminUsecase.GetUser(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	adminUser := toAdminUser(user)
+	resp := generated.GetUserResponse{
+		Success: true,
+		Message: "successfully get user",
+		Data:    &adminUser,
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
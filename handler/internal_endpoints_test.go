@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_GetInternalUser(t *testing.T) {
+	id := int64(1)
+	user := model.User{
+		Id:          id,
+		FullName:    "John Doe",
+		PhoneNumber: "+6285912345678",
+		Roles:       []string{model.RoleUser},
+		Status:      model.UserStatusActive,
+		CreatedAt:   time.Now(),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/users/1", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().GetUser(gomock.Any(), id).Times(1).Return(user, nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.GetInternalUser(c, id))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.GetUserResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Equal(t, id, response.Data.Id)
+		require.Equal(t, user.FullName, response.Data.FullName)
+	})
+
+	t.Run("failed - get user return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/users/1", nil)
+
+		mockAdminUsecase := mocks.NewMockAdminUsecaseInterface(ctrl)
+		mockAdminUsecase.EXPECT().GetUser(gomock.Any(), id).
+			Times(1).Return(model.User{}, utils.NewErrorWithCode(http.StatusNotFound, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{AdminUsecase: mockAdminUsecase})
+		render(s, c, s.GetInternalUser(c, id))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
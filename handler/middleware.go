@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const (
+	permissionsExtension    = "x-permissions"
+	impersonationExtension  = "x-impersonation"
+	apiKeyExtension         = "x-api-key"
+	servicesExtension       = "x-services"
+	userIdContextKey        = "user_id"
+	authTimeContextKey      = "auth_time"
+	keyThumbprintContextKey = "key_thumbprint"
+	deviceIdContextKey      = "device_id"
+	serviceContextKey       = "service"
+	languageContextKey      = "language"
+
+	impersonationPolicyAllow = "allow"
+	impersonationPolicyDeny  = "deny"
+	apiKeyPolicyDeny         = "deny"
+	anyService               = "*"
+
+	validationFailedCode = "VALIDATION_FAILED"
+
+	acceptLanguageHeader = "Accept-Language"
+)
+
+// Authorize checks that the caller's account is active and holds every
+// permission declared with `x-permissions` on the matched operation. The
+// caller's id is stored in the context under userIdContextKey, and for JWTs
+// the time the caller authenticated under authTimeContextKey. The caller's
+// preferred locale, if any, is stored under languageContextKey to render
+// messages in. Operations without the extension are passed through untouched.
+//
+// JWTs bound to a DPoP key must be sent with the DPoP scheme and a proof of
+// possession of the key, whose thumbprint is stored under
+// keyThumbprintContextKey. JWTs issued to a device are rejected once the
+// device is revoked, its id is stored under deviceIdContextKey.
+//
+// Requests made with an impersonation token are restricted by the
+// operation's `x-impersonation` policy and recorded in the audit log.
+// Requests made with an API key are restricted by the key's scopes and the
+// operation's `x-api-key` policy.
+//
+// Operations declaring `x-services` are only open to the services listed,
+// authenticated by their TLS client certificate instead of a JWT. The
+// caller's service identity is stored under serviceContextKey.
+func (s *Server) Authorize(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) (err error) {
+		route := routeKey(ctx.Request().Method, ctx.Path())
+		if services := s.services[route]; len(services) > 0 {
+			return s.authorizeService(ctx, next, services)
+		}
+
+		permissions := s.permissions[route]
+		if len(permissions) == 0 {
+			return next(ctx)
+		}
+
+		scheme, tokenStr, err := getAuthorization(ctx)
+		if err != nil {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+		}
+
+		var user model.User
+		var authTime time.Time
+		var keyThumbprint string
+		var deviceId int64
+		if utils.IsAPIKey(tokenStr) {
+			if s.apiKeyPolicies[route] == apiKeyPolicyDeny {
+				return utils.NewErrorWithKey(http.StatusForbidden, "API_KEY_FORBIDDEN", "Operation Is Not Allowed With An API Key.")
+			}
+
+			user, err = s.AuthUsecase.AuthenticateAPIKey(ctx.Request().Context(), tokenStr, permissions)
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := s.AuthUtil.ValidateJWTToken(tokenStr); err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			keyThumbprint, err = s.AuthUtil.GetKeyThumbprint(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			if err := s.verifyTokenBinding(ctx, scheme, tokenStr, keyThumbprint); err != nil {
+				log.Error(err)
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `DPoP error="invalid_dpop_proof"`)
+				return invalidDPoPProofError(err, http.StatusUnauthorized)
+			}
+
+			var impersonation model.Impersonation
+			var impersonated bool
+			impersonation, impersonated, err = s.AuthUtil.GetImpersonation(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			if impersonated {
+				defer func() {
+					s.recordImpersonatedRequest(ctx, impersonation, err)
+				}()
+
+				if !s.isImpersonationAllowed(ctx.Request().Method, route) {
+					return utils.NewErrorWithKey(http.StatusForbidden, "IMPERSONATION_FORBIDDEN", "Operation Is Not Allowed While Impersonating.")
+				}
+			}
+
+			user.Id, err = s.AuthUtil.GetUserId(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			user.Roles, err = s.AuthUtil.GetUserRoles(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			authTime, err = s.AuthUtil.GetAuthTime(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+
+			deviceId, err = s.AuthUtil.GetDeviceId(tokenStr)
+			if err != nil {
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "Invalid JWT Token")
+			}
+		}
+
+		account, err := s.AuthUsecase.CheckUserStatus(ctx.Request().Context(), user.Id)
+		if err != nil {
+			return err
+		}
+
+		if account.Locale.Valid {
+			ctx.Set(languageContextKey, account.Locale.String)
+		}
+
+		if deviceId != 0 {
+			if err := s.AuthUsecase.CheckDevice(ctx.Request().Context(), user.Id, deviceId); err != nil {
+				return err
+			}
+		}
+
+		if err := s.AuthUsecase.AuthorizeRoles(ctx.Request().Context(), user.Roles, permissions); err != nil {
+			return err
+		}
+
+		ctx.Set(userIdContextKey, user.Id)
+		ctx.Set(authTimeContextKey, authTime)
+		ctx.Set(keyThumbprintContextKey, keyThumbprint)
+		ctx.Set(deviceIdContextKey, deviceId)
+
+		return next(ctx)
+	}
+}
+
+// authorizeService passes the request on when it is made with the client
+// certificate of one of services.
+func (s *Server) authorizeService(ctx echo.Context, next echo.HandlerFunc, services []string) error {
+	service, err := s.TLSUtil.ServiceIdentity(ctx.Request().TLS)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "CLIENT_CERTIFICATE_REQUIRED", "Client Certificate Of A Known Service Required.")
+	}
+
+	for _, allowed := range services {
+		if allowed == anyService || allowed == service {
+			ctx.Set(serviceContextKey, service)
+			return next(ctx)
+		}
+	}
+
+	return utils.NewErrorWithKey(http.StatusForbidden, "SERVICE_FORBIDDEN", "Operation Is Not Allowed For This Service.")
+}
+
+// isImpersonationAllowed applies the `x-impersonation` policy of the
+// operation. Without a policy only safe methods are allowed.
+func (s *Server) isImpersonationAllowed(method, route string) bool {
+	switch s.impersonationPolicies[route] {
+	case impersonationPolicyAllow:
+		return true
+	case impersonationPolicyDeny:
+		return false
+	default:
+		return method == http.MethodGet || method == http.MethodHead
+	}
+}
+
+func (s *Server) recordImpersonatedRequest(ctx echo.Context, impersonation model.Impersonation, err error) {
+	statusCode := ctx.Response().Status
+	if err != nil && !ctx.Response().Committed {
+		statusCode = errorStatus(err)
+	}
+
+	auditLog := model.ImpersonationAuditLog{
+		ImpersonationId: impersonation.Id,
+		Method:          ctx.Request().Method,
+		Path:            ctx.Request().URL.Path,
+		StatusCode:      statusCode,
+	}
+
+	if err := s.ImpersonationUsecase.RecordImpersonatedRequest(ctx.Request().Context(), auditLog); err != nil {
+		log.Error(err)
+	}
+}
+
+// verifyTokenBinding checks that a token bound to a DPoP key is sent with the
+// DPoP scheme along with a proof of possession of that key, and that other
+// tokens are not, see RFC 9449 section 7.
+func (s *Server) verifyTokenBinding(ctx echo.Context, scheme, tokenStr, keyThumbprint string) error {
+	if !strings.EqualFold(scheme, utils.DPoPAuthScheme) {
+		if keyThumbprint != "" {
+			return errors.New("dpop bound token was sent as a bearer token")
+		}
+
+		return nil
+	}
+
+	if keyThumbprint == "" {
+		return errors.New("bearer token was sent with the dpop scheme")
+	}
+
+	proofThumbprint, err := s.verifyDPoPProof(ctx, tokenStr)
+	if err != nil {
+		return err
+	}
+
+	if proofThumbprint != keyThumbprint {
+		return errors.New("dpop proof is missing or signed by another key than the token is bound to")
+	}
+
+	return nil
+}
+
+// verifyDPoPProof verifies the DPoP proof of the request and returns the
+// thumbprint of its key, or an empty string when the request has none.
+// accessToken is the token the request is authorized with, if any.
+func (s *Server) verifyDPoPProof(ctx echo.Context, accessToken string) (string, error) {
+	proofs := ctx.Request().Header.Values(utils.DPoPHeader)
+	if len(proofs) == 0 {
+		return "", nil
+	}
+
+	if len(proofs) > 1 {
+		return "", errors.New("request has more than one dpop proof")
+	}
+
+	req := ctx.Request()
+	requestURL := ctx.Scheme() + "://" + req.Host + req.URL.Path
+
+	return s.DPoPUtil.VerifyProof(proofs[0], req.Method, requestURL, accessToken)
+}
+
+func invalidDPoPProofError(err error, code int) error {
+	return utils.WrapWithKey(err, utils.ErrorCode(code), "INVALID_DPOP_PROOF", "Invalid DPoP Proof.")
+}
+
+// getAuthorization splits the authorization header into its scheme and
+// token.
+func getAuthorization(ctx echo.Context) (string, string, error) {
+	tokenStr := ctx.Request().Header.Get("authorization")
+	idx := strings.Index(tokenStr, " ")
+	if tokenStr == "" || idx < 0 {
+		return "", "", utils.NewErrorWithCode(http.StatusBadRequest, "invalid jwt token")
+	}
+
+	return tokenStr[:idx], tokenStr[idx+1:], nil
+}
+
+// language is the language messages of the request are rendered in: the
+// caller's preferred locale once authorized, otherwise the best match of the
+// Accept-Language header.
+func language(ctx echo.Context) string {
+	if lang, ok := ctx.Get(languageContextKey).(string); ok && lang != "" {
+		return lang
+	}
+
+	return utils.NegotiateLanguage(ctx.Request().Header.Get(acceptLanguageHeader))
+}
+
+// translate renders a message in the language of the request.
+func translate(ctx echo.Context, format string, args ...interface{}) string {
+	return utils.Translate(language(ctx), format, args...)
+}
+
+// errorMessage renders the message of err in the language of the request.
+func errorMessage(ctx echo.Context, err error) string {
+	return utils.GetLocalizedMessage(err, language(ctx))
+}
+
+// getOperationLists indexes the string list of the given extension, such as
+// `x-permissions`, of every operation in the spec by its echo route.
+func getOperationLists(swagger *openapi3.T, extension string) map[string][]string {
+	lists := make(map[string][]string)
+	if swagger == nil {
+		return lists
+	}
+
+	for path, pathItem := range swagger.Paths {
+		for method, operation := range pathItem.Operations() {
+			values, ok := operation.Extensions[extension].([]interface{})
+			if !ok {
+				continue
+			}
+
+			for _, value := range values {
+				if item, ok := value.(string); ok {
+					key := routeKey(method, toEchoPath(path))
+					lists[key] = append(lists[key], item)
+				}
+			}
+		}
+	}
+
+	return lists
+}
+
+// getOperationPolicies indexes the string value of the given extension, such
+// as `x-impersonation`, of every operation in the spec by its echo route.
+func getOperationPolicies(swagger *openapi3.T, extension string) map[string]string {
+	policies := make(map[string]string)
+	if swagger == nil {
+		return policies
+	}
+
+	for path, pathItem := range swagger.Paths {
+		for method, operation := range pathItem.Operations() {
+			if policy, ok := operation.Extensions[extension].(string); ok {
+				policies[routeKey(method, toEchoPath(path))] = policy
+			}
+		}
+	}
+
+	return policies
+}
+
+func toEchoPath(path string) string {
+	path = strings.ReplaceAll(path, "{", ":")
+	return strings.ReplaceAll(path, "}", "")
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
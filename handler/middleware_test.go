@@ -0,0 +1,617 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_Authorize(t *testing.T) {
+	jwtDuration, err := time.ParseDuration(jwtDurationStr)
+	require.NoError(t, err)
+
+	authUtil, err := utils.InitAuth(utils.AuthOptions{
+		JWTSecretKey:      jwtSecretKey,
+		JWTExpiryDuration: jwtDuration,
+	})
+	require.NoError(t, err)
+
+	swagger, err := generated.GetSwagger()
+	require.NoError(t, err)
+
+	roles := []string{model.RoleUser}
+	jwt, err := authUtil.GenerateJWTToken(model.User{Id: 10, Roles: roles}, []string{utils.AuthMethodPassword}, "", 0)
+	require.NoError(t, err)
+
+	boundJwt, err := authUtil.GenerateJWTToken(model.User{Id: 10, Roles: roles}, []string{utils.AuthMethodPassword}, "jkt", 0)
+	require.NoError(t, err)
+
+	next := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+
+	newContext := func(req *http.Request, rec *httptest.ResponseRecorder) echo.Context {
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/users/profile")
+		return c
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("success - operation without permissions", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/auth/login")
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+
+		err := s.Authorize(next)(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - missing jwt token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid jwt token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", dummyJwt))
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - missing permission", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusForbidden, ""))
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotEmpty(t, response.Detail)
+	})
+
+	t.Run("failed - message in preferred locale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+		req.Header.Set("Accept-Language", "en")
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).
+			Times(1).Return(model.User{Id: 10, Locale: null.StringFrom(utils.LanguageIndonesian)}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).
+			Times(1).Return(utils.NewErrorWithCode(http.StatusForbidden, ""))
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.Equal(t, "Akses Ditolak. Anda dilarang mengakses sumber daya ini", response.Detail)
+	})
+
+	t.Run("failed - account suspended", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).
+			Times(1).Return(model.User{}, utils.WrapWithKey(errors.New("account is suspended"), utils.ErrorCode(http.StatusForbidden), "ACCOUNT_SUSPENDED", ""))
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "ACCOUNT_SUSPENDED", *response.Code)
+	})
+
+	deviceJwt, err := authUtil.GenerateJWTToken(model.User{Id: 10, Roles: roles}, []string{utils.AuthMethodPassword}, "", 3)
+	require.NoError(t, err)
+
+	t.Run("success - device token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", deviceJwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().CheckDevice(gomock.Any(), int64(10), int64(3)).Times(1).Return(nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		err := s.Authorize(next)(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, int64(3), c.Get(deviceIdContextKey))
+	})
+
+	t.Run("failed - device revoked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", deviceJwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().CheckDevice(gomock.Any(), int64(10), int64(3)).
+			Times(1).Return(utils.WrapWithKey(errors.New("device is revoked"), utils.ErrorCode(http.StatusUnauthorized), "SESSION_REVOKED", ""))
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "SESSION_REVOKED", *response.Code)
+	})
+
+	impersonationJwt, err := authUtil.GenerateImpersonationToken(model.User{Id: 10, Roles: roles}, model.Impersonation{
+		Id:        5,
+		ActorId:   null.IntFrom(1),
+		UserId:    10,
+		ExpiresAt: time.Now().Add(15 * time.Minute),
+	})
+	require.NoError(t, err)
+
+	t.Run("success - impersonated read is allowed and audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", impersonationJwt))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).Times(1).Return(nil)
+
+		mockImpersonationUsecase := mocks.NewMockImpersonationUsecaseInterface(ctrl)
+		mockImpersonationUsecase.EXPECT().RecordImpersonatedRequest(gomock.Any(), model.ImpersonationAuditLog{
+			ImpersonationId: 5,
+			Method:          http.MethodGet,
+			Path:            "/v1/users/profile",
+			StatusCode:      http.StatusOK,
+		}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase:          mockAuthUsecase,
+			ImpersonationUsecase: mockImpersonationUsecase,
+			AuthUtil:             authUtil,
+			Swagger:              swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - impersonated write is forbidden and audited", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", impersonationJwt))
+
+		mockImpersonationUsecase := mocks.NewMockImpersonationUsecaseInterface(ctrl)
+		mockImpersonationUsecase.EXPECT().RecordImpersonatedRequest(gomock.Any(), model.ImpersonationAuditLog{
+			ImpersonationId: 5,
+			Method:          http.MethodPatch,
+			Path:            "/v1/users/profile",
+			StatusCode:      http.StatusForbidden,
+		}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			ImpersonationUsecase: mockImpersonationUsecase,
+			AuthUtil:             authUtil,
+			Swagger:              swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "IMPERSONATION_FORBIDDEN", *response.Code)
+	})
+
+	t.Run("failed - impersonation denied by operation policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile/export/1", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", impersonationJwt))
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/users/profile/export/:id")
+
+		mockImpersonationUsecase := mocks.NewMockImpersonationUsecaseInterface(ctrl)
+		mockImpersonationUsecase.EXPECT().RecordImpersonatedRequest(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			ImpersonationUsecase: mockImpersonationUsecase,
+			AuthUtil:             authUtil,
+			Swagger:              swagger,
+		})
+
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+
+	t.Run("success - api key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		apiKey := utils.APIKeyPrefix + "0123456789abcdef"
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", apiKey))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().AuthenticateAPIKey(gomock.Any(), apiKey, []string{"profile:read"}).
+			Times(1).Return(model.User{Id: 10, Roles: roles}, nil)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		err := s.Authorize(next)(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, int64(10), c.Get(userIdContextKey))
+	})
+
+	t.Run("failed - api key is missing scope", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		apiKey := utils.APIKeyPrefix + "0123456789abcdef"
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", apiKey))
+
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().AuthenticateAPIKey(gomock.Any(), apiKey, []string{"profile:read"}).
+			Times(1).Return(model.User{}, utils.WrapWithKey(errors.New("missing scope"), utils.ErrorCode(http.StatusForbidden), "API_KEY_SCOPE_MISSING", ""))
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "API_KEY_SCOPE_MISSING", *response.Code)
+	})
+
+	t.Run("failed - api key denied by operation policy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users/profile/api-keys", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s0123456789abcdef", utils.APIKeyPrefix))
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/users/profile/api-keys")
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, Swagger: swagger})
+
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "API_KEY_FORBIDDEN", *response.Code)
+	})
+
+	t.Run("success - dpop bound token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("DPoP %s", boundJwt))
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		mockDPoPUtil := mockUtils.NewMockDPoPInterface(ctrl)
+		mockDPoPUtil.EXPECT().VerifyProof("proof", http.MethodGet, "http://example.com/v1/users/profile", boundJwt).Times(1).Return("jkt", nil)
+		mockAuthUsecase := mocks.NewMockAuthUsecaseInterface(ctrl)
+		mockAuthUsecase.EXPECT().CheckUserStatus(gomock.Any(), int64(10)).Times(1).Return(model.User{Id: 10}, nil)
+		mockAuthUsecase.EXPECT().AuthorizeRoles(gomock.Any(), roles, []string{"profile:read"}).Times(1).Return(nil)
+
+		s := NewServer(NewServerOptions{
+			AuthUsecase: mockAuthUsecase,
+			AuthUtil:    authUtil,
+			DPoPUtil:    mockDPoPUtil,
+			Swagger:     swagger,
+		})
+
+		c := newContext(req, rec)
+		err := s.Authorize(next)(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, "jkt", c.Get(keyThumbprintContextKey))
+	})
+
+	t.Run("failed - dpop bound token sent as bearer token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", boundJwt))
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+		require.Equal(t, `DPoP error="invalid_dpop_proof"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "INVALID_DPOP_PROOF", *response.Code)
+	})
+
+	t.Run("failed - dpop proof signed by another key", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("DPoP %s", boundJwt))
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		mockDPoPUtil := mockUtils.NewMockDPoPInterface(ctrl)
+		mockDPoPUtil.EXPECT().VerifyProof("proof", http.MethodGet, gomock.Any(), boundJwt).Times(1).Return("other", nil)
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, DPoPUtil: mockDPoPUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - invalid dpop proof", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("DPoP %s", boundJwt))
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		mockDPoPUtil := mockUtils.NewMockDPoPInterface(ctrl)
+		mockDPoPUtil.EXPECT().VerifyProof("proof", http.MethodGet, gomock.Any(), boundJwt).Times(1).Return("", errors.New("dpop proof was already used"))
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, DPoPUtil: mockDPoPUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - bearer token sent with dpop scheme", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+		req.Header.Set("authorization", fmt.Sprintf("DPoP %s", jwt))
+		req.Header.Set(utils.DPoPHeader, "proof")
+
+		s := NewServer(NewServerOptions{AuthUtil: authUtil, Swagger: swagger})
+
+		c := newContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+	})
+
+	newInternalContext := func(req *http.Request, rec *httptest.ResponseRecorder) echo.Context {
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/internal/users/:id")
+		return c
+	}
+
+	t.Run("success - internal service", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/users/1", nil)
+
+		mockTLSUtil := mockUtils.NewMockTLSInterface(ctrl)
+		mockTLSUtil.EXPECT().ServiceIdentity(req.TLS).Times(1).Return("billing", nil)
+
+		s := NewServer(NewServerOptions{TLSUtil: mockTLSUtil, Swagger: swagger})
+
+		c := newInternalContext(req, rec)
+		err := s.Authorize(next)(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		require.Equal(t, "billing", c.Get(serviceContextKey))
+	})
+
+	t.Run("failed - internal operation without client certificate", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/users/1", nil)
+		req.Header.Set("authorization", fmt.Sprintf("Bearer %s", jwt))
+
+		mockTLSUtil := mockUtils.NewMockTLSInterface(ctrl)
+		mockTLSUtil.EXPECT().ServiceIdentity(gomock.Any()).Times(1).Return("", utils.ErrNoClientCertificate)
+
+		s := NewServer(NewServerOptions{TLSUtil: mockTLSUtil, Swagger: swagger})
+
+		c := newInternalContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "CLIENT_CERTIFICATE_REQUIRED", *response.Code)
+	})
+
+	t.Run("failed - service not allowed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/internal/users/1", nil)
+
+		mockTLSUtil := mockUtils.NewMockTLSInterface(ctrl)
+		mockTLSUtil.EXPECT().ServiceIdentity(gomock.Any()).Times(1).Return("billing", nil)
+
+		s := NewServer(NewServerOptions{TLSUtil: mockTLSUtil, Swagger: swagger})
+		s.services[routeKey(http.MethodGet, "/v1/internal/users/:id")] = []string{"reports"}
+
+		c := newInternalContext(req, rec)
+		render(s, c, s.Authorize(next)(c))
+		require.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.NotNil(t, response.Code)
+		require.Equal(t, "SERVICE_FORBIDDEN", *response.Code)
+	})
+}
@@ -3,24 +3,76 @@ package handler
 import (
 	"github.com/SawitProRecruitment/UserService/usecase"
 	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
 )
 
 type Server struct {
-	AuthUsecase usecase.AuthUsecaseInterface
-	UserUsecase usecase.UserUsecaseInterface
-	AuthUtil    utils.AuthInterface
+	AdminUsecase          usecase.AdminUsecaseInterface
+	APIKeyUsecase         usecase.APIKeyUsecaseInterface
+	AuthUsecase           usecase.AuthUsecaseInterface
+	CaptchaUsecase        usecase.CaptchaUsecaseInterface
+	EmailUsecase          usecase.EmailUsecaseInterface
+	ExportUsecase         usecase.ExportUsecaseInterface
+	IdentityUsecase       usecase.IdentityUsecaseInterface
+	ImpersonationUsecase  usecase.ImpersonationUsecaseInterface
+	UserUsecase           usecase.UserUsecaseInterface
+	WebAuthnUsecase       usecase.WebAuthnUsecaseInterface
+	AuthUtil              utils.AuthInterface
+	DPoPUtil              utils.DPoPInterface
+	PhoneUtil             utils.PhoneInterface
+	TLSUtil               utils.TLSInterface
+	permissions           map[string][]string
+	services              map[string][]string
+	impersonationPolicies map[string]string
+	apiKeyPolicies        map[string]string
+	routes                map[string]*routers.Route
+	validateResponses     bool
 }
 
 type NewServerOptions struct {
-	AuthUsecase usecase.AuthUsecaseInterface
-	UserUsecase usecase.UserUsecaseInterface
-	AuthUtil    utils.AuthInterface
+	AdminUsecase         usecase.AdminUsecaseInterface
+	APIKeyUsecase        usecase.APIKeyUsecaseInterface
+	AuthUsecase          usecase.AuthUsecaseInterface
+	CaptchaUsecase       usecase.CaptchaUsecaseInterface
+	EmailUsecase         usecase.EmailUsecaseInterface
+	ExportUsecase        usecase.ExportUsecaseInterface
+	IdentityUsecase      usecase.IdentityUsecaseInterface
+	ImpersonationUsecase usecase.ImpersonationUsecaseInterface
+	UserUsecase          usecase.UserUsecaseInterface
+	WebAuthnUsecase      usecase.WebAuthnUsecaseInterface
+	AuthUtil             utils.AuthInterface
+	DPoPUtil             utils.DPoPInterface
+	PhoneUtil            utils.PhoneInterface
+	TLSUtil              utils.TLSInterface
+	Swagger              *openapi3.T
+	// ValidateResponses makes ValidateRequest check responses against
+	// Swagger as well, meant for tests and development.
+	ValidateResponses bool
 }
 
 func NewServer(opts NewServerOptions) *Server {
 	return &Server{
-		AuthUsecase: opts.AuthUsecase,
-		UserUsecase: opts.UserUsecase,
-		AuthUtil:    opts.AuthUtil,
+		AdminUsecase:          opts.AdminUsecase,
+		APIKeyUsecase:         opts.APIKeyUsecase,
+		AuthUsecase:           opts.AuthUsecase,
+		CaptchaUsecase:        opts.CaptchaUsecase,
+		EmailUsecase:          opts.EmailUsecase,
+		ExportUsecase:         opts.ExportUsecase,
+		IdentityUsecase:       opts.IdentityUsecase,
+		ImpersonationUsecase:  opts.ImpersonationUsecase,
+		UserUsecase:           opts.UserUsecase,
+		WebAuthnUsecase:       opts.WebAuthnUsecase,
+		AuthUtil:              opts.AuthUtil,
+		DPoPUtil:              opts.DPoPUtil,
+		PhoneUtil:             opts.PhoneUtil,
+		TLSUtil:               opts.TLSUtil,
+		permissions:           getOperationLists(opts.Swagger, permissionsExtension),
+		services:              getOperationLists(opts.Swagger, servicesExtension),
+		impersonationPolicies: getOperationPolicies(opts.Swagger, impersonationExtension),
+		apiKeyPolicies:        getOperationPolicies(opts.Swagger, apiKeyExtension),
+		routes:                getOperationRoutes(opts.Swagger),
+		validateResponses:     opts.ValidateResponses,
 	}
 }
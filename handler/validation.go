@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// ValidateRequest checks requests against the parameters and body schema the
+// matched operation declares in api.yml, failing them with every failed rule
+// like the handlers' own validation does. Authentication is left to
+// Authorize. Operations missing from the spec are passed through untouched.
+//
+// With validateResponses, responses are checked as well and replaced with a
+// 500 when they do not match the spec, so tests catch handlers drifting from
+// it.
+func (s *Server) ValidateRequest(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		route, ok := s.routes[routeKey(ctx.Request().Method, ctx.Path())]
+		if !ok {
+			return next(ctx)
+		}
+
+		pathParams := make(map[string]string, len(ctx.ParamNames()))
+		for i, name := range ctx.ParamNames() {
+			pathParams[name] = ctx.ParamValues()[i]
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    ctx.Request(),
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+
+		if err := openapi3filter.ValidateRequest(ctx.Request().Context(), input); err != nil {
+			return utils.OpenAPIValidationErrors(err)
+		}
+
+		if !s.validateResponses {
+			return next(ctx)
+		}
+
+		return validateResponse(ctx, next, input)
+	}
+}
+
+// validateResponse buffers the response of next to check it against the spec
+// before sending it.
+func validateResponse(ctx echo.Context, next echo.HandlerFunc, input *openapi3filter.RequestValidationInput) error {
+	writer := ctx.Response().Writer
+	recorder := &responseRecorder{ResponseWriter: writer, status: http.StatusOK}
+	ctx.Response().Writer = recorder
+
+	err := next(ctx)
+	ctx.Response().Writer = writer
+	if err != nil || !ctx.Response().Committed {
+		return err
+	}
+
+	err = openapi3filter.ValidateResponse(ctx.Request().Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.status,
+		Header:                 writer.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options:                &openapi3filter.Options{MultiError: true},
+	})
+	if err != nil {
+		log.Error(err)
+		problem := newProblem(ctx, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "Response does not match the spec: %s", err.Error()))
+		writer.Header().Set(echo.HeaderContentType, problemContentType)
+		writer.Header().Del(echo.HeaderContentLength)
+		writer.WriteHeader(problem.Status)
+		return json.NewEncoder(writer).Encode(problem)
+	}
+
+	writer.WriteHeader(recorder.status)
+	_, err = writer.Write(recorder.body.Bytes())
+	return err
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	return r.body.Write(data)
+}
+
+// getOperationRoutes indexes every operation in the spec by its echo route.
+func getOperationRoutes(swagger *openapi3.T) map[string]*routers.Route {
+	routes := make(map[string]*routers.Route)
+	if swagger == nil {
+		return routes
+	}
+
+	for path, pathItem := range swagger.Paths {
+		for method, operation := range pathItem.Operations() {
+			routes[routeKey(method, toEchoPath(path))] = &routers.Route{
+				Spec:      swagger,
+				Path:      path,
+				PathItem:  pathItem,
+				Method:    method,
+				Operation: operation,
+			}
+		}
+	}
+
+	return routes
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_ValidateRequest(t *testing.T) {
+	swagger, err := generated.GetSwagger()
+	require.NoError(t, err)
+
+	next := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+
+	newContext := func(req *http.Request, rec *httptest.ResponseRecorder, path string) echo.Context {
+		c := echo.New().NewContext(req, rec)
+		c.SetPath(path)
+		return c
+	}
+
+	t.Run("success", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"phone_number":"+6281234567890","password":"Password1!"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		c := newContext(req, rec, "/v1/auth/login")
+		render(s, c, s.ValidateRequest(next)(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("success - login with phone number that is not valid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login", strings.NewReader(`{"phone_number":"081234567890","password":"Password1!"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		c := newContext(req, rec, "/v1/auth/login")
+		render(s, c, s.ValidateRequest(next)(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("success - operation not in spec", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		c := newContext(req, rec, "/unknown")
+		render(s, c, s.ValidateRequest(next)(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("failed - invalid body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(`{"full_name":"ab","phone_number":"+6281234567890","password":"Password1!"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		c := newContext(req, rec, "/v1/users")
+		render(s, c, s.ValidateRequest(next)(c))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Equal(t, validationFailedCode, *resp.Code)
+		require.Len(t, *resp.Errors, 1)
+		require.Equal(t, "full_name", (*resp.Errors)[0].Field)
+		require.Equal(t, utils.ValidationCodeLength, (*resp.Errors)[0].Code)
+	})
+
+	t.Run("failed - invalid query parameter", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users?limit=1000", nil)
+
+		s := NewServer(NewServerOptions{Swagger: swagger})
+		c := newContext(req, rec, "/v1/admin/users")
+		render(s, c, s.ValidateRequest(next)(c))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, *resp.Errors, 1)
+		require.Equal(t, "limit", (*resp.Errors)[0].Field)
+		require.Equal(t, utils.ValidationCodeRange, (*resp.Errors)[0].Code)
+	})
+
+	t.Run("success - response matches spec", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+
+		s := NewServer(NewServerOptions{Swagger: swagger, ValidateResponses: true})
+		c := newContext(req, rec, "/v1/users/profile")
+		render(s, c, s.ValidateRequest(func(ctx echo.Context) error {
+			return ctx.JSON(http.StatusOK, generated.GetUserProfileResponse{
+				Success: true,
+				Message: "Successfully get user profile",
+				Data: &generated.GetUserProfileResponseData{
+					Id:          10,
+					FullName:    "John Doe",
+					PhoneNumber: "+6281234567890",
+				},
+			})
+		})(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("failed - response does not match spec", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/profile", nil)
+
+		s := NewServer(NewServerOptions{Swagger: swagger, ValidateResponses: true})
+		c := newContext(req, rec, "/v1/users/profile")
+		render(s, c, s.ValidateRequest(func(ctx echo.Context) error {
+			return ctx.JSON(http.StatusOK, map[string]interface{}{"success": "yes"})
+		})(c))
+		require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		var resp generated.Problem
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Contains(t, resp.Detail, "Response does not match the spec")
+	})
+}
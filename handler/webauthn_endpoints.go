@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+const webAuthnCredentialType = "public-key"
+
+func (s *Server) BeginWebAuthnRegistration(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	authTime, _ := ctx.Get(authTimeContextKey).(time.Time)
+	options, err := s.WebAuthnUsecase.BeginRegistration(ctx.Request().Context(), userId, authTime)
+	if err != nil {
+		return err
+	}
+
+	params := make([]generated.WebAuthnCredentialParameters, 0, len(options.Algorithms))
+	for _, alg := range options.Algorithms {
+		params = append(params, generated.WebAuthnCredentialParameters{Type: webAuthnCredentialType, Alg: alg})
+	}
+
+	resp := generated.WebAuthnCreationOptionsResponse{
+		Success: true,
+		Message: "successfully start registering passkey",
+		Data: &generated.WebAuthnCreationOptions{
+			Rp: generated.WebAuthnRelyingParty{Id: options.RPID, Name: options.RPName},
+			User: generated.WebAuthnUser{
+				Id:          options.UserID,
+				Name:        options.UserName,
+				DisplayName: options.UserDisplayName,
+			},
+			Challenge:          options.Challenge,
+			PubKeyCredParams:   params,
+			Timeout:            options.Timeout.Milliseconds(),
+			ExcludeCredentials: toWebAuthnCredentialDescriptors(options.ExcludeCredentials),
+			AuthenticatorSelection: generated.WebAuthnAuthenticatorSelection{
+				ResidentKey:        options.ResidentKey,
+				RequireResidentKey: options.ResidentKey == "required",
+				UserVerification:   options.UserVerification,
+			},
+			Attestation: options.Attestation,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) FinishWebAuthnRegistration(ctx echo.Context) error {
+	req := generated.FinishWebAuthnRegistrationJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	var name string
+	if req.Name != nil {
+		name = *req.Name
+	}
+
+	response := utils.WebAuthnAttestationResponse{
+		ClientDataJSON:    req.Credential.Response.ClientDataJSON,
+		AttestationObject: req.Credential.Response.AttestationObject,
+	}
+
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	credential, err := s.WebAuthnUsecase.FinishRegistration(ctx.Request().Context(), userId, name, response)
+	if err != nil {
+		return err
+	}
+
+	data := toWebAuthnCredential(credential)
+	resp := generated.WebAuthnCredentialResponse{
+		Success: true,
+		Message: "successfully register passkey",
+		Data:    &data,
+	}
+
+	return ctx.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) BeginWebAuthnLogin(ctx echo.Context) error {
+	options, err := s.WebAuthnUsecase.BeginLogin(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	resp := generated.WebAuthnRequestOptionsResponse{
+		Success: true,
+		Message: "successfully start logging in with passkey",
+		Data: &generated.WebAuthnRequestOptions{
+			Challenge:        options.Challenge,
+			RpId:             options.RPID,
+			Timeout:          options.Timeout.Milliseconds(),
+			AllowCredentials: make([]generated.WebAuthnCredentialDescriptor, 0),
+			UserVerification: options.UserVerification,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) FinishWebAuthnLogin(ctx echo.Context) error {
+	req := generated.FinishWebAuthnLoginJSONRequestBody{}
+	if err := ctx.Bind(&req); err != nil {
+		return utils.NewErrorWithCode(http.StatusBadRequest, "Invalid Input.")
+	}
+
+	response := utils.WebAuthnAssertionResponse{
+		ClientDataJSON:    req.Credential.Response.ClientDataJSON,
+		AuthenticatorData: req.Credential.Response.AuthenticatorData,
+		Signature:         req.Credential.Response.Signature,
+	}
+
+	if req.Credential.Response.UserHandle != nil {
+		response.UserHandle = *req.Credential.Response.UserHandle
+	}
+
+	keyThumbprint, err := s.verifyDPoPProof(ctx, "")
+	if err != nil {
+		log.Error(err)
+		return invalidDPoPProofError(err, http.StatusBadRequest)
+	}
+
+	user, jwt, err := s.WebAuthnUsecase.FinishLogin(ctx.Request().Context(), req.Credential.Id, response, keyThumbprint, loginClient(ctx))
+	if err != nil {
+		return err
+	}
+
+	resp := generated.AuthLoginResponse{
+		Success: true,
+		Message: "successfully logged-in user",
+		Data: &generated.AuthLoginResponseData{
+			Id:  int(user.Id),
+			Jwt: jwt,
+		},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ListWebAuthnCredentials(ctx echo.Context) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	credentials, err := s.WebAuthnUsecase.ListCredentials(ctx.Request().Context(), userId)
+	if err != nil {
+		return err
+	}
+
+	data := make([]generated.WebAuthnCredential, 0, len(credentials))
+	for _, credential := range credentials {
+		data = append(data, toWebAuthnCredential(credential))
+	}
+
+	resp := generated.ListWebAuthnCredentialsResponse{
+		Success: true,
+		Message: "successfully list passkeys",
+		Data:    &generated.ListWebAuthnCredentialsResponseData{Credentials: data},
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DeleteWebAuthnCredential(ctx echo.Context, id int64) error {
+	userId, _ := ctx.Get(userIdContextKey).(int64)
+	if err := s.WebAuthnUsecase.DeleteCredential(ctx.Request().Context(), userId, id); err != nil {
+		return err
+	}
+
+	resp := generated.DeleteWebAuthnCredentialResponse{
+		Success: true,
+		Message: "successfully delete passkey",
+	}
+
+	return ctx.JSON(http.StatusOK, resp)
+}
+
+func toWebAuthnCredential(credential model.WebAuthnCredential) generated.WebAuthnCredential {
+	return generated.WebAuthnCredential{
+		Id:         credential.Id,
+		Name:       credential.Name,
+		Aaguid:     credential.AAGUID,
+		LastUsedAt: credential.LastUsedAt.Ptr(),
+		CreatedAt:  credential.CreatedAt,
+	}
+}
+
+func toWebAuthnCredentialDescriptors(credentialIds []string) []generated.WebAuthnCredentialDescriptor {
+	descriptors := make([]generated.WebAuthnCredentialDescriptor, 0, len(credentialIds))
+	for _, id := range credentialIds {
+		descriptors = append(descriptors, generated.WebAuthnCredentialDescriptor{Type: webAuthnCredentialType, Id: id})
+	}
+
+	return descriptors
+}
@@ -0,0 +1,428 @@
+package handler
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_BeginWebAuthnRegistration(t *testing.T) {
+	userId := int64(10)
+	authTime := time.Now()
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/register/begin", nil)
+
+		options := utils.WebAuthnCreationOptions{
+			RPID:               "users.example.com",
+			RPName:             "User Service",
+			UserID:             "MTA",
+			UserName:           "+6281234567890",
+			UserDisplayName:    "John Doe",
+			Challenge:          "challenge",
+			Algorithms:         []int64{utils.COSEAlgorithmES256, utils.COSEAlgorithmRS256},
+			Timeout:            5 * time.Minute,
+			ExcludeCredentials: []string{"AQID"},
+			ResidentKey:        "required",
+			UserVerification:   "required",
+			Attestation:        utils.AttestationFormatNone,
+		}
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().BeginRegistration(gomock.Any(), userId, authTime).Times(1).Return(options, nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.BeginWebAuthnRegistration(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.WebAuthnCreationOptionsResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Equal(t, "challenge", response.Data.Challenge)
+		require.Equal(t, "MTA", response.Data.User.Id)
+		require.Equal(t, int64(300000), response.Data.Timeout)
+		require.Equal(t, []generated.WebAuthnCredentialParameters{{Type: "public-key", Alg: -7}, {Type: "public-key", Alg: -257}}, response.Data.PubKeyCredParams)
+		require.Equal(t, []generated.WebAuthnCredentialDescriptor{{Type: "public-key", Id: "AQID"}}, response.Data.ExcludeCredentials)
+		require.True(t, response.Data.AuthenticatorSelection.RequireResidentKey)
+	})
+
+	t.Run("failed - begin registration return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/register/begin", nil)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().BeginRegistration(gomock.Any(), userId, authTime).
+			Times(1).Return(utils.WebAuthnCreationOptions{}, utils.WrapWithKey(utils.ErrWebAuthnDisabled, utils.ErrorCode(http.StatusNotFound), "WEBAUTHN_DISABLED", "Passkeys Are Not Enabled."))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		c.Set(authTimeContextKey, authTime)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.BeginWebAuthnRegistration(c))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+
+		var response generated.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.NotNil(t, response.Code)
+		require.Equal(t, "WEBAUTHN_DISABLED", *response.Code)
+	})
+}
+
+func TestHandler_FinishWebAuthnRegistration(t *testing.T) {
+	userId := int64(10)
+	name := "YubiKey"
+	payload := generated.FinishWebAuthnRegistrationJSONRequestBody{
+		Name: &name,
+		Credential: generated.WebAuthnAttestationCredential{
+			Id:   "AQID",
+			Type: "public-key",
+			Response: generated.WebAuthnAttestationResponse{
+				ClientDataJSON:    "client-data",
+				AttestationObject: "attestation",
+			},
+		},
+	}
+	response := utils.WebAuthnAttestationResponse{ClientDataJSON: "client-data", AttestationObject: "attestation"}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/register/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		credential := model.WebAuthnCredential{Id: 3, UserId: userId, CredentialId: "AQID", Name: name, CreatedAt: time.Now()}
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().FinishRegistration(gomock.Any(), userId, name, response).Times(1).Return(credential, nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.FinishWebAuthnRegistration(c))
+
+		require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+
+		var resp generated.WebAuthnCredentialResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &resp)
+		require.NoError(t, err)
+
+		require.True(t, resp.Success)
+		require.Equal(t, int64(3), resp.Data.Id)
+		require.Equal(t, name, resp.Data.Name)
+		require.Nil(t, resp.Data.LastUsedAt)
+	})
+
+	t.Run("failed - invalid payload", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		invalid := payload
+		invalid.Credential.Type = "password"
+		payloadJSON, err := json.Marshal(invalid)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/register/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		swagger, err := generated.GetSwagger()
+		require.NoError(t, err)
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/auth/webauthn/register/finish")
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mocks.NewMockWebAuthnUsecaseInterface(ctrl), Swagger: swagger})
+		render(s, c, s.ValidateRequest(s.FinishWebAuthnRegistration)(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - finish registration return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/register/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().FinishRegistration(gomock.Any(), userId, name, response).
+			Times(1).Return(model.WebAuthnCredential{}, utils.NewErrorWithCode(http.StatusConflict, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.FinishWebAuthnRegistration(c))
+
+		require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_BeginWebAuthnLogin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/login/begin", nil)
+
+		options := utils.WebAuthnRequestOptions{RPID: "users.example.com", Challenge: "challenge", Timeout: time.Minute, UserVerification: "required"}
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().BeginLogin(gomock.Any()).Times(1).Return(options, nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.BeginWebAuthnLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.WebAuthnRequestOptionsResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Equal(t, "challenge", response.Data.Challenge)
+		require.Equal(t, "users.example.com", response.Data.RpId)
+		require.Equal(t, int64(60000), response.Data.Timeout)
+		require.Empty(t, response.Data.AllowCredentials)
+	})
+
+	t.Run("failed - begin login return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/login/begin", nil)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().BeginLogin(gomock.Any()).
+			Times(1).Return(utils.WebAuthnRequestOptions{}, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.BeginWebAuthnLogin(c))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_FinishWebAuthnLogin(t *testing.T) {
+	userHandle := "MTA"
+	payload := generated.FinishWebAuthnLoginJSONRequestBody{
+		Credential: generated.WebAuthnAssertionCredential{
+			Id:   "AQID",
+			Type: "public-key",
+			Response: generated.WebAuthnAssertionResponse{
+				ClientDataJSON:    "client-data",
+				AuthenticatorData: "auth-data",
+				Signature:         "signature",
+				UserHandle:        &userHandle,
+			},
+		},
+	}
+	response := utils.WebAuthnAssertionResponse{
+		ClientDataJSON:    "client-data",
+		AuthenticatorData: "auth-data",
+		Signature:         "signature",
+		UserHandle:        userHandle,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/login/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().FinishLogin(gomock.Any(), "AQID", response, "", gomock.Any()).Times(1).Return(model.User{Id: 10}, "jwt", nil)
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.FinishWebAuthnLogin(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var resp generated.AuthLoginResponse
+		err = json.Unmarshal(rec.Body.Bytes(), &resp)
+		require.NoError(t, err)
+
+		require.True(t, resp.Success)
+		require.Equal(t, 10, resp.Data.Id)
+		require.Equal(t, "jwt", resp.Data.Jwt)
+	})
+
+	t.Run("failed - invalid payload", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		invalid := payload
+		invalid.Credential.Response.Signature = ""
+		payloadJSON, err := json.Marshal(invalid)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/login/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		swagger, err := generated.GetSwagger()
+		require.NoError(t, err)
+
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/v1/auth/webauthn/login/finish")
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mocks.NewMockWebAuthnUsecaseInterface(ctrl), Swagger: swagger})
+		render(s, c, s.ValidateRequest(s.FinishWebAuthnLogin)(c))
+
+		require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - finish login return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payloadJSON, err := json.Marshal(payload)
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/webauthn/login/finish", bytes.NewReader(payloadJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().FinishLogin(gomock.Any(), "AQID", response, "", gomock.Any()).
+			Times(1).Return(model.User{}, "", utils.NewErrorWithCode(http.StatusUnauthorized, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.FinishWebAuthnLogin(c))
+
+		require.Equal(t, http.StatusUnauthorized, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_ListWebAuthnCredentials(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/webauthn/credentials", nil)
+
+		credentials := []model.WebAuthnCredential{{Id: 3, UserId: userId, CredentialId: "AQID", Name: "Passkey", CreatedAt: time.Now()}}
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().ListCredentials(gomock.Any(), userId).Times(1).Return(credentials, nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.ListWebAuthnCredentials(c))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+		var response generated.ListWebAuthnCredentialsResponse
+		err := json.Unmarshal(rec.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		require.True(t, response.Success)
+		require.Len(t, response.Data.Credentials, 1)
+		require.Equal(t, "Passkey", response.Data.Credentials[0].Name)
+	})
+
+	t.Run("failed - list credentials return error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/auth/webauthn/credentials", nil)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().ListCredentials(gomock.Any(), userId).
+			Times(1).Return(nil, utils.NewErrorWithCode(http.StatusInternalServerError, "usecase error"))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.ListWebAuthnCredentials(c))
+
+		require.Equal(t, http.StatusInternalServerError, rec.Result().StatusCode)
+	})
+}
+
+func TestHandler_DeleteWebAuthnCredential(t *testing.T) {
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/webauthn/credentials/3", nil)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().DeleteCredential(gomock.Any(), userId, int64(3)).Times(1).Return(nil)
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.DeleteWebAuthnCredential(c, 3))
+
+		require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	})
+
+	t.Run("failed - credential not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodDelete, "/v1/auth/webauthn/credentials/3", nil)
+
+		mockWebAuthnUsecase := mocks.NewMockWebAuthnUsecaseInterface(ctrl)
+		mockWebAuthnUsecase.EXPECT().DeleteCredential(gomock.Any(), userId, int64(3)).
+			Times(1).Return(utils.WrapWithCode(sql.ErrNoRows, utils.ErrorCode(http.StatusNotFound), ""))
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(userIdContextKey, userId)
+		s := NewServer(NewServerOptions{WebAuthnUsecase: mockWebAuthnUsecase})
+		render(s, c, s.DeleteWebAuthnCredential(c, 3))
+
+		require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+	})
+}
@@ -0,0 +1,2 @@
+/** users predates migrations and may hold data created before them, so it is
+  kept. Reverting only forgets that this migration was applied. */
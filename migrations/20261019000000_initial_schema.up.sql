@@ -0,0 +1,15 @@
+/** The schema of database.sql before migrations. Databases created from it or
+  any later database.sql are brought up to date by the migrations after it,
+  which tolerate what already exists. */
+CREATE TABLE IF NOT EXISTS users (
+    "id" serial PRIMARY KEY,
+    "full_name" VARCHAR(100) NOT NULL,
+    "phone_number" VARCHAR(25) NOT NULL UNIQUE,
+    "password" TEXT NOT NULL,
+    "login_count" INTEGER NOT NULL DEFAULT 0,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "updated_at" TIMESTAMP,
+    "deleted_at" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_users_phone_number ON users(phone_number);
@@ -0,0 +1,53 @@
+CREATE TABLE IF NOT EXISTS roles (
+    "id" serial PRIMARY KEY,
+    "name" VARCHAR(50) NOT NULL UNIQUE,
+    "description" TEXT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    "id" serial PRIMARY KEY,
+    "name" VARCHAR(100) NOT NULL UNIQUE,
+    "description" TEXT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    "role_id" INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    "permission_id" INTEGER NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY ("role_id", "permission_id")
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "role_id" INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY ("user_id", "role_id")
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO roles(name, description) VALUES
+    ('admin', 'Administrator with access to user management'),
+    ('user', 'Regular user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions(name, description) VALUES
+    ('profile:read', 'Read own profile'),
+    ('profile:write', 'Update own profile'),
+    ('users:read', 'Read any user'),
+    ('users:write', 'Manage any user')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin'
+    OR (r.name = 'user' AND p.name IN ('profile:read', 'profile:write'))
+ON CONFLICT DO NOTHING;
+
+/** Users registered before roles keep access to their own profile. */
+INSERT INTO user_roles(user_id, role_id)
+SELECT u.id, r.id FROM users u, roles r
+WHERE r.name = 'user'
+    AND NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_created_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS "phone_verified_at";
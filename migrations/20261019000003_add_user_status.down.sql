@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS user_status_changes;
+
+DROP INDEX IF EXISTS idx_users_status;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS "status_changed_at",
+    DROP COLUMN IF EXISTS "status_reason",
+    DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,19 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS "status" VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK ("status" IN ('pending', 'active', 'suspended', 'locked', 'deleted')),
+    ADD COLUMN IF NOT EXISTS "status_reason" TEXT,
+    ADD COLUMN IF NOT EXISTS "status_changed_at" TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users(status);
+
+CREATE TABLE IF NOT EXISTS user_status_changes (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "from_status" VARCHAR(20) NOT NULL,
+    "to_status" VARCHAR(20) NOT NULL,
+    "reason" TEXT NOT NULL,
+    "actor_id" INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_status_changes_user_id ON user_status_changes(user_id);
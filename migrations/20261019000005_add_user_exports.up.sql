@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS user_exports (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "status" VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK ("status" IN ('pending', 'processing', 'ready', 'failed')),
+    "archive" BYTEA,
+    "error" TEXT,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "completed_at" TIMESTAMP,
+    "expires_at" TIMESTAMP
+);
+
+/** A user can only have one export in flight at a time. */
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_exports_in_flight ON user_exports(user_id)
+    WHERE status IN ('pending', 'processing');
+CREATE INDEX IF NOT EXISTS idx_user_exports_status ON user_exports(status);
@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'users:impersonate';
+
+DROP TABLE IF EXISTS impersonation_audit_logs;
+DROP TABLE IF EXISTS impersonations;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS impersonations (
+    "id" serial PRIMARY KEY,
+    "actor_id" INTEGER NOT NULL REFERENCES users(id),
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "reason" TEXT NOT NULL,
+    "expires_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_actor_id ON impersonations(actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonations_user_id ON impersonations(user_id);
+
+/** Every request made with an impersonation token, whether it was allowed or not. */
+CREATE TABLE IF NOT EXISTS impersonation_audit_logs (
+    "id" serial PRIMARY KEY,
+    "impersonation_id" INTEGER NOT NULL REFERENCES impersonations(id) ON DELETE CASCADE,
+    "method" VARCHAR(10) NOT NULL,
+    "path" TEXT NOT NULL,
+    "status_code" INTEGER NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_logs_impersonation_id ON impersonation_audit_logs(impersonation_id);
+
+INSERT INTO permissions(name, description) VALUES
+    ('users:impersonate', 'Act as another user for support purposes')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions(role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users:impersonate'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+/** API keys are stored hashed, the prefix is kept so owners can tell them apart. */
+CREATE TABLE IF NOT EXISTS api_keys (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "name" VARCHAR(100) NOT NULL,
+    "prefix" VARCHAR(20) NOT NULL,
+    "key_hash" CHAR(64) NOT NULL UNIQUE,
+    "scopes" TEXT[] NOT NULL DEFAULT '{}',
+    "expires_at" TIMESTAMP,
+    "last_used_at" TIMESTAMP,
+    "revoked_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id) WHERE revoked_at IS NULL;
@@ -0,0 +1,2 @@
+/** Emails are stored lower-cased so uniqueness is case-insensitive. */
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "email" VARCHAR(254) UNIQUE CHECK ("email" = LOWER("email"));
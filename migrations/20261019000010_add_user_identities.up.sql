@@ -0,0 +1,26 @@
+/** Accounts at external identity providers a user can log in with. */
+CREATE TABLE IF NOT EXISTS user_identities (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "provider" VARCHAR(50) NOT NULL,
+    "subject" VARCHAR(255) NOT NULL,
+    "email" VARCHAR(254),
+    "last_login_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE ("provider", "subject"),
+    UNIQUE ("user_id", "provider")
+);
+
+/** Pending redirects to an identity provider, consumed by the callback. */
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+    "id" serial PRIMARY KEY,
+    "state_hash" CHAR(64) NOT NULL UNIQUE,
+    "provider" VARCHAR(50) NOT NULL,
+    "nonce" VARCHAR(64) NOT NULL,
+    "code_verifier" VARCHAR(128) NOT NULL,
+    "user_id" INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    "expires_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_auth_requests_expires_at ON oidc_auth_requests(expires_at);
@@ -0,0 +1,10 @@
+/** Pending SAML authentication requests, consumed by the response answering them. */
+CREATE TABLE IF NOT EXISTS saml_auth_requests (
+    "id" serial PRIMARY KEY,
+    "request_id" VARCHAR(128) NOT NULL UNIQUE,
+    "provider" VARCHAR(50) NOT NULL,
+    "expires_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saml_auth_requests_expires_at ON saml_auth_requests(expires_at);
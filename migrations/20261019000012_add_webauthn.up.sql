@@ -0,0 +1,26 @@
+/** Passkeys registered by users, with the last signature counter of their authenticator. */
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "credential_id" VARCHAR(1366) NOT NULL UNIQUE,
+    "public_key" BYTEA NOT NULL,
+    "sign_count" BIGINT NOT NULL DEFAULT 0,
+    "aaguid" CHAR(36) NOT NULL,
+    "name" VARCHAR(100) NOT NULL,
+    "last_used_at" TIMESTAMP,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials(user_id);
+
+/** Pending WebAuthn ceremonies, consumed by the authenticator's response. */
+CREATE TABLE IF NOT EXISTS webauthn_challenges (
+    "id" serial PRIMARY KEY,
+    "challenge_hash" CHAR(64) NOT NULL UNIQUE,
+    "ceremony" VARCHAR(20) NOT NULL,
+    "user_id" INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    "expires_at" TIMESTAMP NOT NULL,
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_challenges_expires_at ON webauthn_challenges(expires_at);
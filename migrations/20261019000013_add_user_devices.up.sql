@@ -0,0 +1,11 @@
+/** Devices users logged in from, by the fingerprint of the client's device id and User-Agent. */
+CREATE TABLE IF NOT EXISTS user_devices (
+    "id" serial PRIMARY KEY,
+    "user_id" INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "fingerprint" CHAR(64) NOT NULL,
+    "user_agent" VARCHAR(512) NOT NULL,
+    "revoke_token_hash" CHAR(64) NOT NULL UNIQUE,
+    "last_login_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    "created_at" TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE ("user_id", "fingerprint")
+);
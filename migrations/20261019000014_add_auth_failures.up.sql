@@ -0,0 +1,11 @@
+/** Failed logins and registrations, counted to decide when to demand a CAPTCHA. */
+CREATE TABLE IF NOT EXISTS auth_failures (
+    "id" serial PRIMARY KEY,
+    "action" VARCHAR(20) NOT NULL,
+    "identifier" VARCHAR(255) NOT NULL,
+    "ip_address" VARCHAR(45) NOT NULL,
+    "failed_at" TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_failures_identifier ON auth_failures(action, identifier, failed_at);
+CREATE INDEX IF NOT EXISTS idx_auth_failures_ip_address ON auth_failures(action, ip_address, failed_at);
@@ -0,0 +1 @@
+/** The numbers as they were entered are gone, normalized ones are kept. */
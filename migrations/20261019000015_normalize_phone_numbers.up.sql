@@ -0,0 +1,14 @@
+/** Phone numbers are stored in E.164 form. Numbers stored before that with
+  separators or a trunk prefix are normalized, unless that would clash with
+  another user's number, those are left to be merged by hand. */
+UPDATE users SET phone_number = normalized.phone_number
+FROM (
+    SELECT id, phone_number, COUNT(*) OVER (PARTITION BY phone_number) AS clashes
+    FROM (
+        SELECT id, regexp_replace(regexp_replace(phone_number, '[ .()-]', '', 'g'), '^\+620', '+62') AS phone_number
+        FROM users
+    ) stripped
+) normalized
+WHERE users.id = normalized.id
+    AND users.phone_number <> normalized.phone_number
+    AND normalized.clashes = 1;
@@ -0,0 +1,2 @@
+/** Language messages are shown in, NULL to negotiate it per request. */
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "locale" VARCHAR(5) CHECK ("locale" IN ('en', 'id'));
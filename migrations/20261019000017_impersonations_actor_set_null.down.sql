@@ -0,0 +1,6 @@
+DELETE FROM impersonations WHERE actor_id IS NULL;
+
+ALTER TABLE impersonations DROP CONSTRAINT IF EXISTS impersonations_actor_id_fkey;
+ALTER TABLE impersonations ADD CONSTRAINT impersonations_actor_id_fkey
+    FOREIGN KEY ("actor_id") REFERENCES users(id);
+ALTER TABLE impersonations ALTER COLUMN "actor_id" SET NOT NULL;
@@ -0,0 +1,6 @@
+/** Purging a deleted admin keeps the impersonations they started, without
+  their actor, like status changes they made. */
+ALTER TABLE impersonations ALTER COLUMN "actor_id" DROP NOT NULL;
+ALTER TABLE impersonations DROP CONSTRAINT IF EXISTS impersonations_actor_id_fkey;
+ALTER TABLE impersonations ADD CONSTRAINT impersonations_actor_id_fkey
+    FOREIGN KEY ("actor_id") REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE user_exports DROP COLUMN IF EXISTS "attempts";
+ALTER TABLE user_exports DROP COLUMN IF EXISTS "claimed_at";
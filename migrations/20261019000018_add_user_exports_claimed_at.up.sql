@@ -0,0 +1,7 @@
+/** A worker that dies while processing an export leaves it processing. When
+  and how often it was claimed lets other workers take it over, or fail it
+  once it was tried too often. */
+ALTER TABLE user_exports ADD COLUMN IF NOT EXISTS "claimed_at" TIMESTAMP;
+ALTER TABLE user_exports ADD COLUMN IF NOT EXISTS "attempts" INTEGER NOT NULL DEFAULT 0;
+
+UPDATE user_exports SET claimed_at = created_at, attempts = 1 WHERE status = 'processing' AND claimed_at IS NULL;
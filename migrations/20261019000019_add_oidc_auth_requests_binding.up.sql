@@ -0,0 +1,4 @@
+/** Ties each redirect to the browser that started it. Pending requests have
+  no binding to check and are dropped, their users start over. */
+DELETE FROM oidc_auth_requests;
+ALTER TABLE oidc_auth_requests ADD COLUMN IF NOT EXISTS "binding_hash" CHAR(64) NOT NULL;
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned schema migrations, applied with the
+// migrate command of the service binary.
+package migrations
+
+import "embed"
+
+// FS holds the <version>_<name>.up.sql and .down.sql scripts.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	generated "github.com/SawitProRecruitment/UserService/generated"
 	model "github.com/SawitProRecruitment/UserService/model"
@@ -42,19 +43,124 @@ func (m *MockUserRepositoryInterface) EXPECT() *MockUserRepositoryInterfaceMockR
 	return m.recorder
 }
 
+// CreateFirstAdmin mocks base method.
+func (m *MockUserRepositoryInterface) CreateFirstAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFirstAdmin", ctx, payload)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFirstAdmin indicates an expected call of CreateFirstAdmin.
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreateFirstAdmin(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFirstAdmin", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateFirstAdmin), ctx, payload)
+}
+
 // CreateUser mocks base method.
-func (m *MockUserRepositoryInterface) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error) {
+func (m *MockUserRepositoryInterface) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody, roles []string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", ctx, payload)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, payload, roles)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserRepositoryInterfaceMockRecorder) CreateUser(ctx, payload any) *gomock.Call {
+func (mr *MockUserRepositoryInterfaceMockRecorder) CreateUser(ctx, payload, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateUser), ctx, payload, roles)
+}
+
+// EmailExists mocks base method.
+func (m *MockUserRepositoryInterface) EmailExists(ctx context.Context, email string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmailExists", ctx, email)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EmailExists indicates an expected call of EmailExists.
+func (mr *MockUserRepositoryInterfaceMockRecorder) EmailExists(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmailExists", reflect.TypeOf((*MockUserRepositoryInterface)(nil).EmailExists), ctx, email)
+}
+
+// GetDeletedUserByEmail mocks base method.
+func (m *MockUserRepositoryInterface) GetDeletedUserByEmail(ctx context.Context, email string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserByEmail", ctx, email)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserByEmail indicates an expected call of GetDeletedUserByEmail.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetDeletedUserByEmail(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserByEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetDeletedUserByEmail), ctx, email)
+}
+
+// GetDeletedUserById mocks base method.
+func (m *MockUserRepositoryInterface) GetDeletedUserById(ctx context.Context, id int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserById", ctx, id)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserById indicates an expected call of GetDeletedUserById.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetDeletedUserById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserById", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetDeletedUserById), ctx, id)
+}
+
+// GetDeletedUserByPhoneNumber mocks base method.
+func (m *MockUserRepositoryInterface) GetDeletedUserByPhoneNumber(ctx context.Context, phoneNumber string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedUserByPhoneNumber", ctx, phoneNumber)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedUserByPhoneNumber indicates an expected call of GetDeletedUserByPhoneNumber.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetDeletedUserByPhoneNumber(ctx, phoneNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedUserByPhoneNumber", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetDeletedUserByPhoneNumber), ctx, phoneNumber)
+}
+
+// GetLatestUserStatusChange mocks base method.
+func (m *MockUserRepositoryInterface) GetLatestUserStatusChange(ctx context.Context, userId int64) (model.UserStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestUserStatusChange", ctx, userId)
+	ret0, _ := ret[0].(model.UserStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestUserStatusChange indicates an expected call of GetLatestUserStatusChange.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetLatestUserStatusChange(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestUserStatusChange", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetLatestUserStatusChange), ctx, userId)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserRepositoryInterface) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserByEmail(ctx, email any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepositoryInterface)(nil).CreateUser), ctx, payload)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserByEmail), ctx, email)
 }
 
 // GetUserById mocks base method.
@@ -87,6 +193,21 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserByPhoneNumber(ctx, pho
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByPhoneNumber", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserByPhoneNumber), ctx, phoneNumber)
 }
 
+// GetUserDetailById mocks base method.
+func (m *MockUserRepositoryInterface) GetUserDetailById(ctx context.Context, id int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDetailById", ctx, id)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDetailById indicates an expected call of GetUserDetailById.
+func (mr *MockUserRepositoryInterfaceMockRecorder) GetUserDetailById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDetailById", reflect.TypeOf((*MockUserRepositoryInterface)(nil).GetUserDetailById), ctx, id)
+}
+
 // IncrementUserLoginCount mocks base method.
 func (m *MockUserRepositoryInterface) IncrementUserLoginCount(ctx context.Context, id int64) error {
 	m.ctrl.T.Helper()
@@ -101,6 +222,94 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) IncrementUserLoginCount(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementUserLoginCount", reflect.TypeOf((*MockUserRepositoryInterface)(nil).IncrementUserLoginCount), ctx, id)
 }
 
+// ListUserStatusChanges mocks base method.
+func (m *MockUserRepositoryInterface) ListUserStatusChanges(ctx context.Context, userId int64) ([]model.UserStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserStatusChanges", ctx, userId)
+	ret0, _ := ret[0].([]model.UserStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserStatusChanges indicates an expected call of ListUserStatusChanges.
+func (mr *MockUserRepositoryInterfaceMockRecorder) ListUserStatusChanges(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserStatusChanges", reflect.TypeOf((*MockUserRepositoryInterface)(nil).ListUserStatusChanges), ctx, userId)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserRepositoryInterface) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserRepositoryInterfaceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserRepositoryInterface)(nil).ListUsers), ctx, filter)
+}
+
+// PhoneNumberExists mocks base method.
+func (m *MockUserRepositoryInterface) PhoneNumberExists(ctx context.Context, phoneNumber string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PhoneNumberExists", ctx, phoneNumber)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PhoneNumberExists indicates an expected call of PhoneNumberExists.
+func (mr *MockUserRepositoryInterfaceMockRecorder) PhoneNumberExists(ctx, phoneNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PhoneNumberExists", reflect.TypeOf((*MockUserRepositoryInterface)(nil).PhoneNumberExists), ctx, phoneNumber)
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockUserRepositoryInterface) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", ctx, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUserRepositoryInterfaceMockRecorder) PurgeDeletedUsers(ctx, deletedBefore any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUserRepositoryInterface)(nil).PurgeDeletedUsers), ctx, deletedBefore)
+}
+
+// SetUserVerified mocks base method.
+func (m *MockUserRepositoryInterface) SetUserVerified(ctx context.Context, id int64, verified bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserVerified", ctx, id, verified)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserVerified indicates an expected call of SetUserVerified.
+func (mr *MockUserRepositoryInterfaceMockRecorder) SetUserVerified(ctx, id, verified any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserVerified", reflect.TypeOf((*MockUserRepositoryInterface)(nil).SetUserVerified), ctx, id, verified)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockUserRepositoryInterface) UpdateUserPassword(ctx context.Context, id int64, hashedPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, id, hashedPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateUserPassword(ctx, id, hashedPassword any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateUserPassword), ctx, id, hashedPassword)
+}
+
 // UpdateUserProfile mocks base method.
 func (m *MockUserRepositoryInterface) UpdateUserProfile(ctx context.Context, id int64, payload generated.UpdateUserProfileJSONRequestBody) error {
 	m.ctrl.T.Helper()
@@ -114,3 +323,1071 @@ func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateUserProfile(ctx, id, pa
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateUserProfile), ctx, id, payload)
 }
+
+// UpdateUserStatus mocks base method.
+func (m *MockUserRepositoryInterface) UpdateUserStatus(ctx context.Context, change model.UserStatusChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserStatus", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserStatus indicates an expected call of UpdateUserStatus.
+func (mr *MockUserRepositoryInterfaceMockRecorder) UpdateUserStatus(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserStatus", reflect.TypeOf((*MockUserRepositoryInterface)(nil).UpdateUserStatus), ctx, change)
+}
+
+// MockAuthFailureRepositoryInterface is a mock of AuthFailureRepositoryInterface interface.
+type MockAuthFailureRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthFailureRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthFailureRepositoryInterfaceMockRecorder is the mock recorder for MockAuthFailureRepositoryInterface.
+type MockAuthFailureRepositoryInterfaceMockRecorder struct {
+	mock *MockAuthFailureRepositoryInterface
+}
+
+// NewMockAuthFailureRepositoryInterface creates a new mock instance.
+func NewMockAuthFailureRepositoryInterface(ctrl *gomock.Controller) *MockAuthFailureRepositoryInterface {
+	mock := &MockAuthFailureRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockAuthFailureRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthFailureRepositoryInterface) EXPECT() *MockAuthFailureRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CountAuthFailures mocks base method.
+func (m *MockAuthFailureRepositoryInterface) CountAuthFailures(ctx context.Context, attempt model.AuthAttempt, since time.Time) (model.AuthFailureCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAuthFailures", ctx, attempt, since)
+	ret0, _ := ret[0].(model.AuthFailureCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAuthFailures indicates an expected call of CountAuthFailures.
+func (mr *MockAuthFailureRepositoryInterfaceMockRecorder) CountAuthFailures(ctx, attempt, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAuthFailures", reflect.TypeOf((*MockAuthFailureRepositoryInterface)(nil).CountAuthFailures), ctx, attempt, since)
+}
+
+// CreateAuthFailure mocks base method.
+func (m *MockAuthFailureRepositoryInterface) CreateAuthFailure(ctx context.Context, attempt model.AuthAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuthFailure", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuthFailure indicates an expected call of CreateAuthFailure.
+func (mr *MockAuthFailureRepositoryInterfaceMockRecorder) CreateAuthFailure(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuthFailure", reflect.TypeOf((*MockAuthFailureRepositoryInterface)(nil).CreateAuthFailure), ctx, attempt)
+}
+
+// DeleteAuthFailures mocks base method.
+func (m *MockAuthFailureRepositoryInterface) DeleteAuthFailures(ctx context.Context, action, identifier string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAuthFailures", ctx, action, identifier)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuthFailures indicates an expected call of DeleteAuthFailures.
+func (mr *MockAuthFailureRepositoryInterfaceMockRecorder) DeleteAuthFailures(ctx, action, identifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuthFailures", reflect.TypeOf((*MockAuthFailureRepositoryInterface)(nil).DeleteAuthFailures), ctx, action, identifier)
+}
+
+// DeleteExpiredAuthFailures mocks base method.
+func (m *MockAuthFailureRepositoryInterface) DeleteExpiredAuthFailures(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredAuthFailures", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredAuthFailures indicates an expected call of DeleteExpiredAuthFailures.
+func (mr *MockAuthFailureRepositoryInterfaceMockRecorder) DeleteExpiredAuthFailures(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredAuthFailures", reflect.TypeOf((*MockAuthFailureRepositoryInterface)(nil).DeleteExpiredAuthFailures), ctx, before)
+}
+
+// ListAuthFailures mocks base method.
+func (m *MockAuthFailureRepositoryInterface) ListAuthFailures(ctx context.Context, identifiers []string) ([]model.AuthFailure, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuthFailures", ctx, identifiers)
+	ret0, _ := ret[0].([]model.AuthFailure)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuthFailures indicates an expected call of ListAuthFailures.
+func (mr *MockAuthFailureRepositoryInterfaceMockRecorder) ListAuthFailures(ctx, identifiers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuthFailures", reflect.TypeOf((*MockAuthFailureRepositoryInterface)(nil).ListAuthFailures), ctx, identifiers)
+}
+
+// MockDeviceRepositoryInterface is a mock of DeviceRepositoryInterface interface.
+type MockDeviceRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceRepositoryInterfaceMockRecorder is the mock recorder for MockDeviceRepositoryInterface.
+type MockDeviceRepositoryInterfaceMockRecorder struct {
+	mock *MockDeviceRepositoryInterface
+}
+
+// NewMockDeviceRepositoryInterface creates a new mock instance.
+func NewMockDeviceRepositoryInterface(ctrl *gomock.Controller) *MockDeviceRepositoryInterface {
+	mock := &MockDeviceRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockDeviceRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceRepositoryInterface) EXPECT() *MockDeviceRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CountDevices mocks base method.
+func (m *MockDeviceRepositoryInterface) CountDevices(ctx context.Context, userId int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountDevices", ctx, userId)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountDevices indicates an expected call of CountDevices.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) CountDevices(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountDevices", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).CountDevices), ctx, userId)
+}
+
+// CreateDevice mocks base method.
+func (m *MockDeviceRepositoryInterface) CreateDevice(ctx context.Context, device model.Device) (model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDevice", ctx, device)
+	ret0, _ := ret[0].(model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDevice indicates an expected call of CreateDevice.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) CreateDevice(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDevice", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).CreateDevice), ctx, device)
+}
+
+// DeleteDeviceByRevokeTokenHash mocks base method.
+func (m *MockDeviceRepositoryInterface) DeleteDeviceByRevokeTokenHash(ctx context.Context, tokenHash string) (model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDeviceByRevokeTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteDeviceByRevokeTokenHash indicates an expected call of DeleteDeviceByRevokeTokenHash.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) DeleteDeviceByRevokeTokenHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeviceByRevokeTokenHash", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).DeleteDeviceByRevokeTokenHash), ctx, tokenHash)
+}
+
+// DeviceExists mocks base method.
+func (m *MockDeviceRepositoryInterface) DeviceExists(ctx context.Context, userId, id int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeviceExists", ctx, userId, id)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeviceExists indicates an expected call of DeviceExists.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) DeviceExists(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeviceExists", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).DeviceExists), ctx, userId, id)
+}
+
+// GetDevice mocks base method.
+func (m *MockDeviceRepositoryInterface) GetDevice(ctx context.Context, userId int64, fingerprint string) (model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDevice", ctx, userId, fingerprint)
+	ret0, _ := ret[0].(model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDevice indicates an expected call of GetDevice.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) GetDevice(ctx, userId, fingerprint any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDevice", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).GetDevice), ctx, userId, fingerprint)
+}
+
+// ListDevices mocks base method.
+func (m *MockDeviceRepositoryInterface) ListDevices(ctx context.Context, userId int64) ([]model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDevices", ctx, userId)
+	ret0, _ := ret[0].([]model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDevices indicates an expected call of ListDevices.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) ListDevices(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDevices", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).ListDevices), ctx, userId)
+}
+
+// TouchDevice mocks base method.
+func (m *MockDeviceRepositoryInterface) TouchDevice(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchDevice", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchDevice indicates an expected call of TouchDevice.
+func (mr *MockDeviceRepositoryInterfaceMockRecorder) TouchDevice(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchDevice", reflect.TypeOf((*MockDeviceRepositoryInterface)(nil).TouchDevice), ctx, id)
+}
+
+// MockEmailVerificationRepositoryInterface is a mock of EmailVerificationRepositoryInterface interface.
+type MockEmailVerificationRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailVerificationRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailVerificationRepositoryInterfaceMockRecorder is the mock recorder for MockEmailVerificationRepositoryInterface.
+type MockEmailVerificationRepositoryInterfaceMockRecorder struct {
+	mock *MockEmailVerificationRepositoryInterface
+}
+
+// NewMockEmailVerificationRepositoryInterface creates a new mock instance.
+func NewMockEmailVerificationRepositoryInterface(ctrl *gomock.Controller) *MockEmailVerificationRepositoryInterface {
+	mock := &MockEmailVerificationRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockEmailVerificationRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailVerificationRepositoryInterface) EXPECT() *MockEmailVerificationRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConsumeEmailVerification mocks base method.
+func (m *MockEmailVerificationRepositoryInterface) ConsumeEmailVerification(ctx context.Context, verification model.EmailVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeEmailVerification", ctx, verification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeEmailVerification indicates an expected call of ConsumeEmailVerification.
+func (mr *MockEmailVerificationRepositoryInterfaceMockRecorder) ConsumeEmailVerification(ctx, verification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeEmailVerification", reflect.TypeOf((*MockEmailVerificationRepositoryInterface)(nil).ConsumeEmailVerification), ctx, verification)
+}
+
+// CreateEmailVerification mocks base method.
+func (m *MockEmailVerificationRepositoryInterface) CreateEmailVerification(ctx context.Context, verification model.EmailVerification) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmailVerification", ctx, verification)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEmailVerification indicates an expected call of CreateEmailVerification.
+func (mr *MockEmailVerificationRepositoryInterfaceMockRecorder) CreateEmailVerification(ctx, verification any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmailVerification", reflect.TypeOf((*MockEmailVerificationRepositoryInterface)(nil).CreateEmailVerification), ctx, verification)
+}
+
+// GetEmailVerificationByHash mocks base method.
+func (m *MockEmailVerificationRepositoryInterface) GetEmailVerificationByHash(ctx context.Context, tokenHash string) (model.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmailVerificationByHash", ctx, tokenHash)
+	ret0, _ := ret[0].(model.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailVerificationByHash indicates an expected call of GetEmailVerificationByHash.
+func (mr *MockEmailVerificationRepositoryInterfaceMockRecorder) GetEmailVerificationByHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationByHash", reflect.TypeOf((*MockEmailVerificationRepositoryInterface)(nil).GetEmailVerificationByHash), ctx, tokenHash)
+}
+
+// ListEmailVerifications mocks base method.
+func (m *MockEmailVerificationRepositoryInterface) ListEmailVerifications(ctx context.Context, userId int64) ([]model.EmailVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEmailVerifications", ctx, userId)
+	ret0, _ := ret[0].([]model.EmailVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEmailVerifications indicates an expected call of ListEmailVerifications.
+func (mr *MockEmailVerificationRepositoryInterfaceMockRecorder) ListEmailVerifications(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEmailVerifications", reflect.TypeOf((*MockEmailVerificationRepositoryInterface)(nil).ListEmailVerifications), ctx, userId)
+}
+
+// MockIdentityRepositoryInterface is a mock of IdentityRepositoryInterface interface.
+type MockIdentityRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockIdentityRepositoryInterfaceMockRecorder is the mock recorder for MockIdentityRepositoryInterface.
+type MockIdentityRepositoryInterfaceMockRecorder struct {
+	mock *MockIdentityRepositoryInterface
+}
+
+// NewMockIdentityRepositoryInterface creates a new mock instance.
+func NewMockIdentityRepositoryInterface(ctrl *gomock.Controller) *MockIdentityRepositoryInterface {
+	mock := &MockIdentityRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockIdentityRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityRepositoryInterface) EXPECT() *MockIdentityRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConsumeOIDCAuthRequest mocks base method.
+func (m *MockIdentityRepositoryInterface) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (model.OIDCAuthRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeOIDCAuthRequest", ctx, stateHash)
+	ret0, _ := ret[0].(model.OIDCAuthRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeOIDCAuthRequest indicates an expected call of ConsumeOIDCAuthRequest.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) ConsumeOIDCAuthRequest(ctx, stateHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeOIDCAuthRequest", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).ConsumeOIDCAuthRequest), ctx, stateHash)
+}
+
+// ConsumeSAMLAuthRequest mocks base method.
+func (m *MockIdentityRepositoryInterface) ConsumeSAMLAuthRequest(ctx context.Context, requestId string) (model.SAMLAuthRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeSAMLAuthRequest", ctx, requestId)
+	ret0, _ := ret[0].(model.SAMLAuthRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeSAMLAuthRequest indicates an expected call of ConsumeSAMLAuthRequest.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) ConsumeSAMLAuthRequest(ctx, requestId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeSAMLAuthRequest", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).ConsumeSAMLAuthRequest), ctx, requestId)
+}
+
+// CreateIdentity mocks base method.
+func (m *MockIdentityRepositoryInterface) CreateIdentity(ctx context.Context, identity model.UserIdentity) (model.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdentity", ctx, identity)
+	ret0, _ := ret[0].(model.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdentity indicates an expected call of CreateIdentity.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) CreateIdentity(ctx, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdentity", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).CreateIdentity), ctx, identity)
+}
+
+// CreateOIDCAuthRequest mocks base method.
+func (m *MockIdentityRepositoryInterface) CreateOIDCAuthRequest(ctx context.Context, request model.OIDCAuthRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOIDCAuthRequest", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOIDCAuthRequest indicates an expected call of CreateOIDCAuthRequest.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) CreateOIDCAuthRequest(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOIDCAuthRequest", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).CreateOIDCAuthRequest), ctx, request)
+}
+
+// CreateSAMLAuthRequest mocks base method.
+func (m *MockIdentityRepositoryInterface) CreateSAMLAuthRequest(ctx context.Context, request model.SAMLAuthRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSAMLAuthRequest", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSAMLAuthRequest indicates an expected call of CreateSAMLAuthRequest.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) CreateSAMLAuthRequest(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSAMLAuthRequest", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).CreateSAMLAuthRequest), ctx, request)
+}
+
+// DeleteExpiredOIDCAuthRequests mocks base method.
+func (m *MockIdentityRepositoryInterface) DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredOIDCAuthRequests", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredOIDCAuthRequests indicates an expected call of DeleteExpiredOIDCAuthRequests.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) DeleteExpiredOIDCAuthRequests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredOIDCAuthRequests", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).DeleteExpiredOIDCAuthRequests), ctx)
+}
+
+// DeleteExpiredSAMLAuthRequests mocks base method.
+func (m *MockIdentityRepositoryInterface) DeleteExpiredSAMLAuthRequests(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredSAMLAuthRequests", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredSAMLAuthRequests indicates an expected call of DeleteExpiredSAMLAuthRequests.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) DeleteExpiredSAMLAuthRequests(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredSAMLAuthRequests", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).DeleteExpiredSAMLAuthRequests), ctx)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockIdentityRepositoryInterface) DeleteIdentity(ctx context.Context, userId int64, provider string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", ctx, userId, provider)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) DeleteIdentity(ctx, userId, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).DeleteIdentity), ctx, userId, provider)
+}
+
+// GetIdentity mocks base method.
+func (m *MockIdentityRepositoryInterface) GetIdentity(ctx context.Context, provider, subject string) (model.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", ctx, provider, subject)
+	ret0, _ := ret[0].(model.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) GetIdentity(ctx, provider, subject any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).GetIdentity), ctx, provider, subject)
+}
+
+// ListIdentities mocks base method.
+func (m *MockIdentityRepositoryInterface) ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", ctx, userId)
+	ret0, _ := ret[0].([]model.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) ListIdentities(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).ListIdentities), ctx, userId)
+}
+
+// ProvisionUser mocks base method.
+func (m *MockIdentityRepositoryInterface) ProvisionUser(ctx context.Context, user model.User, role string, identity model.UserIdentity) (model.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionUser", ctx, user, role, identity)
+	ret0, _ := ret[0].(model.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionUser indicates an expected call of ProvisionUser.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) ProvisionUser(ctx, user, role, identity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionUser", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).ProvisionUser), ctx, user, role, identity)
+}
+
+// TouchIdentity mocks base method.
+func (m *MockIdentityRepositoryInterface) TouchIdentity(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchIdentity", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchIdentity indicates an expected call of TouchIdentity.
+func (mr *MockIdentityRepositoryInterfaceMockRecorder) TouchIdentity(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchIdentity", reflect.TypeOf((*MockIdentityRepositoryInterface)(nil).TouchIdentity), ctx, id)
+}
+
+// MockRoleRepositoryInterface is a mock of RoleRepositoryInterface interface.
+type MockRoleRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoleRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockRoleRepositoryInterfaceMockRecorder is the mock recorder for MockRoleRepositoryInterface.
+type MockRoleRepositoryInterfaceMockRecorder struct {
+	mock *MockRoleRepositoryInterface
+}
+
+// NewMockRoleRepositoryInterface creates a new mock instance.
+func NewMockRoleRepositoryInterface(ctrl *gomock.Controller) *MockRoleRepositoryInterface {
+	mock := &MockRoleRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockRoleRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoleRepositoryInterface) EXPECT() *MockRoleRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// GetAllRoles mocks base method.
+func (m *MockRoleRepositoryInterface) GetAllRoles(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllRoles", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllRoles indicates an expected call of GetAllRoles.
+func (mr *MockRoleRepositoryInterfaceMockRecorder) GetAllRoles(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllRoles", reflect.TypeOf((*MockRoleRepositoryInterface)(nil).GetAllRoles), ctx)
+}
+
+// GetPermissionsByRoles mocks base method.
+func (m *MockRoleRepositoryInterface) GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPermissionsByRoles", ctx, roles)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPermissionsByRoles indicates an expected call of GetPermissionsByRoles.
+func (mr *MockRoleRepositoryInterfaceMockRecorder) GetPermissionsByRoles(ctx, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissionsByRoles", reflect.TypeOf((*MockRoleRepositoryInterface)(nil).GetPermissionsByRoles), ctx, roles)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockRoleRepositoryInterface) GetUserRoles(ctx context.Context, userId int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userId)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockRoleRepositoryInterfaceMockRecorder) GetUserRoles(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockRoleRepositoryInterface)(nil).GetUserRoles), ctx, userId)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockRoleRepositoryInterface) SetUserRoles(ctx context.Context, userId int64, roles []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", ctx, userId, roles)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockRoleRepositoryInterfaceMockRecorder) SetUserRoles(ctx, userId, roles any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockRoleRepositoryInterface)(nil).SetUserRoles), ctx, userId, roles)
+}
+
+// MockAPIKeyRepositoryInterface is a mock of APIKeyRepositoryInterface interface.
+type MockAPIKeyRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyRepositoryInterfaceMockRecorder is the mock recorder for MockAPIKeyRepositoryInterface.
+type MockAPIKeyRepositoryInterfaceMockRecorder struct {
+	mock *MockAPIKeyRepositoryInterface
+}
+
+// NewMockAPIKeyRepositoryInterface creates a new mock instance.
+func NewMockAPIKeyRepositoryInterface(ctrl *gomock.Controller) *MockAPIKeyRepositoryInterface {
+	mock := &MockAPIKeyRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyRepositoryInterface) EXPECT() *MockAPIKeyRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyRepositoryInterface) CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, apiKey)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) CreateAPIKey(ctx, apiKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).CreateAPIKey), ctx, apiKey)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockAPIKeyRepositoryInterface) GetAPIKeyByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, keyHash)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) GetAPIKeyByHash(ctx, keyHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).GetAPIKeyByHash), ctx, keyHash)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyRepositoryInterface) ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, userId)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) ListAPIKeys(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).ListAPIKeys), ctx, userId)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyRepositoryInterface) RevokeAPIKey(ctx context.Context, userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) RevokeAPIKey(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).RevokeAPIKey), ctx, userId, id)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockAPIKeyRepositoryInterface) TouchAPIKey(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockAPIKeyRepositoryInterfaceMockRecorder) TouchAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockAPIKeyRepositoryInterface)(nil).TouchAPIKey), ctx, id)
+}
+
+// MockExportRepositoryInterface is a mock of ExportRepositoryInterface interface.
+type MockExportRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockExportRepositoryInterfaceMockRecorder is the mock recorder for MockExportRepositoryInterface.
+type MockExportRepositoryInterfaceMockRecorder struct {
+	mock *MockExportRepositoryInterface
+}
+
+// NewMockExportRepositoryInterface creates a new mock instance.
+func NewMockExportRepositoryInterface(ctrl *gomock.Controller) *MockExportRepositoryInterface {
+	mock := &MockExportRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockExportRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportRepositoryInterface) EXPECT() *MockExportRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ClaimPendingExport mocks base method.
+func (m *MockExportRepositoryInterface) ClaimPendingExport(ctx context.Context, staleBefore time.Time, maxAttempts int) (model.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPendingExport", ctx, staleBefore, maxAttempts)
+	ret0, _ := ret[0].(model.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPendingExport indicates an expected call of ClaimPendingExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) ClaimPendingExport(ctx, staleBefore, maxAttempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPendingExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).ClaimPendingExport), ctx, staleBefore, maxAttempts)
+}
+
+// CompleteExport mocks base method.
+func (m *MockExportRepositoryInterface) CompleteExport(ctx context.Context, id int64, archive []byte, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteExport", ctx, id, archive, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteExport indicates an expected call of CompleteExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) CompleteExport(ctx, id, archive, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).CompleteExport), ctx, id, archive, expiresAt)
+}
+
+// CreateExport mocks base method.
+func (m *MockExportRepositoryInterface) CreateExport(ctx context.Context, userId int64) (model.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExport", ctx, userId)
+	ret0, _ := ret[0].(model.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExport indicates an expected call of CreateExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) CreateExport(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).CreateExport), ctx, userId)
+}
+
+// DeleteExpiredExports mocks base method.
+func (m *MockExportRepositoryInterface) DeleteExpiredExports(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredExports", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredExports indicates an expected call of DeleteExpiredExports.
+func (mr *MockExportRepositoryInterfaceMockRecorder) DeleteExpiredExports(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredExports", reflect.TypeOf((*MockExportRepositoryInterface)(nil).DeleteExpiredExports), ctx)
+}
+
+// FailExport mocks base method.
+func (m *MockExportRepositoryInterface) FailExport(ctx context.Context, id int64, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailExport", ctx, id, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailExport indicates an expected call of FailExport.
+func (mr *MockExportRepositoryInterfaceMockRecorder) FailExport(ctx, id, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailExport", reflect.TypeOf((*MockExportRepositoryInterface)(nil).FailExport), ctx, id, reason)
+}
+
+// FailStaleExports mocks base method.
+func (m *MockExportRepositoryInterface) FailStaleExports(ctx context.Context, staleBefore time.Time, maxAttempts int, reason string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailStaleExports", ctx, staleBefore, maxAttempts, reason)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailStaleExports indicates an expected call of FailStaleExports.
+func (mr *MockExportRepositoryInterfaceMockRecorder) FailStaleExports(ctx, staleBefore, maxAttempts, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailStaleExports", reflect.TypeOf((*MockExportRepositoryInterface)(nil).FailStaleExports), ctx, staleBefore, maxAttempts, reason)
+}
+
+// GetExportArchive mocks base method.
+func (m *MockExportRepositoryInterface) GetExportArchive(ctx context.Context, id int64) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExportArchive", ctx, id)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExportArchive indicates an expected call of GetExportArchive.
+func (mr *MockExportRepositoryInterfaceMockRecorder) GetExportArchive(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExportArchive", reflect.TypeOf((*MockExportRepositoryInterface)(nil).GetExportArchive), ctx, id)
+}
+
+// GetExportById mocks base method.
+func (m *MockExportRepositoryInterface) GetExportById(ctx context.Context, id int64) (model.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExportById", ctx, id)
+	ret0, _ := ret[0].(model.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExportById indicates an expected call of GetExportById.
+func (mr *MockExportRepositoryInterfaceMockRecorder) GetExportById(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExportById", reflect.TypeOf((*MockExportRepositoryInterface)(nil).GetExportById), ctx, id)
+}
+
+// MockImpersonationRepositoryInterface is a mock of ImpersonationRepositoryInterface interface.
+type MockImpersonationRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockImpersonationRepositoryInterfaceMockRecorder is the mock recorder for MockImpersonationRepositoryInterface.
+type MockImpersonationRepositoryInterfaceMockRecorder struct {
+	mock *MockImpersonationRepositoryInterface
+}
+
+// NewMockImpersonationRepositoryInterface creates a new mock instance.
+func NewMockImpersonationRepositoryInterface(ctrl *gomock.Controller) *MockImpersonationRepositoryInterface {
+	mock := &MockImpersonationRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockImpersonationRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationRepositoryInterface) EXPECT() *MockImpersonationRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAuditLog mocks base method.
+func (m *MockImpersonationRepositoryInterface) CreateAuditLog(ctx context.Context, auditLog model.ImpersonationAuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", ctx, auditLog)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockImpersonationRepositoryInterfaceMockRecorder) CreateAuditLog(ctx, auditLog any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockImpersonationRepositoryInterface)(nil).CreateAuditLog), ctx, auditLog)
+}
+
+// CreateImpersonation mocks base method.
+func (m *MockImpersonationRepositoryInterface) CreateImpersonation(ctx context.Context, impersonation model.Impersonation) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateImpersonation", ctx, impersonation)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateImpersonation indicates an expected call of CreateImpersonation.
+func (mr *MockImpersonationRepositoryInterfaceMockRecorder) CreateImpersonation(ctx, impersonation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateImpersonation", reflect.TypeOf((*MockImpersonationRepositoryInterface)(nil).CreateImpersonation), ctx, impersonation)
+}
+
+// ListImpersonationAuditLogs mocks base method.
+func (m *MockImpersonationRepositoryInterface) ListImpersonationAuditLogs(ctx context.Context, userId int64) ([]model.ImpersonationAuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImpersonationAuditLogs", ctx, userId)
+	ret0, _ := ret[0].([]model.ImpersonationAuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImpersonationAuditLogs indicates an expected call of ListImpersonationAuditLogs.
+func (mr *MockImpersonationRepositoryInterfaceMockRecorder) ListImpersonationAuditLogs(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImpersonationAuditLogs", reflect.TypeOf((*MockImpersonationRepositoryInterface)(nil).ListImpersonationAuditLogs), ctx, userId)
+}
+
+// ListImpersonations mocks base method.
+func (m *MockImpersonationRepositoryInterface) ListImpersonations(ctx context.Context, userId int64) ([]model.Impersonation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImpersonations", ctx, userId)
+	ret0, _ := ret[0].([]model.Impersonation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImpersonations indicates an expected call of ListImpersonations.
+func (mr *MockImpersonationRepositoryInterfaceMockRecorder) ListImpersonations(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImpersonations", reflect.TypeOf((*MockImpersonationRepositoryInterface)(nil).ListImpersonations), ctx, userId)
+}
+
+// MockWebAuthnRepositoryInterface is a mock of WebAuthnRepositoryInterface interface.
+type MockWebAuthnRepositoryInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnRepositoryInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockWebAuthnRepositoryInterfaceMockRecorder is the mock recorder for MockWebAuthnRepositoryInterface.
+type MockWebAuthnRepositoryInterfaceMockRecorder struct {
+	mock *MockWebAuthnRepositoryInterface
+}
+
+// NewMockWebAuthnRepositoryInterface creates a new mock instance.
+func NewMockWebAuthnRepositoryInterface(ctrl *gomock.Controller) *MockWebAuthnRepositoryInterface {
+	mock := &MockWebAuthnRepositoryInterface{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnRepositoryInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnRepositoryInterface) EXPECT() *MockWebAuthnRepositoryInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ConsumeWebAuthnChallenge mocks base method.
+func (m *MockWebAuthnRepositoryInterface) ConsumeWebAuthnChallenge(ctx context.Context, challengeHash string) (model.WebAuthnChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeWebAuthnChallenge", ctx, challengeHash)
+	ret0, _ := ret[0].(model.WebAuthnChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeWebAuthnChallenge indicates an expected call of ConsumeWebAuthnChallenge.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) ConsumeWebAuthnChallenge(ctx, challengeHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeWebAuthnChallenge", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).ConsumeWebAuthnChallenge), ctx, challengeHash)
+}
+
+// CreateWebAuthnChallenge mocks base method.
+func (m *MockWebAuthnRepositoryInterface) CreateWebAuthnChallenge(ctx context.Context, challenge model.WebAuthnChallenge) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnChallenge", ctx, challenge)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebAuthnChallenge indicates an expected call of CreateWebAuthnChallenge.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) CreateWebAuthnChallenge(ctx, challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnChallenge", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).CreateWebAuthnChallenge), ctx, challenge)
+}
+
+// CreateWebAuthnCredential mocks base method.
+func (m *MockWebAuthnRepositoryInterface) CreateWebAuthnCredential(ctx context.Context, credential model.WebAuthnCredential) (model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebAuthnCredential", ctx, credential)
+	ret0, _ := ret[0].(model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebAuthnCredential indicates an expected call of CreateWebAuthnCredential.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) CreateWebAuthnCredential(ctx, credential any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebAuthnCredential", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).CreateWebAuthnCredential), ctx, credential)
+}
+
+// DeleteExpiredWebAuthnChallenges mocks base method.
+func (m *MockWebAuthnRepositoryInterface) DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredWebAuthnChallenges", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredWebAuthnChallenges indicates an expected call of DeleteExpiredWebAuthnChallenges.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) DeleteExpiredWebAuthnChallenges(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredWebAuthnChallenges", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).DeleteExpiredWebAuthnChallenges), ctx)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockWebAuthnRepositoryInterface) DeleteWebAuthnCredential(ctx context.Context, userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) DeleteWebAuthnCredential(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).DeleteWebAuthnCredential), ctx, userId, id)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockWebAuthnRepositoryInterface) GetWebAuthnCredential(ctx context.Context, credentialId string) (model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", ctx, credentialId)
+	ret0, _ := ret[0].(model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) GetWebAuthnCredential(ctx, credentialId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).GetWebAuthnCredential), ctx, credentialId)
+}
+
+// ListWebAuthnCredentials mocks base method.
+func (m *MockWebAuthnRepositoryInterface) ListWebAuthnCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebAuthnCredentials", ctx, userId)
+	ret0, _ := ret[0].([]model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebAuthnCredentials indicates an expected call of ListWebAuthnCredentials.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) ListWebAuthnCredentials(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebAuthnCredentials", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).ListWebAuthnCredentials), ctx, userId)
+}
+
+// UpdateWebAuthnCredentialSignCount mocks base method.
+func (m *MockWebAuthnRepositoryInterface) UpdateWebAuthnCredentialSignCount(ctx context.Context, id, signCount int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnCredentialSignCount", ctx, id, signCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnCredentialSignCount indicates an expected call of UpdateWebAuthnCredentialSignCount.
+func (mr *MockWebAuthnRepositoryInterfaceMockRecorder) UpdateWebAuthnCredentialSignCount(ctx, id, signCount any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnCredentialSignCount", reflect.TypeOf((*MockWebAuthnRepositoryInterface)(nil).UpdateWebAuthnCredentialSignCount), ctx, id, signCount)
+}
@@ -12,9 +12,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	generated "github.com/SawitProRecruitment/UserService/generated"
 	model "github.com/SawitProRecruitment/UserService/model"
+	utils "github.com/SawitProRecruitment/UserService/utils"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,10 +44,68 @@ func (m *MockAuthUsecaseInterface) EXPECT() *MockAuthUsecaseInterfaceMockRecorde
 	return m.recorder
 }
 
+// AuthenticateAPIKey mocks base method.
+func (m *MockAuthUsecaseInterface) AuthenticateAPIKey(ctx context.Context, key string, permissions []string) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", ctx, key, permissions)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) AuthenticateAPIKey(ctx, key, permissions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).AuthenticateAPIKey), ctx, key, permissions)
+}
+
+// AuthorizeRoles mocks base method.
+func (m *MockAuthUsecaseInterface) AuthorizeRoles(ctx context.Context, roles, permissions []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeRoles", ctx, roles, permissions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AuthorizeRoles indicates an expected call of AuthorizeRoles.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) AuthorizeRoles(ctx, roles, permissions any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeRoles", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).AuthorizeRoles), ctx, roles, permissions)
+}
+
+// CheckDevice mocks base method.
+func (m *MockAuthUsecaseInterface) CheckDevice(ctx context.Context, userId, deviceId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckDevice", ctx, userId, deviceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckDevice indicates an expected call of CheckDevice.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) CheckDevice(ctx, userId, deviceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckDevice", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).CheckDevice), ctx, userId, deviceId)
+}
+
+// CheckUserStatus mocks base method.
+func (m *MockAuthUsecaseInterface) CheckUserStatus(ctx context.Context, userId int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckUserStatus", ctx, userId)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckUserStatus indicates an expected call of CheckUserStatus.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) CheckUserStatus(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUserStatus", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).CheckUserStatus), ctx, userId)
+}
+
 // LoginUser mocks base method.
-func (m *MockAuthUsecaseInterface) LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, string, error) {
+func (m *MockAuthUsecaseInterface) LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody, keyThumbprint string, client model.LoginClient) (model.User, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LoginUser", ctx, payload)
+	ret := m.ctrl.Call(m, "LoginUser", ctx, payload, keyThumbprint, client)
 	ret0, _ := ret[0].(model.User)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -53,9 +113,216 @@ func (m *MockAuthUsecaseInterface) LoginUser(ctx context.Context, payload genera
 }
 
 // LoginUser indicates an expected call of LoginUser.
-func (mr *MockAuthUsecaseInterfaceMockRecorder) LoginUser(ctx, payload any) *gomock.Call {
+func (mr *MockAuthUsecaseInterfaceMockRecorder) LoginUser(ctx, payload, keyThumbprint, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginUser", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).LoginUser), ctx, payload, keyThumbprint, client)
+}
+
+// Reauthenticate mocks base method.
+func (m *MockAuthUsecaseInterface) Reauthenticate(ctx context.Context, userId int64, payload generated.ReauthenticateJSONRequestBody, keyThumbprint string, deviceId int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reauthenticate", ctx, userId, payload, keyThumbprint, deviceId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reauthenticate indicates an expected call of Reauthenticate.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) Reauthenticate(ctx, userId, payload, keyThumbprint, deviceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reauthenticate", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).Reauthenticate), ctx, userId, payload, keyThumbprint, deviceId)
+}
+
+// RevokeDevice mocks base method.
+func (m *MockAuthUsecaseInterface) RevokeDevice(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeDevice", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeDevice indicates an expected call of RevokeDevice.
+func (mr *MockAuthUsecaseInterfaceMockRecorder) RevokeDevice(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeDevice", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).RevokeDevice), ctx, token)
+}
+
+// MockAuthenticatorInterface is a mock of AuthenticatorInterface interface.
+type MockAuthenticatorInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthenticatorInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthenticatorInterfaceMockRecorder is the mock recorder for MockAuthenticatorInterface.
+type MockAuthenticatorInterfaceMockRecorder struct {
+	mock *MockAuthenticatorInterface
+}
+
+// NewMockAuthenticatorInterface creates a new mock instance.
+func NewMockAuthenticatorInterface(ctrl *gomock.Controller) *MockAuthenticatorInterface {
+	mock := &MockAuthenticatorInterface{ctrl: ctrl}
+	mock.recorder = &MockAuthenticatorInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthenticatorInterface) EXPECT() *MockAuthenticatorInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthenticatorInterface) Authenticate(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", ctx, payload)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthenticatorInterfaceMockRecorder) Authenticate(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthenticatorInterface)(nil).Authenticate), ctx, payload)
+}
+
+// MockSecurityNotifierInterface is a mock of SecurityNotifierInterface interface.
+type MockSecurityNotifierInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSecurityNotifierInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSecurityNotifierInterfaceMockRecorder is the mock recorder for MockSecurityNotifierInterface.
+type MockSecurityNotifierInterfaceMockRecorder struct {
+	mock *MockSecurityNotifierInterface
+}
+
+// NewMockSecurityNotifierInterface creates a new mock instance.
+func NewMockSecurityNotifierInterface(ctrl *gomock.Controller) *MockSecurityNotifierInterface {
+	mock := &MockSecurityNotifierInterface{ctrl: ctrl}
+	mock.recorder = &MockSecurityNotifierInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSecurityNotifierInterface) EXPECT() *MockSecurityNotifierInterfaceMockRecorder {
+	return m.recorder
+}
+
+// NotifySecurityEvent mocks base method.
+func (m *MockSecurityNotifierInterface) NotifySecurityEvent(ctx context.Context, event model.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifySecurityEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifySecurityEvent indicates an expected call of NotifySecurityEvent.
+func (mr *MockSecurityNotifierInterfaceMockRecorder) NotifySecurityEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifySecurityEvent", reflect.TypeOf((*MockSecurityNotifierInterface)(nil).NotifySecurityEvent), ctx, event)
+}
+
+// MockDeviceRecognizerInterface is a mock of DeviceRecognizerInterface interface.
+type MockDeviceRecognizerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceRecognizerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceRecognizerInterfaceMockRecorder is the mock recorder for MockDeviceRecognizerInterface.
+type MockDeviceRecognizerInterfaceMockRecorder struct {
+	mock *MockDeviceRecognizerInterface
+}
+
+// NewMockDeviceRecognizerInterface creates a new mock instance.
+func NewMockDeviceRecognizerInterface(ctrl *gomock.Controller) *MockDeviceRecognizerInterface {
+	mock := &MockDeviceRecognizerInterface{ctrl: ctrl}
+	mock.recorder = &MockDeviceRecognizerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceRecognizerInterface) EXPECT() *MockDeviceRecognizerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// RecognizeDevice mocks base method.
+func (m *MockDeviceRecognizerInterface) RecognizeDevice(ctx context.Context, user model.User, client model.LoginClient) (model.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecognizeDevice", ctx, user, client)
+	ret0, _ := ret[0].(model.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecognizeDevice indicates an expected call of RecognizeDevice.
+func (mr *MockDeviceRecognizerInterfaceMockRecorder) RecognizeDevice(ctx, user, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecognizeDevice", reflect.TypeOf((*MockDeviceRecognizerInterface)(nil).RecognizeDevice), ctx, user, client)
+}
+
+// MockCaptchaUsecaseInterface is a mock of CaptchaUsecaseInterface interface.
+type MockCaptchaUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptchaUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCaptchaUsecaseInterfaceMockRecorder is the mock recorder for MockCaptchaUsecaseInterface.
+type MockCaptchaUsecaseInterfaceMockRecorder struct {
+	mock *MockCaptchaUsecaseInterface
+}
+
+// NewMockCaptchaUsecaseInterface creates a new mock instance.
+func NewMockCaptchaUsecaseInterface(ctrl *gomock.Controller) *MockCaptchaUsecaseInterface {
+	mock := &MockCaptchaUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockCaptchaUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptchaUsecaseInterface) EXPECT() *MockCaptchaUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Challenge mocks base method.
+func (m *MockCaptchaUsecaseInterface) Challenge(ctx context.Context, attempt model.AuthAttempt, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Challenge", ctx, attempt, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Challenge indicates an expected call of Challenge.
+func (mr *MockCaptchaUsecaseInterfaceMockRecorder) Challenge(ctx, attempt, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Challenge", reflect.TypeOf((*MockCaptchaUsecaseInterface)(nil).Challenge), ctx, attempt, token)
+}
+
+// RecordFailure mocks base method.
+func (m *MockCaptchaUsecaseInterface) RecordFailure(ctx context.Context, attempt model.AuthAttempt) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordFailure", ctx, attempt)
+}
+
+// RecordFailure indicates an expected call of RecordFailure.
+func (mr *MockCaptchaUsecaseInterfaceMockRecorder) RecordFailure(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFailure", reflect.TypeOf((*MockCaptchaUsecaseInterface)(nil).RecordFailure), ctx, attempt)
+}
+
+// ResetFailures mocks base method.
+func (m *MockCaptchaUsecaseInterface) ResetFailures(ctx context.Context, attempt model.AuthAttempt) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetFailures", ctx, attempt)
+}
+
+// ResetFailures indicates an expected call of ResetFailures.
+func (mr *MockCaptchaUsecaseInterfaceMockRecorder) ResetFailures(ctx, attempt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginUser", reflect.TypeOf((*MockAuthUsecaseInterface)(nil).LoginUser), ctx, payload)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetFailures", reflect.TypeOf((*MockCaptchaUsecaseInterface)(nil).ResetFailures), ctx, attempt)
 }
 
 // MockUserUsecaseInterface is a mock of UserUsecaseInterface interface.
@@ -82,6 +349,35 @@ func (m *MockUserUsecaseInterface) EXPECT() *MockUserUsecaseInterfaceMockRecorde
 	return m.recorder
 }
 
+// BootstrapAdmin mocks base method.
+func (m *MockUserUsecaseInterface) BootstrapAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BootstrapAdmin", ctx, payload)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BootstrapAdmin indicates an expected call of BootstrapAdmin.
+func (mr *MockUserUsecaseInterfaceMockRecorder) BootstrapAdmin(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapAdmin", reflect.TypeOf((*MockUserUsecaseInterface)(nil).BootstrapAdmin), ctx, payload)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUserUsecaseInterface) ChangePassword(ctx context.Context, userId int64, authTime time.Time, payload generated.ChangeUserPasswordJSONRequestBody) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userId, authTime, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUserUsecaseInterfaceMockRecorder) ChangePassword(ctx, userId, authTime, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUserUsecaseInterface)(nil).ChangePassword), ctx, userId, authTime, payload)
+}
+
 // CreateUser mocks base method.
 func (m *MockUserUsecaseInterface) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -97,6 +393,20 @@ func (mr *MockUserUsecaseInterfaceMockRecorder) CreateUser(ctx, payload any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserUsecaseInterface)(nil).CreateUser), ctx, payload)
 }
 
+// DeleteUser mocks base method.
+func (m *MockUserUsecaseInterface) DeleteUser(ctx context.Context, userId int64, authTime time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userId, authTime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUserUsecaseInterfaceMockRecorder) DeleteUser(ctx, userId, authTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserUsecaseInterface)(nil).DeleteUser), ctx, userId, authTime)
+}
+
 // GetUserProfile mocks base method.
 func (m *MockUserUsecaseInterface) GetUserProfile(ctx context.Context, userId int64) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -112,16 +422,678 @@ func (mr *MockUserUsecaseInterfaceMockRecorder) GetUserProfile(ctx, userId any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserProfile", reflect.TypeOf((*MockUserUsecaseInterface)(nil).GetUserProfile), ctx, userId)
 }
 
+// PurgeDeletedUsers mocks base method.
+func (m *MockUserUsecaseInterface) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUserUsecaseInterfaceMockRecorder) PurgeDeletedUsers(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUserUsecaseInterface)(nil).PurgeDeletedUsers), ctx)
+}
+
 // UpdateUserProfile mocks base method.
-func (m *MockUserUsecaseInterface) UpdateUserProfile(ctx context.Context, userId int64, payload generated.UpdateUserProfileJSONRequestBody) error {
+func (m *MockUserUsecaseInterface) UpdateUserProfile(ctx context.Context, userId int64, authTime time.Time, payload generated.UpdateUserProfileJSONRequestBody) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUserProfile", ctx, userId, payload)
+	ret := m.ctrl.Call(m, "UpdateUserProfile", ctx, userId, authTime, payload)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUserProfile indicates an expected call of UpdateUserProfile.
-func (mr *MockUserUsecaseInterfaceMockRecorder) UpdateUserProfile(ctx, userId, payload any) *gomock.Call {
+func (mr *MockUserUsecaseInterfaceMockRecorder) UpdateUserProfile(ctx, userId, authTime, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockUserUsecaseInterface)(nil).UpdateUserProfile), ctx, userId, authTime, payload)
+}
+
+// MockAdminUsecaseInterface is a mock of AdminUsecaseInterface interface.
+type MockAdminUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAdminUsecaseInterfaceMockRecorder is the mock recorder for MockAdminUsecaseInterface.
+type MockAdminUsecaseInterfaceMockRecorder struct {
+	mock *MockAdminUsecaseInterface
+}
+
+// NewMockAdminUsecaseInterface creates a new mock instance.
+func NewMockAdminUsecaseInterface(ctrl *gomock.Controller) *MockAdminUsecaseInterface {
+	mock := &MockAdminUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockAdminUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminUsecaseInterface) EXPECT() *MockAdminUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// ChangeUserStatus mocks base method.
+func (m *MockAdminUsecaseInterface) ChangeUserStatus(ctx context.Context, actorId, userId int64, payload generated.ChangeUserStatusJSONRequestBody) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUserStatus", ctx, actorId, userId, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeUserStatus indicates an expected call of ChangeUserStatus.
+func (mr *MockAdminUsecaseInterfaceMockRecorder) ChangeUserStatus(ctx, actorId, userId, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserStatus", reflect.TypeOf((*MockAdminUsecaseInterface)(nil).ChangeUserStatus), ctx, actorId, userId, payload)
+}
+
+// GetUser mocks base method.
+func (m *MockAdminUsecaseInterface) GetUser(ctx context.Context, userId int64) (model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, userId)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockAdminUsecaseInterfaceMockRecorder) GetUser(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAdminUsecaseInterface)(nil).GetUser), ctx, userId)
+}
+
+// ListUsers mocks base method.
+func (m *MockAdminUsecaseInterface) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminUsecaseInterfaceMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdminUsecaseInterface)(nil).ListUsers), ctx, filter)
+}
+
+// UpdateUser mocks base method.
+func (m *MockAdminUsecaseInterface) UpdateUser(ctx context.Context, userId int64, payload generated.UpdateUserJSONRequestBody) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, userId, payload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockAdminUsecaseInterfaceMockRecorder) UpdateUser(ctx, userId, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockAdminUsecaseInterface)(nil).UpdateUser), ctx, userId, payload)
+}
+
+// MockAPIKeyUsecaseInterface is a mock of APIKeyUsecaseInterface interface.
+type MockAPIKeyUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyUsecaseInterfaceMockRecorder is the mock recorder for MockAPIKeyUsecaseInterface.
+type MockAPIKeyUsecaseInterfaceMockRecorder struct {
+	mock *MockAPIKeyUsecaseInterface
+}
+
+// NewMockAPIKeyUsecaseInterface creates a new mock instance.
+func NewMockAPIKeyUsecaseInterface(ctrl *gomock.Controller) *MockAPIKeyUsecaseInterface {
+	mock := &MockAPIKeyUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyUsecaseInterface) EXPECT() *MockAPIKeyUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyUsecaseInterface) CreateAPIKey(ctx context.Context, userId int64, payload generated.CreateApiKeyJSONRequestBody) (model.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, userId, payload)
+	ret0, _ := ret[0].(model.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyUsecaseInterfaceMockRecorder) CreateAPIKey(ctx, userId, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyUsecaseInterface)(nil).CreateAPIKey), ctx, userId, payload)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyUsecaseInterface) ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx, userId)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyUsecaseInterfaceMockRecorder) ListAPIKeys(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyUsecaseInterface)(nil).ListAPIKeys), ctx, userId)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyUsecaseInterface) RevokeAPIKey(ctx context.Context, userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyUsecaseInterfaceMockRecorder) RevokeAPIKey(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyUsecaseInterface)(nil).RevokeAPIKey), ctx, userId, id)
+}
+
+// MockEmailUsecaseInterface is a mock of EmailUsecaseInterface interface.
+type MockEmailUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailUsecaseInterfaceMockRecorder is the mock recorder for MockEmailUsecaseInterface.
+type MockEmailUsecaseInterfaceMockRecorder struct {
+	mock *MockEmailUsecaseInterface
+}
+
+// NewMockEmailUsecaseInterface creates a new mock instance.
+func NewMockEmailUsecaseInterface(ctrl *gomock.Controller) *MockEmailUsecaseInterface {
+	mock := &MockEmailUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockEmailUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailUsecaseInterface) EXPECT() *MockEmailUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// NotifySecurityEvent mocks base method.
+func (m *MockEmailUsecaseInterface) NotifySecurityEvent(ctx context.Context, event model.SecurityEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NotifySecurityEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NotifySecurityEvent indicates an expected call of NotifySecurityEvent.
+func (mr *MockEmailUsecaseInterfaceMockRecorder) NotifySecurityEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NotifySecurityEvent", reflect.TypeOf((*MockEmailUsecaseInterface)(nil).NotifySecurityEvent), ctx, event)
+}
+
+// SendSecurityAlert mocks base method.
+func (m *MockEmailUsecaseInterface) SendSecurityAlert(ctx context.Context, user model.User, event string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendSecurityAlert", ctx, user, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendSecurityAlert indicates an expected call of SendSecurityAlert.
+func (mr *MockEmailUsecaseInterfaceMockRecorder) SendSecurityAlert(ctx, user, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSecurityAlert", reflect.TypeOf((*MockEmailUsecaseInterface)(nil).SendSecurityAlert), ctx, user, event)
+}
+
+// SendVerificationEmail mocks base method.
+func (m *MockEmailUsecaseInterface) SendVerificationEmail(ctx context.Context, userId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail.
+func (mr *MockEmailUsecaseInterfaceMockRecorder) SendVerificationEmail(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockEmailUsecaseInterface)(nil).SendVerificationEmail), ctx, userId)
+}
+
+// VerifyEmail mocks base method.
+func (m *MockEmailUsecaseInterface) VerifyEmail(ctx context.Context, token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockEmailUsecaseInterfaceMockRecorder) VerifyEmail(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockEmailUsecaseInterface)(nil).VerifyEmail), ctx, token)
+}
+
+// MockExportUsecaseInterface is a mock of ExportUsecaseInterface interface.
+type MockExportUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockExportUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockExportUsecaseInterfaceMockRecorder is the mock recorder for MockExportUsecaseInterface.
+type MockExportUsecaseInterfaceMockRecorder struct {
+	mock *MockExportUsecaseInterface
+}
+
+// NewMockExportUsecaseInterface creates a new mock instance.
+func NewMockExportUsecaseInterface(ctrl *gomock.Controller) *MockExportUsecaseInterface {
+	mock := &MockExportUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockExportUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockExportUsecaseInterface) EXPECT() *MockExportUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DownloadExport mocks base method.
+func (m *MockExportUsecaseInterface) DownloadExport(ctx context.Context, exportId int64, expiresAt time.Time, signature string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadExport", ctx, exportId, expiresAt, signature)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DownloadExport indicates an expected call of DownloadExport.
+func (mr *MockExportUsecaseInterfaceMockRecorder) DownloadExport(ctx, exportId, expiresAt, signature any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadExport", reflect.TypeOf((*MockExportUsecaseInterface)(nil).DownloadExport), ctx, exportId, expiresAt, signature)
+}
+
+// GetExport mocks base method.
+func (m *MockExportUsecaseInterface) GetExport(ctx context.Context, userId, exportId int64) (model.UserExport, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExport", ctx, userId, exportId)
+	ret0, _ := ret[0].(model.UserExport)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetExport indicates an expected call of GetExport.
+func (mr *MockExportUsecaseInterfaceMockRecorder) GetExport(ctx, userId, exportId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExport", reflect.TypeOf((*MockExportUsecaseInterface)(nil).GetExport), ctx, userId, exportId)
+}
+
+// ProcessPendingExport mocks base method.
+func (m *MockExportUsecaseInterface) ProcessPendingExport(ctx context.Context) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessPendingExport", ctx)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessPendingExport indicates an expected call of ProcessPendingExport.
+func (mr *MockExportUsecaseInterfaceMockRecorder) ProcessPendingExport(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPendingExport", reflect.TypeOf((*MockExportUsecaseInterface)(nil).ProcessPendingExport), ctx)
+}
+
+// PurgeExpiredExports mocks base method.
+func (m *MockExportUsecaseInterface) PurgeExpiredExports(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpiredExports", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpiredExports indicates an expected call of PurgeExpiredExports.
+func (mr *MockExportUsecaseInterfaceMockRecorder) PurgeExpiredExports(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpiredExports", reflect.TypeOf((*MockExportUsecaseInterface)(nil).PurgeExpiredExports), ctx)
+}
+
+// RequestExport mocks base method.
+func (m *MockExportUsecaseInterface) RequestExport(ctx context.Context, userId int64) (model.UserExport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestExport", ctx, userId)
+	ret0, _ := ret[0].(model.UserExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestExport indicates an expected call of RequestExport.
+func (mr *MockExportUsecaseInterfaceMockRecorder) RequestExport(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestExport", reflect.TypeOf((*MockExportUsecaseInterface)(nil).RequestExport), ctx, userId)
+}
+
+// MockIdentityUsecaseInterface is a mock of IdentityUsecaseInterface interface.
+type MockIdentityUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockIdentityUsecaseInterfaceMockRecorder is the mock recorder for MockIdentityUsecaseInterface.
+type MockIdentityUsecaseInterfaceMockRecorder struct {
+	mock *MockIdentityUsecaseInterface
+}
+
+// NewMockIdentityUsecaseInterface creates a new mock instance.
+func NewMockIdentityUsecaseInterface(ctrl *gomock.Controller) *MockIdentityUsecaseInterface {
+	mock := &MockIdentityUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockIdentityUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityUsecaseInterface) EXPECT() *MockIdentityUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// HandleOIDCCallback mocks base method.
+func (m *MockIdentityUsecaseInterface) HandleOIDCCallback(ctx context.Context, provider, code, state, binding string, client model.LoginClient) (model.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleOIDCCallback", ctx, provider, code, state, binding, client)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HandleOIDCCallback indicates an expected call of HandleOIDCCallback.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) HandleOIDCCallback(ctx, provider, code, state, binding, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleOIDCCallback", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).HandleOIDCCallback), ctx, provider, code, state, binding, client)
+}
+
+// HandleSAMLResponse mocks base method.
+func (m *MockIdentityUsecaseInterface) HandleSAMLResponse(ctx context.Context, provider, samlResponse string, client model.LoginClient) (model.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HandleSAMLResponse", ctx, provider, samlResponse, client)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// HandleSAMLResponse indicates an expected call of HandleSAMLResponse.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) HandleSAMLResponse(ctx, provider, samlResponse, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HandleSAMLResponse", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).HandleSAMLResponse), ctx, provider, samlResponse, client)
+}
+
+// ListIdentities mocks base method.
+func (m *MockIdentityUsecaseInterface) ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListIdentities", ctx, userId)
+	ret0, _ := ret[0].([]model.UserIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListIdentities indicates an expected call of ListIdentities.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) ListIdentities(ctx, userId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListIdentities", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).ListIdentities), ctx, userId)
+}
+
+// SAMLMetadata mocks base method.
+func (m *MockIdentityUsecaseInterface) SAMLMetadata(ctx context.Context, provider string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SAMLMetadata", ctx, provider)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SAMLMetadata indicates an expected call of SAMLMetadata.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) SAMLMetadata(ctx, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SAMLMetadata", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).SAMLMetadata), ctx, provider)
+}
+
+// StartIdentityLink mocks base method.
+func (m *MockIdentityUsecaseInterface) StartIdentityLink(ctx context.Context, userId int64, authTime time.Time, provider string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartIdentityLink", ctx, userId, authTime, provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartIdentityLink indicates an expected call of StartIdentityLink.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) StartIdentityLink(ctx, userId, authTime, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartIdentityLink", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).StartIdentityLink), ctx, userId, authTime, provider)
+}
+
+// StartOIDCLogin mocks base method.
+func (m *MockIdentityUsecaseInterface) StartOIDCLogin(ctx context.Context, provider string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOIDCLogin", ctx, provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartOIDCLogin indicates an expected call of StartOIDCLogin.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) StartOIDCLogin(ctx, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOIDCLogin", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).StartOIDCLogin), ctx, provider)
+}
+
+// StartSAMLLogin mocks base method.
+func (m *MockIdentityUsecaseInterface) StartSAMLLogin(ctx context.Context, provider string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSAMLLogin", ctx, provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSAMLLogin indicates an expected call of StartSAMLLogin.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) StartSAMLLogin(ctx, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSAMLLogin", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).StartSAMLLogin), ctx, provider)
+}
+
+// UnlinkIdentity mocks base method.
+func (m *MockIdentityUsecaseInterface) UnlinkIdentity(ctx context.Context, userId int64, provider string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlinkIdentity", ctx, userId, provider)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlinkIdentity indicates an expected call of UnlinkIdentity.
+func (mr *MockIdentityUsecaseInterfaceMockRecorder) UnlinkIdentity(ctx, userId, provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlinkIdentity", reflect.TypeOf((*MockIdentityUsecaseInterface)(nil).UnlinkIdentity), ctx, userId, provider)
+}
+
+// MockImpersonationUsecaseInterface is a mock of ImpersonationUsecaseInterface interface.
+type MockImpersonationUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockImpersonationUsecaseInterfaceMockRecorder is the mock recorder for MockImpersonationUsecaseInterface.
+type MockImpersonationUsecaseInterfaceMockRecorder struct {
+	mock *MockImpersonationUsecaseInterface
+}
+
+// NewMockImpersonationUsecaseInterface creates a new mock instance.
+func NewMockImpersonationUsecaseInterface(ctrl *gomock.Controller) *MockImpersonationUsecaseInterface {
+	mock := &MockImpersonationUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockImpersonationUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationUsecaseInterface) EXPECT() *MockImpersonationUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// RecordImpersonatedRequest mocks base method.
+func (m *MockImpersonationUsecaseInterface) RecordImpersonatedRequest(ctx context.Context, auditLog model.ImpersonationAuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordImpersonatedRequest", ctx, auditLog)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordImpersonatedRequest indicates an expected call of RecordImpersonatedRequest.
+func (mr *MockImpersonationUsecaseInterfaceMockRecorder) RecordImpersonatedRequest(ctx, auditLog any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordImpersonatedRequest", reflect.TypeOf((*MockImpersonationUsecaseInterface)(nil).RecordImpersonatedRequest), ctx, auditLog)
+}
+
+// StartImpersonation mocks base method.
+func (m *MockImpersonationUsecaseInterface) StartImpersonation(ctx context.Context, actorId, userId int64, payload generated.ImpersonateUserJSONRequestBody) (string, model.Impersonation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImpersonation", ctx, actorId, userId, payload)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(model.Impersonation)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartImpersonation indicates an expected call of StartImpersonation.
+func (mr *MockImpersonationUsecaseInterfaceMockRecorder) StartImpersonation(ctx, actorId, userId, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImpersonation", reflect.TypeOf((*MockImpersonationUsecaseInterface)(nil).StartImpersonation), ctx, actorId, userId, payload)
+}
+
+// MockWebAuthnUsecaseInterface is a mock of WebAuthnUsecaseInterface interface.
+type MockWebAuthnUsecaseInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnUsecaseInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockWebAuthnUsecaseInterfaceMockRecorder is the mock recorder for MockWebAuthnUsecaseInterface.
+type MockWebAuthnUsecaseInterfaceMockRecorder struct {
+	mock *MockWebAuthnUsecaseInterface
+}
+
+// NewMockWebAuthnUsecaseInterface creates a new mock instance.
+func NewMockWebAuthnUsecaseInterface(ctrl *gomock.Controller) *MockWebAuthnUsecaseInterface {
+	mock := &MockWebAuthnUsecaseInterface{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnUsecaseInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnUsecaseInterface) EXPECT() *MockWebAuthnUsecaseInterfaceMockRecorder {
+	return m.recorder
+}
+
+// BeginLogin mocks base method.
+func (m *MockWebAuthnUsecaseInterface) BeginLogin(ctx context.Context) (utils.WebAuthnRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginLogin", ctx)
+	ret0, _ := ret[0].(utils.WebAuthnRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginLogin indicates an expected call of BeginLogin.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) BeginLogin(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginLogin", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).BeginLogin), ctx)
+}
+
+// BeginRegistration mocks base method.
+func (m *MockWebAuthnUsecaseInterface) BeginRegistration(ctx context.Context, userId int64, authTime time.Time) (utils.WebAuthnCreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginRegistration", ctx, userId, authTime)
+	ret0, _ := ret[0].(utils.WebAuthnCreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginRegistration indicates an expected call of BeginRegistration.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) BeginRegistration(ctx, userId, authTime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginRegistration", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).BeginRegistration), ctx, userId, authTime)
+}
+
+// DeleteCredential mocks base method.
+func (m *MockWebAuthnUsecaseInterface) DeleteCredential(ctx context.Context, userId, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCredential", ctx, userId, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCredential indicates an expected call of DeleteCredential.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) DeleteCredential(ctx, userId, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCredential", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).DeleteCredential), ctx, userId, id)
+}
+
+// FinishLogin mocks base method.
+func (m *MockWebAuthnUsecaseInterface) FinishLogin(ctx context.Context, credentialId string, response utils.WebAuthnAssertionResponse, keyThumbprint string, client model.LoginClient) (model.User, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishLogin", ctx, credentialId, response, keyThumbprint, client)
+	ret0, _ := ret[0].(model.User)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FinishLogin indicates an expected call of FinishLogin.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) FinishLogin(ctx, credentialId, response, keyThumbprint, client any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishLogin", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).FinishLogin), ctx, credentialId, response, keyThumbprint, client)
+}
+
+// FinishRegistration mocks base method.
+func (m *MockWebAuthnUsecaseInterface) FinishRegistration(ctx context.Context, userId int64, name string, response utils.WebAuthnAttestationResponse) (model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishRegistration", ctx, userId, name, response)
+	ret0, _ := ret[0].(model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishRegistration indicates an expected call of FinishRegistration.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) FinishRegistration(ctx, userId, name, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishRegistration", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).FinishRegistration), ctx, userId, name, response)
+}
+
+// ListCredentials mocks base method.
+func (m *MockWebAuthnUsecaseInterface) ListCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCredentials", ctx, userId)
+	ret0, _ := ret[0].([]model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCredentials indicates an expected call of ListCredentials.
+func (mr *MockWebAuthnUsecaseInterfaceMockRecorder) ListCredentials(ctx, userId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockUserUsecaseInterface)(nil).UpdateUserProfile), ctx, userId, payload)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCredentials", reflect.TypeOf((*MockWebAuthnUsecaseInterface)(nil).ListCredentials), ctx, userId)
 }
@@ -11,6 +11,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	model "github.com/SawitProRecruitment/UserService/model"
 	gomock "go.uber.org/mock/gomock"
@@ -40,19 +41,95 @@ func (m *MockAuthInterface) EXPECT() *MockAuthInterfaceMockRecorder {
 	return m.recorder
 }
 
+// GenerateImpersonationToken mocks base method.
+func (m *MockAuthInterface) GenerateImpersonationToken(user model.User, impersonation model.Impersonation) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateImpersonationToken", user, impersonation)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateImpersonationToken indicates an expected call of GenerateImpersonationToken.
+func (mr *MockAuthInterfaceMockRecorder) GenerateImpersonationToken(user, impersonation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateImpersonationToken", reflect.TypeOf((*MockAuthInterface)(nil).GenerateImpersonationToken), user, impersonation)
+}
+
 // GenerateJWTToken mocks base method.
-func (m *MockAuthInterface) GenerateJWTToken(user model.User) (string, error) {
+func (m *MockAuthInterface) GenerateJWTToken(user model.User, authMethods []string, keyThumbprint string, deviceId int64) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateJWTToken", user)
+	ret := m.ctrl.Call(m, "GenerateJWTToken", user, authMethods, keyThumbprint, deviceId)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateJWTToken indicates an expected call of GenerateJWTToken.
-func (mr *MockAuthInterfaceMockRecorder) GenerateJWTToken(user any) *gomock.Call {
+func (mr *MockAuthInterfaceMockRecorder) GenerateJWTToken(user, authMethods, keyThumbprint, deviceId any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWTToken", reflect.TypeOf((*MockAuthInterface)(nil).GenerateJWTToken), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWTToken", reflect.TypeOf((*MockAuthInterface)(nil).GenerateJWTToken), user, authMethods, keyThumbprint, deviceId)
+}
+
+// GetAuthTime mocks base method.
+func (m *MockAuthInterface) GetAuthTime(tokenStr string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuthTime", tokenStr)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuthTime indicates an expected call of GetAuthTime.
+func (mr *MockAuthInterfaceMockRecorder) GetAuthTime(tokenStr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthTime", reflect.TypeOf((*MockAuthInterface)(nil).GetAuthTime), tokenStr)
+}
+
+// GetDeviceId mocks base method.
+func (m *MockAuthInterface) GetDeviceId(tokenStr string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeviceId", tokenStr)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeviceId indicates an expected call of GetDeviceId.
+func (mr *MockAuthInterfaceMockRecorder) GetDeviceId(tokenStr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeviceId", reflect.TypeOf((*MockAuthInterface)(nil).GetDeviceId), tokenStr)
+}
+
+// GetImpersonation mocks base method.
+func (m *MockAuthInterface) GetImpersonation(tokenStr string) (model.Impersonation, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImpersonation", tokenStr)
+	ret0, _ := ret[0].(model.Impersonation)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetImpersonation indicates an expected call of GetImpersonation.
+func (mr *MockAuthInterfaceMockRecorder) GetImpersonation(tokenStr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImpersonation", reflect.TypeOf((*MockAuthInterface)(nil).GetImpersonation), tokenStr)
+}
+
+// GetKeyThumbprint mocks base method.
+func (m *MockAuthInterface) GetKeyThumbprint(tokenStr string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeyThumbprint", tokenStr)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyThumbprint indicates an expected call of GetKeyThumbprint.
+func (mr *MockAuthInterfaceMockRecorder) GetKeyThumbprint(tokenStr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyThumbprint", reflect.TypeOf((*MockAuthInterface)(nil).GetKeyThumbprint), tokenStr)
 }
 
 // GetUserId mocks base method.
@@ -70,6 +147,21 @@ func (mr *MockAuthInterfaceMockRecorder) GetUserId(tokenStr any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserId", reflect.TypeOf((*MockAuthInterface)(nil).GetUserId), tokenStr)
 }
 
+// GetUserRoles mocks base method.
+func (m *MockAuthInterface) GetUserRoles(tokenStr string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", tokenStr)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockAuthInterfaceMockRecorder) GetUserRoles(tokenStr any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockAuthInterface)(nil).GetUserRoles), tokenStr)
+}
+
 // ValidateJWTToken mocks base method.
 func (m *MockAuthInterface) ValidateJWTToken(tokenStr string) error {
 	m.ctrl.T.Helper()
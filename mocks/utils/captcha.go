@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/captcha.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/captcha.go -source=utils/captcha.go -package=mocks CaptchaVerifierInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCaptchaVerifierInterface is a mock of CaptchaVerifierInterface interface.
+type MockCaptchaVerifierInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCaptchaVerifierInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCaptchaVerifierInterfaceMockRecorder is the mock recorder for MockCaptchaVerifierInterface.
+type MockCaptchaVerifierInterfaceMockRecorder struct {
+	mock *MockCaptchaVerifierInterface
+}
+
+// NewMockCaptchaVerifierInterface creates a new mock instance.
+func NewMockCaptchaVerifierInterface(ctrl *gomock.Controller) *MockCaptchaVerifierInterface {
+	mock := &MockCaptchaVerifierInterface{ctrl: ctrl}
+	mock.recorder = &MockCaptchaVerifierInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCaptchaVerifierInterface) EXPECT() *MockCaptchaVerifierInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method.
+func (m *MockCaptchaVerifierInterface) Verify(ctx context.Context, token, remoteIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, token, remoteIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockCaptchaVerifierInterfaceMockRecorder) Verify(ctx, token, remoteIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockCaptchaVerifierInterface)(nil).Verify), ctx, token, remoteIP)
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/dpop.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/dpop.go -source=utils/dpop.go -package=mocks DPoPInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDPoPInterface is a mock of DPoPInterface interface.
+type MockDPoPInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDPoPInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDPoPInterfaceMockRecorder is the mock recorder for MockDPoPInterface.
+type MockDPoPInterfaceMockRecorder struct {
+	mock *MockDPoPInterface
+}
+
+// NewMockDPoPInterface creates a new mock instance.
+func NewMockDPoPInterface(ctrl *gomock.Controller) *MockDPoPInterface {
+	mock := &MockDPoPInterface{ctrl: ctrl}
+	mock.recorder = &MockDPoPInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDPoPInterface) EXPECT() *MockDPoPInterfaceMockRecorder {
+	return m.recorder
+}
+
+// VerifyProof mocks base method.
+func (m *MockDPoPInterface) VerifyProof(proof, method, requestURL, accessToken string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyProof", proof, method, requestURL, accessToken)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyProof indicates an expected call of VerifyProof.
+func (mr *MockDPoPInterfaceMockRecorder) VerifyProof(proof, method, requestURL, accessToken any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProof", reflect.TypeOf((*MockDPoPInterface)(nil).VerifyProof), proof, method, requestURL, accessToken)
+}
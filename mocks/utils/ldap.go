@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/ldap.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/ldap.go -source=utils/ldap.go -package=mocks LDAPInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	utils "github.com/SawitProRecruitment/UserService/utils"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLDAPInterface is a mock of LDAPInterface interface.
+type MockLDAPInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockLDAPInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockLDAPInterfaceMockRecorder is the mock recorder for MockLDAPInterface.
+type MockLDAPInterfaceMockRecorder struct {
+	mock *MockLDAPInterface
+}
+
+// NewMockLDAPInterface creates a new mock instance.
+func NewMockLDAPInterface(ctrl *gomock.Controller) *MockLDAPInterface {
+	mock := &MockLDAPInterface{ctrl: ctrl}
+	mock.recorder = &MockLDAPInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLDAPInterface) EXPECT() *MockLDAPInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockLDAPInterface) Authenticate(login, password string) (utils.LDAPEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", login, password)
+	ret0, _ := ret[0].(utils.LDAPEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockLDAPInterfaceMockRecorder) Authenticate(login, password any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockLDAPInterface)(nil).Authenticate), login, password)
+}
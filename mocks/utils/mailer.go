@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/mailer.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/mailer.go -source=utils/mailer.go -package=mocks MailerInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	utils "github.com/SawitProRecruitment/UserService/utils"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMailerInterface is a mock of MailerInterface interface.
+type MockMailerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockMailerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockMailerInterfaceMockRecorder is the mock recorder for MockMailerInterface.
+type MockMailerInterfaceMockRecorder struct {
+	mock *MockMailerInterface
+}
+
+// NewMockMailerInterface creates a new mock instance.
+func NewMockMailerInterface(ctrl *gomock.Controller) *MockMailerInterface {
+	mock := &MockMailerInterface{ctrl: ctrl}
+	mock.recorder = &MockMailerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMailerInterface) EXPECT() *MockMailerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method.
+func (m *MockMailerInterface) Send(ctx context.Context, email utils.Email) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockMailerInterfaceMockRecorder) Send(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockMailerInterface)(nil).Send), ctx, email)
+}
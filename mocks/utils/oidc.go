@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/oidc.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/oidc.go -source=utils/oidc.go -package=mocks OIDCInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	utils "github.com/SawitProRecruitment/UserService/utils"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockOIDCInterface is a mock of OIDCInterface interface.
+type MockOIDCInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockOIDCInterfaceMockRecorder is the mock recorder for MockOIDCInterface.
+type MockOIDCInterfaceMockRecorder struct {
+	mock *MockOIDCInterface
+}
+
+// NewMockOIDCInterface creates a new mock instance.
+func NewMockOIDCInterface(ctrl *gomock.Controller) *MockOIDCInterface {
+	mock := &MockOIDCInterface{ctrl: ctrl}
+	mock.recorder = &MockOIDCInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCInterface) EXPECT() *MockOIDCInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AuthCodeURL mocks base method.
+func (m *MockOIDCInterface) AuthCodeURL(provider, state, nonce, codeVerifier string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthCodeURL", provider, state, nonce, codeVerifier)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthCodeURL indicates an expected call of AuthCodeURL.
+func (mr *MockOIDCInterfaceMockRecorder) AuthCodeURL(provider, state, nonce, codeVerifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthCodeURL", reflect.TypeOf((*MockOIDCInterface)(nil).AuthCodeURL), provider, state, nonce, codeVerifier)
+}
+
+// Exchange mocks base method.
+func (m *MockOIDCInterface) Exchange(ctx context.Context, provider, code, codeVerifier string) (utils.OIDCIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", ctx, provider, code, codeVerifier)
+	ret0, _ := ret[0].(utils.OIDCIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockOIDCInterfaceMockRecorder) Exchange(ctx, provider, code, codeVerifier any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockOIDCInterface)(nil).Exchange), ctx, provider, code, codeVerifier)
+}
@@ -0,0 +1,102 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/saml.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/saml.go -source=utils/saml.go -package=mocks SAMLInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	utils "github.com/SawitProRecruitment/UserService/utils"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSAMLInterface is a mock of SAMLInterface interface.
+type MockSAMLInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSAMLInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSAMLInterfaceMockRecorder is the mock recorder for MockSAMLInterface.
+type MockSAMLInterfaceMockRecorder struct {
+	mock *MockSAMLInterface
+}
+
+// NewMockSAMLInterface creates a new mock instance.
+func NewMockSAMLInterface(ctrl *gomock.Controller) *MockSAMLInterface {
+	mock := &MockSAMLInterface{ctrl: ctrl}
+	mock.recorder = &MockSAMLInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSAMLInterface) EXPECT() *MockSAMLInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AuthnRequest mocks base method.
+func (m *MockSAMLInterface) AuthnRequest(provider string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthnRequest", provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AuthnRequest indicates an expected call of AuthnRequest.
+func (mr *MockSAMLInterfaceMockRecorder) AuthnRequest(provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthnRequest", reflect.TypeOf((*MockSAMLInterface)(nil).AuthnRequest), provider)
+}
+
+// Metadata mocks base method.
+func (m *MockSAMLInterface) Metadata(provider string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Metadata", provider)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Metadata indicates an expected call of Metadata.
+func (mr *MockSAMLInterfaceMockRecorder) Metadata(provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Metadata", reflect.TypeOf((*MockSAMLInterface)(nil).Metadata), provider)
+}
+
+// ParseResponse mocks base method.
+func (m *MockSAMLInterface) ParseResponse(provider, samlResponse, requestID string) (utils.SAMLIdentity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseResponse", provider, samlResponse, requestID)
+	ret0, _ := ret[0].(utils.SAMLIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseResponse indicates an expected call of ParseResponse.
+func (mr *MockSAMLInterfaceMockRecorder) ParseResponse(provider, samlResponse, requestID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseResponse", reflect.TypeOf((*MockSAMLInterface)(nil).ParseResponse), provider, samlResponse, requestID)
+}
+
+// RequestID mocks base method.
+func (m *MockSAMLInterface) RequestID(samlResponse string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestID", samlResponse)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestID indicates an expected call of RequestID.
+func (mr *MockSAMLInterfaceMockRecorder) RequestID(samlResponse any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestID", reflect.TypeOf((*MockSAMLInterface)(nil).RequestID), samlResponse)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/signer.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/signer.go -source=utils/signer.go -package=mocks SignerInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSignerInterface is a mock of SignerInterface interface.
+type MockSignerInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockSignerInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockSignerInterfaceMockRecorder is the mock recorder for MockSignerInterface.
+type MockSignerInterfaceMockRecorder struct {
+	mock *MockSignerInterface
+}
+
+// NewMockSignerInterface creates a new mock instance.
+func NewMockSignerInterface(ctrl *gomock.Controller) *MockSignerInterface {
+	mock := &MockSignerInterface{ctrl: ctrl}
+	mock.recorder = &MockSignerInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSignerInterface) EXPECT() *MockSignerInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Sign mocks base method.
+func (m *MockSignerInterface) Sign(payload string, expiresAt time.Time) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sign", payload, expiresAt)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Sign indicates an expected call of Sign.
+func (mr *MockSignerInterfaceMockRecorder) Sign(payload, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockSignerInterface)(nil).Sign), payload, expiresAt)
+}
+
+// Verify mocks base method.
+func (m *MockSignerInterface) Verify(payload string, expiresAt time.Time, signature string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", payload, expiresAt, signature)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockSignerInterfaceMockRecorder) Verify(payload, expiresAt, signature any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockSignerInterface)(nil).Verify), payload, expiresAt, signature)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/tls.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/tls.go -source=utils/tls.go -package=mocks TLSInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	tls "crypto/tls"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockTLSInterface is a mock of TLSInterface interface.
+type MockTLSInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockTLSInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockTLSInterfaceMockRecorder is the mock recorder for MockTLSInterface.
+type MockTLSInterfaceMockRecorder struct {
+	mock *MockTLSInterface
+}
+
+// NewMockTLSInterface creates a new mock instance.
+func NewMockTLSInterface(ctrl *gomock.Controller) *MockTLSInterface {
+	mock := &MockTLSInterface{ctrl: ctrl}
+	mock.recorder = &MockTLSInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTLSInterface) EXPECT() *MockTLSInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Config mocks base method.
+func (m *MockTLSInterface) Config() *tls.Config {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Config")
+	ret0, _ := ret[0].(*tls.Config)
+	return ret0
+}
+
+// Config indicates an expected call of Config.
+func (mr *MockTLSInterfaceMockRecorder) Config() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Config", reflect.TypeOf((*MockTLSInterface)(nil).Config))
+}
+
+// ServiceIdentity mocks base method.
+func (m *MockTLSInterface) ServiceIdentity(state *tls.ConnectionState) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServiceIdentity", state)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ServiceIdentity indicates an expected call of ServiceIdentity.
+func (mr *MockTLSInterfaceMockRecorder) ServiceIdentity(state any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServiceIdentity", reflect.TypeOf((*MockTLSInterface)(nil).ServiceIdentity), state)
+}
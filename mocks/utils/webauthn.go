@@ -0,0 +1,117 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: utils/webauthn.go
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/utils/webauthn.go -source=utils/webauthn.go -package=mocks WebAuthnInterface
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	model "github.com/SawitProRecruitment/UserService/model"
+	utils "github.com/SawitProRecruitment/UserService/utils"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockWebAuthnInterface is a mock of WebAuthnInterface interface.
+type MockWebAuthnInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockWebAuthnInterfaceMockRecorder is the mock recorder for MockWebAuthnInterface.
+type MockWebAuthnInterfaceMockRecorder struct {
+	mock *MockWebAuthnInterface
+}
+
+// NewMockWebAuthnInterface creates a new mock instance.
+func NewMockWebAuthnInterface(ctrl *gomock.Controller) *MockWebAuthnInterface {
+	mock := &MockWebAuthnInterface{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnInterface) EXPECT() *MockWebAuthnInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Challenge mocks base method.
+func (m *MockWebAuthnInterface) Challenge(clientDataJSON string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Challenge", clientDataJSON)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Challenge indicates an expected call of Challenge.
+func (mr *MockWebAuthnInterfaceMockRecorder) Challenge(clientDataJSON any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Challenge", reflect.TypeOf((*MockWebAuthnInterface)(nil).Challenge), clientDataJSON)
+}
+
+// CreationOptions mocks base method.
+func (m *MockWebAuthnInterface) CreationOptions(user model.User, challenge string, excludeCredentials []string) (utils.WebAuthnCreationOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreationOptions", user, challenge, excludeCredentials)
+	ret0, _ := ret[0].(utils.WebAuthnCreationOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreationOptions indicates an expected call of CreationOptions.
+func (mr *MockWebAuthnInterfaceMockRecorder) CreationOptions(user, challenge, excludeCredentials any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreationOptions", reflect.TypeOf((*MockWebAuthnInterface)(nil).CreationOptions), user, challenge, excludeCredentials)
+}
+
+// RequestOptions mocks base method.
+func (m *MockWebAuthnInterface) RequestOptions(challenge string) (utils.WebAuthnRequestOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestOptions", challenge)
+	ret0, _ := ret[0].(utils.WebAuthnRequestOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequestOptions indicates an expected call of RequestOptions.
+func (mr *MockWebAuthnInterfaceMockRecorder) RequestOptions(challenge any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestOptions", reflect.TypeOf((*MockWebAuthnInterface)(nil).RequestOptions), challenge)
+}
+
+// VerifyAssertion mocks base method.
+func (m *MockWebAuthnInterface) VerifyAssertion(challenge string, credential model.WebAuthnCredential, response utils.WebAuthnAssertionResponse) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyAssertion", challenge, credential, response)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyAssertion indicates an expected call of VerifyAssertion.
+func (mr *MockWebAuthnInterfaceMockRecorder) VerifyAssertion(challenge, credential, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyAssertion", reflect.TypeOf((*MockWebAuthnInterface)(nil).VerifyAssertion), challenge, credential, response)
+}
+
+// VerifyRegistration mocks base method.
+func (m *MockWebAuthnInterface) VerifyRegistration(challenge string, response utils.WebAuthnAttestationResponse) (utils.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyRegistration", challenge, response)
+	ret0, _ := ret[0].(utils.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyRegistration indicates an expected call of VerifyRegistration.
+func (mr *MockWebAuthnInterfaceMockRecorder) VerifyRegistration(challenge, response any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyRegistration", reflect.TypeOf((*MockWebAuthnInterface)(nil).VerifyRegistration), challenge, response)
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// APIKey is a long-lived credential a user creates for scripted access. Only
+// the hash of the key is stored. An empty Scopes grants every permission the
+// user holds.
+type APIKey struct {
+	Id         int64
+	UserId     int64
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	ExpiresAt  null.Time
+	LastUsedAt null.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Actions an AuthAttempt is made for.
+const (
+	AuthActionLogin          = "login"
+	AuthActionReauthenticate = "reauthenticate"
+	AuthActionRegister       = "register"
+)
+
+// AuthAttempt is an attempt to log in, re-authenticate or register, counted
+// against the account it names by Identifier (the phone number or email
+// address, or the user id when re-authenticating) and the IP address it came
+// from when it fails.
+type AuthAttempt struct {
+	Action     string
+	Identifier string
+	IPAddress  string
+}
+
+// AuthFailure is a failed AuthAttempt as it is kept until it is too old to
+// be counted.
+type AuthFailure struct {
+	Id         int64
+	Action     string
+	Identifier string
+	IPAddress  string
+	FailedAt   time.Time
+}
+
+// AuthFailureCount is how often attempts failed recently, for the same
+// identifier and from the same IP address.
+type AuthFailureCount struct {
+	Identifier int64
+	IPAddress  int64
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// Device is a device a user logged in from, recognized by the fingerprint of
+// the device id the client sent and its User-Agent. Tokens issued for a login
+// from the device carry its id and are revoked along with it.
+// RevokeTokenHash is the hash of the token in the link sent to the user when
+// the device was first seen.
+type Device struct {
+	Id              int64
+	UserId          int64
+	Fingerprint     string
+	UserAgent       string
+	RevokeTokenHash string
+	LastLoginAt     time.Time
+	CreatedAt       time.Time
+}
+
+// LoginClient describes the client a login is made from, as reported by the
+// client itself.
+type LoginClient struct {
+	DeviceId  string
+	UserAgent string
+	IPAddress string
+}
+
+// Types of SecurityEvent.
+const (
+	SecurityEventNewDeviceLogin = "new_device_login"
+)
+
+// SecurityEvent is a security relevant event in a user's account, delivered
+// to the user by a notifier. RevokeURL, when set, lets the user undo the
+// event if it was not them.
+type SecurityEvent struct {
+	Type       string
+	User       User
+	Device     Device
+	IPAddress  string
+	RevokeURL  string
+	OccurredAt time.Time
+}
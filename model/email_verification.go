@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// EmailVerification is a single-use token proving that a user controls Email.
+// Only the hash of the token is stored.
+type EmailVerification struct {
+	Id        int64
+	UserId    int64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    null.Time
+	CreatedAt time.Time
+}
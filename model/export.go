@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+const (
+	ExportStatusPending    = "pending"
+	ExportStatusProcessing = "processing"
+	ExportStatusReady      = "ready"
+	ExportStatusFailed     = "failed"
+)
+
+// UserExport is an archive of all the data stored about a user, assembled
+// in the background after the user requests it.
+type UserExport struct {
+	Id          int64
+	UserId      int64
+	Status      string
+	Error       null.String
+	CreatedAt   time.Time
+	ClaimedAt   null.Time
+	Attempts    int
+	CompletedAt null.Time
+	ExpiresAt   null.Time
+}
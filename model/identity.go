@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// IdentityProviderLDAP is the provider of identities provisioned from the
+// LDAP directory on first login.
+const IdentityProviderLDAP = "ldap"
+
+// UserIdentity links a user to their account at an external OpenID Connect or
+// SAML provider or the LDAP directory, identified by a stable subject.
+type UserIdentity struct {
+	Id          int64
+	UserId      int64
+	Provider    string
+	Subject     string
+	Email       null.String
+	LastLoginAt null.Time
+	CreatedAt   time.Time
+}
+
+// OIDCAuthRequest is a pending redirect to an identity provider. It is looked
+// up by the hash of the `state` parameter when the user comes back and can be
+// used once. BindingHash is the hash of a cookie set in the browser that
+// started it. UserId is set when an authenticated user links a new identity.
+type OIDCAuthRequest struct {
+	Id           int64
+	StateHash    string
+	BindingHash  string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	UserId       null.Int
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// SAMLAuthRequest is a pending SAML authentication request. It is looked up by
+// the ID the response claims to answer and can be used once.
+type SAMLAuthRequest struct {
+	Id        int64
+	RequestId string
+	Provider  string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
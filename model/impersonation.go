@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// Impersonation is a support session in which ActorId acts as UserId.
+// ActorId is null once the acting admin was purged.
+type Impersonation struct {
+	Id        int64
+	ActorId   null.Int
+	UserId    int64
+	Reason    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// ImpersonationAuditLog records a single request made during an
+// impersonation session.
+type ImpersonationAuditLog struct {
+	ImpersonationId int64
+	Method          string
+	Path            string
+	StatusCode      int
+	CreatedAt       time.Time
+}
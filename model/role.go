@@ -0,0 +1,6 @@
+package model
+
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
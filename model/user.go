@@ -6,12 +6,50 @@ import (
 	"github.com/guregu/null/v5"
 )
 
+const (
+	UserStatusPending   = "pending"
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusLocked    = "locked"
+	UserStatusDeleted   = "deleted"
+)
+
 type User struct {
-	Id          int64
-	FullName    string
-	PhoneNumber string
-	Password    string
-	CreatedAt   time.Time
-	UpdatedAt   null.Time
-	DeletedAt   null.Time
+	Id              int64
+	FullName        string
+	PhoneNumber     string
+	Email           null.String
+	EmailVerifiedAt null.Time
+	Password        string
+	Roles           []string
+	Status          string
+	StatusReason    null.String
+	LoginCount      int64
+	PhoneVerifiedAt null.Time
+	Locale          null.String
+	CreatedAt       time.Time
+	UpdatedAt       null.Time
+	DeletedAt       null.Time
+}
+
+// UserFilter narrows down the users returned by a listing. Zero values are
+// ignored, except Limit which must always be set.
+type UserFilter struct {
+	Search      string
+	CreatedFrom null.Time
+	CreatedTo   null.Time
+	Verified    null.Bool
+	Status      string
+	AfterId     int64
+	Limit       int
+}
+
+// UserStatusChange records a transition of a user's account status.
+type UserStatusChange struct {
+	UserId     int64
+	FromStatus string
+	ToStatus   string
+	Reason     string
+	ActorId    null.Int
+	CreatedAt  time.Time
 }
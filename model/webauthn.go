@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// Ceremonies a WebAuthn challenge is issued for.
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+// WebAuthnChallenge is a pending WebAuthn ceremony. It is looked up by the
+// hash of the challenge the authenticator signed and can be used once. UserId
+// is set when an authenticated user registers a new credential.
+type WebAuthnChallenge struct {
+	Id            int64
+	ChallengeHash string
+	Ceremony      string
+	UserId        null.Int
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+// WebAuthnCredential is a passkey a user can log in with. CredentialId is
+// base64url encoded and PublicKey is in COSE_Key format. SignCount is the
+// signature counter last reported by the authenticator, used to detect
+// cloned authenticators.
+type WebAuthnCredential struct {
+	Id           int64
+	UserId       int64
+	CredentialId string
+	PublicKey    []byte
+	SignCount    int64
+	AAGUID       string
+	Name         string
+	LastUsedAt   null.Time
+	CreatedAt    time.Time
+}
@@ -0,0 +1,116 @@
+// This file contains the API key repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository struct {
+	Db *sql.DB
+}
+
+type APIKeyRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(opts APIKeyRepositoryOptions) *APIKeyRepository {
+	return &APIKeyRepository{Db: opts.DB}
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error) {
+	query := "INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) " +
+		"RETURNING id, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, apiKey.UserId, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, pq.Array(apiKey.Scopes), apiKey.ExpiresAt).
+		Scan(&apiKey.Id, &apiKey.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return model.APIKey{}, err
+	}
+
+	return apiKey, nil
+}
+
+// GetAPIKeyByHash returns the key with the given hash unless it is revoked.
+// Expiry is left to the caller.
+func (r *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	apiKey := model.APIKey{}
+	query := "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys " +
+		"WHERE key_hash = $1 AND revoked_at IS NULL;"
+	err := r.Db.QueryRowContext(ctx, query, keyHash).
+		Scan(&apiKey.Id, &apiKey.UserId, &apiKey.Name, &apiKey.Prefix, pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt)
+	if err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+// ListAPIKeys returns the user's keys that are not revoked, expired ones
+// included so that their owner can clean them up.
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error) {
+	query := "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys " +
+		"WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := make([]model.APIKey, 0)
+	for rows.Next() {
+		apiKey := model.APIKey{}
+		err := rows.Scan(&apiKey.Id, &apiKey.UserId, &apiKey.Name, &apiKey.Prefix, pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeAPIKey revokes one of the user's keys. It returns sql.ErrNoRows when
+// the user has no such key or it is already revoked.
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, userId, id int64) error {
+	query := "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;"
+	result, err := r.Db.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id int64) error {
+	query := "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/guregu/null/v5"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRepository_CreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	apiKeyRepo := NewAPIKeyRepository(APIKeyRepositoryOptions{DB: db})
+
+	apiKey := model.APIKey{
+		UserId:    10,
+		Name:      "backup script",
+		Prefix:    "usk_01234567",
+		KeyHash:   "hash",
+		Scopes:    []string{"profile:read"},
+		ExpiresAt: null.TimeFrom(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) " +
+		"RETURNING id, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(apiKey.UserId, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, pq.Array(apiKey.Scopes), apiKey.ExpiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt))
+
+		result, err := apiKeyRepo.CreateAPIKey(ctx, apiKey)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), result.Id)
+		require.Equal(t, createdAt, result.CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(apiKey.UserId, apiKey.Name, apiKey.Prefix, apiKey.KeyHash, pq.Array(apiKey.Scopes), apiKey.ExpiresAt).
+			WillReturnError(errors.New("db error"))
+
+		_, err := apiKeyRepo.CreateAPIKey(ctx, apiKey)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAPIKeyRepository_GetAPIKeyByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	apiKeyRepo := NewAPIKeyRepository(APIKeyRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys " +
+		"WHERE key_hash = $1 AND revoked_at IS NULL;"
+	columns := []string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(3, 10, "backup script", "usk_01234567", "{profile:read,profile:write}", nil, nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+
+		apiKey, err := apiKeyRepo.GetAPIKeyByHash(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, int64(3), apiKey.Id)
+		require.Equal(t, int64(10), apiKey.UserId)
+		require.Equal(t, []string{"profile:read", "profile:write"}, apiKey.Scopes)
+		require.False(t, apiKey.ExpiresAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnError(sql.ErrNoRows)
+
+		_, err := apiKeyRepo.GetAPIKeyByHash(ctx, "hash")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAPIKeyRepository_ListAPIKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	apiKeyRepo := NewAPIKeyRepository(APIKeyRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at FROM api_keys " +
+		"WHERE user_id = $1 AND revoked_at IS NULL ORDER BY id;"
+	columns := []string{"id", "user_id", "name", "prefix", "scopes", "expires_at", "last_used_at", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(3, userId, "backup script", "usk_01234567", "{}", nil, createdAt, createdAt).
+			AddRow(4, userId, "deploy", "usk_89abcdef", "{profile:read}", createdAt, nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(rows)
+
+		apiKeys, err := apiKeyRepo.ListAPIKeys(ctx, userId)
+		require.NoError(t, err)
+		require.Len(t, apiKeys, 2)
+		require.Empty(t, apiKeys[0].Scopes)
+		require.True(t, apiKeys[0].LastUsedAt.Valid)
+		require.Equal(t, []string{"profile:read"}, apiKeys[1].Scopes)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnError(errors.New("db error"))
+
+		_, err := apiKeyRepo.ListAPIKeys(ctx, userId)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAPIKeyRepository_RevokeAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	apiKeyRepo := NewAPIKeyRepository(APIKeyRepositoryOptions{DB: db})
+
+	userId, id := int64(10), int64(3)
+	query := "UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := apiKeyRepo.RevokeAPIKey(ctx, userId, id)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not found", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := apiKeyRepo.RevokeAPIKey(ctx, userId, id)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAPIKeyRepository_TouchAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	apiKeyRepo := NewAPIKeyRepository(APIKeyRepositoryOptions{DB: db})
+
+	query := "UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := apiKeyRepo.TouchAPIKey(ctx, 3)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,105 @@
+// This file contains the auth failure repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+	"github.com/lib/pq"
+)
+
+type AuthFailureRepository struct {
+	Db *sql.DB
+}
+
+type AuthFailureRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewAuthFailureRepository(opts AuthFailureRepositoryOptions) *AuthFailureRepository {
+	return &AuthFailureRepository{Db: opts.DB}
+}
+
+func (r *AuthFailureRepository) CreateAuthFailure(ctx context.Context, attempt model.AuthAttempt) error {
+	query := "INSERT INTO auth_failures(action, identifier, ip_address) VALUES ($1, $2, $3);"
+	if _, err := r.Db.ExecContext(ctx, query, attempt.Action, attempt.Identifier, attempt.IPAddress); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// CountAuthFailures counts the failures of attempts for the same action
+// since the given time, separately for the attempt's identifier and its IP
+// address.
+func (r *AuthFailureRepository) CountAuthFailures(ctx context.Context, attempt model.AuthAttempt, since time.Time) (model.AuthFailureCount, error) {
+	count := model.AuthFailureCount{}
+	query := "SELECT COUNT(*) FILTER (WHERE identifier = $2), COUNT(*) FILTER (WHERE ip_address = $3) " +
+		"FROM auth_failures WHERE action = $1 AND failed_at > $4 AND (identifier = $2 OR ip_address = $3);"
+	err := r.Db.QueryRowContext(ctx, query, attempt.Action, attempt.Identifier, attempt.IPAddress, since).
+		Scan(&count.Identifier, &count.IPAddress)
+	if err != nil {
+		log.Error(err)
+		return model.AuthFailureCount{}, err
+	}
+
+	return count, nil
+}
+
+// ListAuthFailures returns the failures counted against any of the
+// identifiers, oldest first.
+func (r *AuthFailureRepository) ListAuthFailures(ctx context.Context, identifiers []string) ([]model.AuthFailure, error) {
+	query := "SELECT id, action, identifier, ip_address, failed_at FROM auth_failures WHERE identifier = ANY($1) ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, pq.Array(identifiers))
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	failures := make([]model.AuthFailure, 0)
+	for rows.Next() {
+		failure := model.AuthFailure{}
+		if err := rows.Scan(&failure.Id, &failure.Action, &failure.Identifier, &failure.IPAddress, &failure.FailedAt); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		failures = append(failures, failure)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return failures, nil
+}
+
+// DeleteAuthFailures forgets the failures counted against an identifier,
+// failures counted against IP addresses are kept.
+func (r *AuthFailureRepository) DeleteAuthFailures(ctx context.Context, action, identifier string) error {
+	query := "DELETE FROM auth_failures WHERE action = $1 AND identifier = $2;"
+	if _, err := r.Db.ExecContext(ctx, query, action, identifier); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredAuthFailures removes failures too old to be counted anymore.
+func (r *AuthFailureRepository) DeleteExpiredAuthFailures(ctx context.Context, before time.Time) (int64, error) {
+	query := "DELETE FROM auth_failures WHERE failed_at <= $1;"
+	result, err := r.Db.ExecContext(ctx, query, before)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
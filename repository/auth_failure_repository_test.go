@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+var authAttempt = model.AuthAttempt{Action: model.AuthActionLogin, Identifier: "+628123456789", IPAddress: "192.0.2.1"}
+
+func TestAuthFailureRepository_CreateAuthFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	authFailureRepo := NewAuthFailureRepository(AuthFailureRepositoryOptions{DB: db})
+
+	query := "INSERT INTO auth_failures(action, identifier, ip_address) VALUES ($1, $2, $3);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier, authAttempt.IPAddress).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := authFailureRepo.CreateAuthFailure(ctx, authAttempt)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier, authAttempt.IPAddress).
+			WillReturnError(errors.New("db error"))
+
+		err := authFailureRepo.CreateAuthFailure(ctx, authAttempt)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAuthFailureRepository_CountAuthFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	authFailureRepo := NewAuthFailureRepository(AuthFailureRepositoryOptions{DB: db})
+
+	since := time.Now().Add(-15 * time.Minute)
+	query := "SELECT COUNT(*) FILTER (WHERE identifier = $2), COUNT(*) FILTER (WHERE ip_address = $3) " +
+		"FROM auth_failures WHERE action = $1 AND failed_at > $4 AND (identifier = $2 OR ip_address = $3);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier, authAttempt.IPAddress, since).
+			WillReturnRows(sqlmock.NewRows([]string{"identifier", "ip_address"}).AddRow(3, 7))
+
+		count, err := authFailureRepo.CountAuthFailures(ctx, authAttempt, since)
+		require.NoError(t, err)
+		require.Equal(t, model.AuthFailureCount{Identifier: 3, IPAddress: 7}, count)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier, authAttempt.IPAddress, since).
+			WillReturnError(errors.New("db error"))
+
+		_, err := authFailureRepo.CountAuthFailures(ctx, authAttempt, since)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAuthFailureRepository_ListAuthFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	authFailureRepo := NewAuthFailureRepository(AuthFailureRepositoryOptions{DB: db})
+
+	now := time.Now()
+	identifiers := []string{"+628123456789", "john@example.com", "10"}
+	query := "SELECT id, action, identifier, ip_address, failed_at FROM auth_failures WHERE identifier = ANY($1) ORDER BY id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "action", "identifier", "ip_address", "failed_at"}).
+			AddRow(1, model.AuthActionLogin, "+628123456789", "192.0.2.1", now).
+			AddRow(2, model.AuthActionReauthenticate, "10", "192.0.2.2", now)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pq.Array(identifiers)).WillReturnRows(rows)
+
+		failures, err := authFailureRepo.ListAuthFailures(ctx, identifiers)
+		require.NoError(t, err)
+		require.Len(t, failures, 2)
+		require.Equal(t, model.AuthActionLogin, failures[0].Action)
+		require.Equal(t, "192.0.2.2", failures[1].IPAddress)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pq.Array(identifiers)).WillReturnError(errors.New("db error"))
+
+		_, err := authFailureRepo.ListAuthFailures(ctx, identifiers)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAuthFailureRepository_DeleteAuthFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	authFailureRepo := NewAuthFailureRepository(AuthFailureRepositoryOptions{DB: db})
+
+	query := "DELETE FROM auth_failures WHERE action = $1 AND identifier = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		err := authFailureRepo.DeleteAuthFailures(ctx, authAttempt.Action, authAttempt.Identifier)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(authAttempt.Action, authAttempt.Identifier).
+			WillReturnError(errors.New("db error"))
+
+		err := authFailureRepo.DeleteAuthFailures(ctx, authAttempt.Action, authAttempt.Identifier)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestAuthFailureRepository_DeleteExpiredAuthFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	authFailureRepo := NewAuthFailureRepository(AuthFailureRepositoryOptions{DB: db})
+
+	before := time.Now().Add(-15 * time.Minute)
+	query := "DELETE FROM auth_failures WHERE failed_at <= $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		deleted, err := authFailureRepo.DeleteExpiredAuthFailures(ctx, before)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(before).WillReturnError(errors.New("db error"))
+
+		_, err := authFailureRepo.DeleteExpiredAuthFailures(ctx, before)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,129 @@
+// This file contains the device repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type DeviceRepository struct {
+	Db *sql.DB
+}
+
+type DeviceRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewDeviceRepository(opts DeviceRepositoryOptions) *DeviceRepository {
+	return &DeviceRepository{Db: opts.DB}
+}
+
+// CreateDevice remembers a device of the user. It returns sql.ErrNoRows when
+// the device is already known, e.g. because a concurrent login created it.
+func (r *DeviceRepository) CreateDevice(ctx context.Context, device model.Device) (model.Device, error) {
+	query := "INSERT INTO user_devices(user_id, fingerprint, user_agent, revoke_token_hash) " +
+		"VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, fingerprint) DO NOTHING RETURNING id, last_login_at, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, device.UserId, device.Fingerprint, device.UserAgent, device.RevokeTokenHash).
+		Scan(&device.Id, &device.LastLoginAt, &device.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return model.Device{}, err
+	}
+
+	return device, nil
+}
+
+func (r *DeviceRepository) GetDevice(ctx context.Context, userId int64, fingerprint string) (model.Device, error) {
+	device := model.Device{}
+	query := "SELECT id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at " +
+		"FROM user_devices WHERE user_id = $1 AND fingerprint = $2;"
+	err := r.Db.QueryRowContext(ctx, query, userId, fingerprint).
+		Scan(&device.Id, &device.UserId, &device.Fingerprint, &device.UserAgent, &device.RevokeTokenHash, &device.LastLoginAt, &device.CreatedAt)
+	if err != nil {
+		return device, err
+	}
+
+	return device, nil
+}
+
+func (r *DeviceRepository) ListDevices(ctx context.Context, userId int64) ([]model.Device, error) {
+	query := "SELECT id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at " +
+		"FROM user_devices WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := make([]model.Device, 0)
+	for rows.Next() {
+		device := model.Device{}
+		err := rows.Scan(&device.Id, &device.UserId, &device.Fingerprint, &device.UserAgent, &device.RevokeTokenHash, &device.LastLoginAt, &device.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		devices = append(devices, device)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (r *DeviceRepository) CountDevices(ctx context.Context, userId int64) (int64, error) {
+	var count int64
+	query := "SELECT COUNT(*) FROM user_devices WHERE user_id = $1;"
+	if err := r.Db.QueryRowContext(ctx, query, userId).Scan(&count); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// DeviceExists reports whether the user's device is still known, that is
+// not revoked.
+func (r *DeviceRepository) DeviceExists(ctx context.Context, userId, id int64) (bool, error) {
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM user_devices WHERE id = $1 AND user_id = $2);"
+	if err := r.Db.QueryRowContext(ctx, query, id, userId).Scan(&exists); err != nil {
+		log.Error(err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func (r *DeviceRepository) TouchDevice(ctx context.Context, id int64) error {
+	query := "UPDATE user_devices SET last_login_at = NOW() WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteDeviceByRevokeTokenHash forgets and returns the device the revoke
+// token was issued for, so the link works once.
+func (r *DeviceRepository) DeleteDeviceByRevokeTokenHash(ctx context.Context, tokenHash string) (model.Device, error) {
+	device := model.Device{}
+	query := "DELETE FROM user_devices WHERE revoke_token_hash = $1 " +
+		"RETURNING id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, tokenHash).
+		Scan(&device.Id, &device.UserId, &device.Fingerprint, &device.UserAgent, &device.RevokeTokenHash, &device.LastLoginAt, &device.CreatedAt)
+	if err != nil {
+		return device, err
+	}
+
+	return device, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/stretchr/testify/require"
+)
+
+var deviceColumns = []string{"id", "user_id", "fingerprint", "user_agent", "revoke_token_hash", "last_login_at", "created_at"}
+
+func TestDeviceRepository_CreateDevice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	now := time.Now()
+	device := model.Device{UserId: 10, Fingerprint: "fingerprint", UserAgent: "curl/8.0", RevokeTokenHash: "hash"}
+	query := "INSERT INTO user_devices(user_id, fingerprint, user_agent, revoke_token_hash) " +
+		"VALUES ($1, $2, $3, $4) ON CONFLICT (user_id, fingerprint) DO NOTHING RETURNING id, last_login_at, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(device.UserId, device.Fingerprint, device.UserAgent, device.RevokeTokenHash).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "last_login_at", "created_at"}).AddRow(1, now, now))
+
+		created, err := deviceRepo.CreateDevice(ctx, device)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), created.Id)
+		require.Equal(t, device.Fingerprint, created.Fingerprint)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - already known", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(device.UserId, device.Fingerprint, device.UserAgent, device.RevokeTokenHash).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "last_login_at", "created_at"}))
+
+		_, err := deviceRepo.CreateDevice(ctx, device)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(device.UserId, device.Fingerprint, device.UserAgent, device.RevokeTokenHash).
+			WillReturnError(errors.New("db error"))
+
+		_, err := deviceRepo.CreateDevice(ctx, device)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_GetDevice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	now := time.Now()
+	query := "SELECT id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at " +
+		"FROM user_devices WHERE user_id = $1 AND fingerprint = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(10), "fingerprint").
+			WillReturnRows(sqlmock.NewRows(deviceColumns).AddRow(1, 10, "fingerprint", "curl/8.0", "hash", now, now))
+
+		device, err := deviceRepo.GetDevice(ctx, 10, "fingerprint")
+		require.NoError(t, err)
+		require.Equal(t, int64(1), device.Id)
+		require.Equal(t, "curl/8.0", device.UserAgent)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(10), "fingerprint").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := deviceRepo.GetDevice(ctx, 10, "fingerprint")
+		require.Equal(t, sql.ErrNoRows, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_ListDevices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	now := time.Now()
+	query := "SELECT id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at " +
+		"FROM user_devices WHERE user_id = $1 ORDER BY id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(deviceColumns).
+			AddRow(1, 10, "fingerprint", "curl/8.0", "hash", now, now).
+			AddRow(2, 10, "other", "Mozilla/5.0", "other-hash", now, now)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		devices, err := deviceRepo.ListDevices(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, devices, 2)
+		require.Equal(t, "curl/8.0", devices[0].UserAgent)
+		require.Equal(t, "other", devices[1].Fingerprint)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		_, err := deviceRepo.ListDevices(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_CountDevices(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	query := "SELECT COUNT(*) FROM user_devices WHERE user_id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		count, err := deviceRepo.CountDevices(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), count)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(10)).
+			WillReturnError(errors.New("db error"))
+
+		_, err := deviceRepo.CountDevices(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_DeviceExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	query := "SELECT EXISTS(SELECT 1 FROM user_devices WHERE id = $1 AND user_id = $2);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(1), int64(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		exists, err := deviceRepo.DeviceExists(ctx, 10, 1)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(1), int64(10)).
+			WillReturnError(errors.New("db error"))
+
+		_, err := deviceRepo.DeviceExists(ctx, 10, 1)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_TouchDevice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	query := "UPDATE user_devices SET last_login_at = NOW() WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := deviceRepo.TouchDevice(ctx, 1)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(int64(1)).
+			WillReturnError(errors.New("db error"))
+
+		err := deviceRepo.TouchDevice(ctx, 1)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestDeviceRepository_DeleteDeviceByRevokeTokenHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	deviceRepo := NewDeviceRepository(DeviceRepositoryOptions{DB: db})
+
+	now := time.Now()
+	query := "DELETE FROM user_devices WHERE revoke_token_hash = $1 " +
+		"RETURNING id, user_id, fingerprint, user_agent, revoke_token_hash, last_login_at, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows(deviceColumns).AddRow(1, 10, "fingerprint", "curl/8.0", "hash", now, now))
+
+		device, err := deviceRepo.DeleteDeviceByRevokeTokenHash(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), device.UserId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs("hash").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := deviceRepo.DeleteDeviceByRevokeTokenHash(ctx, "hash")
+		require.Equal(t, sql.ErrNoRows, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,133 @@
+// This file contains the email verification repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type EmailVerificationRepository struct {
+	Db *sql.DB
+}
+
+type EmailVerificationRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewEmailVerificationRepository(opts EmailVerificationRepositoryOptions) *EmailVerificationRepository {
+	return &EmailVerificationRepository{Db: opts.DB}
+}
+
+func (r *EmailVerificationRepository) CreateEmailVerification(ctx context.Context, verification model.EmailVerification) (int64, error) {
+	var id int64
+	query := "INSERT INTO email_verifications(user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id;"
+	err := r.Db.QueryRowContext(ctx, query, verification.UserId, verification.Email, verification.TokenHash, verification.ExpiresAt).Scan(&id)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetEmailVerificationByHash returns the verification with the given token
+// hash. Expiry and prior use are left to the caller.
+func (r *EmailVerificationRepository) GetEmailVerificationByHash(ctx context.Context, tokenHash string) (model.EmailVerification, error) {
+	verification := model.EmailVerification{}
+	query := "SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE token_hash = $1;"
+	err := r.Db.QueryRowContext(ctx, query, tokenHash).
+		Scan(&verification.Id, &verification.UserId, &verification.Email, &verification.TokenHash,
+			&verification.ExpiresAt, &verification.UsedAt, &verification.CreatedAt)
+	if err != nil {
+		return verification, err
+	}
+
+	return verification, nil
+}
+
+// ListEmailVerifications returns every verification sent to the user,
+// oldest first.
+func (r *EmailVerificationRepository) ListEmailVerifications(ctx context.Context, userId int64) ([]model.EmailVerification, error) {
+	query := "SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifications := make([]model.EmailVerification, 0)
+	for rows.Next() {
+		verification := model.EmailVerification{}
+		err := rows.Scan(&verification.Id, &verification.UserId, &verification.Email, &verification.TokenHash,
+			&verification.ExpiresAt, &verification.UsedAt, &verification.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		verifications = append(verifications, verification)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return verifications, nil
+}
+
+// ConsumeEmailVerification marks the verification as used and the user's
+// email as verified. It returns sql.ErrNoRows when the verification was
+// already used or the user's email is no longer the one it was sent to.
+func (r *EmailVerificationRepository) ConsumeEmailVerification(ctx context.Context, verification model.EmailVerification) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE email_verifications SET used_at = NOW() WHERE id = $1 AND used_at IS NULL;", verification.Id)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	query := "UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email = $2 AND deleted_at IS NULL;"
+	result, err = tx.ExecContext(ctx, query, verification.UserId, verification.Email)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err = result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerificationRepository_CreateEmailVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	verificationRepo := NewEmailVerificationRepository(EmailVerificationRepositoryOptions{DB: db})
+
+	verification := model.EmailVerification{
+		UserId:    10,
+		Email:     "john@example.com",
+		TokenHash: "hash",
+		ExpiresAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	query := "INSERT INTO email_verifications(user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(verification.UserId, verification.Email, verification.TokenHash, verification.ExpiresAt).WillReturnRows(rows)
+
+		id, err := verificationRepo.CreateEmailVerification(ctx, verification)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), id)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(verification.UserId, verification.Email, verification.TokenHash, verification.ExpiresAt).WillReturnError(errors.New("db error"))
+
+		id, err := verificationRepo.CreateEmailVerification(ctx, verification)
+		require.Error(t, err)
+		require.Zero(t, id)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestEmailVerificationRepository_GetEmailVerificationByHash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	verificationRepo := NewEmailVerificationRepository(EmailVerificationRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+	query := "SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE token_hash = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "email", "token_hash", "expires_at", "used_at", "created_at"}).
+			AddRow(1, 10, "john@example.com", "hash", expiresAt, nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+
+		verification, err := verificationRepo.GetEmailVerificationByHash(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), verification.UserId)
+		require.Equal(t, "john@example.com", verification.Email)
+		require.Equal(t, expiresAt, verification.ExpiresAt)
+		require.False(t, verification.UsedAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnError(sql.ErrNoRows)
+
+		_, err := verificationRepo.GetEmailVerificationByHash(ctx, "hash")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestEmailVerificationRepository_ListEmailVerifications(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	verificationRepo := NewEmailVerificationRepository(EmailVerificationRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+	query := "SELECT id, user_id, email, token_hash, expires_at, used_at, created_at FROM email_verifications WHERE user_id = $1 ORDER BY id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "email", "token_hash", "expires_at", "used_at", "created_at"}).
+			AddRow(1, 10, "old@example.com", "hash", expiresAt, createdAt, createdAt).
+			AddRow(2, 10, "john@example.com", "other-hash", expiresAt, nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		verifications, err := verificationRepo.ListEmailVerifications(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, verifications, 2)
+		require.True(t, verifications[0].UsedAt.Valid)
+		require.Equal(t, "john@example.com", verifications[1].Email)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		_, err := verificationRepo.ListEmailVerifications(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestEmailVerificationRepository_ConsumeEmailVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	verificationRepo := NewEmailVerificationRepository(EmailVerificationRepositoryOptions{DB: db})
+
+	verification := model.EmailVerification{Id: 1, UserId: 10, Email: "john@example.com"}
+	useQuery := "UPDATE email_verifications SET used_at = NOW() WHERE id = $1 AND used_at IS NULL;"
+	verifyQuery := "UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email = $2 AND deleted_at IS NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(useQuery)).WithArgs(verification.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(verifyQuery)).WithArgs(verification.UserId, verification.Email).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := verificationRepo.ConsumeEmailVerification(ctx, verification)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - already used", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(useQuery)).WithArgs(verification.Id).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := verificationRepo.ConsumeEmailVerification(ctx, verification)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - email changed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(useQuery)).WithArgs(verification.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(verifyQuery)).WithArgs(verification.UserId, verification.Email).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := verificationRepo.ConsumeEmailVerification(ctx, verification)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(useQuery)).WithArgs(verification.Id).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := verificationRepo.ConsumeEmailVerification(ctx, verification)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,143 @@
+// This file contains the user export repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type ExportRepository struct {
+	Db *sql.DB
+}
+
+type ExportRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewExportRepository(opts ExportRepositoryOptions) *ExportRepository {
+	return &ExportRepository{Db: opts.DB}
+}
+
+// CreateExport queues a new export for the user. It returns sql.ErrNoRows
+// when the user already has an export that is not finished yet.
+func (r *ExportRepository) CreateExport(ctx context.Context, userId int64) (model.UserExport, error) {
+	export := model.UserExport{}
+	query := "INSERT INTO user_exports(user_id) VALUES ($1) " +
+		"ON CONFLICT (user_id) WHERE status IN ('pending', 'processing') DO NOTHING " +
+		"RETURNING id, user_id, status, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, userId).Scan(&export.Id, &export.UserId, &export.Status, &export.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return export, err
+	}
+
+	return export, nil
+}
+
+func (r *ExportRepository) GetExportById(ctx context.Context, id int64) (model.UserExport, error) {
+	export := model.UserExport{}
+	query := "SELECT id, user_id, status, error, created_at, completed_at, expires_at FROM user_exports WHERE id = $1;"
+	err := r.Db.QueryRowContext(ctx, query, id).
+		Scan(&export.Id, &export.UserId, &export.Status, &export.Error, &export.CreatedAt, &export.CompletedAt, &export.ExpiresAt)
+	if err != nil {
+		log.Error(err)
+		return export, err
+	}
+
+	return export, nil
+}
+
+// GetExportArchive returns the archive of a ready export that has not
+// expired yet.
+func (r *ExportRepository) GetExportArchive(ctx context.Context, id int64) ([]byte, error) {
+	var archive []byte
+	query := "SELECT archive FROM user_exports WHERE id = $1 AND status = 'ready' AND expires_at > NOW();"
+	if err := r.Db.QueryRowContext(ctx, query, id).Scan(&archive); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// ClaimPendingExport marks the oldest pending export as processing and
+// returns it. An export claimed before staleBefore is claimed again, as the
+// worker processing it is assumed dead, unless it was claimed maxAttempts
+// times already. Concurrent workers never claim the same export. It returns
+// sql.ErrNoRows when there is nothing to process.
+func (r *ExportRepository) ClaimPendingExport(ctx context.Context, staleBefore time.Time, maxAttempts int) (model.UserExport, error) {
+	export := model.UserExport{}
+	query := "UPDATE user_exports SET status = 'processing', claimed_at = NOW(), attempts = attempts + 1 WHERE id = (" +
+		"SELECT id FROM user_exports WHERE status = 'pending' OR (status = 'processing' AND claimed_at < $1 AND attempts < $2) " +
+		"ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED" +
+		") RETURNING id, user_id, status, created_at, claimed_at, attempts;"
+	err := r.Db.QueryRowContext(ctx, query, staleBefore, maxAttempts).
+		Scan(&export.Id, &export.UserId, &export.Status, &export.CreatedAt, &export.ClaimedAt, &export.Attempts)
+	if err != nil {
+		return export, err
+	}
+
+	return export, nil
+}
+
+// FailStaleExports fails the exports claimed before staleBefore that were
+// claimed maxAttempts times already, so an export no worker gets through does
+// not stay processing forever. It returns how many were failed.
+func (r *ExportRepository) FailStaleExports(ctx context.Context, staleBefore time.Time, maxAttempts int, reason string) (int64, error) {
+	query := "UPDATE user_exports SET status = 'failed', error = $3, completed_at = NOW() " +
+		"WHERE status = 'processing' AND claimed_at < $1 AND attempts >= $2;"
+	result, err := r.Db.ExecContext(ctx, query, staleBefore, maxAttempts, reason)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	failed, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return failed, nil
+}
+
+func (r *ExportRepository) CompleteExport(ctx context.Context, id int64, archive []byte, expiresAt time.Time) error {
+	query := "UPDATE user_exports SET status = 'ready', archive = $2, completed_at = NOW(), expires_at = $3 WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id, archive, expiresAt); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ExportRepository) FailExport(ctx context.Context, id int64, reason string) error {
+	query := "UPDATE user_exports SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id, reason); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteExpiredExports removes exports whose archive is past its retention.
+func (r *ExportRepository) DeleteExpiredExports(ctx context.Context) (int64, error) {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM user_exports WHERE expires_at < NOW();")
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRepository_CreateExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "INSERT INTO user_exports(user_id) VALUES ($1) " +
+		"ON CONFLICT (user_id) WHERE status IN ('pending', 'processing') DO NOTHING " +
+		"RETURNING id, user_id, status, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "status", "created_at"}).
+			AddRow(1, userId, model.ExportStatusPending, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(rows)
+
+		export, err := exportRepo.CreateExport(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), export.Id)
+		require.Equal(t, model.ExportStatusPending, export.Status)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - export in progress", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "status", "created_at"}))
+
+		_, err := exportRepo.CreateExport(ctx, userId)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_GetExportById(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	id := int64(1)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiresAt := createdAt.Add(24 * time.Hour)
+	query := "SELECT id, user_id, status, error, created_at, completed_at, expires_at FROM user_exports WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "status", "error", "created_at", "completed_at", "expires_at"}).
+			AddRow(id, 10, model.ExportStatusReady, nil, createdAt, createdAt, expiresAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnRows(rows)
+
+		export, err := exportRepo.GetExportById(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), export.UserId)
+		require.Equal(t, expiresAt, export.ExpiresAt.Time)
+		require.False(t, export.Error.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnError(errors.New("db error"))
+
+		_, err := exportRepo.GetExportById(ctx, id)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_GetExportArchive(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	id := int64(1)
+	query := "SELECT archive FROM user_exports WHERE id = $1 AND status = 'ready' AND expires_at > NOW();"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"archive"}).AddRow([]byte("archive"))
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnRows(rows)
+
+		archive, err := exportRepo.GetExportArchive(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, []byte("archive"), archive)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnError(sql.ErrNoRows)
+
+		archive, err := exportRepo.GetExportArchive(ctx, id)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.Nil(t, archive)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_ClaimPendingExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	claimedAt := createdAt.Add(time.Minute)
+	staleBefore := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	query := "UPDATE user_exports SET status = 'processing', claimed_at = NOW(), attempts = attempts + 1 WHERE id = (" +
+		"SELECT id FROM user_exports WHERE status = 'pending' OR (status = 'processing' AND claimed_at < $1 AND attempts < $2) " +
+		"ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED" +
+		") RETURNING id, user_id, status, created_at, claimed_at, attempts;"
+	columns := []string{"id", "user_id", "status", "created_at", "claimed_at", "attempts"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 10, model.ExportStatusProcessing, createdAt, claimedAt, 1)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(staleBefore, 3).WillReturnRows(rows)
+
+		export, err := exportRepo.ClaimPendingExport(ctx, staleBefore, 3)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), export.Id)
+		require.Equal(t, int64(10), export.UserId)
+		require.Equal(t, claimedAt, export.ClaimedAt.Time)
+		require.Equal(t, 1, export.Attempts)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("success - stale export claimed again", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(1, 10, model.ExportStatusProcessing, createdAt, staleBefore.Add(time.Minute), 2)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(staleBefore, 3).WillReturnRows(rows)
+
+		export, err := exportRepo.ClaimPendingExport(ctx, staleBefore, 3)
+		require.NoError(t, err)
+		require.Equal(t, 2, export.Attempts)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - nothing to claim", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(staleBefore, 3).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := exportRepo.ClaimPendingExport(ctx, staleBefore, 3)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_FailStaleExports(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	staleBefore := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	query := "UPDATE user_exports SET status = 'failed', error = $3, completed_at = NOW() " +
+		"WHERE status = 'processing' AND claimed_at < $1 AND attempts >= $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(staleBefore, 3, "export timed out").WillReturnResult(sqlmock.NewResult(0, 2))
+
+		failed, err := exportRepo.FailStaleExports(ctx, staleBefore, 3, "export timed out")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), failed)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - db error", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(staleBefore, 3, "export timed out").WillReturnError(errors.New("db error"))
+
+		_, err := exportRepo.FailStaleExports(ctx, staleBefore, 3, "export timed out")
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_CompleteExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	id := int64(1)
+	archive := []byte("archive")
+	expiresAt := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	query := "UPDATE user_exports SET status = 'ready', archive = $2, completed_at = NOW(), expires_at = $3 WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, archive, expiresAt).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := exportRepo.CompleteExport(ctx, id, archive, expiresAt)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, archive, expiresAt).WillReturnError(errors.New("db error"))
+
+		err := exportRepo.CompleteExport(ctx, id, archive, expiresAt)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_FailExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	id := int64(1)
+	query := "UPDATE user_exports SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, "db error").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := exportRepo.FailExport(ctx, id, "db error")
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, "db error").WillReturnError(errors.New("db error"))
+
+		err := exportRepo.FailExport(ctx, id, "db error")
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestExportRepository_DeleteExpiredExports(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	exportRepo := NewExportRepository(ExportRepositoryOptions{DB: db})
+
+	query := "DELETE FROM user_exports WHERE expires_at < NOW();"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		deleted, err := exportRepo.DeleteExpiredExports(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		deleted, err := exportRepo.DeleteExpiredExports(ctx)
+		require.Error(t, err)
+		require.Zero(t, deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,227 @@
+// This file contains the federated identity repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type IdentityRepository struct {
+	Db *sql.DB
+}
+
+type IdentityRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewIdentityRepository(opts IdentityRepositoryOptions) *IdentityRepository {
+	return &IdentityRepository{Db: opts.DB}
+}
+
+func (r *IdentityRepository) CreateIdentity(ctx context.Context, identity model.UserIdentity) (model.UserIdentity, error) {
+	query := "INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, identity.UserId, identity.Provider, identity.Subject, identity.Email).
+		Scan(&identity.Id, &identity.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+func (r *IdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (model.UserIdentity, error) {
+	identity := model.UserIdentity{}
+	query := "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities " +
+		"WHERE provider = $1 AND subject = $2;"
+	err := r.Db.QueryRowContext(ctx, query, provider, subject).
+		Scan(&identity.Id, &identity.UserId, &identity.Provider, &identity.Subject, &identity.Email, &identity.LastLoginAt, &identity.CreatedAt)
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (r *IdentityRepository) ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error) {
+	query := "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities " +
+		"WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := make([]model.UserIdentity, 0)
+	for rows.Next() {
+		identity := model.UserIdentity{}
+		err := rows.Scan(&identity.Id, &identity.UserId, &identity.Provider, &identity.Subject, &identity.Email, &identity.LastLoginAt, &identity.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// DeleteIdentity unlinks the user's identity at provider. It returns
+// sql.ErrNoRows when there is none.
+func (r *IdentityRepository) DeleteIdentity(ctx context.Context, userId int64, provider string) error {
+	query := "DELETE FROM user_identities WHERE user_id = $1 AND provider = $2;"
+	result, err := r.Db.ExecContext(ctx, query, userId, provider)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *IdentityRepository) TouchIdentity(ctx context.Context, id int64) error {
+	query := "UPDATE user_identities SET last_login_at = NOW() WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ProvisionUser creates a user without a local password, assigns them role
+// and links identity to them within a single transaction.
+func (r *IdentityRepository) ProvisionUser(ctx context.Context, user model.User, role string, identity model.UserIdentity) (model.UserIdentity, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+	defer tx.Rollback()
+
+	userQuery := "INSERT INTO users(full_name, phone_number, email, email_verified_at, password) VALUES ($1, $2, $3, $4, '') RETURNING id;"
+	err = tx.QueryRowContext(ctx, userQuery, user.FullName, user.PhoneNumber, user.Email, user.EmailVerifiedAt).Scan(&identity.UserId)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+
+	roleQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = $2;"
+	if _, err := tx.ExecContext(ctx, roleQuery, identity.UserId, role); err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+
+	identityQuery := "INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at;"
+	err = tx.QueryRowContext(ctx, identityQuery, identity.UserId, identity.Provider, identity.Subject, identity.Email).
+		Scan(&identity.Id, &identity.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, err
+	}
+
+	return identity, nil
+}
+
+func (r *IdentityRepository) CreateOIDCAuthRequest(ctx context.Context, request model.OIDCAuthRequest) error {
+	query := "INSERT INTO oidc_auth_requests(state_hash, binding_hash, provider, nonce, code_verifier, user_id, expires_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7);"
+	_, err := r.Db.ExecContext(ctx, query, request.StateHash, request.BindingHash, request.Provider, request.Nonce, request.CodeVerifier, request.UserId, request.ExpiresAt)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeOIDCAuthRequest deletes and returns the request with the given state
+// hash so that it can not be replayed. Expiry is left to the caller.
+func (r *IdentityRepository) ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (model.OIDCAuthRequest, error) {
+	request := model.OIDCAuthRequest{}
+	query := "DELETE FROM oidc_auth_requests WHERE state_hash = $1 " +
+		"RETURNING id, state_hash, binding_hash, provider, nonce, code_verifier, user_id, expires_at, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, stateHash).
+		Scan(&request.Id, &request.StateHash, &request.BindingHash, &request.Provider, &request.Nonce, &request.CodeVerifier, &request.UserId, &request.ExpiresAt, &request.CreatedAt)
+	if err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// DeleteExpiredOIDCAuthRequests removes requests of users who never came back
+// from their identity provider.
+func (r *IdentityRepository) DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error) {
+	query := "DELETE FROM oidc_auth_requests WHERE expires_at < NOW();"
+	result, err := r.Db.ExecContext(ctx, query)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *IdentityRepository) CreateSAMLAuthRequest(ctx context.Context, request model.SAMLAuthRequest) error {
+	query := "INSERT INTO saml_auth_requests(request_id, provider, expires_at) VALUES ($1, $2, $3);"
+	_, err := r.Db.ExecContext(ctx, query, request.RequestId, request.Provider, request.ExpiresAt)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeSAMLAuthRequest deletes and returns the request with the given ID so
+// that its response can not be replayed. Expiry is left to the caller.
+func (r *IdentityRepository) ConsumeSAMLAuthRequest(ctx context.Context, requestId string) (model.SAMLAuthRequest, error) {
+	request := model.SAMLAuthRequest{}
+	query := "DELETE FROM saml_auth_requests WHERE request_id = $1 RETURNING id, request_id, provider, expires_at, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, requestId).
+		Scan(&request.Id, &request.RequestId, &request.Provider, &request.ExpiresAt, &request.CreatedAt)
+	if err != nil {
+		return request, err
+	}
+
+	return request, nil
+}
+
+// DeleteExpiredSAMLAuthRequests removes requests of users who never came back
+// from their identity provider.
+func (r *IdentityRepository) DeleteExpiredSAMLAuthRequests(ctx context.Context) (int64, error) {
+	query := "DELETE FROM saml_auth_requests WHERE expires_at < NOW();"
+	result, err := r.Db.ExecContext(ctx, query)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityRepository_CreateIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	identity := model.UserIdentity{UserId: 10, Provider: "google", Subject: "1234", Email: null.StringFrom("john@example.com")}
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(identity.UserId, identity.Provider, identity.Subject, identity.Email).WillReturnRows(rows)
+
+		created, err := identityRepo.CreateIdentity(ctx, identity)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), created.Id)
+		require.Equal(t, createdAt, created.CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(identity.UserId, identity.Provider, identity.Subject, identity.Email).WillReturnError(errors.New("db error"))
+
+		_, err := identityRepo.CreateIdentity(ctx, identity)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_GetIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities " +
+		"WHERE provider = $1 AND subject = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "last_login_at", "created_at"}).
+			AddRow(1, 10, "google", "1234", "john@example.com", nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("google", "1234").WillReturnRows(rows)
+
+		identity, err := identityRepo.GetIdentity(ctx, "google", "1234")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), identity.UserId)
+		require.Equal(t, "john@example.com", identity.Email.String)
+		require.False(t, identity.LastLoginAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("google", "1234").WillReturnError(sql.ErrNoRows)
+
+		_, err := identityRepo.GetIdentity(ctx, "google", "1234")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_ListIdentities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, provider, subject, email, last_login_at, created_at FROM user_identities " +
+		"WHERE user_id = $1 ORDER BY id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email", "last_login_at", "created_at"}).
+			AddRow(1, 10, "google", "1234", nil, createdAt, createdAt).
+			AddRow(2, 10, "microsoft", "5678", "john@example.com", nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		identities, err := identityRepo.ListIdentities(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, identities, 2)
+		require.Equal(t, "google", identities[0].Provider)
+		require.Equal(t, "microsoft", identities[1].Provider)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		identities, err := identityRepo.ListIdentities(ctx, 10)
+		require.Error(t, err)
+		require.Nil(t, identities)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_DeleteIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	query := "DELETE FROM user_identities WHERE user_id = $1 AND provider = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(10), "google").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := identityRepo.DeleteIdentity(ctx, 10, "google")
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not linked", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(10), "google").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := identityRepo.DeleteIdentity(ctx, 10, "google")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_ProvisionUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := model.User{
+		FullName:        "John Doe",
+		PhoneNumber:     "+6281234567890",
+		Email:           null.StringFrom("john@example.com"),
+		EmailVerifiedAt: null.TimeFrom(verifiedAt),
+	}
+	identity := model.UserIdentity{Provider: model.IdentityProviderLDAP, Subject: "uid=john,dc=example,dc=com", Email: user.Email}
+	userQuery := "INSERT INTO users(full_name, phone_number, email, email_verified_at, password) VALUES ($1, $2, $3, $4, '') RETURNING id;"
+	roleQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = $2;"
+	identityQuery := "INSERT INTO user_identities(user_id, provider, subject, email) VALUES ($1, $2, $3, $4) RETURNING id, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).
+			WithArgs(user.FullName, user.PhoneNumber, user.Email, user.EmailVerifiedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(regexp.QuoteMeta(roleQuery)).WithArgs(int64(10), model.RoleUser).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(identityQuery)).
+			WithArgs(int64(10), identity.Provider, identity.Subject, identity.Email).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, verifiedAt))
+		mock.ExpectCommit()
+
+		created, err := identityRepo.ProvisionUser(ctx, user, model.RoleUser, identity)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), created.Id)
+		require.Equal(t, int64(10), created.UserId)
+		require.Equal(t, verifiedAt, created.CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - phone number taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).
+			WithArgs(user.FullName, user.PhoneNumber, user.Email, user.EmailVerifiedAt).
+			WillReturnError(errors.New("duplicate key value violates unique constraint"))
+		mock.ExpectRollback()
+
+		_, err := identityRepo.ProvisionUser(ctx, user, model.RoleUser, identity)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - identity", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).
+			WithArgs(user.FullName, user.PhoneNumber, user.Email, user.EmailVerifiedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+		mock.ExpectExec(regexp.QuoteMeta(roleQuery)).WithArgs(int64(10), model.RoleUser).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(identityQuery)).
+			WithArgs(int64(10), identity.Provider, identity.Subject, identity.Email).
+			WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		_, err := identityRepo.ProvisionUser(ctx, user, model.RoleUser, identity)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_CreateOIDCAuthRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	request := model.OIDCAuthRequest{
+		StateHash:    "hash",
+		BindingHash:  "binding-hash",
+		Provider:     "google",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		UserId:       null.IntFrom(10),
+		ExpiresAt:    time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+	}
+	query := "INSERT INTO oidc_auth_requests(state_hash, binding_hash, provider, nonce, code_verifier, user_id, expires_at) " +
+		"VALUES ($1, $2, $3, $4, $5, $6, $7);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(request.StateHash, request.BindingHash, request.Provider, request.Nonce, request.CodeVerifier, request.UserId, request.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := identityRepo.CreateOIDCAuthRequest(ctx, request)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(request.StateHash, request.BindingHash, request.Provider, request.Nonce, request.CodeVerifier, request.UserId, request.ExpiresAt).
+			WillReturnError(errors.New("db error"))
+
+		err := identityRepo.CreateOIDCAuthRequest(ctx, request)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_ConsumeOIDCAuthRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "DELETE FROM oidc_auth_requests WHERE state_hash = $1 " +
+		"RETURNING id, state_hash, binding_hash, provider, nonce, code_verifier, user_id, expires_at, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "state_hash", "binding_hash", "provider", "nonce", "code_verifier", "user_id", "expires_at", "created_at"}).
+			AddRow(1, "hash", "binding-hash", "google", "nonce", "verifier", nil, createdAt.Add(10*time.Minute), createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+
+		request, err := identityRepo.ConsumeOIDCAuthRequest(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, "binding-hash", request.BindingHash)
+		require.Equal(t, "google", request.Provider)
+		require.Equal(t, "nonce", request.Nonce)
+		require.False(t, request.UserId.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnError(sql.ErrNoRows)
+
+		_, err := identityRepo.ConsumeOIDCAuthRequest(ctx, "hash")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_DeleteExpiredOIDCAuthRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	query := "DELETE FROM oidc_auth_requests WHERE expires_at < NOW();"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 3))
+
+		deleted, err := identityRepo.DeleteExpiredOIDCAuthRequests(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		_, err := identityRepo.DeleteExpiredOIDCAuthRequests(ctx)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_CreateSAMLAuthRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	request := model.SAMLAuthRequest{
+		RequestId: "id-123",
+		Provider:  "acme",
+		ExpiresAt: time.Date(2024, 1, 1, 0, 10, 0, 0, time.UTC),
+	}
+	query := "INSERT INTO saml_auth_requests(request_id, provider, expires_at) VALUES ($1, $2, $3);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(request.RequestId, request.Provider, request.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := identityRepo.CreateSAMLAuthRequest(ctx, request)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(request.RequestId, request.Provider, request.ExpiresAt).
+			WillReturnError(errors.New("db error"))
+
+		err := identityRepo.CreateSAMLAuthRequest(ctx, request)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_ConsumeSAMLAuthRequest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "DELETE FROM saml_auth_requests WHERE request_id = $1 RETURNING id, request_id, provider, expires_at, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "request_id", "provider", "expires_at", "created_at"}).
+			AddRow(1, "id-123", "acme", createdAt.Add(10*time.Minute), createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("id-123").WillReturnRows(rows)
+
+		request, err := identityRepo.ConsumeSAMLAuthRequest(ctx, "id-123")
+		require.NoError(t, err)
+		require.Equal(t, "acme", request.Provider)
+		require.Equal(t, createdAt.Add(10*time.Minute), request.ExpiresAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("id-123").WillReturnError(sql.ErrNoRows)
+
+		_, err := identityRepo.ConsumeSAMLAuthRequest(ctx, "id-123")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestIdentityRepository_DeleteExpiredSAMLAuthRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	identityRepo := NewIdentityRepository(IdentityRepositoryOptions{DB: db})
+
+	query := "DELETE FROM saml_auth_requests WHERE expires_at < NOW();"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		deleted, err := identityRepo.DeleteExpiredSAMLAuthRequests(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		_, err := identityRepo.DeleteExpiredSAMLAuthRequests(ctx)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
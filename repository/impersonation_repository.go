@@ -0,0 +1,110 @@
+// This file contains the impersonation repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type ImpersonationRepository struct {
+	Db *sql.DB
+}
+
+type ImpersonationRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewImpersonationRepository(opts ImpersonationRepositoryOptions) *ImpersonationRepository {
+	return &ImpersonationRepository{Db: opts.DB}
+}
+
+func (r *ImpersonationRepository) CreateImpersonation(ctx context.Context, impersonation model.Impersonation) (int64, error) {
+	var id int64
+	query := "INSERT INTO impersonations(actor_id, user_id, reason, expires_at) VALUES ($1, $2, $3, $4) RETURNING id;"
+	err := r.Db.QueryRowContext(ctx, query, impersonation.ActorId, impersonation.UserId, impersonation.Reason, impersonation.ExpiresAt).
+		Scan(&id)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *ImpersonationRepository) CreateAuditLog(ctx context.Context, auditLog model.ImpersonationAuditLog) error {
+	query := "INSERT INTO impersonation_audit_logs(impersonation_id, method, path, status_code) VALUES ($1, $2, $3, $4);"
+	_, err := r.Db.ExecContext(ctx, query, auditLog.ImpersonationId, auditLog.Method, auditLog.Path, auditLog.StatusCode)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ListImpersonations returns the impersonation sessions in which the user was
+// acted as, oldest first.
+func (r *ImpersonationRepository) ListImpersonations(ctx context.Context, userId int64) ([]model.Impersonation, error) {
+	query := "SELECT id, actor_id, user_id, reason, expires_at, created_at FROM impersonations WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	impersonations := make([]model.Impersonation, 0)
+	for rows.Next() {
+		impersonation := model.Impersonation{}
+		err := rows.Scan(&impersonation.Id, &impersonation.ActorId, &impersonation.UserId, &impersonation.Reason,
+			&impersonation.ExpiresAt, &impersonation.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		impersonations = append(impersonations, impersonation)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return impersonations, nil
+}
+
+// ListImpersonationAuditLogs returns the requests made while the user was
+// acted as, across all their impersonation sessions.
+func (r *ImpersonationRepository) ListImpersonationAuditLogs(ctx context.Context, userId int64) ([]model.ImpersonationAuditLog, error) {
+	query := "SELECT l.impersonation_id, l.method, l.path, l.status_code, l.created_at FROM impersonation_audit_logs l " +
+		"JOIN impersonations i ON i.id = l.impersonation_id WHERE i.user_id = $1 ORDER BY l.id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	auditLogs := make([]model.ImpersonationAuditLog, 0)
+	for rows.Next() {
+		auditLog := model.ImpersonationAuditLog{}
+		err := rows.Scan(&auditLog.ImpersonationId, &auditLog.Method, &auditLog.Path, &auditLog.StatusCode, &auditLog.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return auditLogs, nil
+}
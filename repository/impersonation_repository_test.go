@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationRepository_CreateImpersonation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	impersonationRepo := NewImpersonationRepository(ImpersonationRepositoryOptions{DB: db})
+
+	impersonation := model.Impersonation{
+		ActorId:   null.IntFrom(1),
+		UserId:    10,
+		Reason:    "debugging checkout",
+		ExpiresAt: time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC),
+	}
+	query := "INSERT INTO impersonations(actor_id, user_id, reason, expires_at) VALUES ($1, $2, $3, $4) RETURNING id;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(impersonation.ActorId, impersonation.UserId, impersonation.Reason, impersonation.ExpiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+
+		id, err := impersonationRepo.CreateImpersonation(ctx, impersonation)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), id)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(impersonation.ActorId, impersonation.UserId, impersonation.Reason, impersonation.ExpiresAt).
+			WillReturnError(errors.New("db error"))
+
+		id, err := impersonationRepo.CreateImpersonation(ctx, impersonation)
+		require.Error(t, err)
+		require.Zero(t, id)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestImpersonationRepository_CreateAuditLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	impersonationRepo := NewImpersonationRepository(ImpersonationRepositoryOptions{DB: db})
+
+	auditLog := model.ImpersonationAuditLog{
+		ImpersonationId: 5,
+		Method:          "GET",
+		Path:            "/v1/users/profile",
+		StatusCode:      200,
+	}
+	query := "INSERT INTO impersonation_audit_logs(impersonation_id, method, path, status_code) VALUES ($1, $2, $3, $4);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(auditLog.ImpersonationId, auditLog.Method, auditLog.Path, auditLog.StatusCode).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := impersonationRepo.CreateAuditLog(ctx, auditLog)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(auditLog.ImpersonationId, auditLog.Method, auditLog.Path, auditLog.StatusCode).
+			WillReturnError(errors.New("db error"))
+
+		err := impersonationRepo.CreateAuditLog(ctx, auditLog)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestImpersonationRepository_ListImpersonations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	impersonationRepo := NewImpersonationRepository(ImpersonationRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, actor_id, user_id, reason, expires_at, created_at FROM impersonations WHERE user_id = $1 ORDER BY id;"
+	columns := []string{"id", "actor_id", "user_id", "reason", "expires_at", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(5, 1, 10, "debugging checkout", createdAt.Add(15*time.Minute), createdAt).
+			AddRow(6, nil, 10, "checking settings", createdAt.Add(15*time.Minute), createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		impersonations, err := impersonationRepo.ListImpersonations(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, impersonations, 2)
+		require.Equal(t, null.IntFrom(1), impersonations[0].ActorId)
+		require.Equal(t, "debugging checkout", impersonations[0].Reason)
+		require.False(t, impersonations[1].ActorId.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		_, err := impersonationRepo.ListImpersonations(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestImpersonationRepository_ListImpersonationAuditLogs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	impersonationRepo := NewImpersonationRepository(ImpersonationRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT l.impersonation_id, l.method, l.path, l.status_code, l.created_at FROM impersonation_audit_logs l " +
+		"JOIN impersonations i ON i.id = l.impersonation_id WHERE i.user_id = $1 ORDER BY l.id;"
+	columns := []string{"impersonation_id", "method", "path", "status_code", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(5, "GET", "/v1/users/profile", 200, createdAt).
+			AddRow(5, "PUT", "/v1/users/profile", 403, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		auditLogs, err := impersonationRepo.ListImpersonationAuditLogs(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, auditLogs, 2)
+		require.Equal(t, int64(5), auditLogs[0].ImpersonationId)
+		require.Equal(t, 403, auditLogs[1].StatusCode)
+		require.Equal(t, createdAt, auditLogs[1].CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		_, err := impersonationRepo.ListImpersonationAuditLogs(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -6,15 +6,115 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/model"
 )
 
 type UserRepositoryInterface interface {
-	CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error)
+	CreateFirstAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error)
+	CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody, roles []string) (int64, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
 	GetUserById(ctx context.Context, id int64) (model.User, error)
+	GetDeletedUserByEmail(ctx context.Context, email string) (model.User, error)
+	GetDeletedUserById(ctx context.Context, id int64) (model.User, error)
+	GetDeletedUserByPhoneNumber(ctx context.Context, phoneNumber string) (model.User, error)
+	GetLatestUserStatusChange(ctx context.Context, userId int64) (model.UserStatusChange, error)
+	GetUserByEmail(ctx context.Context, email string) (model.User, error)
 	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (model.User, error)
+	GetUserDetailById(ctx context.Context, id int64) (model.User, error)
 	IncrementUserLoginCount(ctx context.Context, id int64) error
+	ListUserStatusChanges(ctx context.Context, userId int64) ([]model.UserStatusChange, error)
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, error)
+	PhoneNumberExists(ctx context.Context, phoneNumber string) (bool, error)
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SetUserVerified(ctx context.Context, id int64, verified bool) error
+	UpdateUserPassword(ctx context.Context, id int64, hashedPassword string) error
 	UpdateUserProfile(ctx context.Context, id int64, payload generated.UpdateUserProfileJSONRequestBody) error
+	UpdateUserStatus(ctx context.Context, change model.UserStatusChange) error
+}
+
+type AuthFailureRepositoryInterface interface {
+	CountAuthFailures(ctx context.Context, attempt model.AuthAttempt, since time.Time) (model.AuthFailureCount, error)
+	CreateAuthFailure(ctx context.Context, attempt model.AuthAttempt) error
+	DeleteAuthFailures(ctx context.Context, action, identifier string) error
+	DeleteExpiredAuthFailures(ctx context.Context, before time.Time) (int64, error)
+	ListAuthFailures(ctx context.Context, identifiers []string) ([]model.AuthFailure, error)
+}
+
+type DeviceRepositoryInterface interface {
+	CountDevices(ctx context.Context, userId int64) (int64, error)
+	CreateDevice(ctx context.Context, device model.Device) (model.Device, error)
+	DeleteDeviceByRevokeTokenHash(ctx context.Context, tokenHash string) (model.Device, error)
+	DeviceExists(ctx context.Context, userId, id int64) (bool, error)
+	GetDevice(ctx context.Context, userId int64, fingerprint string) (model.Device, error)
+	ListDevices(ctx context.Context, userId int64) ([]model.Device, error)
+	TouchDevice(ctx context.Context, id int64) error
+}
+
+type EmailVerificationRepositoryInterface interface {
+	ConsumeEmailVerification(ctx context.Context, verification model.EmailVerification) error
+	CreateEmailVerification(ctx context.Context, verification model.EmailVerification) (int64, error)
+	GetEmailVerificationByHash(ctx context.Context, tokenHash string) (model.EmailVerification, error)
+	ListEmailVerifications(ctx context.Context, userId int64) ([]model.EmailVerification, error)
+}
+
+type IdentityRepositoryInterface interface {
+	ConsumeOIDCAuthRequest(ctx context.Context, stateHash string) (model.OIDCAuthRequest, error)
+	ConsumeSAMLAuthRequest(ctx context.Context, requestId string) (model.SAMLAuthRequest, error)
+	CreateIdentity(ctx context.Context, identity model.UserIdentity) (model.UserIdentity, error)
+	CreateOIDCAuthRequest(ctx context.Context, request model.OIDCAuthRequest) error
+	CreateSAMLAuthRequest(ctx context.Context, request model.SAMLAuthRequest) error
+	DeleteExpiredOIDCAuthRequests(ctx context.Context) (int64, error)
+	DeleteExpiredSAMLAuthRequests(ctx context.Context) (int64, error)
+	DeleteIdentity(ctx context.Context, userId int64, provider string) error
+	GetIdentity(ctx context.Context, provider, subject string) (model.UserIdentity, error)
+	ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error)
+	ProvisionUser(ctx context.Context, user model.User, role string, identity model.UserIdentity) (model.UserIdentity, error)
+	TouchIdentity(ctx context.Context, id int64) error
+}
+
+type RoleRepositoryInterface interface {
+	GetAllRoles(ctx context.Context) ([]string, error)
+	GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error)
+	GetUserRoles(ctx context.Context, userId int64) ([]string, error)
+	SetUserRoles(ctx context.Context, userId int64, roles []string) error
+}
+
+type APIKeyRepositoryInterface interface {
+	CreateAPIKey(ctx context.Context, apiKey model.APIKey) (model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userId, id int64) error
+	TouchAPIKey(ctx context.Context, id int64) error
+}
+
+type ExportRepositoryInterface interface {
+	ClaimPendingExport(ctx context.Context, staleBefore time.Time, maxAttempts int) (model.UserExport, error)
+	CompleteExport(ctx context.Context, id int64, archive []byte, expiresAt time.Time) error
+	CreateExport(ctx context.Context, userId int64) (model.UserExport, error)
+	DeleteExpiredExports(ctx context.Context) (int64, error)
+	FailExport(ctx context.Context, id int64, reason string) error
+	FailStaleExports(ctx context.Context, staleBefore time.Time, maxAttempts int, reason string) (int64, error)
+	GetExportArchive(ctx context.Context, id int64) ([]byte, error)
+	GetExportById(ctx context.Context, id int64) (model.UserExport, error)
+}
+
+type ImpersonationRepositoryInterface interface {
+	CreateAuditLog(ctx context.Context, auditLog model.ImpersonationAuditLog) error
+	CreateImpersonation(ctx context.Context, impersonation model.Impersonation) (int64, error)
+	ListImpersonationAuditLogs(ctx context.Context, userId int64) ([]model.ImpersonationAuditLog, error)
+	ListImpersonations(ctx context.Context, userId int64) ([]model.Impersonation, error)
+}
+
+type WebAuthnRepositoryInterface interface {
+	ConsumeWebAuthnChallenge(ctx context.Context, challengeHash string) (model.WebAuthnChallenge, error)
+	CreateWebAuthnChallenge(ctx context.Context, challenge model.WebAuthnChallenge) error
+	CreateWebAuthnCredential(ctx context.Context, credential model.WebAuthnCredential) (model.WebAuthnCredential, error)
+	DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error)
+	DeleteWebAuthnCredential(ctx context.Context, userId, id int64) error
+	GetWebAuthnCredential(ctx context.Context, credentialId string) (model.WebAuthnCredential, error)
+	ListWebAuthnCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error)
+	UpdateWebAuthnCredentialSignCount(ctx context.Context, id, signCount int64) error
 }
@@ -0,0 +1,97 @@
+// This file contains the role repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/labstack/gommon/log"
+
+	"github.com/lib/pq"
+)
+
+type RoleRepository struct {
+	Db *sql.DB
+}
+
+type RoleRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewRoleRepository(opts RoleRepositoryOptions) *RoleRepository {
+	return &RoleRepository{Db: opts.DB}
+}
+
+func (r *RoleRepository) GetAllRoles(ctx context.Context) ([]string, error) {
+	return r.queryNames(ctx, "SELECT name FROM roles ORDER BY name;")
+}
+
+func (r *RoleRepository) GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error) {
+	query := "SELECT DISTINCT p.name FROM permissions p " +
+		"JOIN role_permissions rp ON rp.permission_id = p.id " +
+		"JOIN roles r ON r.id = rp.role_id WHERE r.name = ANY($1);"
+
+	return r.queryNames(ctx, query, pq.Array(roles))
+}
+
+func (r *RoleRepository) GetUserRoles(ctx context.Context, userId int64) ([]string, error) {
+	query := "SELECT r.name FROM roles r JOIN user_roles ur ON ur.role_id = r.id WHERE ur.user_id = $1 ORDER BY r.name;"
+
+	return r.queryNames(ctx, query, userId)
+}
+
+// SetUserRoles replaces the roles of a user within a single transaction.
+func (r *RoleRepository) SetUserRoles(ctx context.Context, userId int64, roles []string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	deleteQuery := "DELETE FROM user_roles WHERE user_id = $1;"
+	if _, err := tx.ExecContext(ctx, deleteQuery, userId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	insertQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2);"
+	if _, err := tx.ExecContext(ctx, insertQuery, userId, pq.Array(roles)); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) queryNames(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := r.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return names, nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleRepository_GetPermissionsByRoles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	roleRepo := NewRoleRepository(RoleRepositoryOptions{DB: db})
+
+	roles := []string{"admin", "user"}
+	query := "SELECT DISTINCT p.name FROM permissions p " +
+		"JOIN role_permissions rp ON rp.permission_id = p.id " +
+		"JOIN roles r ON r.id = rp.role_id WHERE r.name = ANY($1);"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("profile:read").AddRow("users:read")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pq.Array(roles)).WillReturnRows(rows)
+
+		permissions, err := roleRepo.GetPermissionsByRoles(ctx, roles)
+		require.NoError(t, err)
+		require.Equal(t, []string{"profile:read", "users:read"}, permissions)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(pq.Array(roles)).WillReturnError(errors.New("db error"))
+
+		permissions, err := roleRepo.GetPermissionsByRoles(ctx, roles)
+		require.Error(t, err)
+		require.Empty(t, permissions)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestRoleRepository_GetUserRoles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	roleRepo := NewRoleRepository(RoleRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	query := "SELECT r.name FROM roles r JOIN user_roles ur ON ur.role_id = r.id WHERE ur.user_id = $1 ORDER BY r.name;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("admin").AddRow("user")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(rows)
+
+		roles, err := roleRepo.GetUserRoles(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin", "user"}, roles)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnError(errors.New("db error"))
+
+		roles, err := roleRepo.GetUserRoles(ctx, userId)
+		require.Error(t, err)
+		require.Empty(t, roles)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestRoleRepository_GetAllRoles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	roleRepo := NewRoleRepository(RoleRepositoryOptions{DB: db})
+
+	query := "SELECT name FROM roles ORDER BY name;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"name"}).AddRow("admin").AddRow("user")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+
+		roles, err := roleRepo.GetAllRoles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin", "user"}, roles)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		roles, err := roleRepo.GetAllRoles(ctx)
+		require.Error(t, err)
+		require.Empty(t, roles)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestRoleRepository_SetUserRoles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	roleRepo := NewRoleRepository(RoleRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	roles := []string{"admin", "user"}
+	deleteQuery := "DELETE FROM user_roles WHERE user_id = $1;"
+	insertQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(userId, pq.Array(roles)).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err := roleRepo.SetUserRoles(ctx, userId, roles)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - rollback on insert error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(userId).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(userId, pq.Array(roles)).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := roleRepo.SetUserRoles(ctx, userId, roles)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
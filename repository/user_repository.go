@@ -4,15 +4,30 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strings"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/labstack/gommon/log"
 
 	sq "github.com/Masterminds/squirrel"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// userDetailColumns are the columns selected for the admin view of a user.
+var userDetailColumns = []string{
+	"id", "full_name", "phone_number", "email", "email_verified_at", "login_count", "phone_verified_at", "created_at", "updated_at", "deleted_at",
+	"status", "status_reason", "locale",
+	"ARRAY(SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id ORDER BY r.name) AS roles",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type UserRepository struct {
 	Db *sql.DB
 }
@@ -25,22 +40,93 @@ func NewUserRepository(opts UserRepositoryOptions) *UserRepository {
 	return &UserRepository{Db: opts.DB}
 }
 
-func (r *UserRepository) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error) {
+// CreateUser creates the user along with their roles in one transaction, so
+// no user is left without a role.
+func (r *UserRepository) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody, roles []string) (int64, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertUser(ctx, tx, payload, roles)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// CreateFirstAdmin creates the user with the user and admin roles unless an
+// admin exists already, in which case it returns sql.ErrNoRows. The admin
+// role is locked until the user is created, so concurrent calls create one
+// admin at most.
+func (r *UserRepository) CreateFirstAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var roleId int64
+	if err := tx.QueryRowContext(ctx, "SELECT id FROM roles WHERE name = $1 FOR UPDATE;", model.RoleAdmin).Scan(&roleId); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	var adminExists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM user_roles WHERE role_id = $1);", roleId).Scan(&adminExists); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	if adminExists {
+		return 0, sql.ErrNoRows
+	}
+
+	id, err := insertUser(ctx, tx, payload, []string{model.RoleUser, model.RoleAdmin})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func insertUser(ctx context.Context, tx *sql.Tx, payload generated.RegisterUserJSONRequestBody, roles []string) (int64, error) {
 	var id int64
-	query := "INSERT INTO users(full_name, phone_number, password) VALUES ($1, $2, $3) RETURNING id;"
-	err := r.Db.QueryRow(query, payload.FullName, payload.PhoneNumber, payload.Password).Scan(&id)
+	userQuery := "INSERT INTO users(full_name, phone_number, email, password) VALUES ($1, $2, $3, $4) RETURNING id;"
+	err := tx.QueryRowContext(ctx, userQuery, payload.FullName, payload.PhoneNumber, payload.Email, payload.Password).Scan(&id)
 	if err != nil {
 		log.Error(err)
 		return 0, err
 	}
 
+	roleQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2);"
+	if _, err := tx.ExecContext(ctx, roleQuery, id, pq.Array(roles)); err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
 	return id, nil
 }
 
 func (r *UserRepository) GetUserById(ctx context.Context, id int64) (model.User, error) {
 	user := model.User{}
-	err := r.Db.QueryRowContext(ctx, "SELECT id, full_name, phone_number, password FROM users WHERE id = $1;", id).
-		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Password)
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, locale FROM users WHERE id = $1 AND deleted_at IS NULL;"
+	err := r.Db.QueryRowContext(ctx, query, id).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status, &user.Locale)
 	if err != nil {
 		log.Error(err)
 		return user, err
@@ -49,10 +135,11 @@ func (r *UserRepository) GetUserById(ctx context.Context, id int64) (model.User,
 	return user, nil
 }
 
+// GetUserByPhoneNumber looks a user up by their phone number in E.164 form.
 func (r *UserRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (model.User, error) {
 	user := model.User{}
-	err := r.Db.QueryRowContext(ctx, "SELECT id, full_name, phone_number, password FROM users WHERE phone_number = $1;", phoneNumber).
-		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Password)
+	err := r.Db.QueryRowContext(ctx, "SELECT id, full_name, phone_number, email, email_verified_at, password, status FROM users WHERE phone_number = $1 AND deleted_at IS NULL;", phoneNumber).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status)
 	if err != nil {
 		log.Error(err)
 		return user, err
@@ -61,6 +148,90 @@ func (r *UserRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber s
 	return user, nil
 }
 
+// GetUserByEmail looks a user up by their normalized email address.
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (model.User, error) {
+	user := model.User{}
+	err := r.Db.QueryRowContext(ctx, "SELECT id, full_name, phone_number, email, email_verified_at, password, status FROM users WHERE email = $1 AND deleted_at IS NULL;", email).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status)
+	if err != nil {
+		log.Error(err)
+		return user, err
+	}
+
+	return user, nil
+}
+
+// GetDeletedUserByPhoneNumber returns a soft-deleted user that has not been
+// purged yet, so that it can be reactivated.
+func (r *UserRepository) GetDeletedUserByPhoneNumber(ctx context.Context, phoneNumber string) (model.User, error) {
+	user := model.User{}
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE phone_number = $1 AND deleted_at IS NOT NULL;"
+	err := r.Db.QueryRowContext(ctx, query, phoneNumber).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status, &user.DeletedAt)
+	if err != nil {
+		log.Error(err)
+		return user, err
+	}
+
+	return user, nil
+}
+
+// GetDeletedUserByEmail is the email counterpart of
+// GetDeletedUserByPhoneNumber.
+func (r *UserRepository) GetDeletedUserByEmail(ctx context.Context, email string) (model.User, error) {
+	user := model.User{}
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE email = $1 AND deleted_at IS NOT NULL;"
+	err := r.Db.QueryRowContext(ctx, query, email).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status, &user.DeletedAt)
+	if err != nil {
+		log.Error(err)
+		return user, err
+	}
+
+	return user, nil
+}
+
+// GetDeletedUserById is the counterpart of GetDeletedUserByPhoneNumber for
+// users recognised by a linked identity.
+func (r *UserRepository) GetDeletedUserById(ctx context.Context, id int64) (model.User, error) {
+	user := model.User{}
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL;"
+	err := r.Db.QueryRowContext(ctx, query, id).
+		Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Status, &user.DeletedAt)
+	if err != nil {
+		log.Error(err)
+		return user, err
+	}
+
+	return user, nil
+}
+
+// PhoneNumberExists reports whether the phone number is held by any user,
+// including soft-deleted users that are still within their grace period.
+func (r *UserRepository) PhoneNumberExists(ctx context.Context, phoneNumber string) (bool, error) {
+	var exists bool
+	err := r.Db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE phone_number = $1);", phoneNumber).Scan(&exists)
+	if err != nil {
+		log.Error(err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// EmailExists reports whether the normalized email is held by any user,
+// including soft-deleted users that are still within their grace period.
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var exists bool
+	err := r.Db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1);", email).Scan(&exists)
+	if err != nil {
+		log.Error(err)
+		return false, err
+	}
+
+	return exists, nil
+}
+
 func (r *UserRepository) IncrementUserLoginCount(ctx context.Context, id int64) error {
 	query := "UPDATE users SET login_count = COALESCE(login_count, 0) + 1, updated_at = NOW() WHERE id = $1"
 	if err := r.Db.QueryRow(query, id).Err(); err != nil {
@@ -83,6 +254,17 @@ func (r *UserRepository) UpdateUserProfile(ctx context.Context, id int64, payloa
 		query = query.Set("phone_number", payload.PhoneNumber)
 	}
 
+	if payload.Email != nil {
+		// Only a different email has to be verified again; resubmitting the
+		// current one keeps it verified.
+		query = query.Set("email", *payload.Email).
+			Set("email_verified_at", sq.Expr("CASE WHEN email IS DISTINCT FROM ? THEN NULL ELSE email_verified_at END", *payload.Email))
+	}
+
+	if payload.Locale != nil {
+		query = query.Set("locale", string(*payload.Locale))
+	}
+
 	sql, args, err := query.ToSql()
 	if err != nil {
 		log.Error(err)
@@ -96,3 +278,234 @@ func (r *UserRepository) UpdateUserProfile(ctx context.Context, id int64, payloa
 
 	return nil
 }
+
+func (r *UserRepository) GetUserDetailById(ctx context.Context, id int64) (model.User, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	sql, args, err := psql.Select(userDetailColumns...).From("users").Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+		log.Error(err)
+		return model.User{}, err
+	}
+
+	user, err := scanUserDetail(r.Db.QueryRowContext(ctx, sql, args...))
+	if err != nil {
+		log.Error(err)
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (r *UserRepository) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, error) {
+	psql := sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+	query := psql.Select(userDetailColumns...).From("users").OrderBy("id").Limit(uint64(filter.Limit))
+
+	if filter.AfterId > 0 {
+		query = query.Where(sq.Gt{"id": filter.AfterId})
+	}
+
+	if filter.Search != "" {
+		pattern := "%" + likeEscaper.Replace(filter.Search) + "%"
+		query = query.Where(sq.Or{sq.ILike{"full_name": pattern}, sq.ILike{"phone_number": pattern}, sq.ILike{"email": pattern}})
+	}
+
+	if filter.CreatedFrom.Valid {
+		query = query.Where(sq.GtOrEq{"created_at": filter.CreatedFrom.Time})
+	}
+
+	if filter.CreatedTo.Valid {
+		query = query.Where(sq.Lt{"created_at": filter.CreatedTo.Time})
+	}
+
+	if filter.Verified.Valid {
+		if filter.Verified.Bool {
+			query = query.Where(sq.NotEq{"phone_verified_at": nil})
+		} else {
+			query = query.Where(sq.Eq{"phone_verified_at": nil})
+		}
+	}
+
+	if filter.Status != "" {
+		query = query.Where(sq.Eq{"status": filter.Status})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	rows, err := r.Db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		user, err := scanUserDetail(rows)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) SetUserVerified(ctx context.Context, id int64, verified bool) error {
+	query := "UPDATE users SET phone_verified_at = CASE WHEN $2 THEN COALESCE(phone_verified_at, NOW()) END, updated_at = NOW() WHERE id = $1;"
+	if _, err := r.Db.ExecContext(ctx, query, id, verified); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUserPassword replaces the password hash of a user. It returns
+// sql.ErrNoRows when the user does not exist.
+func (r *UserRepository) UpdateUserPassword(ctx context.Context, id int64, hashedPassword string) error {
+	query := "UPDATE users SET password = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL;"
+	result, err := r.Db.ExecContext(ctx, query, id, hashedPassword)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateUserStatus moves a user from change.FromStatus to change.ToStatus and
+// records the change. It returns sql.ErrNoRows when the user is no longer in
+// change.FromStatus, e.g. because of a concurrent update.
+func (r *UserRepository) UpdateUserStatus(ctx context.Context, change model.UserStatusChange) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer tx.Rollback()
+
+	updateQuery := "UPDATE users SET status = $1, status_reason = $2, status_changed_at = NOW(), updated_at = NOW(), " +
+		"deleted_at = CASE WHEN $1 = 'deleted' THEN COALESCE(deleted_at, NOW()) END WHERE id = $3 AND status = $4;"
+	result, err := tx.ExecContext(ctx, updateQuery, change.ToStatus, change.Reason, change.UserId, change.FromStatus)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	insertQuery := "INSERT INTO user_status_changes(user_id, from_status, to_status, reason, actor_id) VALUES ($1, $2, $3, $4, $5);"
+	if _, err := tx.ExecContext(ctx, insertQuery, change.UserId, change.FromStatus, change.ToStatus, change.Reason, change.ActorId); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ListUserStatusChanges(ctx context.Context, userId int64) ([]model.UserStatusChange, error) {
+	query := "SELECT user_id, from_status, to_status, reason, actor_id, created_at FROM user_status_changes WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]model.UserStatusChange, 0)
+	for rows.Next() {
+		change := model.UserStatusChange{}
+		err := rows.Scan(&change.UserId, &change.FromStatus, &change.ToStatus, &change.Reason, &change.ActorId, &change.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetLatestUserStatusChange returns the last status change of the user, the
+// one that put them in their current status.
+func (r *UserRepository) GetLatestUserStatusChange(ctx context.Context, userId int64) (model.UserStatusChange, error) {
+	change := model.UserStatusChange{}
+	query := "SELECT user_id, from_status, to_status, reason, actor_id, created_at FROM user_status_changes WHERE user_id = $1 ORDER BY id DESC LIMIT 1;"
+	err := r.Db.QueryRowContext(ctx, query, userId).
+		Scan(&change.UserId, &change.FromStatus, &change.ToStatus, &change.Reason, &change.ActorId, &change.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return change, err
+	}
+
+	return change, nil
+}
+
+// PurgeDeletedUsers hard-deletes users that were soft-deleted before
+// deletedBefore. Their roles and status history are removed along with them,
+// status changes and impersonations they made as an admin are kept without
+// their actor.
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE status = 'deleted' AND deleted_at < $1;"
+	result, err := r.Db.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+func scanUserDetail(row rowScanner) (model.User, error) {
+	user := model.User{}
+	err := row.Scan(&user.Id, &user.FullName, &user.PhoneNumber, &user.Email, &user.EmailVerifiedAt, &user.LoginCount, &user.PhoneVerifiedAt,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletedAt, &user.Status, &user.StatusReason, &user.Locale, pq.Array(&user.Roles))
+
+	return user, err
+}
@@ -2,13 +2,18 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/guregu/null/v5"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,20 +29,27 @@ func TestUserRepository_CreateUser(t *testing.T) {
 	fullName := "John Doe"
 	password := "password"
 	phoneNumber := "+6285912345678"
+	email := "john@example.com"
+	roles := []string{model.RoleUser}
 
 	payload := generated.RegisterUserRequest{
 		FullName:    fullName,
 		Password:    password,
 		PhoneNumber: phoneNumber,
+		Email:       &email,
 	}
 
-	query := "INSERT INTO users(full_name, phone_number, password) VALUES ($1, $2, $3) RETURNING id;"
+	userQuery := "INSERT INTO users(full_name, phone_number, email, password) VALUES ($1, $2, $3, $4) RETURNING id;"
+	roleQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2);"
 
 	t.Run("success", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id"}).AddRow(strconv.FormatInt(id, 10))
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(fullName, phoneNumber, password).WillReturnRows(rows)
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).WithArgs(fullName, phoneNumber, email, password).WillReturnRows(rows)
+		mock.ExpectExec(regexp.QuoteMeta(roleQuery)).WithArgs(id, pq.Array(roles)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
 
-		resId, err := userRepo.CreateUser(ctx, payload)
+		resId, err := userRepo.CreateUser(ctx, payload, roles)
 		require.NoError(t, err)
 		require.Equal(t, id, resId)
 
@@ -45,10 +57,94 @@ func TestUserRepository_CreateUser(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("failed - assign roles", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(strconv.FormatInt(id, 10))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).WithArgs(fullName, phoneNumber, email, password).WillReturnRows(rows)
+		mock.ExpectExec(regexp.QuoteMeta(roleQuery)).WithArgs(id, pq.Array(roles)).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		resId, err := userRepo.CreateUser(ctx, payload, roles)
+		require.Error(t, err)
+		require.Zero(t, resId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
 	t.Run("failed", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(fullName, phoneNumber, password).WillReturnError(errors.New("db error"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).WithArgs(fullName, phoneNumber, email, password).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
 
-		resId, err := userRepo.CreateUser(ctx, payload)
+		resId, err := userRepo.CreateUser(ctx, payload, roles)
+		require.Error(t, err)
+		require.Zero(t, resId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_CreateFirstAdmin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	payload := generated.RegisterUserRequest{
+		FullName:    "John Doe",
+		Password:    "password",
+		PhoneNumber: "+6285912345678",
+	}
+
+	lockQuery := "SELECT id FROM roles WHERE name = $1 FOR UPDATE;"
+	existsQuery := "SELECT EXISTS(SELECT 1 FROM user_roles WHERE role_id = $1);"
+	userQuery := "INSERT INTO users(full_name, phone_number, email, password) VALUES ($1, $2, $3, $4) RETURNING id;"
+	roleQuery := "INSERT INTO user_roles(user_id, role_id) SELECT $1, id FROM roles WHERE name = ANY($2);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(model.RoleAdmin).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(regexp.QuoteMeta(userQuery)).
+			WithArgs(payload.FullName, payload.PhoneNumber, payload.Email, payload.Password).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+		mock.ExpectExec(regexp.QuoteMeta(roleQuery)).
+			WithArgs(id, pq.Array([]string{model.RoleUser, model.RoleAdmin})).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		resId, err := userRepo.CreateFirstAdmin(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, id, resId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - admin already exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(model.RoleAdmin).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		resId, err := userRepo.CreateFirstAdmin(ctx, payload)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.Zero(t, resId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lockQuery)).WithArgs(model.RoleAdmin).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		resId, err := userRepo.CreateFirstAdmin(ctx, payload)
 		require.Error(t, err)
 		require.Zero(t, resId)
 
@@ -70,11 +166,13 @@ func TestUserRepository_GetUserById(t *testing.T) {
 	password := "password"
 	phoneNumber := "+6285912345678"
 
-	query := "SELECT id, full_name, phone_number, password FROM users WHERE id = $1;"
+	status := "active"
+	locale := "id"
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, locale FROM users WHERE id = $1 AND deleted_at IS NULL;"
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "password"}).
-			AddRow(strconv.FormatInt(id, 10), fullName, phoneNumber, password)
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status", "locale"}).
+			AddRow(strconv.FormatInt(id, 10), fullName, phoneNumber, nil, nil, password, status, locale)
 		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnRows(rows)
 
 		resUser, err := userRepo.GetUserById(ctx, id)
@@ -83,6 +181,8 @@ func TestUserRepository_GetUserById(t *testing.T) {
 		require.Equal(t, fullName, resUser.FullName)
 		require.Equal(t, phoneNumber, resUser.PhoneNumber)
 		require.Equal(t, password, resUser.Password)
+		require.Equal(t, status, resUser.Status)
+		require.Equal(t, locale, resUser.Locale.String)
 
 		err = mock.ExpectationsWereMet()
 		require.NoError(t, err)
@@ -113,11 +213,12 @@ func TestUserRepository_GetUserByPhoneNumber(t *testing.T) {
 	password := "password"
 	phoneNumber := "+6285912345678"
 
-	query := "SELECT id, full_name, phone_number, password FROM users WHERE phone_number = $1;"
+	status := "active"
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status FROM users WHERE phone_number = $1 AND deleted_at IS NULL;"
 
 	t.Run("success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "password"}).
-			AddRow(strconv.FormatInt(id, 10), fullName, phoneNumber, password)
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status"}).
+			AddRow(strconv.FormatInt(id, 10), fullName, phoneNumber, nil, nil, password, status)
 		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(phoneNumber).WillReturnRows(rows)
 
 		resUser, err := userRepo.GetUserByPhoneNumber(ctx, phoneNumber)
@@ -126,6 +227,7 @@ func TestUserRepository_GetUserByPhoneNumber(t *testing.T) {
 		require.Equal(t, fullName, resUser.FullName)
 		require.Equal(t, phoneNumber, resUser.PhoneNumber)
 		require.Equal(t, password, resUser.Password)
+		require.Equal(t, status, resUser.Status)
 
 		err = mock.ExpectationsWereMet()
 		require.NoError(t, err)
@@ -143,6 +245,45 @@ func TestUserRepository_GetUserByPhoneNumber(t *testing.T) {
 	})
 }
 
+func TestUserRepository_GetUserByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	email := "john@example.com"
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status FROM users WHERE email = $1 AND deleted_at IS NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status"}).
+			AddRow(id, "John Doe", "+6285912345678", email, verifiedAt, "password", "active")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnRows(rows)
+
+		resUser, err := userRepo.GetUserByEmail(ctx, email)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, email, resUser.Email.String)
+		require.Equal(t, verifiedAt, resUser.EmailVerifiedAt.Time)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnError(sql.ErrNoRows)
+
+		_, err := userRepo.GetUserByEmail(ctx, email)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
 func TestUserRepository_IncrementUserLoginCount(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -206,6 +347,38 @@ func TestUserRepository_UpdateUserProfile(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("success - locale", func(t *testing.T) {
+		locale := generated.Locale("id")
+		payload := generated.UpdateUserProfileJSONRequestBody{Locale: &locale}
+
+		query := "UPDATE users SET locale = $1 WHERE id = $2"
+
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(strconv.FormatInt(id, 10))
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("id", id).WillReturnRows(rows)
+
+		err := userRepo.UpdateUserProfile(ctx, id, payload)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("success - email is verified again only when changed", func(t *testing.T) {
+		email := "john@example.com"
+		payload := generated.UpdateUserProfileJSONRequestBody{Email: &email}
+
+		query := "UPDATE users SET email = $1, email_verified_at = CASE WHEN email IS DISTINCT FROM $2 THEN NULL ELSE email_verified_at END WHERE id = $3"
+
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(strconv.FormatInt(id, 10))
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email, email, id).WillReturnRows(rows)
+
+		err := userRepo.UpdateUserProfile(ctx, id, payload)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
 	t.Run("failed", func(t *testing.T) {
 		payload := generated.UpdateUserProfileJSONRequestBody{PhoneNumber: phoneNumber}
 		query := "UPDATE users SET phone_number = $1 WHERE id = $2"
@@ -219,3 +392,589 @@ func TestUserRepository_UpdateUserProfile(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestUserRepository_UpdateUserPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	hashedPassword := "hashed"
+	query := "UPDATE users SET password = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, hashedPassword).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userRepo.UpdateUserPassword(ctx, id, hashedPassword)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, hashedPassword).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := userRepo.UpdateUserPassword(ctx, id, hashedPassword)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, hashedPassword).WillReturnError(errors.New("db error"))
+
+		err := userRepo.UpdateUserPassword(ctx, id, hashedPassword)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetUserDetailById(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	fullName := "John Doe"
+	phoneNumber := "+6285912345678"
+	createdAt := time.Now()
+
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, login_count, phone_verified_at, created_at, updated_at, deleted_at, " +
+		"status, status_reason, locale, " +
+		"ARRAY(SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id ORDER BY r.name) AS roles " +
+		"FROM users WHERE id = $1"
+
+	columns := []string{"id", "full_name", "phone_number", "email", "email_verified_at", "login_count", "phone_verified_at", "created_at", "updated_at", "deleted_at", "status", "status_reason", "locale", "roles"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(id, fullName, phoneNumber, "john@example.com", createdAt, 3, createdAt, createdAt, nil, nil, "active", nil, "id", "{admin,user}")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnRows(rows)
+
+		resUser, err := userRepo.GetUserDetailById(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, fullName, resUser.FullName)
+		require.Equal(t, "john@example.com", resUser.Email.String)
+		require.True(t, resUser.EmailVerifiedAt.Valid)
+		require.Equal(t, int64(3), resUser.LoginCount)
+		require.True(t, resUser.PhoneVerifiedAt.Valid)
+		require.False(t, resUser.UpdatedAt.Valid)
+		require.Equal(t, "active", resUser.Status)
+		require.Equal(t, []string{"admin", "user"}, resUser.Roles)
+		require.Equal(t, "id", resUser.Locale.String)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnError(errors.New("db error"))
+
+		resUser, err := userRepo.GetUserDetailById(ctx, id)
+		require.Error(t, err)
+		require.Empty(t, resUser)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_ListUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	createdFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdTo := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	columns := []string{"id", "full_name", "phone_number", "email", "email_verified_at", "login_count", "phone_verified_at", "created_at", "updated_at", "deleted_at", "status", "status_reason", "locale", "roles"}
+	selectQuery := "SELECT id, full_name, phone_number, email, email_verified_at, login_count, phone_verified_at, created_at, updated_at, deleted_at, " +
+		"status, status_reason, locale, " +
+		"ARRAY(SELECT r.name FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id ORDER BY r.name) AS roles " +
+		"FROM users"
+
+	t.Run("success - all filters", func(t *testing.T) {
+		filter := model.UserFilter{
+			Search:      "50%_",
+			CreatedFrom: null.TimeFrom(createdFrom),
+			CreatedTo:   null.TimeFrom(createdTo),
+			Verified:    null.BoolFrom(true),
+			Status:      model.UserStatusSuspended,
+			AfterId:     5,
+			Limit:       2,
+		}
+
+		query := selectQuery + " WHERE id > $1 AND (full_name ILIKE $2 OR phone_number ILIKE $3 OR email ILIKE $4) AND created_at >= $5 " +
+			"AND created_at < $6 AND phone_verified_at IS NOT NULL AND status = $7 ORDER BY id LIMIT 2"
+		pattern := `%50\%\_%`
+
+		rows := sqlmock.NewRows(columns).
+			AddRow(6, "John Doe", "+6285912345678", nil, nil, 0, createdFrom, createdFrom, nil, nil, "active", nil, nil, "{user}").
+			AddRow(7, "Jane Doe", "+6285912345679", "jane@example.com", nil, 1, createdFrom, createdFrom, nil, nil, "suspended", "spam", nil, "{admin}")
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(int64(5), pattern, pattern, pattern, createdFrom, createdTo, model.UserStatusSuspended).WillReturnRows(rows)
+
+		users, err := userRepo.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		require.Equal(t, int64(6), users[0].Id)
+		require.Equal(t, []string{"admin"}, users[1].Roles)
+		require.Equal(t, "spam", users[1].StatusReason.String)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("success - unverified deleted users", func(t *testing.T) {
+		filter := model.UserFilter{
+			Verified: null.BoolFrom(false),
+			Status:   model.UserStatusDeleted,
+			Limit:    20,
+		}
+
+		query := selectQuery + " WHERE phone_verified_at IS NULL AND status = $1 ORDER BY id LIMIT 20"
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(model.UserStatusDeleted).WillReturnRows(sqlmock.NewRows(columns))
+
+		users, err := userRepo.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		require.Empty(t, users)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		query := selectQuery + " ORDER BY id LIMIT 20"
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		users, err := userRepo.ListUsers(ctx, model.UserFilter{Limit: 20})
+		require.Error(t, err)
+		require.Empty(t, users)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_SetUserVerified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	query := "UPDATE users SET phone_verified_at = CASE WHEN $2 THEN COALESCE(phone_verified_at, NOW()) END, updated_at = NOW() WHERE id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, true).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := userRepo.SetUserVerified(ctx, id, true)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, false).WillReturnError(errors.New("db error"))
+
+		err := userRepo.SetUserVerified(ctx, id, false)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_UpdateUserStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	change := model.UserStatusChange{
+		UserId:     10,
+		FromStatus: model.UserStatusActive,
+		ToStatus:   model.UserStatusSuspended,
+		Reason:     "spamming other users",
+		ActorId:    null.IntFrom(1),
+	}
+
+	updateQuery := "UPDATE users SET status = $1, status_reason = $2, status_changed_at = NOW(), updated_at = NOW(), " +
+		"deleted_at = CASE WHEN $1 = 'deleted' THEN COALESCE(deleted_at, NOW()) END WHERE id = $3 AND status = $4;"
+	insertQuery := "INSERT INTO user_status_changes(user_id, from_status, to_status, reason, actor_id) VALUES ($1, $2, $3, $4, $5);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+			WithArgs(change.ToStatus, change.Reason, change.UserId, change.FromStatus).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).
+			WithArgs(change.UserId, change.FromStatus, change.ToStatus, change.Reason, change.ActorId).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := userRepo.UpdateUserStatus(ctx, change)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - status changed concurrently", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+			WithArgs(change.ToStatus, change.Reason, change.UserId, change.FromStatus).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := userRepo.UpdateUserStatus(ctx, change)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
+			WithArgs(change.ToStatus, change.Reason, change.UserId, change.FromStatus).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+
+		err := userRepo.UpdateUserStatus(ctx, change)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetDeletedUserByPhoneNumber(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	phoneNumber := "+6285912345678"
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE phone_number = $1 AND deleted_at IS NOT NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status", "deleted_at"}).
+			AddRow(id, "John Doe", phoneNumber, nil, nil, "password", "deleted", deletedAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(phoneNumber).WillReturnRows(rows)
+
+		resUser, err := userRepo.GetDeletedUserByPhoneNumber(ctx, phoneNumber)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, model.UserStatusDeleted, resUser.Status)
+		require.Equal(t, deletedAt, resUser.DeletedAt.Time)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(phoneNumber).WillReturnError(sql.ErrNoRows)
+
+		_, err := userRepo.GetDeletedUserByPhoneNumber(ctx, phoneNumber)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetDeletedUserByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	email := "john@example.com"
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE email = $1 AND deleted_at IS NOT NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status", "deleted_at"}).
+			AddRow(id, "John Doe", "+6285912345678", email, nil, "password", "deleted", deletedAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnRows(rows)
+
+		resUser, err := userRepo.GetDeletedUserByEmail(ctx, email)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, deletedAt, resUser.DeletedAt.Time)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnError(sql.ErrNoRows)
+
+		_, err := userRepo.GetDeletedUserByEmail(ctx, email)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetDeletedUserById(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	id := int64(10)
+	deletedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	query := "SELECT id, full_name, phone_number, email, email_verified_at, password, status, deleted_at FROM users WHERE id = $1 AND deleted_at IS NOT NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "full_name", "phone_number", "email", "email_verified_at", "password", "status", "deleted_at"}).
+			AddRow(id, "John Doe", "+6285912345678", "john@example.com", nil, "password", "deleted", deletedAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnRows(rows)
+
+		resUser, err := userRepo.GetDeletedUserById(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, model.UserStatusDeleted, resUser.Status)
+		require.Equal(t, deletedAt, resUser.DeletedAt.Time)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnError(sql.ErrNoRows)
+
+		_, err := userRepo.GetDeletedUserById(ctx, id)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_PhoneNumberExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	phoneNumber := "+6285912345678"
+	query := "SELECT EXISTS(SELECT 1 FROM users WHERE phone_number = $1);"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"exists"}).AddRow(true)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(phoneNumber).WillReturnRows(rows)
+
+		exists, err := userRepo.PhoneNumberExists(ctx, phoneNumber)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(phoneNumber).WillReturnError(errors.New("db error"))
+
+		exists, err := userRepo.PhoneNumberExists(ctx, phoneNumber)
+		require.Error(t, err)
+		require.False(t, exists)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_EmailExists(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	email := "john@example.com"
+	query := "SELECT EXISTS(SELECT 1 FROM users WHERE email = $1);"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"exists"}).AddRow(false)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnRows(rows)
+
+		exists, err := userRepo.EmailExists(ctx, email)
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(email).WillReturnError(errors.New("db error"))
+
+		exists, err := userRepo.EmailExists(ctx, email)
+		require.Error(t, err)
+		require.False(t, exists)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_PurgeDeletedUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	deletedBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "DELETE FROM users WHERE status = 'deleted' AND deleted_at < $1;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		purged, err := userRepo.PurgeDeletedUsers(ctx, deletedBefore)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), purged)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("success - impersonation actor", func(t *testing.T) {
+		// An admin who impersonated someone is purged like anyone else, the
+		// database clears the actor of their impersonations.
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(deletedBefore).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		purged, err := userRepo.PurgeDeletedUsers(ctx, deletedBefore)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), purged)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(deletedBefore).WillReturnError(errors.New("db error"))
+
+		purged, err := userRepo.PurgeDeletedUsers(ctx, deletedBefore)
+		require.Error(t, err)
+		require.Zero(t, purged)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_ListUserStatusChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT user_id, from_status, to_status, reason, actor_id, created_at FROM user_status_changes WHERE user_id = $1 ORDER BY id;"
+	columns := []string{"user_id", "from_status", "to_status", "reason", "actor_id", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(userId, model.UserStatusActive, model.UserStatusSuspended, "spam", 1, createdAt).
+			AddRow(userId, model.UserStatusSuspended, model.UserStatusActive, "appeal", nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(rows)
+
+		changes, err := userRepo.ListUserStatusChanges(ctx, userId)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, int64(1), changes[0].ActorId.Int64)
+		require.False(t, changes[1].ActorId.Valid)
+		require.Equal(t, createdAt, changes[1].CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnError(errors.New("db error"))
+
+		changes, err := userRepo.ListUserStatusChanges(ctx, userId)
+		require.Error(t, err)
+		require.Nil(t, changes)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUserRepository_GetLatestUserStatusChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	userRepo := NewUserRepository(UserRepositoryOptions{DB: db})
+
+	userId := int64(10)
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT user_id, from_status, to_status, reason, actor_id, created_at FROM user_status_changes WHERE user_id = $1 ORDER BY id DESC LIMIT 1;"
+	columns := []string{"user_id", "from_status", "to_status", "reason", "actor_id", "created_at"}
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).AddRow(userId, model.UserStatusActive, model.UserStatusDeleted, "deleted by user", userId, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(rows)
+
+		change, err := userRepo.GetLatestUserStatusChange(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, model.UserStatusDeleted, change.ToStatus)
+		require.Equal(t, userId, change.ActorId.Int64)
+		require.Equal(t, createdAt, change.CreatedAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - no status change", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(userId).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := userRepo.GetLatestUserStatusChange(ctx, userId)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
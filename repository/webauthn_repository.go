@@ -0,0 +1,155 @@
+// This file contains the WebAuthn repository implementation layer.
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/labstack/gommon/log"
+)
+
+type WebAuthnRepository struct {
+	Db *sql.DB
+}
+
+type WebAuthnRepositoryOptions struct {
+	DB *sql.DB
+}
+
+func NewWebAuthnRepository(opts WebAuthnRepositoryOptions) *WebAuthnRepository {
+	return &WebAuthnRepository{Db: opts.DB}
+}
+
+func (r *WebAuthnRepository) CreateWebAuthnChallenge(ctx context.Context, challenge model.WebAuthnChallenge) error {
+	query := "INSERT INTO webauthn_challenges(challenge_hash, ceremony, user_id, expires_at) VALUES ($1, $2, $3, $4);"
+	_, err := r.Db.ExecContext(ctx, query, challenge.ChallengeHash, challenge.Ceremony, challenge.UserId, challenge.ExpiresAt)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeWebAuthnChallenge deletes and returns the challenge with the given
+// hash so that its response can not be replayed. Expiry is left to the
+// caller.
+func (r *WebAuthnRepository) ConsumeWebAuthnChallenge(ctx context.Context, challengeHash string) (model.WebAuthnChallenge, error) {
+	challenge := model.WebAuthnChallenge{}
+	query := "DELETE FROM webauthn_challenges WHERE challenge_hash = $1 " +
+		"RETURNING id, challenge_hash, ceremony, user_id, expires_at, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, challengeHash).
+		Scan(&challenge.Id, &challenge.ChallengeHash, &challenge.Ceremony, &challenge.UserId, &challenge.ExpiresAt, &challenge.CreatedAt)
+	if err != nil {
+		return challenge, err
+	}
+
+	return challenge, nil
+}
+
+// DeleteExpiredWebAuthnChallenges removes challenges no authenticator
+// answered.
+func (r *WebAuthnRepository) DeleteExpiredWebAuthnChallenges(ctx context.Context) (int64, error) {
+	query := "DELETE FROM webauthn_challenges WHERE expires_at < NOW();"
+	result, err := r.Db.ExecContext(ctx, query)
+	if err != nil {
+		log.Error(err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *WebAuthnRepository) CreateWebAuthnCredential(ctx context.Context, credential model.WebAuthnCredential) (model.WebAuthnCredential, error) {
+	query := "INSERT INTO webauthn_credentials(user_id, credential_id, public_key, sign_count, aaguid, name) " +
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at;"
+	err := r.Db.QueryRowContext(ctx, query, credential.UserId, credential.CredentialId, credential.PublicKey, credential.SignCount, credential.AAGUID, credential.Name).
+		Scan(&credential.Id, &credential.CreatedAt)
+	if err != nil {
+		log.Error(err)
+		return model.WebAuthnCredential{}, err
+	}
+
+	return credential, nil
+}
+
+func (r *WebAuthnRepository) GetWebAuthnCredential(ctx context.Context, credentialId string) (model.WebAuthnCredential, error) {
+	credential := model.WebAuthnCredential{}
+	query := "SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at " +
+		"FROM webauthn_credentials WHERE credential_id = $1;"
+	err := r.Db.QueryRowContext(ctx, query, credentialId).
+		Scan(&credential.Id, &credential.UserId, &credential.CredentialId, &credential.PublicKey, &credential.SignCount,
+			&credential.AAGUID, &credential.Name, &credential.LastUsedAt, &credential.CreatedAt)
+	if err != nil {
+		return credential, err
+	}
+
+	return credential, nil
+}
+
+func (r *WebAuthnRepository) ListWebAuthnCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error) {
+	query := "SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at " +
+		"FROM webauthn_credentials WHERE user_id = $1 ORDER BY id;"
+	rows, err := r.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	credentials := make([]model.WebAuthnCredential, 0)
+	for rows.Next() {
+		credential := model.WebAuthnCredential{}
+		err := rows.Scan(&credential.Id, &credential.UserId, &credential.CredentialId, &credential.PublicKey, &credential.SignCount,
+			&credential.AAGUID, &credential.Name, &credential.LastUsedAt, &credential.CreatedAt)
+		if err != nil {
+			log.Error(err)
+			return nil, err
+		}
+
+		credentials = append(credentials, credential)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateWebAuthnCredentialSignCount records the signature counter of a login
+// with the credential.
+func (r *WebAuthnRepository) UpdateWebAuthnCredentialSignCount(ctx context.Context, id, signCount int64) error {
+	query := "UPDATE webauthn_credentials SET sign_count = $1, last_used_at = NOW() WHERE id = $2;"
+	if _, err := r.Db.ExecContext(ctx, query, signCount, id); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWebAuthnCredential removes the user's credential. It returns
+// sql.ErrNoRows when there is none.
+func (r *WebAuthnRepository) DeleteWebAuthnCredential(ctx context.Context, userId, id int64) error {
+	query := "DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2;"
+	result, err := r.Db.ExecContext(ctx, query, id, userId)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+)
+
+var webAuthnCredentialColumns = []string{"id", "user_id", "credential_id", "public_key", "sign_count", "aaguid", "name", "last_used_at", "created_at"}
+
+func TestWebAuthnRepository_CreateWebAuthnChallenge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	challenge := model.WebAuthnChallenge{
+		ChallengeHash: "hash",
+		Ceremony:      model.WebAuthnCeremonyRegistration,
+		UserId:        null.IntFrom(10),
+		ExpiresAt:     time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+	}
+	query := "INSERT INTO webauthn_challenges(challenge_hash, ceremony, user_id, expires_at) VALUES ($1, $2, $3, $4);"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(challenge.ChallengeHash, challenge.Ceremony, challenge.UserId, challenge.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := webAuthnRepo.CreateWebAuthnChallenge(ctx, challenge)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(challenge.ChallengeHash, challenge.Ceremony, challenge.UserId, challenge.ExpiresAt).
+			WillReturnError(errors.New("db error"))
+
+		err := webAuthnRepo.CreateWebAuthnChallenge(ctx, challenge)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_ConsumeWebAuthnChallenge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "DELETE FROM webauthn_challenges WHERE challenge_hash = $1 " +
+		"RETURNING id, challenge_hash, ceremony, user_id, expires_at, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "challenge_hash", "ceremony", "user_id", "expires_at", "created_at"}).
+			AddRow(1, "hash", model.WebAuthnCeremonyLogin, nil, createdAt.Add(5*time.Minute), createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnRows(rows)
+
+		challenge, err := webAuthnRepo.ConsumeWebAuthnChallenge(ctx, "hash")
+		require.NoError(t, err)
+		require.Equal(t, model.WebAuthnCeremonyLogin, challenge.Ceremony)
+		require.False(t, challenge.UserId.Valid)
+		require.Equal(t, createdAt.Add(5*time.Minute), challenge.ExpiresAt)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash").WillReturnError(sql.ErrNoRows)
+
+		_, err := webAuthnRepo.ConsumeWebAuthnChallenge(ctx, "hash")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_DeleteExpiredWebAuthnChallenges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	query := "DELETE FROM webauthn_challenges WHERE expires_at < NOW();"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnResult(sqlmock.NewResult(0, 2))
+
+		deleted, err := webAuthnRepo.DeleteExpiredWebAuthnChallenges(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WillReturnError(errors.New("db error"))
+
+		_, err := webAuthnRepo.DeleteExpiredWebAuthnChallenges(ctx)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_CreateWebAuthnCredential(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	credential := model.WebAuthnCredential{
+		UserId:       10,
+		CredentialId: "AQID",
+		PublicKey:    []byte{0xa5, 0x01, 0x02},
+		SignCount:    1,
+		AAGUID:       "00000000-0000-0000-0000-000000000000",
+		Name:         "Laptop",
+	}
+	query := "INSERT INTO webauthn_credentials(user_id, credential_id, public_key, sign_count, aaguid, name) " +
+		"VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(credential.UserId, credential.CredentialId, credential.PublicKey, credential.SignCount, credential.AAGUID, credential.Name).
+			WillReturnRows(rows)
+
+		created, err := webAuthnRepo.CreateWebAuthnCredential(ctx, credential)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), created.Id)
+		require.Equal(t, createdAt, created.CreatedAt)
+		require.Equal(t, credential.CredentialId, created.CredentialId)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).
+			WithArgs(credential.UserId, credential.CredentialId, credential.PublicKey, credential.SignCount, credential.AAGUID, credential.Name).
+			WillReturnError(errors.New("db error"))
+
+		_, err := webAuthnRepo.CreateWebAuthnCredential(ctx, credential)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_GetWebAuthnCredential(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at " +
+		"FROM webauthn_credentials WHERE credential_id = $1;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(webAuthnCredentialColumns).
+			AddRow(3, 10, "AQID", []byte{0xa5}, 7, "00000000-0000-0000-0000-000000000000", "Laptop", nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("AQID").WillReturnRows(rows)
+
+		credential, err := webAuthnRepo.GetWebAuthnCredential(ctx, "AQID")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), credential.UserId)
+		require.Equal(t, []byte{0xa5}, credential.PublicKey)
+		require.Equal(t, int64(7), credential.SignCount)
+		require.False(t, credential.LastUsedAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("AQID").WillReturnError(sql.ErrNoRows)
+
+		_, err := webAuthnRepo.GetWebAuthnCredential(ctx, "AQID")
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_ListWebAuthnCredentials(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := "SELECT id, user_id, credential_id, public_key, sign_count, aaguid, name, last_used_at, created_at " +
+		"FROM webauthn_credentials WHERE user_id = $1 ORDER BY id;"
+
+	t.Run("success", func(t *testing.T) {
+		rows := sqlmock.NewRows(webAuthnCredentialColumns).
+			AddRow(3, 10, "AQID", []byte{0xa5}, 7, "00000000-0000-0000-0000-000000000000", "Laptop", createdAt, createdAt).
+			AddRow(4, 10, "BAUG", []byte{0xa5}, 0, "00000000-0000-0000-0000-000000000000", "Phone", nil, createdAt)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnRows(rows)
+
+		credentials, err := webAuthnRepo.ListWebAuthnCredentials(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, credentials, 2)
+		require.Equal(t, "AQID", credentials[0].CredentialId)
+		require.Equal(t, null.TimeFrom(createdAt), credentials[0].LastUsedAt)
+		require.Equal(t, "Phone", credentials[1].Name)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(int64(10)).WillReturnError(errors.New("db error"))
+
+		_, err := webAuthnRepo.ListWebAuthnCredentials(ctx, 10)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_UpdateWebAuthnCredentialSignCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	query := "UPDATE webauthn_credentials SET sign_count = $1, last_used_at = NOW() WHERE id = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(8), int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := webAuthnRepo.UpdateWebAuthnCredentialSignCount(ctx, 3, 8)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(int64(8), int64(3)).WillReturnError(errors.New("db error"))
+
+		err := webAuthnRepo.UpdateWebAuthnCredentialSignCount(ctx, 3, 8)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestWebAuthnRepository_DeleteWebAuthnCredential(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	webAuthnRepo := NewWebAuthnRepository(WebAuthnRepositoryOptions{DB: db})
+
+	userId, id := int64(10), int64(3)
+	query := "DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2;"
+
+	t.Run("success", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, userId).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := webAuthnRepo.DeleteWebAuthnCredential(ctx, userId, id)
+		require.NoError(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not found", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(id, userId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := webAuthnRepo.DeleteWebAuthnCredential(ctx, userId, id)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/guregu/null/v5"
+	"github.com/labstack/gommon/log"
+)
+
+type AdminUsecase struct {
+	UserRepository repository.UserRepositoryInterface
+	RoleRepository repository.RoleRepositoryInterface
+}
+
+type AdminUsecaseOptions struct {
+	UserRepository repository.UserRepositoryInterface
+	RoleRepository repository.RoleRepositoryInterface
+}
+
+func NewAdminUsecase(opts AdminUsecaseOptions) *AdminUsecase {
+	u := &AdminUsecase{
+		UserRepository: opts.UserRepository,
+		RoleRepository: opts.RoleRepository,
+	}
+
+	return u
+}
+
+// ListUsers returns a page of users matching the filter along with the
+// cursor of the next page, which is empty on the last page.
+func (u AdminUsecase) ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, string, error) {
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	users, err := u.UserRepository.ListUsers(ctx, filter)
+	if err != nil {
+		log.Error(err)
+		return nil, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if len(users) <= limit {
+		return users, "", nil
+	}
+
+	users = users[:limit]
+
+	return users, utils.EncodeCursor(users[limit-1].Id), nil
+}
+
+func (u AdminUsecase) GetUser(ctx context.Context, userId int64) (model.User, error) {
+	user, err := u.UserRepository.GetUserDetailById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return user, nil
+}
+
+func (u AdminUsecase) UpdateUser(ctx context.Context, userId int64, payload generated.UpdateUserJSONRequestBody) error {
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	profile := generated.UpdateUserProfileJSONRequestBody{}
+	if payload.FullName != nil {
+		profile.FullName = *payload.FullName
+	}
+
+	if payload.PhoneNumber != nil && *payload.PhoneNumber != user.PhoneNumber {
+		exists, err := u.UserRepository.PhoneNumberExists(ctx, *payload.PhoneNumber)
+		if err != nil {
+			log.Error(err)
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+
+		if exists {
+			err = fmt.Errorf("phone number is already used by another user")
+			log.Error(err)
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
+
+		profile.PhoneNumber = *payload.PhoneNumber
+	}
+
+	if payload.Roles != nil {
+		if err := u.validateRoles(ctx, *payload.Roles); err != nil {
+			return err
+		}
+	}
+
+	if profile.FullName != "" || profile.PhoneNumber != "" {
+		if err := u.UserRepository.UpdateUserProfile(ctx, userId, profile); err != nil {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+	}
+
+	if payload.Verified != nil {
+		if err := u.UserRepository.SetUserVerified(ctx, userId, *payload.Verified); err != nil {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+	}
+
+	if payload.Roles != nil {
+		if err := u.RoleRepository.SetUserRoles(ctx, userId, *payload.Roles); err != nil {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+	}
+
+	return nil
+}
+
+// ChangeUserStatus moves a user to another account status on behalf of the
+// admin identified by actorId, following userStatusTransitions.
+func (u AdminUsecase) ChangeUserStatus(ctx context.Context, actorId, userId int64, payload generated.ChangeUserStatusJSONRequestBody) error {
+	if actorId == userId {
+		err := fmt.Errorf("admin cannot change their own status")
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "")
+	}
+
+	user, err := u.UserRepository.GetUserDetailById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	status := string(payload.Status)
+	if !canTransitionUserStatus(user.Status, status) {
+		err = fmt.Errorf("cannot change status from %s to %s", user.Status, status)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "INVALID_STATUS_TRANSITION", err.Error())
+	}
+
+	change := model.UserStatusChange{
+		UserId:     userId,
+		FromStatus: user.Status,
+		ToStatus:   status,
+		Reason:     payload.Reason,
+		ActorId:    null.IntFrom(actorId),
+	}
+
+	if err := u.UserRepository.UpdateUserStatus(ctx, change); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+func (u AdminUsecase) validateRoles(ctx context.Context, roles []string) error {
+	allRoles, err := u.RoleRepository.GetAllRoles(ctx)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	knownRoles := make(map[string]bool, len(allRoles))
+	for _, role := range allRoles {
+		knownRoles[role] = true
+	}
+
+	for _, role := range roles {
+		if !knownRoles[role] {
+			err = fmt.Errorf("role %s does not exist", role)
+			log.Error(err)
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "")
+		}
+	}
+
+	return nil
+}
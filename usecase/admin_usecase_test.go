@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminUsecase_ListUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	adminUsecase := NewAdminUsecase(AdminUsecaseOptions{
+		UserRepository: mockUserRepo,
+	})
+
+	filter := model.UserFilter{Search: "john", Limit: 2}
+	repoFilter := model.UserFilter{Search: "john", Limit: 3}
+
+	t.Run("success - has next page", func(t *testing.T) {
+		users := []model.User{{Id: 1}, {Id: 2}, {Id: 3}}
+		mockUserRepo.EXPECT().ListUsers(ctx, repoFilter).Times(1).Return(users, nil)
+
+		res, nextCursor, err := adminUsecase.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, utils.EncodeCursor(2), nextCursor)
+	})
+
+	t.Run("success - last page", func(t *testing.T) {
+		users := []model.User{{Id: 1}}
+		mockUserRepo.EXPECT().ListUsers(ctx, repoFilter).Times(1).Return(users, nil)
+
+		res, nextCursor, err := adminUsecase.ListUsers(ctx, filter)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Empty(t, nextCursor)
+	})
+
+	t.Run("failed - list users return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().ListUsers(ctx, repoFilter).Times(1).Return(nil, errors.New("db error"))
+
+		res, nextCursor, err := adminUsecase.ListUsers(ctx, filter)
+		require.Error(t, err)
+		require.Empty(t, res)
+		require.Empty(t, nextCursor)
+	})
+}
+
+func TestAdminUsecase_GetUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	adminUsecase := NewAdminUsecase(AdminUsecaseOptions{
+		UserRepository: mockUserRepo,
+	})
+
+	id := int64(1)
+	user := model.User{Id: id, FullName: "John Doe", Roles: []string{model.RoleUser}}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, id).Times(1).Return(user, nil)
+
+		res, err := adminUsecase.GetUser(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, user, res)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		res, err := adminUsecase.GetUser(ctx, id)
+		require.Error(t, err)
+		require.Empty(t, res)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - get user return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, id).Times(1).Return(model.User{}, errors.New("db error"))
+
+		res, err := adminUsecase.GetUser(ctx, id)
+		require.Error(t, err)
+		require.Empty(t, res)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAdminUsecase_UpdateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+
+	adminUsecase := NewAdminUsecase(AdminUsecaseOptions{
+		UserRepository: mockUserRepo,
+		RoleRepository: mockRoleRepo,
+	})
+
+	id := int64(1)
+	fullName := "John Doe"
+	phoneNumber := "+6285912345678"
+	phoneNumberToUpdate := "+6285912345679"
+	verified := true
+	roles := []string{model.RoleAdmin, model.RoleUser}
+
+	payload := generated.UpdateUserJSONRequestBody{
+		FullName:    &fullName,
+		PhoneNumber: &phoneNumberToUpdate,
+		Verified:    &verified,
+		Roles:       &roles,
+	}
+
+	profile := generated.UpdateUserProfileJSONRequestBody{
+		FullName:    fullName,
+		PhoneNumber: phoneNumberToUpdate,
+	}
+
+	user := model.User{Id: id, FullName: fullName, PhoneNumber: phoneNumber}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, nil)
+		mockRoleRepo.EXPECT().GetAllRoles(ctx).Times(1).Return(roles, nil)
+		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, profile).Times(1).Return(nil)
+		mockUserRepo.EXPECT().SetUserVerified(ctx, id, verified).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().SetUserRoles(ctx, id, roles).Times(1).Return(nil)
+
+		err := adminUsecase.UpdateUser(ctx, id, payload)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - only roles", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetAllRoles(ctx).Times(1).Return(roles, nil)
+		mockRoleRepo.EXPECT().SetUserRoles(ctx, id, roles).Times(1).Return(nil)
+
+		err := adminUsecase.UpdateUser(ctx, id, generated.UpdateUserJSONRequestBody{Roles: &roles})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		err := adminUsecase.UpdateUser(ctx, id, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - phone number is already used", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(true, nil)
+
+		err := adminUsecase.UpdateUser(ctx, id, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+	})
+
+	t.Run("failed - unknown role", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, nil)
+		mockRoleRepo.EXPECT().GetAllRoles(ctx).Times(1).Return([]string{model.RoleUser}, nil)
+
+		err := adminUsecase.UpdateUser(ctx, id, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("failed - set user roles return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, nil)
+		mockRoleRepo.EXPECT().GetAllRoles(ctx).Times(1).Return(roles, nil)
+		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, profile).Times(1).Return(nil)
+		mockUserRepo.EXPECT().SetUserVerified(ctx, id, verified).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().SetUserRoles(ctx, id, roles).Times(1).Return(errors.New("db error"))
+
+		err := adminUsecase.UpdateUser(ctx, id, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAdminUsecase_ChangeUserStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	adminUsecase := NewAdminUsecase(AdminUsecaseOptions{
+		UserRepository: mockUserRepo,
+	})
+
+	actorId := int64(1)
+	userId := int64(10)
+
+	payload := generated.ChangeUserStatusJSONRequestBody{
+		Status: generated.UserStatus(model.UserStatusSuspended),
+		Reason: "spamming other users",
+	}
+
+	user := model.User{Id: userId, Status: model.UserStatusActive}
+
+	change := model.UserStatusChange{
+		UserId:     userId,
+		FromStatus: model.UserStatusActive,
+		ToStatus:   model.UserStatusSuspended,
+		Reason:     payload.Reason,
+		ActorId:    null.IntFrom(actorId),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(nil)
+
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, userId, payload)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - change own status", func(t *testing.T) {
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, actorId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - invalid transition", func(t *testing.T) {
+		lockedUser := user
+		lockedUser.Status = model.UserStatusLocked
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(lockedUser, nil)
+
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "INVALID_STATUS_TRANSITION", utils.GetKey(err))
+	})
+
+	t.Run("failed - status changed concurrently", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(sql.ErrNoRows)
+
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+	})
+
+	t.Run("failed - update user status return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(errors.New("db error"))
+
+		err := adminUsecase.ChangeUserStatus(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
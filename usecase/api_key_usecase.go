@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+type APIKeyUsecase struct {
+	APIKeyRepository repository.APIKeyRepositoryInterface
+	RoleRepository   repository.RoleRepositoryInterface
+}
+
+type APIKeyUsecaseOptions struct {
+	APIKeyRepository repository.APIKeyRepositoryInterface
+	RoleRepository   repository.RoleRepositoryInterface
+}
+
+func NewAPIKeyUsecase(opts APIKeyUsecaseOptions) *APIKeyUsecase {
+	u := &APIKeyUsecase{
+		APIKeyRepository: opts.APIKeyRepository,
+		RoleRepository:   opts.RoleRepository,
+	}
+
+	return u
+}
+
+// CreateAPIKey creates a key for the user and returns it along with the key
+// itself, which is not stored and can not be retrieved afterwards.
+func (u APIKeyUsecase) CreateAPIKey(ctx context.Context, userId int64, payload generated.CreateApiKeyJSONRequestBody) (model.APIKey, string, error) {
+	scopes := make([]string, 0)
+	if payload.Scopes != nil {
+		scopes = *payload.Scopes
+	}
+
+	if err := u.validateScopes(ctx, userId, scopes); err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	key, prefix, keyHash, err := utils.GenerateAPIKey()
+	if err != nil {
+		log.Error(err)
+		return model.APIKey{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	apiKey := model.APIKey{
+		UserId:    userId,
+		Name:      payload.Name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    scopes,
+		ExpiresAt: null.TimeFromPtr(payload.ExpiresAt),
+	}
+
+	apiKey, err = u.APIKeyRepository.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		log.Error(err)
+		return model.APIKey{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return apiKey, key, nil
+}
+
+func (u APIKeyUsecase) ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error) {
+	apiKeys, err := u.APIKeyRepository.ListAPIKeys(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return apiKeys, nil
+}
+
+func (u APIKeyUsecase) RevokeAPIKey(ctx context.Context, userId, id int64) error {
+	if err := u.APIKeyRepository.RevokeAPIKey(ctx, userId, id); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// validateScopes makes sure a key never grants more than its owner holds.
+func (u APIKeyUsecase) validateScopes(ctx context.Context, userId int64, scopes []string) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+
+	roles, err := u.RoleRepository.GetUserRoles(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	grantedPermissions, err := u.RoleRepository.GetPermissionsByRoles(ctx, roles)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	granted := make(map[string]bool, len(grantedPermissions))
+	for _, permission := range grantedPermissions {
+		granted[permission] = true
+	}
+
+	for _, scope := range scopes {
+		if !granted[scope] {
+			err = fmt.Errorf("scope %s is not granted to user %d", scope, userId)
+			log.Error(err)
+			return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_API_KEY_SCOPE",
+				"Scope %s Is Not Granted To You.", scope)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyUsecase_CreateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+
+	apiKeyUsecase := NewAPIKeyUsecase(APIKeyUsecaseOptions{
+		APIKeyRepository: mockAPIKeyRepo,
+		RoleRepository:   mockRoleRepo,
+	})
+
+	userId := int64(10)
+	roles := []string{model.RoleUser}
+	expiresAt := time.Now().Add(24 * time.Hour)
+	scopes := []string{"profile:read"}
+	payload := generated.CreateApiKeyJSONRequestBody{Name: "backup script", Scopes: &scopes, ExpiresAt: &expiresAt}
+
+	t.Run("success", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return([]string{"profile:read", "profile:write"}, nil)
+		mockAPIKeyRepo.EXPECT().CreateAPIKey(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, apiKey model.APIKey) (model.APIKey, error) {
+				require.Equal(t, userId, apiKey.UserId)
+				require.Equal(t, payload.Name, apiKey.Name)
+				require.Equal(t, scopes, apiKey.Scopes)
+				require.Equal(t, expiresAt, apiKey.ExpiresAt.Time)
+				apiKey.Id = 3
+				return apiKey, nil
+			})
+
+		apiKey, key, err := apiKeyUsecase.CreateAPIKey(ctx, userId, payload)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), apiKey.Id)
+		require.True(t, strings.HasPrefix(key, apiKey.Prefix))
+		require.Equal(t, utils.HashAPIKey(key), apiKey.KeyHash)
+	})
+
+	t.Run("success - without scopes", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().CreateAPIKey(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, apiKey model.APIKey) (model.APIKey, error) {
+				require.Empty(t, apiKey.Scopes)
+				require.False(t, apiKey.ExpiresAt.Valid)
+				return apiKey, nil
+			})
+
+		_, key, err := apiKeyUsecase.CreateAPIKey(ctx, userId, generated.CreateApiKeyJSONRequestBody{Name: "backup script"})
+		require.NoError(t, err)
+		require.True(t, utils.IsAPIKey(key))
+	})
+
+	t.Run("failed - scope is not granted", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return([]string{"profile:write"}, nil)
+
+		_, _, err := apiKeyUsecase.CreateAPIKey(ctx, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_API_KEY_SCOPE", utils.GetKey(err))
+	})
+
+	t.Run("failed - create api key return error", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return([]string{"profile:read"}, nil)
+		mockAPIKeyRepo.EXPECT().CreateAPIKey(ctx, gomock.Any()).Times(1).Return(model.APIKey{}, errors.New("db error"))
+
+		_, _, err := apiKeyUsecase.CreateAPIKey(ctx, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAPIKeyUsecase_ListAPIKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepositoryInterface(ctrl)
+
+	apiKeyUsecase := NewAPIKeyUsecase(APIKeyUsecaseOptions{APIKeyRepository: mockAPIKeyRepo})
+
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		apiKeys := []model.APIKey{{Id: 3, UserId: userId, Name: "backup script"}}
+		mockAPIKeyRepo.EXPECT().ListAPIKeys(ctx, userId).Times(1).Return(apiKeys, nil)
+
+		result, err := apiKeyUsecase.ListAPIKeys(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, apiKeys, result)
+	})
+
+	t.Run("failed - list api keys return error", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().ListAPIKeys(ctx, userId).Times(1).Return(nil, errors.New("db error"))
+
+		_, err := apiKeyUsecase.ListAPIKeys(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAPIKeyUsecase_RevokeAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepositoryInterface(ctrl)
+
+	apiKeyUsecase := NewAPIKeyUsecase(APIKeyUsecaseOptions{APIKeyRepository: mockAPIKeyRepo})
+
+	userId, id := int64(10), int64(3)
+
+	t.Run("success", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().RevokeAPIKey(ctx, userId, id).Times(1).Return(nil)
+
+		err := apiKeyUsecase.RevokeAPIKey(ctx, userId, id)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - api key not found", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().RevokeAPIKey(ctx, userId, id).Times(1).Return(sql.ErrNoRows)
+
+		err := apiKeyUsecase.RevokeAPIKey(ctx, userId, id)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - revoke api key return error", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().RevokeAPIKey(ctx, userId, id).Times(1).Return(errors.New("db error"))
+
+		err := apiKeyUsecase.RevokeAPIKey(ctx, userId, id)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
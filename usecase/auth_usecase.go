@@ -3,54 +3,105 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/SawitProRecruitment/UserService/repository"
 	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
 
 	"github.com/labstack/gommon/log"
 )
 
 type AuthUsecase struct {
-	UserRepository repository.UserRepositoryInterface
-	AuthUtil       utils.AuthInterface
-	CryptUtil      utils.CryptInterface
+	UserRepository      repository.UserRepositoryInterface
+	RoleRepository      repository.RoleRepositoryInterface
+	APIKeyRepository    repository.APIKeyRepositoryInterface
+	DeviceRepository    repository.DeviceRepositoryInterface
+	AuthUtil            utils.AuthInterface
+	CryptUtil           utils.CryptInterface
+	Authenticators      []AuthenticatorInterface
+	DeviceRecognizer    DeviceRecognizerInterface
+	DeletionGracePeriod time.Duration
 }
 
 type AuthUsecaseOptions struct {
-	UserRepository repository.UserRepositoryInterface
-	AuthUtil       utils.AuthInterface
-	CryptUtil      utils.CryptInterface
+	UserRepository      repository.UserRepositoryInterface
+	RoleRepository      repository.RoleRepositoryInterface
+	APIKeyRepository    repository.APIKeyRepositoryInterface
+	DeviceRepository    repository.DeviceRepositoryInterface
+	AuthUtil            utils.AuthInterface
+	CryptUtil           utils.CryptInterface
+	Authenticators      []AuthenticatorInterface
+	DeviceRecognizer    DeviceRecognizerInterface
+	DeletionGracePeriod time.Duration
 }
 
+// NewAuthUsecase creates the usecase. Logins are verified by the
+// Authenticators in order, by default only against the local password.
+// Devices logged in from are remembered by the DeviceRecognizer.
 func NewAuthUsecase(opts AuthUsecaseOptions) *AuthUsecase {
 	u := &AuthUsecase{
-		UserRepository: opts.UserRepository,
-		AuthUtil:       opts.AuthUtil,
-		CryptUtil:      opts.CryptUtil,
+		UserRepository:      opts.UserRepository,
+		RoleRepository:      opts.RoleRepository,
+		APIKeyRepository:    opts.APIKeyRepository,
+		DeviceRepository:    opts.DeviceRepository,
+		AuthUtil:            opts.AuthUtil,
+		CryptUtil:           opts.CryptUtil,
+		Authenticators:      opts.Authenticators,
+		DeviceRecognizer:    opts.DeviceRecognizer,
+		DeletionGracePeriod: opts.DeletionGracePeriod,
+	}
+
+	if len(u.Authenticators) == 0 {
+		u.Authenticators = []AuthenticatorInterface{NewPasswordAuthenticator(PasswordAuthenticatorOptions{
+			UserRepository: opts.UserRepository,
+			CryptUtil:      opts.CryptUtil,
+		})}
 	}
 
 	return u
 }
 
-func (u AuthUsecase) LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, string, error) {
-	user, err := u.UserRepository.GetUserByPhoneNumber(ctx, payload.PhoneNumber)
+// LoginUser authenticates the user and issues a token, bound to the client's
+// DPoP key when keyThumbprint is set. The token is tied to the device the
+// client logs in from, which is reported to the user when new.
+func (u AuthUsecase) LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody, keyThumbprint string, client model.LoginClient) (model.User, string, error) {
+	user, err := u.authenticate(ctx, payload)
 	if err != nil {
 		log.Error(err)
-		if err == sql.ErrNoRows {
-			return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "")
+		return model.User{}, "", err
+	}
+
+	if user.Status == model.UserStatusDeleted {
+		if err = u.reactivateDeletedUser(ctx, &user); err != nil {
+			log.Error(err)
+			return model.User{}, "", err
 		}
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return model.User{}, "", err
+	}
+
+	user.Roles, err = u.RoleRepository.GetUserRoles(ctx, user.Id)
+	if err != nil {
+		log.Error(err)
 		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
-	if err = u.CryptUtil.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
+	device, err := u.DeviceRecognizer.RecognizeDevice(ctx, user, client)
+	if err != nil {
 		log.Error(err)
-		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusUnauthorized), "")
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
-	jwt, err := u.AuthUtil.GenerateJWTToken(user)
+	jwt, err := u.AuthUtil.GenerateJWTToken(user, []string{utils.AuthMethodPassword}, keyThumbprint, device.Id)
 	if err != nil {
 		log.Error(err)
 		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
@@ -62,3 +113,219 @@ func (u AuthUsecase) LoginUser(ctx context.Context, payload generated.AuthLoginJ
 
 	return user, jwt, nil
 }
+
+// authenticate passes the login to each authenticator until one knows the
+// user. Its answer is final, even when the password is wrong.
+func (u AuthUsecase) authenticate(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error) {
+	for _, authenticator := range u.Authenticators {
+		user, err := authenticator.Authenticate(ctx, payload)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return user, err
+		}
+	}
+
+	return model.User{}, utils.WrapWithCode(sql.ErrNoRows, utils.ErrorCode(http.StatusBadRequest), "")
+}
+
+// Reauthenticate confirms the password of an already authenticated user and
+// issues a fresh token whose `auth_time` satisfies requireRecentAuth. The
+// token stays bound to the DPoP key and device of the current one, if any.
+func (u AuthUsecase) Reauthenticate(ctx context.Context, userId int64, payload generated.ReauthenticateJSONRequestBody, keyThumbprint string, deviceId int64) (string, error) {
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.confirmPassword(ctx, user, payload.Password); err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return "", err
+	}
+
+	user.Roles, err = u.RoleRepository.GetUserRoles(ctx, user.Id)
+	if err != nil {
+		log.Error(err)
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	jwt, err := u.AuthUtil.GenerateJWTToken(user, []string{utils.AuthMethodPassword}, keyThumbprint, deviceId)
+	if err != nil {
+		log.Error(err)
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return jwt, nil
+}
+
+// confirmPassword checks the password of an authenticated user. Users without
+// a local password are confirmed by the authenticator that logged them in.
+func (u AuthUsecase) confirmPassword(ctx context.Context, user model.User, password string) error {
+	if user.Password != "" {
+		if err := u.CryptUtil.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusUnauthorized), "")
+		}
+
+		return nil
+	}
+
+	payload := generated.AuthLoginJSONRequestBody{
+		PhoneNumber: &user.PhoneNumber,
+		Email:       user.Email.Ptr(),
+		Password:    password,
+	}
+
+	authenticated, err := u.authenticate(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	if authenticated.Id != user.Id {
+		return utils.WrapWithCode(fmt.Errorf("password confirmed for user %d instead of %d", authenticated.Id, user.Id),
+			utils.ErrorCode(http.StatusUnauthorized), "")
+	}
+
+	return nil
+}
+
+// CheckUserStatus rejects tokens of users whose account is no longer active,
+// and returns the user otherwise.
+func (u AuthUsecase) CheckUserStatus(ctx context.Context, userId int64) (model.User, error) {
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+// AuthenticateAPIKey resolves an API key to its owner with their current
+// roles. The key must not be expired and its scopes, if any, must cover the
+// permissions.
+func (u AuthUsecase) AuthenticateAPIKey(ctx context.Context, key string, permissions []string) (model.User, error) {
+	apiKey, err := u.APIKeyRepository.GetAPIKeyByHash(ctx, utils.HashAPIKey(key))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "INVALID_API_KEY", "Invalid API Key.")
+		}
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		err = fmt.Errorf("api key %d is expired", apiKey.Id)
+		log.Error(err)
+		return model.User{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "API_KEY_EXPIRED", "API Key Is Expired.")
+	}
+
+	if len(apiKey.Scopes) > 0 {
+		scopes := make(map[string]bool, len(apiKey.Scopes))
+		for _, scope := range apiKey.Scopes {
+			scopes[scope] = true
+		}
+
+		for _, permission := range permissions {
+			if !scopes[permission] {
+				err = fmt.Errorf("api key %d is missing scope %s", apiKey.Id, permission)
+				log.Error(err)
+				return model.User{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "API_KEY_SCOPE_MISSING", "")
+			}
+		}
+	}
+
+	roles, err := u.RoleRepository.GetUserRoles(ctx, apiKey.UserId)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.APIKeyRepository.TouchAPIKey(ctx, apiKey.Id); err != nil {
+		log.Warn(err)
+	}
+
+	return model.User{Id: apiKey.UserId, Roles: roles}, nil
+}
+
+func (u AuthUsecase) AuthorizeRoles(ctx context.Context, roles []string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	grantedPermissions, err := u.RoleRepository.GetPermissionsByRoles(ctx, roles)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	granted := make(map[string]bool, len(grantedPermissions))
+	for _, permission := range grantedPermissions {
+		granted[permission] = true
+	}
+
+	for _, permission := range permissions {
+		if !granted[permission] {
+			err = fmt.Errorf("missing permission %s", permission)
+			log.Error(err)
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+	}
+
+	return nil
+}
+
+// reactivateDeletedUser restores an account its owner deleted when they log in
+// before the grace period ends. Afterwards the account stays deleted, as does
+// one an admin deleted.
+func (u AuthUsecase) reactivateDeletedUser(ctx context.Context, user *model.User) error {
+	if !user.DeletedAt.Valid || time.Since(user.DeletedAt.Time) > u.DeletionGracePeriod {
+		return checkUserStatus(model.UserStatusDeleted)
+	}
+
+	deletion, err := u.UserRepository.GetLatestUserStatusChange(ctx, user.Id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if deletion.ToStatus != model.UserStatusDeleted || deletion.ActorId != null.IntFrom(user.Id) {
+		return checkUserStatus(model.UserStatusDeleted)
+	}
+
+	change := model.UserStatusChange{
+		UserId:     user.Id,
+		FromStatus: model.UserStatusDeleted,
+		ToStatus:   model.UserStatusActive,
+		Reason:     "reactivated by login",
+		ActorId:    null.IntFrom(user.Id),
+	}
+
+	if err := u.UserRepository.UpdateUserStatus(ctx, change); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	user.Status = model.UserStatusActive
+	user.DeletedAt = null.Time{}
+
+	return nil
+}
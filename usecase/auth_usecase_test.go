@@ -2,13 +2,19 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/mocks"
 	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
 	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
 	"github.com/stretchr/testify/require"
 
 	"go.uber.org/mock/gomock"
@@ -24,13 +30,24 @@ func TestAuthUsecase_LoginUser(t *testing.T) {
 	}()
 
 	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockDeviceRepo := mocks.NewMockDeviceRepositoryInterface(ctrl)
 	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
 	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
+	mockNotifier := mocks.NewMockSecurityNotifierInterface(ctrl)
 
 	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
-		UserRepository: mockUserRepo,
-		AuthUtil:       mockAuthUtil,
-		CryptUtil:      mockCryptUtil,
+		UserRepository:      mockUserRepo,
+		RoleRepository:      mockRoleRepo,
+		DeviceRepository:    mockDeviceRepo,
+		AuthUtil:            mockAuthUtil,
+		CryptUtil:           mockCryptUtil,
+		DeletionGracePeriod: 24 * time.Hour,
+		DeviceRecognizer: NewDeviceRecognizer(DeviceRecognizerOptions{
+			DeviceRepository: mockDeviceRepo,
+			Notifier:         mockNotifier,
+			DeviceRevokeURL:  "https://example.com/devices/revoke",
+		}),
 	})
 
 	id := int64(1)
@@ -39,33 +56,166 @@ func TestAuthUsecase_LoginUser(t *testing.T) {
 	jwtToken := "thisisjwt"
 
 	payload := generated.AuthLoginRequest{
-		PhoneNumber: phoneNumber,
+		PhoneNumber: &phoneNumber,
 		Password:    password,
 	}
 
+	roles := []string{model.RoleUser}
+
 	user := model.User{
 		Id:          id,
 		PhoneNumber: phoneNumber,
 		Password:    password,
+		Status:      model.UserStatusActive,
 	}
 
+	userWithRoles := user
+	userWithRoles.Roles = roles
+
+	client := model.LoginClient{DeviceId: "device", UserAgent: "Mozilla/5.0", IPAddress: "203.0.113.7"}
+	fingerprint := utils.DeviceFingerprint(client.DeviceId, client.UserAgent)
+	device := model.Device{Id: 3, UserId: id, Fingerprint: fingerprint, UserAgent: client.UserAgent}
+
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
 		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
-		mockAuthUtil.EXPECT().GenerateJWTToken(user).Times(1).Return(jwtToken, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
 		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(errors.New("db error"))
 
-		resUser, resToken, err := authUsecase.LoginUser(ctx, payload)
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
 		require.NoError(t, err)
 		require.NotEmpty(t, resUser)
 		require.Equal(t, jwtToken, resToken)
 	})
 
+	t.Run("success - dpop bound token", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "jkt", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, resToken, err := authUsecase.LoginUser(ctx, payload, "jkt", client)
+		require.NoError(t, err)
+		require.Equal(t, jwtToken, resToken)
+	})
+
+	t.Run("success - login with email", func(t *testing.T) {
+		email := " John@Example.com"
+		emailPayload := generated.AuthLoginRequest{Email: &email, Password: password}
+
+		mockUserRepo.EXPECT().GetUserByEmail(ctx, "john@example.com").Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, emailPayload, "", client)
+		require.NoError(t, err)
+		require.Equal(t, id, resUser.Id)
+		require.Equal(t, jwtToken, resToken)
+	})
+
+	t.Run("failed - email not found", func(t *testing.T) {
+		email := "john@example.com"
+		emailPayload := generated.AuthLoginRequest{Email: &email, Password: password}
+
+		mockUserRepo.EXPECT().GetUserByEmail(ctx, email).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByEmail(ctx, email).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, _, err := authUsecase.LoginUser(ctx, emailPayload, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
 	t.Run("failed - get user by phone number return error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, errors.New("repo error"))
 
-		resUser, resToken, err := authUsecase.LoginUser(ctx, payload)
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Empty(t, resUser)
+		require.Zero(t, resToken)
+	})
+
+	t.Run("success - reactivate deleted user within grace period", func(t *testing.T) {
+		deletedUser := user
+		deletedUser.Status = model.UserStatusDeleted
+		deletedUser.DeletedAt = null.TimeFrom(time.Now().Add(-time.Hour))
+		deletion := model.UserStatusChange{UserId: id, FromStatus: model.UserStatusActive, ToStatus: model.UserStatusDeleted, ActorId: null.IntFrom(id)}
+
+		change := model.UserStatusChange{
+			UserId:     id,
+			FromStatus: model.UserStatusDeleted,
+			ToStatus:   model.UserStatusActive,
+			Reason:     "reactivated by login",
+			ActorId:    null.IntFrom(id),
+		}
+
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(deletedUser, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetLatestUserStatusChange(ctx, id).Times(1).Return(deletion, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+		require.Equal(t, model.UserStatusActive, resUser.Status)
+		require.Equal(t, jwtToken, resToken)
+	})
+
+	t.Run("failed - deleted by admin within grace period", func(t *testing.T) {
+		deletedUser := user
+		deletedUser.Status = model.UserStatusDeleted
+		deletedUser.DeletedAt = null.TimeFrom(time.Now().Add(-time.Hour))
+		deletion := model.UserStatusChange{UserId: id, FromStatus: model.UserStatusSuspended, ToStatus: model.UserStatusDeleted, ActorId: null.IntFrom(2)}
+
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(deletedUser, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetLatestUserStatusChange(ctx, id).Times(1).Return(deletion, nil)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, "ACCOUNT_DELETED", utils.GetKey(err))
+		require.Empty(t, resUser)
+		require.Zero(t, resToken)
+	})
+
+	t.Run("failed - deleted user past grace period", func(t *testing.T) {
+		deletedUser := user
+		deletedUser.Status = model.UserStatusDeleted
+		deletedUser.DeletedAt = null.TimeFrom(time.Now().Add(-48 * time.Hour))
+
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(deletedUser, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, "ACCOUNT_DELETED", utils.GetKey(err))
+		require.Empty(t, resUser)
+		require.Zero(t, resToken)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
 		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
 		require.Empty(t, resUser)
 		require.Zero(t, resToken)
 	})
@@ -74,20 +224,612 @@ func TestAuthUsecase_LoginUser(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
 		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(errors.New("password doesnt match"))
 
-		resUser, resToken, err := authUsecase.LoginUser(ctx, payload)
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Empty(t, resUser)
+		require.Zero(t, resToken)
+	})
+
+	t.Run("failed - user is suspended", func(t *testing.T) {
+		suspendedUser := user
+		suspendedUser.Status = model.UserStatusSuspended
+
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(suspendedUser, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "ACCOUNT_SUSPENDED", utils.GetKey(err))
+		require.Empty(t, resUser)
+		require.Zero(t, resToken)
+	})
+
+	t.Run("failed - get user roles return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(nil, errors.New("db error"))
+
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
 		require.Error(t, err)
 		require.Empty(t, resUser)
 		require.Zero(t, resToken)
 	})
 
+	t.Run("success - new device is reported", func(t *testing.T) {
+		var revokeTokenHash string
+		newDevice := model.Device{Id: 4, UserId: id, Fingerprint: fingerprint, UserAgent: client.UserAgent}
+
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().CountDevices(ctx, id).Times(1).Return(int64(1), nil)
+		mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, created model.Device) (model.Device, error) {
+				require.Equal(t, fingerprint, created.Fingerprint)
+				require.Equal(t, client.UserAgent, created.UserAgent)
+				require.NotEmpty(t, created.RevokeTokenHash)
+				revokeTokenHash = created.RevokeTokenHash
+				return newDevice, nil
+			})
+		mockNotifier.EXPECT().NotifySecurityEvent(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, event model.SecurityEvent) error {
+				require.Equal(t, model.SecurityEventNewDeviceLogin, event.Type)
+				require.Equal(t, id, event.User.Id)
+				require.Equal(t, newDevice, event.Device)
+				require.Equal(t, client.IPAddress, event.IPAddress)
+
+				link, err := url.Parse(event.RevokeURL)
+				require.NoError(t, err)
+				require.Equal(t, "/devices/revoke", link.Path)
+				require.Equal(t, revokeTokenHash, utils.HashToken(link.Query().Get("token")))
+				return nil
+			})
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", newDevice.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+		require.Equal(t, jwtToken, resToken)
+	})
+
+	t.Run("success - new device is reported to verified email", func(t *testing.T) {
+		email := "john@example.com"
+		emailPayload := generated.AuthLoginRequest{Email: &email, Password: password}
+
+		verifiedUser := user
+		verifiedUser.Email = null.StringFrom(email)
+		verifiedUser.EmailVerifiedAt = null.TimeFrom(time.Now())
+		verifiedUserWithRoles := verifiedUser
+		verifiedUserWithRoles.Roles = roles
+
+		mockUserRepo.EXPECT().GetUserByEmail(ctx, email).Times(1).Return(verifiedUser, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().CountDevices(ctx, id).Times(1).Return(int64(1), nil)
+		mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).Times(1).Return(device, nil)
+		mockNotifier.EXPECT().NotifySecurityEvent(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, event model.SecurityEvent) error {
+				require.Equal(t, email, event.User.Email.String)
+				require.True(t, event.User.EmailVerifiedAt.Valid)
+				return nil
+			})
+		mockAuthUtil.EXPECT().GenerateJWTToken(verifiedUserWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, _, err := authUsecase.LoginUser(ctx, emailPayload, "", client)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - first device is not reported", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().CountDevices(ctx, id).Times(1).Return(int64(0), nil)
+		mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, _, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - device remembered by a concurrent login", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().CountDevices(ctx, id).Times(1).Return(int64(1), nil)
+		mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+		require.Equal(t, jwtToken, resToken)
+	})
+
+	t.Run("success - failed notification does not fail login", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, sql.ErrNoRows)
+		mockDeviceRepo.EXPECT().CountDevices(ctx, id).Times(1).Return(int64(2), nil)
+		mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).Times(1).Return(device, nil)
+		mockNotifier.EXPECT().NotifySecurityEvent(ctx, gomock.Any()).Times(1).Return(errors.New("smtp error"))
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return(jwtToken, nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, id).Times(1).Return(nil)
+
+		_, _, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - get device return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(model.Device{}, errors.New("db error"))
+
+		_, _, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
 	t.Run("failed - failed generate jwt", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
 		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(password), []byte(password)).Times(1).Return(nil)
-		mockAuthUtil.EXPECT().GenerateJWTToken(user).Times(1).Return("", errors.New("failed generate jwt token"))
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return("", errors.New("failed generate jwt token"))
 
-		resUser, resToken, err := authUsecase.LoginUser(ctx, payload)
+		resUser, resToken, err := authUsecase.LoginUser(ctx, payload, "", client)
 		require.Error(t, err)
 		require.Empty(t, resUser)
 		require.Zero(t, resToken)
 	})
 }
+
+func TestAuthUsecase_Reauthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		UserRepository: mockUserRepo,
+		RoleRepository: mockRoleRepo,
+		AuthUtil:       mockAuthUtil,
+		CryptUtil:      mockCryptUtil,
+	})
+
+	id := int64(1)
+	hashedPassword := "hashed"
+	payload := generated.ReauthenticateJSONRequestBody{Password: "password"}
+	roles := []string{model.RoleUser}
+
+	user := model.User{Id: id, Password: hashedPassword, Status: model.UserStatusActive}
+	userWithRoles := user
+	userWithRoles.Roles = roles
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(hashedPassword), []byte(payload.Password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", int64(0)).Times(1).Return("jwt", nil)
+
+		jwt, err := authUsecase.Reauthenticate(ctx, id, payload, "", 0)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("success - keeps dpop binding and device", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(hashedPassword), []byte(payload.Password)).Times(1).Return(nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, id).Times(1).Return(roles, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "jkt", int64(3)).Times(1).Return("jwt", nil)
+
+		jwt, err := authUsecase.Reauthenticate(ctx, id, payload, "jkt", 3)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, err := authUsecase.Reauthenticate(ctx, id, payload, "", 0)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+
+	t.Run("failed - wrong password", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(hashedPassword), []byte(payload.Password)).Times(1).Return(errors.New("mismatch"))
+
+		_, err := authUsecase.Reauthenticate(ctx, id, payload, "", 0)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+	})
+
+	t.Run("failed - user suspended", func(t *testing.T) {
+		suspended := user
+		suspended.Status = model.UserStatusSuspended
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(suspended, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte(hashedPassword), []byte(payload.Password)).Times(1).Return(nil)
+
+		_, err := authUsecase.Reauthenticate(ctx, id, payload, "", 0)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+}
+
+func TestAuthUsecase_LoginUserAuthenticators(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockDeviceRepo := mocks.NewMockDeviceRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+	mockPasswordAuthenticator := mocks.NewMockAuthenticatorInterface(ctrl)
+	mockLDAPAuthenticator := mocks.NewMockAuthenticatorInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		UserRepository:   mockUserRepo,
+		RoleRepository:   mockRoleRepo,
+		DeviceRepository: mockDeviceRepo,
+		AuthUtil:         mockAuthUtil,
+		Authenticators:   []AuthenticatorInterface{mockPasswordAuthenticator, mockLDAPAuthenticator},
+		DeviceRecognizer: NewDeviceRecognizer(DeviceRecognizerOptions{DeviceRepository: mockDeviceRepo}),
+	})
+
+	email := "john@example.com"
+	payload := generated.AuthLoginRequest{Email: &email, Password: "password"}
+	roles := []string{model.RoleUser}
+	user := model.User{Id: 1, Email: null.StringFrom(email), Status: model.UserStatusActive}
+	userWithRoles := user
+	userWithRoles.Roles = roles
+
+	client := model.LoginClient{UserAgent: "Mozilla/5.0"}
+	fingerprint := utils.DeviceFingerprint(client.DeviceId, client.UserAgent)
+	device := model.Device{Id: 3, UserId: user.Id, Fingerprint: fingerprint}
+
+	t.Run("success - second authenticator", func(t *testing.T) {
+		mockPasswordAuthenticator.EXPECT().Authenticate(ctx, payload).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockLDAPAuthenticator.EXPECT().Authenticate(ctx, payload).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, user.Id).Times(1).Return(roles, nil)
+		mockDeviceRepo.EXPECT().GetDevice(ctx, user.Id, fingerprint).Times(1).Return(device, nil)
+		mockDeviceRepo.EXPECT().TouchDevice(ctx, device.Id).Times(1).Return(nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", device.Id).Times(1).Return("jwt", nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, user.Id).Times(1).Return(nil)
+
+		resUser, jwt, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.NoError(t, err)
+		require.Equal(t, userWithRoles, resUser)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - first answer is final", func(t *testing.T) {
+		mockPasswordAuthenticator.EXPECT().Authenticate(ctx, payload).Times(1).
+			Return(model.User{}, utils.WrapWithCode(errors.New("mismatch"), utils.ErrorCode(http.StatusUnauthorized), ""))
+
+		_, _, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+	})
+
+	t.Run("failed - unknown to all", func(t *testing.T) {
+		mockPasswordAuthenticator.EXPECT().Authenticate(ctx, payload).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockLDAPAuthenticator.EXPECT().Authenticate(ctx, payload).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, _, err := authUsecase.LoginUser(ctx, payload, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("success - reauthenticate directory user", func(t *testing.T) {
+		reauthPayload := generated.ReauthenticateJSONRequestBody{Password: "password"}
+		loginPayload := generated.AuthLoginJSONRequestBody{PhoneNumber: &user.PhoneNumber, Email: &email, Password: "password"}
+
+		mockUserRepo.EXPECT().GetUserById(ctx, user.Id).Times(1).Return(user, nil)
+		mockPasswordAuthenticator.EXPECT().Authenticate(ctx, loginPayload).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockLDAPAuthenticator.EXPECT().Authenticate(ctx, loginPayload).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, user.Id).Times(1).Return(roles, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(userWithRoles, []string{utils.AuthMethodPassword}, "", int64(0)).Times(1).Return("jwt", nil)
+
+		jwt, err := authUsecase.Reauthenticate(ctx, user.Id, reauthPayload, "", 0)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - reauthenticate as another directory user", func(t *testing.T) {
+		reauthPayload := generated.ReauthenticateJSONRequestBody{Password: "password"}
+		other := user
+		other.Id = 2
+
+		mockUserRepo.EXPECT().GetUserById(ctx, user.Id).Times(1).Return(user, nil)
+		mockPasswordAuthenticator.EXPECT().Authenticate(ctx, gomock.Any()).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockLDAPAuthenticator.EXPECT().Authenticate(ctx, gomock.Any()).Times(1).Return(other, nil)
+
+		_, err := authUsecase.Reauthenticate(ctx, user.Id, reauthPayload, "", 0)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+	})
+}
+
+func TestAuthUsecase_AuthorizeRoles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		RoleRepository: mockRoleRepo,
+	})
+
+	roles := []string{model.RoleUser}
+
+	t.Run("success", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return([]string{"profile:read", "profile:write"}, nil)
+
+		err := authUsecase.AuthorizeRoles(ctx, roles, []string{"profile:read"})
+		require.NoError(t, err)
+	})
+
+	t.Run("success - no permission required", func(t *testing.T) {
+		err := authUsecase.AuthorizeRoles(ctx, roles, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - get permissions return error", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return(nil, errors.New("db error"))
+
+		err := authUsecase.AuthorizeRoles(ctx, roles, []string{"profile:read"})
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - missing permission", func(t *testing.T) {
+		mockRoleRepo.EXPECT().GetPermissionsByRoles(ctx, roles).Times(1).Return([]string{"profile:read"}, nil)
+
+		err := authUsecase.AuthorizeRoles(ctx, roles, []string{"users:read"})
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+}
+
+func TestAuthUsecase_CheckUserStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		UserRepository: mockUserRepo,
+	})
+
+	id := int64(1)
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{Id: id, Status: model.UserStatusActive}, nil)
+
+		user, err := authUsecase.CheckUserStatus(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, user.Id)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, err := authUsecase.CheckUserStatus(ctx, id)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+
+	t.Run("failed - get user return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, errors.New("db error"))
+
+		_, err := authUsecase.CheckUserStatus(ctx, id)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - user is locked", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{Id: id, Status: model.UserStatusLocked}, nil)
+
+		_, err := authUsecase.CheckUserStatus(ctx, id)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusLocked), utils.GetCode(err))
+		require.Equal(t, "ACCOUNT_LOCKED", utils.GetKey(err))
+	})
+}
+
+func TestAuthUsecase_AuthenticateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepositoryInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		RoleRepository:   mockRoleRepo,
+		APIKeyRepository: mockAPIKeyRepo,
+	})
+
+	key := "usk_0123456789abcdef"
+	keyHash := utils.HashAPIKey(key)
+	roles := []string{model.RoleUser}
+	permissions := []string{"profile:read"}
+	apiKey := model.APIKey{Id: 3, UserId: 10, Scopes: []string{"profile:read"}}
+
+	t.Run("success", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(apiKey, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, apiKey.UserId).Times(1).Return(roles, nil)
+		mockAPIKeyRepo.EXPECT().TouchAPIKey(ctx, apiKey.Id).Times(1).Return(nil)
+
+		user, err := authUsecase.AuthenticateAPIKey(ctx, key, permissions)
+		require.NoError(t, err)
+		require.Equal(t, apiKey.UserId, user.Id)
+		require.Equal(t, roles, user.Roles)
+	})
+
+	t.Run("success - key without scopes", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(model.APIKey{Id: 3, UserId: 10}, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, apiKey.UserId).Times(1).Return(roles, nil)
+		mockAPIKeyRepo.EXPECT().TouchAPIKey(ctx, apiKey.Id).Times(1).Return(errors.New("db error"))
+
+		user, err := authUsecase.AuthenticateAPIKey(ctx, key, []string{"profile:write"})
+		require.NoError(t, err)
+		require.Equal(t, apiKey.UserId, user.Id)
+	})
+
+	t.Run("failed - unknown key", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(model.APIKey{}, sql.ErrNoRows)
+
+		_, err := authUsecase.AuthenticateAPIKey(ctx, key, permissions)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_API_KEY", utils.GetKey(err))
+	})
+
+	t.Run("failed - key is expired", func(t *testing.T) {
+		expiredKey := apiKey
+		expiredKey.ExpiresAt = null.TimeFrom(time.Now().Add(-time.Minute))
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(expiredKey, nil)
+
+		_, err := authUsecase.AuthenticateAPIKey(ctx, key, permissions)
+		require.Error(t, err)
+		require.Equal(t, "API_KEY_EXPIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - key is missing scope", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(apiKey, nil)
+
+		_, err := authUsecase.AuthenticateAPIKey(ctx, key, []string{"profile:write"})
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "API_KEY_SCOPE_MISSING", utils.GetKey(err))
+	})
+
+	t.Run("failed - get api key return error", func(t *testing.T) {
+		mockAPIKeyRepo.EXPECT().GetAPIKeyByHash(ctx, keyHash).Times(1).Return(model.APIKey{}, errors.New("db error"))
+
+		_, err := authUsecase.AuthenticateAPIKey(ctx, key, permissions)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAuthUsecase_CheckDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockDeviceRepo := mocks.NewMockDeviceRepositoryInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		DeviceRepository: mockDeviceRepo,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeviceExists(ctx, int64(10), int64(3)).Times(1).Return(true, nil)
+
+		err := authUsecase.CheckDevice(ctx, 10, 3)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - revoked device", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeviceExists(ctx, int64(10), int64(3)).Times(1).Return(false, nil)
+
+		err := authUsecase.CheckDevice(ctx, 10, 3)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "SESSION_REVOKED", utils.GetKey(err))
+	})
+
+	t.Run("failed - device exists return error", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeviceExists(ctx, int64(10), int64(3)).Times(1).Return(false, errors.New("db error"))
+
+		err := authUsecase.CheckDevice(ctx, 10, 3)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestAuthUsecase_RevokeDevice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockDeviceRepo := mocks.NewMockDeviceRepositoryInterface(ctrl)
+
+	authUsecase := NewAuthUsecase(AuthUsecaseOptions{
+		DeviceRepository: mockDeviceRepo,
+	})
+
+	token := "token"
+
+	t.Run("success", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeleteDeviceByRevokeTokenHash(ctx, utils.HashToken(token)).Times(1).Return(model.Device{Id: 3, UserId: 10}, nil)
+
+		err := authUsecase.RevokeDevice(ctx, token)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - invalid token", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeleteDeviceByRevokeTokenHash(ctx, utils.HashToken(token)).Times(1).Return(model.Device{}, sql.ErrNoRows)
+
+		err := authUsecase.RevokeDevice(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_REVOKE_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - delete device return error", func(t *testing.T) {
+		mockDeviceRepo.EXPECT().DeleteDeviceByRevokeTokenHash(ctx, utils.HashToken(token)).Times(1).Return(model.Device{}, errors.New("db error"))
+
+		err := authUsecase.RevokeDevice(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+// PasswordAuthenticator checks the login against the bcrypt hash of the
+// user's local password. Users provisioned from a directory have none and are
+// left to the other authenticators.
+type PasswordAuthenticator struct {
+	UserRepository repository.UserRepositoryInterface
+	CryptUtil      utils.CryptInterface
+}
+
+type PasswordAuthenticatorOptions struct {
+	UserRepository repository.UserRepositoryInterface
+	CryptUtil      utils.CryptInterface
+}
+
+func NewPasswordAuthenticator(opts PasswordAuthenticatorOptions) *PasswordAuthenticator {
+	a := &PasswordAuthenticator{
+		UserRepository: opts.UserRepository,
+		CryptUtil:      opts.CryptUtil,
+	}
+
+	return a
+}
+
+func (a PasswordAuthenticator) Authenticate(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error) {
+	user, err := a.findLoginUser(ctx, payload)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, err
+		}
+
+		log.Error(err)
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if user.Password == "" {
+		return model.User{}, sql.ErrNoRows
+	}
+
+	if err = a.CryptUtil.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password)); err != nil {
+		log.Error(err)
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusUnauthorized), "")
+	}
+
+	return user, nil
+}
+
+// findLoginUser looks the user up by the phone number or email of the login
+// request. Soft-deleted users are returned as well so that they can be
+// reactivated.
+func (a PasswordAuthenticator) findLoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error) {
+	if payload.Email != nil {
+		email := utils.NormalizeEmail(*payload.Email)
+		user, err := a.UserRepository.GetUserByEmail(ctx, email)
+		if errors.Is(err, sql.ErrNoRows) {
+			user, err = a.UserRepository.GetDeletedUserByEmail(ctx, email)
+		}
+
+		return user, err
+	}
+
+	if payload.PhoneNumber == nil {
+		return model.User{}, sql.ErrNoRows
+	}
+
+	user, err := a.UserRepository.GetUserByPhoneNumber(ctx, *payload.PhoneNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = a.UserRepository.GetDeletedUserByPhoneNumber(ctx, *payload.PhoneNumber)
+	}
+
+	return user, err
+}
+
+// LDAPAuthenticator binds to the directory with the login's email, or phone
+// number, and password. Directory users are provisioned on their first login
+// and recognised afterwards by their identity with IdentityProviderLDAP.
+type LDAPAuthenticator struct {
+	UserRepository     repository.UserRepositoryInterface
+	IdentityRepository repository.IdentityRepositoryInterface
+	LDAPUtil           utils.LDAPInterface
+	PhoneUtil          utils.PhoneInterface
+}
+
+type LDAPAuthenticatorOptions struct {
+	UserRepository     repository.UserRepositoryInterface
+	IdentityRepository repository.IdentityRepositoryInterface
+	LDAPUtil           utils.LDAPInterface
+	PhoneUtil          utils.PhoneInterface
+}
+
+func NewLDAPAuthenticator(opts LDAPAuthenticatorOptions) *LDAPAuthenticator {
+	a := &LDAPAuthenticator{
+		UserRepository:     opts.UserRepository,
+		IdentityRepository: opts.IdentityRepository,
+		LDAPUtil:           opts.LDAPUtil,
+		PhoneUtil:          opts.PhoneUtil,
+	}
+
+	return a
+}
+
+func (a LDAPAuthenticator) Authenticate(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error) {
+	var login string
+	switch {
+	case payload.Email != nil:
+		login = utils.NormalizeEmail(*payload.Email)
+	case payload.PhoneNumber != nil:
+		login = *payload.PhoneNumber
+	default:
+		return model.User{}, sql.ErrNoRows
+	}
+
+	entry, err := a.LDAPUtil.Authenticate(login, payload.Password)
+	if err != nil {
+		if errors.Is(err, utils.ErrLDAPUserNotFound) {
+			return model.User{}, sql.ErrNoRows
+		}
+
+		log.Error(err)
+		if errors.Is(err, utils.ErrLDAPInvalidCredentials) {
+			return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusUnauthorized), "")
+		}
+
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	identity, err := a.IdentityRepository.GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		user := model.User{
+			FullName:    entry.FullName,
+			PhoneNumber: entry.PhoneNumber,
+			Email:       null.StringFrom(entry.Email),
+		}
+
+		identity, err = provisionUser(ctx, a.UserRepository, a.IdentityRepository, a.PhoneUtil, user,
+			model.UserIdentity{Provider: model.IdentityProviderLDAP, Subject: entry.ID})
+		if err != nil {
+			return model.User{}, err
+		}
+	} else if err != nil {
+		log.Error(err)
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	// Soft-deleted users are returned as well so that they can be
+	// reactivated.
+	user, err := a.UserRepository.GetUserById(ctx, identity.UserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = a.UserRepository.GetDeletedUserById(ctx, identity.UserId)
+	}
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = a.IdentityRepository.TouchIdentity(ctx, identity.Id); err != nil {
+		log.Warn(err)
+	}
+
+	return user, nil
+}
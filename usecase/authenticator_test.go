@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestPasswordAuthenticator_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
+
+	authenticator := NewPasswordAuthenticator(PasswordAuthenticatorOptions{
+		UserRepository: mockUserRepo,
+		CryptUtil:      mockCryptUtil,
+	})
+
+	phoneNumber := "+6285912345678"
+	payload := generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"}
+	user := model.User{Id: 1, PhoneNumber: phoneNumber, Password: "hashed", Status: model.UserStatusActive}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte("hashed"), []byte("password")).Times(1).Return(nil)
+
+		resUser, err := authenticator.Authenticate(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, user, resUser)
+	})
+
+	t.Run("failed - unknown user", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("failed - directory user", func(t *testing.T) {
+		directoryUser := user
+		directoryUser.Password = ""
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(directoryUser, nil)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("failed - wrong password", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().CompareHashAndPassword([]byte("hashed"), []byte("password")).Times(1).Return(errors.New("mismatch"))
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+	})
+
+	t.Run("failed - db error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, errors.New("db error"))
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestLDAPAuthenticator_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockLDAPUtil := mockUtils.NewMockLDAPInterface(ctrl)
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	authenticator := NewLDAPAuthenticator(LDAPAuthenticatorOptions{
+		UserRepository:     mockUserRepo,
+		IdentityRepository: mockIdentityRepo,
+		LDAPUtil:           mockLDAPUtil,
+		PhoneUtil:          phone,
+	})
+
+	email := " John@Example.com"
+	payload := generated.AuthLoginJSONRequestBody{Email: &email, Password: "password"}
+	entry := utils.LDAPEntry{
+		DN:          "uid=john,ou=people,dc=example,dc=com",
+		ID:          "6f1d0b2e-3c4a-4e0e-9d63-2a7f0e5b8c11",
+		FullName:    "John Doe",
+		Email:       "John@Example.com",
+		PhoneNumber: "+6281234567890",
+	}
+	identity := model.UserIdentity{Id: 5, UserId: 10, Provider: model.IdentityProviderLDAP, Subject: entry.ID}
+	user := model.User{Id: 10, FullName: entry.FullName, PhoneNumber: entry.PhoneNumber, Email: null.StringFrom("john@example.com"), Status: model.UserStatusActive}
+
+	t.Run("success - provisioned before", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(user, nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(errors.New("db error"))
+
+		resUser, err := authenticator.Authenticate(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, user, resUser)
+	})
+
+	t.Run("success - provisioned on first login", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, entry.PhoneNumber).Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, "john@example.com").Times(1).Return(false, nil)
+		mockIdentityRepo.EXPECT().ProvisionUser(ctx, gomock.Any(), model.RoleUser, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, newUser model.User, _ string, newIdentity model.UserIdentity) (model.UserIdentity, error) {
+				require.Equal(t, entry.FullName, newUser.FullName)
+				require.Equal(t, entry.PhoneNumber, newUser.PhoneNumber)
+				require.Equal(t, null.StringFrom("john@example.com"), newUser.Email)
+				require.True(t, newUser.EmailVerifiedAt.Valid)
+				require.Equal(t, model.IdentityProviderLDAP, newIdentity.Provider)
+				require.Equal(t, entry.ID, newIdentity.Subject)
+
+				return identity, nil
+			})
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(user, nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(nil)
+
+		resUser, err := authenticator.Authenticate(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, user, resUser)
+	})
+
+	t.Run("success - provisioned with a normalized phone number", func(t *testing.T) {
+		formatted := entry
+		formatted.PhoneNumber = "+62 (0)812-3456-7890"
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(formatted, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, "+6281234567890").Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, "john@example.com").Times(1).Return(false, nil)
+		mockIdentityRepo.EXPECT().ProvisionUser(ctx, gomock.Any(), model.RoleUser, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, newUser model.User, _ string, _ model.UserIdentity) (model.UserIdentity, error) {
+				require.Equal(t, "+6281234567890", newUser.PhoneNumber)
+
+				return identity, nil
+			})
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(user, nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(nil)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - not in directory", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(utils.LDAPEntry{}, utils.ErrLDAPUserNotFound)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("failed - wrong password", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(utils.LDAPEntry{}, utils.ErrLDAPInvalidCredentials)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+	})
+
+	t.Run("failed - directory unavailable", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(utils.LDAPEntry{}, errors.New("connection refused"))
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - incomplete entry", func(t *testing.T) {
+		incomplete := entry
+		incomplete.PhoneNumber = ""
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(incomplete, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "INCOMPLETE_IDENTITY", utils.GetKey(err))
+	})
+
+	t.Run("failed - phone number of another country", func(t *testing.T) {
+		foreign := entry
+		foreign.PhoneNumber = "+447911123456"
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(foreign, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, "INCOMPLETE_IDENTITY", utils.GetKey(err))
+	})
+
+	t.Run("failed - phone number of a local user", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, entry.PhoneNumber).Times(1).Return(true, nil)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "ACCOUNT_CONFLICT", utils.GetKey(err))
+	})
+
+	t.Run("failed - email of a local user", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, entry.PhoneNumber).Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, "john@example.com").Times(1).Return(true, nil)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+	})
+
+	t.Run("success - provisioned user deleted", func(t *testing.T) {
+		deletedUser := user
+		deletedUser.Status = model.UserStatusDeleted
+		deletedUser.DeletedAt = null.TimeFrom(time.Now().Add(-time.Hour))
+
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserById(ctx, int64(10)).Times(1).Return(deletedUser, nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(nil)
+
+		resUser, err := authenticator.Authenticate(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, deletedUser, resUser)
+	})
+
+	t.Run("failed - provisioned user purged", func(t *testing.T) {
+		mockLDAPUtil.EXPECT().Authenticate("john@example.com", "password").Times(1).Return(entry, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, model.IdentityProviderLDAP, entry.ID).Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(model.User{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().GetDeletedUserById(ctx, int64(10)).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, err := authenticator.Authenticate(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+}
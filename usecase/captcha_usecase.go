@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/gommon/log"
+)
+
+// CaptchaUsecase demands a CAPTCHA for attempts to log in or register once
+// too many attempts for the same account, or from the same IP address,
+// failed within Window. A threshold of 0 disables that check, without a
+// Verifier no CAPTCHA is ever demanded.
+type CaptchaUsecase struct {
+	AuthFailureRepository repository.AuthFailureRepositoryInterface
+	Verifier              utils.CaptchaVerifierInterface
+	IdentifierThreshold   int64
+	IPThreshold           int64
+	Window                time.Duration
+}
+
+type CaptchaUsecaseOptions struct {
+	AuthFailureRepository repository.AuthFailureRepositoryInterface
+	Verifier              utils.CaptchaVerifierInterface
+	IdentifierThreshold   int64
+	IPThreshold           int64
+	Window                time.Duration
+}
+
+func NewCaptchaUsecase(opts CaptchaUsecaseOptions) *CaptchaUsecase {
+	u := &CaptchaUsecase{
+		AuthFailureRepository: opts.AuthFailureRepository,
+		Verifier:              opts.Verifier,
+		IdentifierThreshold:   opts.IdentifierThreshold,
+		IPThreshold:           opts.IPThreshold,
+		Window:                opts.Window,
+	}
+
+	return u
+}
+
+// Challenge lets the attempt through when it is not suspicious, or when
+// token is a solved CAPTCHA. Tokens sent while no CAPTCHA is demanded are
+// ignored.
+func (u CaptchaUsecase) Challenge(ctx context.Context, attempt model.AuthAttempt, token string) error {
+	if u.Verifier == nil {
+		return nil
+	}
+
+	count, err := u.AuthFailureRepository.CountAuthFailures(ctx, attempt, time.Now().Add(-u.Window))
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if !u.isSuspicious(count) {
+		return nil
+	}
+
+	if token == "" {
+		err = errors.New("captcha is required")
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "CAPTCHA_REQUIRED", "Captcha Required.")
+	}
+
+	if err = u.Verifier.Verify(ctx, token, attempt.IPAddress); err != nil {
+		log.Error(err)
+		if errors.Is(err, utils.ErrCaptchaRejected) {
+			return utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "INVALID_CAPTCHA", "Invalid Captcha.")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed attempt. Failing to record it does not fail
+// the request, the attempt already failed for another reason.
+func (u CaptchaUsecase) RecordFailure(ctx context.Context, attempt model.AuthAttempt) {
+	if u.Verifier == nil {
+		return
+	}
+
+	if _, err := u.AuthFailureRepository.DeleteExpiredAuthFailures(ctx, time.Now().Add(-u.Window)); err != nil {
+		log.Warn(err)
+	}
+
+	if err := u.AuthFailureRepository.CreateAuthFailure(ctx, attempt); err != nil {
+		log.Warn(err)
+	}
+}
+
+// ResetFailures forgets the failures counted against the attempt's account
+// once it succeeded. Failures from its IP address keep counting, so an
+// attacker can not reset them by logging into an account of their own.
+func (u CaptchaUsecase) ResetFailures(ctx context.Context, attempt model.AuthAttempt) {
+	if u.Verifier == nil {
+		return
+	}
+
+	if err := u.AuthFailureRepository.DeleteAuthFailures(ctx, attempt.Action, attempt.Identifier); err != nil {
+		log.Warn(err)
+	}
+}
+
+func (u CaptchaUsecase) isSuspicious(count model.AuthFailureCount) bool {
+	return (u.IdentifierThreshold > 0 && count.Identifier >= u.IdentifierThreshold) ||
+		(u.IPThreshold > 0 && count.IPAddress >= u.IPThreshold)
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestCaptchaUsecase_Challenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAuthFailureRepo := mocks.NewMockAuthFailureRepositoryInterface(ctrl)
+	mockVerifier := mockUtils.NewMockCaptchaVerifierInterface(ctrl)
+
+	captchaUsecase := NewCaptchaUsecase(CaptchaUsecaseOptions{
+		AuthFailureRepository: mockAuthFailureRepo,
+		Verifier:              mockVerifier,
+		IdentifierThreshold:   3,
+		IPThreshold:           10,
+		Window:                15 * time.Minute,
+	})
+
+	attempt := model.AuthAttempt{Action: model.AuthActionLogin, Identifier: "+628123456789", IPAddress: "192.0.2.1"}
+
+	t.Run("success - not suspicious", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, _ model.AuthAttempt, since time.Time) (model.AuthFailureCount, error) {
+				require.WithinDuration(t, time.Now().Add(-15*time.Minute), since, time.Minute)
+				return model.AuthFailureCount{Identifier: 2, IPAddress: 9}, nil
+			})
+
+		err := captchaUsecase.Challenge(ctx, attempt, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("success - solved captcha", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{Identifier: 3}, nil)
+		mockVerifier.EXPECT().Verify(ctx, "token", attempt.IPAddress).Times(1).Return(nil)
+
+		err := captchaUsecase.Challenge(ctx, attempt, "token")
+		require.NoError(t, err)
+	})
+
+	t.Run("success - disabled", func(t *testing.T) {
+		disabled := NewCaptchaUsecase(CaptchaUsecaseOptions{AuthFailureRepository: mockAuthFailureRepo, IdentifierThreshold: 3})
+
+		err := disabled.Challenge(ctx, attempt, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - captcha required by identifier", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{Identifier: 3}, nil)
+
+		err := captchaUsecase.Challenge(ctx, attempt, "")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "CAPTCHA_REQUIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - captcha required by ip address", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{IPAddress: 10}, nil)
+
+		err := captchaUsecase.Challenge(ctx, attempt, "")
+		require.Error(t, err)
+		require.Equal(t, "CAPTCHA_REQUIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - invalid captcha", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{Identifier: 3}, nil)
+		mockVerifier.EXPECT().Verify(ctx, "token", attempt.IPAddress).Times(1).Return(utils.ErrCaptchaRejected)
+
+		err := captchaUsecase.Challenge(ctx, attempt, "token")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "INVALID_CAPTCHA", utils.GetKey(err))
+	})
+
+	t.Run("failed - verifier error", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{Identifier: 3}, nil)
+		mockVerifier.EXPECT().Verify(ctx, "token", attempt.IPAddress).Times(1).Return(errors.New("timeout"))
+
+		err := captchaUsecase.Challenge(ctx, attempt, "token")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - count failures error", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().CountAuthFailures(ctx, attempt, gomock.Any()).Times(1).Return(model.AuthFailureCount{}, errors.New("db error"))
+
+		err := captchaUsecase.Challenge(ctx, attempt, "")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestCaptchaUsecase_RecordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAuthFailureRepo := mocks.NewMockAuthFailureRepositoryInterface(ctrl)
+
+	captchaUsecase := NewCaptchaUsecase(CaptchaUsecaseOptions{
+		AuthFailureRepository: mockAuthFailureRepo,
+		Verifier:              mockUtils.NewMockCaptchaVerifierInterface(ctrl),
+		IdentifierThreshold:   3,
+		Window:                15 * time.Minute,
+	})
+
+	attempt := model.AuthAttempt{Action: model.AuthActionRegister, Identifier: "+628123456789", IPAddress: "192.0.2.1"}
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().DeleteExpiredAuthFailures(ctx, gomock.Any()).Times(1).Return(int64(1), nil)
+		mockAuthFailureRepo.EXPECT().CreateAuthFailure(ctx, attempt).Times(1).Return(nil)
+
+		captchaUsecase.RecordFailure(ctx, attempt)
+	})
+
+	t.Run("success - purge error", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().DeleteExpiredAuthFailures(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+		mockAuthFailureRepo.EXPECT().CreateAuthFailure(ctx, attempt).Times(1).Return(nil)
+
+		captchaUsecase.RecordFailure(ctx, attempt)
+	})
+
+	t.Run("success - disabled", func(t *testing.T) {
+		disabled := NewCaptchaUsecase(CaptchaUsecaseOptions{AuthFailureRepository: mockAuthFailureRepo})
+
+		disabled.RecordFailure(ctx, attempt)
+	})
+}
+
+func TestCaptchaUsecase_ResetFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockAuthFailureRepo := mocks.NewMockAuthFailureRepositoryInterface(ctrl)
+
+	captchaUsecase := NewCaptchaUsecase(CaptchaUsecaseOptions{
+		AuthFailureRepository: mockAuthFailureRepo,
+		Verifier:              mockUtils.NewMockCaptchaVerifierInterface(ctrl),
+		IdentifierThreshold:   3,
+		Window:                15 * time.Minute,
+	})
+
+	attempt := model.AuthAttempt{Action: model.AuthActionLogin, Identifier: "+628123456789", IPAddress: "192.0.2.1"}
+
+	t.Run("success", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().DeleteAuthFailures(ctx, attempt.Action, attempt.Identifier).Times(1).Return(nil)
+
+		captchaUsecase.ResetFailures(ctx, attempt)
+	})
+
+	t.Run("success - delete error", func(t *testing.T) {
+		mockAuthFailureRepo.EXPECT().DeleteAuthFailures(ctx, attempt.Action, attempt.Identifier).Times(1).Return(errors.New("db error"))
+
+		captchaUsecase.ResetFailures(ctx, attempt)
+	})
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/gommon/log"
+)
+
+const maxUserAgentLen = 512
+
+// DeviceRecognizer remembers the devices users log in from, whichever way
+// they log in. Logins from new devices are reported through the Notifier,
+// with a link to DeviceRevokeURL to revoke the device.
+type DeviceRecognizer struct {
+	DeviceRepository repository.DeviceRepositoryInterface
+	Notifier         SecurityNotifierInterface
+	DeviceRevokeURL  string
+}
+
+type DeviceRecognizerOptions struct {
+	DeviceRepository repository.DeviceRepositoryInterface
+	Notifier         SecurityNotifierInterface
+	DeviceRevokeURL  string
+}
+
+func NewDeviceRecognizer(opts DeviceRecognizerOptions) *DeviceRecognizer {
+	return &DeviceRecognizer{
+		DeviceRepository: opts.DeviceRepository,
+		Notifier:         opts.Notifier,
+		DeviceRevokeURL:  opts.DeviceRevokeURL,
+	}
+}
+
+// RecognizeDevice returns the device the user logs in from, remembering it
+// when it is new. A new device is reported to the user unless it is their
+// first one, which alerting on would only teach them to ignore alerts. When
+// concurrent logins from a new device race, only the one remembering it
+// reports it.
+func (u DeviceRecognizer) RecognizeDevice(ctx context.Context, user model.User, client model.LoginClient) (model.Device, error) {
+	fingerprint := utils.DeviceFingerprint(client.DeviceId, client.UserAgent)
+	device, err := u.DeviceRepository.GetDevice(ctx, user.Id, fingerprint)
+	if err == nil {
+		if err = u.DeviceRepository.TouchDevice(ctx, device.Id); err != nil {
+			log.Warn(err)
+		}
+
+		return device, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return model.Device{}, err
+	}
+
+	known, err := u.DeviceRepository.CountDevices(ctx, user.Id)
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	token, tokenHash, err := utils.GenerateToken()
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	device, err = u.DeviceRepository.CreateDevice(ctx, model.Device{
+		UserId:          user.Id,
+		Fingerprint:     fingerprint,
+		UserAgent:       truncateUserAgent(client.UserAgent),
+		RevokeTokenHash: tokenHash,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return u.DeviceRepository.GetDevice(ctx, user.Id, fingerprint)
+	}
+	if err != nil {
+		return model.Device{}, err
+	}
+
+	if known > 0 {
+		u.notifyNewDevice(ctx, user, device, client, token)
+	}
+
+	return device, nil
+}
+
+// notifyNewDevice reports a login from a new device along with a link
+// carrying revokeToken to sign the device out. Failing to deliver it does not
+// fail the login.
+func (u DeviceRecognizer) notifyNewDevice(ctx context.Context, user model.User, device model.Device, client model.LoginClient, revokeToken string) {
+	log.Warnf("security event %s: user %d, device %d, ip %s", model.SecurityEventNewDeviceLogin, user.Id, device.Id, client.IPAddress)
+
+	event := model.SecurityEvent{
+		Type:       model.SecurityEventNewDeviceLogin,
+		User:       user,
+		Device:     device,
+		IPAddress:  client.IPAddress,
+		OccurredAt: time.Now(),
+	}
+
+	if u.DeviceRevokeURL != "" {
+		link, err := url.Parse(u.DeviceRevokeURL)
+		if err != nil {
+			log.Warn(err)
+			return
+		}
+
+		query := link.Query()
+		query.Set("token", revokeToken)
+		link.RawQuery = query.Encode()
+		event.RevokeURL = link.String()
+	}
+
+	if err := u.Notifier.NotifySecurityEvent(ctx, event); err != nil {
+		log.Warn(err)
+	}
+}
+
+// CheckDevice rejects tokens issued to a device that has since been revoked.
+func (u AuthUsecase) CheckDevice(ctx context.Context, userId, deviceId int64) error {
+	exists, err := u.DeviceRepository.DeviceExists(ctx, userId, deviceId)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if !exists {
+		err = fmt.Errorf("device %d of user %d is revoked", deviceId, userId)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "SESSION_REVOKED", "Session Has Been Revoked.")
+	}
+
+	return nil
+}
+
+// RevokeDevice signs out the device a new device alert was sent for, given
+// the token of its link. Every token issued to the device stops working and
+// the next login from it is reported again.
+func (u AuthUsecase) RevokeDevice(ctx context.Context, token string) error {
+	device, err := u.DeviceRepository.DeleteDeviceByRevokeTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_REVOKE_TOKEN", "Invalid Revoke Token.")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	log.Warnf("security event device_revoked: user %d, device %d", device.UserId, device.Id)
+
+	return nil
+}
+
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxUserAgentLen {
+		return userAgent
+	}
+
+	userAgent = userAgent[:maxUserAgentLen]
+	for !utf8.ValidString(userAgent) {
+		userAgent = userAgent[:len(userAgent)-1]
+	}
+
+	return userAgent
+}
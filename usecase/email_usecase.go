@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Events reported by SendSecurityAlert.
+const (
+	SecurityEventPasswordChanged    = "The password of your account was changed."
+	SecurityEventEmailChanged       = "The email address of your account was changed."
+	SecurityEventPhoneNumberChanged = "The phone number of your account was changed."
+)
+
+type EmailUsecase struct {
+	UserRepository              repository.UserRepositoryInterface
+	EmailVerificationRepository repository.EmailVerificationRepositoryInterface
+	Mailer                      utils.MailerInterface
+	VerificationURL             string
+	VerificationTokenDuration   time.Duration
+}
+
+type EmailUsecaseOptions struct {
+	UserRepository              repository.UserRepositoryInterface
+	EmailVerificationRepository repository.EmailVerificationRepositoryInterface
+	Mailer                      utils.MailerInterface
+	VerificationURL             string
+	VerificationTokenDuration   time.Duration
+}
+
+func NewEmailUsecase(opts EmailUsecaseOptions) *EmailUsecase {
+	u := &EmailUsecase{
+		UserRepository:              opts.UserRepository,
+		EmailVerificationRepository: opts.EmailVerificationRepository,
+		Mailer:                      opts.Mailer,
+		VerificationURL:             opts.VerificationURL,
+		VerificationTokenDuration:   opts.VerificationTokenDuration,
+	}
+
+	return u
+}
+
+// SendVerificationEmail mails the user a link to VerificationURL carrying a
+// token that verifies their current email address.
+func (u EmailUsecase) SendVerificationEmail(ctx context.Context, userId int64) error {
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if !user.Email.Valid {
+		err = fmt.Errorf("user %d has no email", userId)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "EMAIL_NOT_SET", "User Has No Email Address.")
+	}
+
+	if user.EmailVerifiedAt.Valid {
+		err = fmt.Errorf("email of user %d is already verified", userId)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "EMAIL_ALREADY_VERIFIED", "Email Address Is Already Verified.")
+	}
+
+	token, tokenHash, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	verification := model.EmailVerification{
+		UserId:    user.Id,
+		Email:     user.Email.String,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(u.VerificationTokenDuration),
+	}
+
+	if _, err = u.EmailVerificationRepository.CreateEmailVerification(ctx, verification); err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	link, err := url.Parse(u.VerificationURL)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	email, err := utils.RenderEmail(user.Email.String, utils.EmailTemplateVerification, map[string]interface{}{
+		"FullName":  user.FullName,
+		"Email":     user.Email.String,
+		"Link":      link.String(),
+		"ExpiresIn": u.VerificationTokenDuration.String(),
+	})
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.Mailer.Send(ctx, email); err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email address a verification token was sent to as
+// verified, provided it is still the user's address.
+func (u EmailUsecase) VerifyEmail(ctx context.Context, token string) error {
+	verification, err := u.EmailVerificationRepository.GetEmailVerificationByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_VERIFICATION_TOKEN", "Invalid Verification Token.")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if time.Now().After(verification.ExpiresAt) {
+		err = fmt.Errorf("email verification %d expired at %s", verification.Id, verification.ExpiresAt)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "VERIFICATION_TOKEN_EXPIRED", "Verification Token Has Expired.")
+	}
+
+	if verification.UsedAt.Valid {
+		err = fmt.Errorf("email verification %d is already used", verification.Id)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_VERIFICATION_TOKEN", "Invalid Verification Token.")
+	}
+
+	if err = u.EmailVerificationRepository.ConsumeEmailVerification(ctx, verification); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_VERIFICATION_TOKEN", "Invalid Verification Token.")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// SendSecurityAlert notifies the user of a security relevant event. Alerts are
+// only sent to verified email addresses, users without one are skipped.
+func (u EmailUsecase) SendSecurityAlert(ctx context.Context, user model.User, event string) error {
+	if !user.Email.Valid || !user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	email, err := utils.RenderEmail(user.Email.String, utils.EmailTemplateSecurityAlert, map[string]interface{}{
+		"FullName": user.FullName,
+		"Event":    event,
+		"Time":     time.Now(),
+	})
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.Mailer.Send(ctx, email); err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// NotifySecurityEvent mails the user about a security event, such as a login
+// from a new device. Like alerts, they are only sent to verified email
+// addresses.
+func (u EmailUsecase) NotifySecurityEvent(ctx context.Context, event model.SecurityEvent) error {
+	user := event.User
+	if !user.Email.Valid || !user.EmailVerifiedAt.Valid {
+		return nil
+	}
+
+	if event.Type != model.SecurityEventNewDeviceLogin {
+		err := fmt.Errorf("no email for security event %s", event.Type)
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	email, err := utils.RenderEmail(user.Email.String, utils.EmailTemplateNewDevice, map[string]interface{}{
+		"FullName":  user.FullName,
+		"UserAgent": event.Device.UserAgent,
+		"IPAddress": event.IPAddress,
+		"RevokeURL": event.RevokeURL,
+		"Time":      event.OccurredAt,
+	})
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.Mailer.Send(ctx, email); err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
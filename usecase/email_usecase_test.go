@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestEmailUsecase_SendVerificationEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockVerificationRepo := mocks.NewMockEmailVerificationRepositoryInterface(ctrl)
+	mockMailer := mockUtils.NewMockMailerInterface(ctrl)
+
+	emailUsecase := NewEmailUsecase(EmailUsecaseOptions{
+		UserRepository:              mockUserRepo,
+		EmailVerificationRepository: mockVerificationRepo,
+		Mailer:                      mockMailer,
+		VerificationURL:             "https://example.com/verify-email",
+		VerificationTokenDuration:   24 * time.Hour,
+	})
+
+	userId := int64(10)
+	user := model.User{Id: userId, FullName: "John Doe", Email: null.StringFrom("john@example.com")}
+
+	t.Run("success", func(t *testing.T) {
+		var tokenHash string
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockVerificationRepo.EXPECT().CreateEmailVerification(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, verification model.EmailVerification) (int64, error) {
+				require.Equal(t, userId, verification.UserId)
+				require.Equal(t, "john@example.com", verification.Email)
+				require.True(t, verification.ExpiresAt.After(time.Now()))
+				tokenHash = verification.TokenHash
+				return 1, nil
+			})
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, email utils.Email) error {
+				require.Equal(t, "john@example.com", email.To)
+
+				idx := strings.Index(email.Body, "https://example.com/verify-email?token=")
+				require.GreaterOrEqual(t, idx, 0)
+				token := strings.Fields(email.Body[idx+len("https://example.com/verify-email?token="):])[0]
+				require.Equal(t, tokenHash, utils.HashToken(token))
+				return nil
+			})
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - email not set", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{Id: userId}, nil)
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "EMAIL_NOT_SET", utils.GetKey(err))
+	})
+
+	t.Run("failed - email already verified", func(t *testing.T) {
+		verifiedUser := user
+		verifiedUser.EmailVerifiedAt = null.TimeFrom(time.Now())
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(verifiedUser, nil)
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "EMAIL_ALREADY_VERIFIED", utils.GetKey(err))
+	})
+
+	t.Run("failed - create email verification return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockVerificationRepo.EXPECT().CreateEmailVerification(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - send email return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockVerificationRepo.EXPECT().CreateEmailVerification(ctx, gomock.Any()).Times(1).Return(int64(1), nil)
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).Return(errors.New("smtp error"))
+
+		err := emailUsecase.SendVerificationEmail(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestEmailUsecase_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockVerificationRepo := mocks.NewMockEmailVerificationRepositoryInterface(ctrl)
+
+	emailUsecase := NewEmailUsecase(EmailUsecaseOptions{
+		EmailVerificationRepository: mockVerificationRepo,
+	})
+
+	token := "token"
+	verification := model.EmailVerification{
+		Id:        1,
+		UserId:    10,
+		Email:     "john@example.com",
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(verification, nil)
+		mockVerificationRepo.EXPECT().ConsumeEmailVerification(ctx, verification).Times(1).Return(nil)
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - token not found", func(t *testing.T) {
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(model.EmailVerification{}, sql.ErrNoRows)
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_VERIFICATION_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - token expired", func(t *testing.T) {
+		expired := verification
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(expired, nil)
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "VERIFICATION_TOKEN_EXPIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - token already used", func(t *testing.T) {
+		used := verification
+		used.UsedAt = null.TimeFrom(time.Now())
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(used, nil)
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_VERIFICATION_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - email changed since the token was sent", func(t *testing.T) {
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(verification, nil)
+		mockVerificationRepo.EXPECT().ConsumeEmailVerification(ctx, verification).Times(1).Return(sql.ErrNoRows)
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_VERIFICATION_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - consume email verification return error", func(t *testing.T) {
+		mockVerificationRepo.EXPECT().GetEmailVerificationByHash(ctx, utils.HashToken(token)).Times(1).Return(verification, nil)
+		mockVerificationRepo.EXPECT().ConsumeEmailVerification(ctx, verification).Times(1).Return(errors.New("db error"))
+
+		err := emailUsecase.VerifyEmail(ctx, token)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestEmailUsecase_SendSecurityAlert(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockMailer := mockUtils.NewMockMailerInterface(ctrl)
+
+	emailUsecase := NewEmailUsecase(EmailUsecaseOptions{
+		Mailer: mockMailer,
+	})
+
+	user := model.User{
+		Id:              10,
+		FullName:        "John Doe",
+		Email:           null.StringFrom("john@example.com"),
+		EmailVerifiedAt: null.TimeFrom(time.Now()),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, email utils.Email) error {
+				require.Equal(t, "john@example.com", email.To)
+				require.Contains(t, email.Body, SecurityEventPasswordChanged)
+				return nil
+			})
+
+		err := emailUsecase.SendSecurityAlert(ctx, user, SecurityEventPasswordChanged)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - unverified email is skipped", func(t *testing.T) {
+		unverified := user
+		unverified.EmailVerifiedAt = null.Time{}
+
+		err := emailUsecase.SendSecurityAlert(ctx, unverified, SecurityEventPasswordChanged)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - send email return error", func(t *testing.T) {
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).Return(errors.New("smtp error"))
+
+		err := emailUsecase.SendSecurityAlert(ctx, user, SecurityEventPasswordChanged)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestEmailUsecase_NotifySecurityEvent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockMailer := mockUtils.NewMockMailerInterface(ctrl)
+
+	emailUsecase := NewEmailUsecase(EmailUsecaseOptions{
+		Mailer: mockMailer,
+	})
+
+	event := model.SecurityEvent{
+		Type: model.SecurityEventNewDeviceLogin,
+		User: model.User{
+			Id:              10,
+			FullName:        "John Doe",
+			Email:           null.StringFrom("john@example.com"),
+			EmailVerifiedAt: null.TimeFrom(time.Now()),
+		},
+		Device:     model.Device{Id: 2, UserAgent: "Mozilla/5.0"},
+		IPAddress:  "203.0.113.7",
+		RevokeURL:  "https://example.com/devices/revoke?token=token",
+		OccurredAt: time.Now(),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, email utils.Email) error {
+				require.Equal(t, "john@example.com", email.To)
+				require.Contains(t, email.Body, "Mozilla/5.0")
+				require.Contains(t, email.Body, "203.0.113.7")
+				require.Contains(t, email.Body, event.RevokeURL)
+				return nil
+			})
+
+		err := emailUsecase.NotifySecurityEvent(ctx, event)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - unverified email is skipped", func(t *testing.T) {
+		unverified := event
+		unverified.User.EmailVerifiedAt = null.Time{}
+
+		err := emailUsecase.NotifySecurityEvent(ctx, unverified)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - unknown event", func(t *testing.T) {
+		unknown := event
+		unknown.Type = "unknown"
+
+		err := emailUsecase.NotifySecurityEvent(ctx, unknown)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - send email return error", func(t *testing.T) {
+		mockMailer.EXPECT().Send(ctx, gomock.Any()).Times(1).Return(errors.New("smtp error"))
+
+		err := emailUsecase.NotifySecurityEvent(ctx, event)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
@@ -0,0 +1,526 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+// maxExportAttempts is how often an export is claimed before one still
+// processing past the claim timeout is failed rather than claimed again.
+const maxExportAttempts = 3
+
+type ExportUsecase struct {
+	ExportRepository            repository.ExportRepositoryInterface
+	UserRepository              repository.UserRepositoryInterface
+	APIKeyRepository            repository.APIKeyRepositoryInterface
+	IdentityRepository          repository.IdentityRepositoryInterface
+	WebAuthnRepository          repository.WebAuthnRepositoryInterface
+	DeviceRepository            repository.DeviceRepositoryInterface
+	ImpersonationRepository     repository.ImpersonationRepositoryInterface
+	AuthFailureRepository       repository.AuthFailureRepositoryInterface
+	EmailVerificationRepository repository.EmailVerificationRepositoryInterface
+	Signer                      utils.SignerInterface
+	LinkDuration                time.Duration
+	Retention                   time.Duration
+	ClaimTimeout                time.Duration
+}
+
+type ExportUsecaseOptions struct {
+	ExportRepository            repository.ExportRepositoryInterface
+	UserRepository              repository.UserRepositoryInterface
+	APIKeyRepository            repository.APIKeyRepositoryInterface
+	IdentityRepository          repository.IdentityRepositoryInterface
+	WebAuthnRepository          repository.WebAuthnRepositoryInterface
+	DeviceRepository            repository.DeviceRepositoryInterface
+	ImpersonationRepository     repository.ImpersonationRepositoryInterface
+	AuthFailureRepository       repository.AuthFailureRepositoryInterface
+	EmailVerificationRepository repository.EmailVerificationRepositoryInterface
+	Signer                      utils.SignerInterface
+	LinkDuration                time.Duration
+	Retention                   time.Duration
+	ClaimTimeout                time.Duration
+}
+
+func NewExportUsecase(opts ExportUsecaseOptions) *ExportUsecase {
+	u := &ExportUsecase{
+		ExportRepository:            opts.ExportRepository,
+		UserRepository:              opts.UserRepository,
+		APIKeyRepository:            opts.APIKeyRepository,
+		IdentityRepository:          opts.IdentityRepository,
+		WebAuthnRepository:          opts.WebAuthnRepository,
+		DeviceRepository:            opts.DeviceRepository,
+		ImpersonationRepository:     opts.ImpersonationRepository,
+		AuthFailureRepository:       opts.AuthFailureRepository,
+		EmailVerificationRepository: opts.EmailVerificationRepository,
+		Signer:                      opts.Signer,
+		LinkDuration:                opts.LinkDuration,
+		Retention:                   opts.Retention,
+		ClaimTimeout:                opts.ClaimTimeout,
+	}
+
+	return u
+}
+
+// exportFile is a single JSON document of the export archive.
+type exportFile struct {
+	name string
+	data interface{}
+}
+
+type exportedProfile struct {
+	Id              int64       `json:"id"`
+	FullName        string      `json:"full_name"`
+	PhoneNumber     string      `json:"phone_number"`
+	Email           null.String `json:"email"`
+	EmailVerifiedAt null.Time   `json:"email_verified_at"`
+	Roles           []string    `json:"roles"`
+	Status          string      `json:"status"`
+	StatusReason    null.String `json:"status_reason"`
+	LoginCount      int64       `json:"login_count"`
+	PhoneVerifiedAt null.Time   `json:"phone_verified_at"`
+	Locale          null.String `json:"locale"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       null.Time   `json:"updated_at"`
+}
+
+type exportedStatusChange struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportedAPIKey struct {
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  null.Time `json:"expires_at"`
+	LastUsedAt null.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportedIdentity struct {
+	Provider    string      `json:"provider"`
+	Subject     string      `json:"subject"`
+	Email       null.String `json:"email"`
+	LastLoginAt null.Time   `json:"last_login_at"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type exportedPasskey struct {
+	Name       string    `json:"name"`
+	AAGUID     string    `json:"aaguid"`
+	LastUsedAt null.Time `json:"last_used_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type exportedDevice struct {
+	UserAgent   string    `json:"user_agent"`
+	Fingerprint string    `json:"fingerprint"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// exportedImpersonation is a support session in which an admin acted as the
+// user, along with the requests made during it. ActorId is null once the
+// admin was purged.
+type exportedImpersonation struct {
+	ActorId   null.Int                        `json:"actor_id"`
+	Reason    string                          `json:"reason"`
+	ExpiresAt time.Time                       `json:"expires_at"`
+	CreatedAt time.Time                       `json:"created_at"`
+	Requests  []exportedImpersonationAuditLog `json:"requests"`
+}
+
+type exportedImpersonationAuditLog struct {
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// exportedAuthFailure is a failed login, re-authentication or registration
+// counted against one of the user's identifiers. Failures are only kept for
+// as long as they count towards demanding a CAPTCHA.
+type exportedAuthFailure struct {
+	Action     string    `json:"action"`
+	Identifier string    `json:"identifier"`
+	IPAddress  string    `json:"ip_address"`
+	FailedAt   time.Time `json:"failed_at"`
+}
+
+// exportedEmailVerification is a verification link sent to one of the
+// addresses the user had. The token itself is not exported.
+type exportedEmailVerification struct {
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UsedAt    null.Time `json:"used_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RequestExport queues an export of all the data stored about the user. The
+// archive is assembled by ProcessPendingExport.
+func (u ExportUsecase) RequestExport(ctx context.Context, userId int64) (model.UserExport, error) {
+	export, err := u.ExportRepository.CreateExport(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("user %d already has an export in progress", userId)
+			return model.UserExport{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "Export Is Already In Progress.")
+		}
+
+		return model.UserExport{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return export, nil
+}
+
+// GetExport returns one of the user's exports along with a signed download
+// link once its archive is ready.
+func (u ExportUsecase) GetExport(ctx context.Context, userId, exportId int64) (model.UserExport, string, error) {
+	export, err := u.ExportRepository.GetExportById(ctx, exportId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.UserExport{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return model.UserExport{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if export.UserId != userId {
+		err = fmt.Errorf("export %d does not belong to user %d", exportId, userId)
+		log.Error(err)
+		return model.UserExport{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+	}
+
+	if export.Status != model.ExportStatusReady || !export.ExpiresAt.Valid || time.Now().After(export.ExpiresAt.Time) {
+		return export, "", nil
+	}
+
+	linkExpiresAt := time.Now().Add(u.LinkDuration)
+	if export.ExpiresAt.Time.Before(linkExpiresAt) {
+		linkExpiresAt = export.ExpiresAt.Time
+	}
+
+	link := fmt.Sprintf("/v1/exports/%d/download?expires=%d&signature=%s",
+		export.Id, linkExpiresAt.Unix(), u.Signer.Sign(exportSignaturePayload(export.Id), linkExpiresAt))
+
+	return export, link, nil
+}
+
+// DownloadExport returns the archive of an export given a link signed by
+// GetExport.
+func (u ExportUsecase) DownloadExport(ctx context.Context, exportId int64, expiresAt time.Time, signature string) ([]byte, error) {
+	if err := u.Signer.Verify(exportSignaturePayload(exportId), expiresAt, signature); err != nil {
+		log.Error(err)
+		if errors.Is(err, utils.ErrSignatureExpired) {
+			return nil, utils.WrapWithKey(err, utils.ErrorCode(http.StatusGone), "LINK_EXPIRED", "Download Link Is Expired.")
+		}
+
+		return nil, utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "LINK_INVALID", "Download Link Is Invalid.")
+	}
+
+	archive, err := u.ExportRepository.GetExportArchive(ctx, exportId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return archive, nil
+}
+
+// ProcessPendingExport assembles the archive of the oldest pending export. It
+// reports false when there was no export to process. Exports still processing
+// past ClaimTimeout are assumed to belong to a dead worker and are claimed
+// again, or failed once they were tried maxExportAttempts times.
+func (u ExportUsecase) ProcessPendingExport(ctx context.Context) (bool, error) {
+	staleBefore := time.Now().Add(-u.ClaimTimeout)
+	failed, err := u.ExportRepository.FailStaleExports(ctx, staleBefore, maxExportAttempts, "export timed out")
+	if err != nil {
+		log.Error(err)
+		return false, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+	if failed > 0 {
+		log.Warnf("failed %d exports that timed out", failed)
+	}
+
+	export, err := u.ExportRepository.ClaimPendingExport(ctx, staleBefore, maxExportAttempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+
+		log.Error(err)
+		return false, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	archive, err := u.buildArchive(ctx, export.UserId)
+	if err != nil {
+		log.Error(err)
+		if err := u.ExportRepository.FailExport(ctx, export.Id, err.Error()); err != nil {
+			return true, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+
+		return true, nil
+	}
+
+	if err := u.ExportRepository.CompleteExport(ctx, export.Id, archive, time.Now().Add(u.Retention)); err != nil {
+		return true, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return true, nil
+}
+
+// PurgeExpiredExports deletes the exports past their retention and returns
+// how many were removed.
+func (u ExportUsecase) PurgeExpiredExports(ctx context.Context) (int64, error) {
+	deleted, err := u.ExportRepository.DeleteExpiredExports(ctx)
+	if err != nil {
+		return 0, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return deleted, nil
+}
+
+// collectExportFiles gathers every piece of data linked to the user. Data
+// stored in new user-linked tables must be added here.
+func (u ExportUsecase) collectExportFiles(ctx context.Context, userId int64) ([]exportFile, error) {
+	user, err := u.UserRepository.GetUserDetailById(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := u.UserRepository.ListUserStatusChanges(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := u.APIKeyRepository.ListAPIKeys(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	linkedIdentities, err := u.IdentityRepository.ListIdentities(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := u.WebAuthnRepository.ListWebAuthnCredentials(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	knownDevices, err := u.DeviceRepository.ListDevices(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.ImpersonationRepository.ListImpersonations(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogs, err := u.ImpersonationRepository.ListImpersonationAuditLogs(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Failures name the account by the identifier the attempt was made with:
+	// the phone number or email address, or the user id when
+	// re-authenticating.
+	identifiers := []string{user.PhoneNumber, strconv.FormatInt(user.Id, 10)}
+	if user.Email.Valid {
+		identifiers = append(identifiers, user.Email.String)
+	}
+
+	failures, err := u.AuthFailureRepository.ListAuthFailures(ctx, identifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	verifications, err := u.EmailVerificationRepository.ListEmailVerifications(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := exportedProfile{
+		Id:              user.Id,
+		FullName:        user.FullName,
+		PhoneNumber:     user.PhoneNumber,
+		Email:           user.Email,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Roles:           user.Roles,
+		Status:          user.Status,
+		StatusReason:    user.StatusReason,
+		LoginCount:      user.LoginCount,
+		PhoneVerifiedAt: user.PhoneVerifiedAt,
+		Locale:          user.Locale,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+	}
+
+	statusChanges := make([]exportedStatusChange, 0, len(changes))
+	for _, change := range changes {
+		statusChanges = append(statusChanges, exportedStatusChange{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			Reason:     change.Reason,
+			CreatedAt:  change.CreatedAt,
+		})
+	}
+
+	apiKeys := make([]exportedAPIKey, 0, len(keys))
+	for _, key := range keys {
+		apiKeys = append(apiKeys, exportedAPIKey{
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+
+	identities := make([]exportedIdentity, 0, len(linkedIdentities))
+	for _, identity := range linkedIdentities {
+		identities = append(identities, exportedIdentity{
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: identity.LastLoginAt,
+			CreatedAt:   identity.CreatedAt,
+		})
+	}
+
+	passkeys := make([]exportedPasskey, 0, len(credentials))
+	for _, credential := range credentials {
+		passkeys = append(passkeys, exportedPasskey{
+			Name:       credential.Name,
+			AAGUID:     credential.AAGUID,
+			LastUsedAt: credential.LastUsedAt,
+			CreatedAt:  credential.CreatedAt,
+		})
+	}
+
+	devices := make([]exportedDevice, 0, len(knownDevices))
+	for _, device := range knownDevices {
+		devices = append(devices, exportedDevice{
+			UserAgent:   device.UserAgent,
+			Fingerprint: device.Fingerprint,
+			LastLoginAt: device.LastLoginAt,
+			CreatedAt:   device.CreatedAt,
+		})
+	}
+
+	requests := map[int64][]exportedImpersonationAuditLog{}
+	for _, auditLog := range auditLogs {
+		requests[auditLog.ImpersonationId] = append(requests[auditLog.ImpersonationId], exportedImpersonationAuditLog{
+			Method:     auditLog.Method,
+			Path:       auditLog.Path,
+			StatusCode: auditLog.StatusCode,
+			CreatedAt:  auditLog.CreatedAt,
+		})
+	}
+
+	impersonations := make([]exportedImpersonation, 0, len(sessions))
+	for _, session := range sessions {
+		impersonation := exportedImpersonation{
+			ActorId:   session.ActorId,
+			Reason:    session.Reason,
+			ExpiresAt: session.ExpiresAt,
+			CreatedAt: session.CreatedAt,
+			Requests:  requests[session.Id],
+		}
+		if impersonation.Requests == nil {
+			impersonation.Requests = make([]exportedImpersonationAuditLog, 0)
+		}
+
+		impersonations = append(impersonations, impersonation)
+	}
+
+	authFailures := make([]exportedAuthFailure, 0, len(failures))
+	for _, failure := range failures {
+		authFailures = append(authFailures, exportedAuthFailure{
+			Action:     failure.Action,
+			Identifier: failure.Identifier,
+			IPAddress:  failure.IPAddress,
+			FailedAt:   failure.FailedAt,
+		})
+	}
+
+	emailVerifications := make([]exportedEmailVerification, 0, len(verifications))
+	for _, verification := range verifications {
+		emailVerifications = append(emailVerifications, exportedEmailVerification{
+			Email:     verification.Email,
+			ExpiresAt: verification.ExpiresAt,
+			UsedAt:    verification.UsedAt,
+			CreatedAt: verification.CreatedAt,
+		})
+	}
+
+	files := []exportFile{
+		{name: "profile.json", data: profile},
+		{name: "status_changes.json", data: statusChanges},
+		{name: "api_keys.json", data: apiKeys},
+		{name: "identities.json", data: identities},
+		{name: "passkeys.json", data: passkeys},
+		{name: "devices.json", data: devices},
+		{name: "impersonations.json", data: impersonations},
+		{name: "auth_failures.json", data: authFailures},
+		{name: "email_verifications.json", data: emailVerifications},
+	}
+
+	return files, nil
+}
+
+func (u ExportUsecase) buildArchive(ctx context.Context, userId int64) ([]byte, error) {
+	files, err := u.collectExportFiles(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func exportSignaturePayload(exportId int64) string {
+	return fmt.Sprintf("export:%d", exportId)
+}
@@ -0,0 +1,430 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportUsecase_RequestExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockExportRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+
+	exportUsecase := NewExportUsecase(ExportUsecaseOptions{
+		ExportRepository: mockExportRepo,
+	})
+
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		export := model.UserExport{Id: 1, UserId: userId, Status: model.ExportStatusPending}
+		mockExportRepo.EXPECT().CreateExport(ctx, userId).Times(1).Return(export, nil)
+
+		res, err := exportUsecase.RequestExport(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, export, res)
+	})
+
+	t.Run("failed - export in progress", func(t *testing.T) {
+		mockExportRepo.EXPECT().CreateExport(ctx, userId).Times(1).Return(model.UserExport{}, sql.ErrNoRows)
+
+		_, err := exportUsecase.RequestExport(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+	})
+
+	t.Run("failed - create export return error", func(t *testing.T) {
+		mockExportRepo.EXPECT().CreateExport(ctx, userId).Times(1).Return(model.UserExport{}, errors.New("db error"))
+
+		_, err := exportUsecase.RequestExport(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestExportUsecase_GetExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockExportRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+	mockSigner := mockUtils.NewMockSignerInterface(ctrl)
+
+	exportUsecase := NewExportUsecase(ExportUsecaseOptions{
+		ExportRepository: mockExportRepo,
+		Signer:           mockSigner,
+		LinkDuration:     15 * time.Minute,
+	})
+
+	userId := int64(10)
+	exportId := int64(1)
+
+	t.Run("success - ready", func(t *testing.T) {
+		export := model.UserExport{
+			Id:        exportId,
+			UserId:    userId,
+			Status:    model.ExportStatusReady,
+			ExpiresAt: null.TimeFrom(time.Now().Add(24 * time.Hour)),
+		}
+		mockExportRepo.EXPECT().GetExportById(ctx, exportId).Times(1).Return(export, nil)
+		mockSigner.EXPECT().Sign("export:1", gomock.Any()).Times(1).Return("signature")
+
+		res, link, err := exportUsecase.GetExport(ctx, userId, exportId)
+		require.NoError(t, err)
+		require.Equal(t, export, res)
+		require.True(t, strings.HasPrefix(link, "/v1/exports/1/download?expires="))
+		require.True(t, strings.HasSuffix(link, "&signature=signature"))
+	})
+
+	t.Run("success - pending", func(t *testing.T) {
+		export := model.UserExport{Id: exportId, UserId: userId, Status: model.ExportStatusPending}
+		mockExportRepo.EXPECT().GetExportById(ctx, exportId).Times(1).Return(export, nil)
+
+		res, link, err := exportUsecase.GetExport(ctx, userId, exportId)
+		require.NoError(t, err)
+		require.Equal(t, export, res)
+		require.Empty(t, link)
+	})
+
+	t.Run("failed - export of another user", func(t *testing.T) {
+		export := model.UserExport{Id: exportId, UserId: 2, Status: model.ExportStatusReady}
+		mockExportRepo.EXPECT().GetExportById(ctx, exportId).Times(1).Return(export, nil)
+
+		_, link, err := exportUsecase.GetExport(ctx, userId, exportId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+		require.Empty(t, link)
+	})
+
+	t.Run("failed - export not found", func(t *testing.T) {
+		mockExportRepo.EXPECT().GetExportById(ctx, exportId).Times(1).Return(model.UserExport{}, sql.ErrNoRows)
+
+		_, _, err := exportUsecase.GetExport(ctx, userId, exportId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+}
+
+func TestExportUsecase_DownloadExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockExportRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+	mockSigner := mockUtils.NewMockSignerInterface(ctrl)
+
+	exportUsecase := NewExportUsecase(ExportUsecaseOptions{
+		ExportRepository: mockExportRepo,
+		Signer:           mockSigner,
+	})
+
+	exportId := int64(1)
+	expiresAt := time.Unix(1700000000, 0)
+	signature := "signature"
+
+	t.Run("success", func(t *testing.T) {
+		mockSigner.EXPECT().Verify("export:1", expiresAt, signature).Times(1).Return(nil)
+		mockExportRepo.EXPECT().GetExportArchive(ctx, exportId).Times(1).Return([]byte("archive"), nil)
+
+		archive, err := exportUsecase.DownloadExport(ctx, exportId, expiresAt, signature)
+		require.NoError(t, err)
+		require.Equal(t, []byte("archive"), archive)
+	})
+
+	t.Run("failed - invalid signature", func(t *testing.T) {
+		mockSigner.EXPECT().Verify("export:1", expiresAt, signature).Times(1).Return(utils.ErrSignatureInvalid)
+
+		_, err := exportUsecase.DownloadExport(ctx, exportId, expiresAt, signature)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "LINK_INVALID", utils.GetKey(err))
+	})
+
+	t.Run("failed - expired signature", func(t *testing.T) {
+		mockSigner.EXPECT().Verify("export:1", expiresAt, signature).Times(1).Return(utils.ErrSignatureExpired)
+
+		_, err := exportUsecase.DownloadExport(ctx, exportId, expiresAt, signature)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusGone), utils.GetCode(err))
+		require.Equal(t, "LINK_EXPIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - archive not found", func(t *testing.T) {
+		mockSigner.EXPECT().Verify("export:1", expiresAt, signature).Times(1).Return(nil)
+		mockExportRepo.EXPECT().GetExportArchive(ctx, exportId).Times(1).Return(nil, sql.ErrNoRows)
+
+		_, err := exportUsecase.DownloadExport(ctx, exportId, expiresAt, signature)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+}
+
+func TestExportUsecase_ProcessPendingExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockExportRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockAPIKeyRepo := mocks.NewMockAPIKeyRepositoryInterface(ctrl)
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+	mockDeviceRepo := mocks.NewMockDeviceRepositoryInterface(ctrl)
+	mockImpersonationRepo := mocks.NewMockImpersonationRepositoryInterface(ctrl)
+	mockAuthFailureRepo := mocks.NewMockAuthFailureRepositoryInterface(ctrl)
+	mockEmailVerificationRepo := mocks.NewMockEmailVerificationRepositoryInterface(ctrl)
+
+	exportUsecase := NewExportUsecase(ExportUsecaseOptions{
+		ExportRepository:            mockExportRepo,
+		UserRepository:              mockUserRepo,
+		APIKeyRepository:            mockAPIKeyRepo,
+		IdentityRepository:          mockIdentityRepo,
+		WebAuthnRepository:          mockWebAuthnRepo,
+		DeviceRepository:            mockDeviceRepo,
+		ImpersonationRepository:     mockImpersonationRepo,
+		AuthFailureRepository:       mockAuthFailureRepo,
+		EmailVerificationRepository: mockEmailVerificationRepo,
+		Retention:                   24 * time.Hour,
+		ClaimTimeout:                15 * time.Minute,
+	})
+
+	userId := int64(10)
+	export := model.UserExport{Id: 1, UserId: userId, Status: model.ExportStatusProcessing}
+	user := model.User{
+		Id:          userId,
+		FullName:    "John Doe",
+		PhoneNumber: "+6285912345678",
+		Password:    "password",
+		Roles:       []string{model.RoleUser},
+		Status:      model.UserStatusActive,
+		Locale:      null.StringFrom("id"),
+	}
+	changes := []model.UserStatusChange{
+		{UserId: userId, FromStatus: model.UserStatusSuspended, ToStatus: model.UserStatusActive, Reason: "appeal"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).Return(int64(0), nil)
+		mockExportRepo.EXPECT().ClaimPendingExport(ctx, gomock.Any(), maxExportAttempts).Times(1).Return(export, nil)
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().ListUserStatusChanges(ctx, userId).Times(1).Return(changes, nil)
+		mockAPIKeyRepo.EXPECT().ListAPIKeys(ctx, userId).Times(1).Return([]model.APIKey{{Id: 3, UserId: userId, KeyHash: "hash"}}, nil)
+		mockIdentityRepo.EXPECT().ListIdentities(ctx, userId).Times(1).Return([]model.UserIdentity{{Id: 4, UserId: userId, Provider: "google", Subject: "1234"}}, nil)
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, userId).Times(1).Return([]model.WebAuthnCredential{
+			{Id: 5, UserId: userId, CredentialId: "credential", PublicKey: []byte("key"), AAGUID: "aaguid", Name: "Laptop"},
+		}, nil)
+		mockDeviceRepo.EXPECT().ListDevices(ctx, userId).Times(1).Return([]model.Device{
+			{Id: 6, UserId: userId, Fingerprint: "fingerprint", UserAgent: "curl/8.0", RevokeTokenHash: "hash"},
+		}, nil)
+		mockImpersonationRepo.EXPECT().ListImpersonations(ctx, userId).Times(1).Return([]model.Impersonation{
+			{Id: 7, ActorId: null.IntFrom(1), UserId: userId, Reason: "debugging checkout"},
+			{Id: 8, UserId: userId, Reason: "checking settings"},
+		}, nil)
+		mockImpersonationRepo.EXPECT().ListImpersonationAuditLogs(ctx, userId).Times(1).Return([]model.ImpersonationAuditLog{
+			{ImpersonationId: 7, Method: "GET", Path: "/v1/users/profile", StatusCode: 200},
+		}, nil)
+		mockAuthFailureRepo.EXPECT().ListAuthFailures(ctx, []string{user.PhoneNumber, "10"}).Times(1).Return([]model.AuthFailure{
+			{Id: 9, Action: model.AuthActionLogin, Identifier: user.PhoneNumber, IPAddress: "192.0.2.1"},
+		}, nil)
+		mockEmailVerificationRepo.EXPECT().ListEmailVerifications(ctx, userId).Times(1).Return([]model.EmailVerification{
+			{Id: 11, UserId: userId, Email: "john@example.com", TokenHash: "hash"},
+		}, nil)
+		mockExportRepo.EXPECT().CompleteExport(ctx, export.Id, gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, _ int64, archive []byte, expiresAt time.Time) error {
+				require.WithinDuration(t, time.Now().Add(24*time.Hour), expiresAt, time.Minute)
+
+				zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+				require.NoError(t, err)
+				require.Len(t, zr.File, 9)
+				require.Equal(t, "profile.json", zr.File[0].Name)
+				require.Equal(t, "status_changes.json", zr.File[1].Name)
+				require.Equal(t, "api_keys.json", zr.File[2].Name)
+				require.Equal(t, "identities.json", zr.File[3].Name)
+				require.Equal(t, "passkeys.json", zr.File[4].Name)
+				require.Equal(t, "devices.json", zr.File[5].Name)
+				require.Equal(t, "impersonations.json", zr.File[6].Name)
+				require.Equal(t, "auth_failures.json", zr.File[7].Name)
+				require.Equal(t, "email_verifications.json", zr.File[8].Name)
+
+				var profile map[string]interface{}
+				readExportFile(t, zr.File[0], &profile)
+				require.Equal(t, user.FullName, profile["full_name"])
+				require.Equal(t, "id", profile["locale"])
+				require.NotContains(t, profile, "password")
+
+				var passkeys []map[string]interface{}
+				readExportFile(t, zr.File[4], &passkeys)
+				require.Len(t, passkeys, 1)
+				require.Equal(t, "Laptop", passkeys[0]["name"])
+				require.Equal(t, "aaguid", passkeys[0]["aaguid"])
+				require.NotContains(t, passkeys[0], "public_key")
+
+				var devices []map[string]interface{}
+				readExportFile(t, zr.File[5], &devices)
+				require.Len(t, devices, 1)
+				require.Equal(t, "curl/8.0", devices[0]["user_agent"])
+				require.Equal(t, "fingerprint", devices[0]["fingerprint"])
+				require.Contains(t, devices[0], "last_login_at")
+				require.NotContains(t, devices[0], "revoke_token_hash")
+
+				var impersonations []map[string]interface{}
+				readExportFile(t, zr.File[6], &impersonations)
+				require.Len(t, impersonations, 2)
+				require.Equal(t, float64(1), impersonations[0]["actor_id"])
+				require.Equal(t, "debugging checkout", impersonations[0]["reason"])
+				require.Len(t, impersonations[0]["requests"], 1)
+				require.Equal(t, "/v1/users/profile", impersonations[0]["requests"].([]interface{})[0].(map[string]interface{})["path"])
+				require.Nil(t, impersonations[1]["actor_id"])
+				require.Empty(t, impersonations[1]["requests"])
+
+				var authFailures []map[string]interface{}
+				readExportFile(t, zr.File[7], &authFailures)
+				require.Len(t, authFailures, 1)
+				require.Equal(t, model.AuthActionLogin, authFailures[0]["action"])
+				require.Equal(t, user.PhoneNumber, authFailures[0]["identifier"])
+				require.Equal(t, "192.0.2.1", authFailures[0]["ip_address"])
+
+				var emailVerifications []map[string]interface{}
+				readExportFile(t, zr.File[8], &emailVerifications)
+				require.Len(t, emailVerifications, 1)
+				require.Equal(t, "john@example.com", emailVerifications[0]["email"])
+				require.NotContains(t, emailVerifications[0], "token_hash")
+
+				return nil
+			})
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run("success - nothing to process", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).Return(int64(0), nil)
+		mockExportRepo.EXPECT().ClaimPendingExport(ctx, gomock.Any(), maxExportAttempts).Times(1).Return(model.UserExport{}, sql.ErrNoRows)
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.NoError(t, err)
+		require.False(t, processed)
+	})
+
+	t.Run("success - building archive failed", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).Return(int64(0), nil)
+		mockExportRepo.EXPECT().ClaimPendingExport(ctx, gomock.Any(), maxExportAttempts).Times(1).Return(export, nil)
+		mockUserRepo.EXPECT().GetUserDetailById(ctx, userId).Times(1).Return(model.User{}, errors.New("db error"))
+		mockExportRepo.EXPECT().FailExport(ctx, export.Id, "db error").Times(1).Return(nil)
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.NoError(t, err)
+		require.True(t, processed)
+	})
+
+	t.Run("success - stale exports", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).
+			DoAndReturn(func(_ context.Context, staleBefore time.Time, _ int, _ string) (int64, error) {
+				require.WithinDuration(t, time.Now().Add(-15*time.Minute), staleBefore, time.Minute)
+				return 1, nil
+			})
+		mockExportRepo.EXPECT().ClaimPendingExport(ctx, gomock.Any(), maxExportAttempts).Times(1).
+			DoAndReturn(func(_ context.Context, staleBefore time.Time, _ int) (model.UserExport, error) {
+				require.WithinDuration(t, time.Now().Add(-15*time.Minute), staleBefore, time.Minute)
+				return model.UserExport{}, sql.ErrNoRows
+			})
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.NoError(t, err)
+		require.False(t, processed)
+	})
+
+	t.Run("failed - fail stale exports return error", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).Return(int64(0), errors.New("db error"))
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.Error(t, err)
+		require.False(t, processed)
+	})
+
+	t.Run("failed - claim pending export return error", func(t *testing.T) {
+		mockExportRepo.EXPECT().FailStaleExports(ctx, gomock.Any(), maxExportAttempts, "export timed out").Times(1).Return(int64(0), nil)
+		mockExportRepo.EXPECT().ClaimPendingExport(ctx, gomock.Any(), maxExportAttempts).Times(1).Return(model.UserExport{}, errors.New("db error"))
+
+		processed, err := exportUsecase.ProcessPendingExport(ctx)
+		require.Error(t, err)
+		require.False(t, processed)
+	})
+}
+
+func TestExportUsecase_PurgeExpiredExports(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockExportRepo := mocks.NewMockExportRepositoryInterface(ctrl)
+
+	exportUsecase := NewExportUsecase(ExportUsecaseOptions{
+		ExportRepository: mockExportRepo,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockExportRepo.EXPECT().DeleteExpiredExports(ctx).Times(1).Return(int64(2), nil)
+
+		deleted, err := exportUsecase.PurgeExpiredExports(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), deleted)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mockExportRepo.EXPECT().DeleteExpiredExports(ctx).Times(1).Return(int64(0), errors.New("db error"))
+
+		deleted, err := exportUsecase.PurgeExpiredExports(ctx)
+		require.Error(t, err)
+		require.Zero(t, deleted)
+	})
+}
+
+// readExportFile decodes the JSON document of an export archive into v.
+func readExportFile(t *testing.T, file *zip.File, v interface{}) {
+	f, err := file.Open()
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, json.NewDecoder(f).Decode(v))
+}
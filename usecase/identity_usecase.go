@@ -0,0 +1,446 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+type IdentityUsecase struct {
+	UserRepository         repository.UserRepositoryInterface
+	RoleRepository         repository.RoleRepositoryInterface
+	IdentityRepository     repository.IdentityRepositoryInterface
+	AuthUtil               utils.AuthInterface
+	OIDCUtil               utils.OIDCInterface
+	SAMLUtil               utils.SAMLInterface
+	PhoneUtil              utils.PhoneInterface
+	DeviceRecognizer       DeviceRecognizerInterface
+	AuthRequestDuration    time.Duration
+	ReauthenticationWindow time.Duration
+}
+
+type IdentityUsecaseOptions struct {
+	UserRepository         repository.UserRepositoryInterface
+	RoleRepository         repository.RoleRepositoryInterface
+	IdentityRepository     repository.IdentityRepositoryInterface
+	AuthUtil               utils.AuthInterface
+	OIDCUtil               utils.OIDCInterface
+	SAMLUtil               utils.SAMLInterface
+	PhoneUtil              utils.PhoneInterface
+	DeviceRecognizer       DeviceRecognizerInterface
+	AuthRequestDuration    time.Duration
+	ReauthenticationWindow time.Duration
+}
+
+func NewIdentityUsecase(opts IdentityUsecaseOptions) *IdentityUsecase {
+	u := &IdentityUsecase{
+		UserRepository:         opts.UserRepository,
+		RoleRepository:         opts.RoleRepository,
+		IdentityRepository:     opts.IdentityRepository,
+		AuthUtil:               opts.AuthUtil,
+		OIDCUtil:               opts.OIDCUtil,
+		SAMLUtil:               opts.SAMLUtil,
+		PhoneUtil:              opts.PhoneUtil,
+		DeviceRecognizer:       opts.DeviceRecognizer,
+		AuthRequestDuration:    opts.AuthRequestDuration,
+		ReauthenticationWindow: opts.ReauthenticationWindow,
+	}
+
+	return u
+}
+
+// StartOIDCLogin returns the URL of the provider the user has to be
+// redirected to in order to log in with a linked identity, and the binding
+// the browser has to keep until it comes back.
+func (u IdentityUsecase) StartOIDCLogin(ctx context.Context, provider string) (string, string, error) {
+	return u.startAuthRequest(ctx, provider, null.Int{})
+}
+
+// StartIdentityLink returns the URL of the provider the user has to be
+// redirected to in order to link their identity there to their account, and
+// the binding the browser has to keep until it comes back.
+func (u IdentityUsecase) StartIdentityLink(ctx context.Context, userId int64, authTime time.Time, provider string) (string, string, error) {
+	if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+		return "", "", err
+	}
+
+	return u.startAuthRequest(ctx, provider, null.IntFrom(userId))
+}
+
+// HandleOIDCCallback completes the redirect started by StartOIDCLogin or
+// StartIdentityLink. The state must belong to a pending request for the
+// provider, started in the browser holding binding, and the ID token must
+// carry its nonce. The user of the identity is logged in from client, linking
+// it first if the request was started for that.
+func (u IdentityUsecase) HandleOIDCCallback(ctx context.Context, provider, code, state, binding string, client model.LoginClient) (model.User, string, error) {
+	request, err := u.IdentityRepository.ConsumeOIDCAuthRequest(ctx, utils.HashToken(state))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_OIDC_STATE", "Invalid Or Expired Login Request.")
+		}
+
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if request.Provider != provider || time.Now().After(request.ExpiresAt) {
+		err = fmt.Errorf("oidc auth request %d is expired or not for provider %s", request.Id, provider)
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_OIDC_STATE", "Invalid Or Expired Login Request.")
+	}
+
+	// Without the binding, a state lured out of one browser could complete
+	// the login or link in another.
+	if binding == "" || subtle.ConstantTimeCompare([]byte(utils.HashToken(binding)), []byte(request.BindingHash)) != 1 {
+		err = fmt.Errorf("oidc auth request %d was started in another browser", request.Id)
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_OIDC_STATE", "Invalid Or Expired Login Request.")
+	}
+
+	claims, err := u.OIDCUtil.Exchange(ctx, provider, code, request.CodeVerifier)
+	if err == nil && claims.Nonce != request.Nonce {
+		err = fmt.Errorf("id token nonce does not match oidc auth request %d", request.Id)
+	}
+
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "INVALID_ID_TOKEN", "Could Not Verify The Identity Provider's Response.")
+	}
+
+	var identity model.UserIdentity
+	if request.UserId.Valid {
+		identity, err = u.linkIdentity(ctx, request.UserId.Int64, provider, claims)
+	} else {
+		identity, err = u.IdentityRepository.GetIdentity(ctx, provider, claims.Subject)
+		if err != nil {
+			log.Error(err)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "IDENTITY_NOT_LINKED",
+					"Log In With Your Phone Number And Link This Account First.")
+			} else {
+				err = utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+			}
+		}
+	}
+
+	if err != nil {
+		return model.User{}, "", err
+	}
+
+	return u.loginIdentity(ctx, identity, client)
+}
+
+// SAMLMetadata returns the metadata of our service provider at provider.
+func (u IdentityUsecase) SAMLMetadata(ctx context.Context, provider string) ([]byte, error) {
+	metadata, err := u.SAMLUtil.Metadata(provider)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, utils.ErrUnknownSAMLProvider) {
+			return nil, utils.WrapWithKey(err, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider.")
+		}
+
+		return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return metadata, nil
+}
+
+// StartSAMLLogin returns the URL of the SAML provider the user has to be
+// redirected to in order to log in. The ID of the authentication request is
+// stored until the response comes back.
+func (u IdentityUsecase) StartSAMLLogin(ctx context.Context, provider string) (string, error) {
+	requestId, redirectURL, err := u.SAMLUtil.AuthnRequest(provider)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, utils.ErrUnknownSAMLProvider) {
+			return "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider.")
+		}
+
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if _, err = u.IdentityRepository.DeleteExpiredSAMLAuthRequests(ctx); err != nil {
+		log.Warn(err)
+	}
+
+	request := model.SAMLAuthRequest{
+		RequestId: requestId,
+		Provider:  provider,
+		ExpiresAt: time.Now().Add(u.AuthRequestDuration),
+	}
+
+	if err = u.IdentityRepository.CreateSAMLAuthRequest(ctx, request); err != nil {
+		log.Error(err)
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return redirectURL, nil
+}
+
+// HandleSAMLResponse completes the login started by StartSAMLLogin. The
+// response must answer a pending request for the provider, which is consumed
+// so that the response can not be replayed, and carry a valid assertion. Users
+// are provisioned from the assertion's attributes on their first login.
+func (u IdentityUsecase) HandleSAMLResponse(ctx context.Context, provider, samlResponse string, client model.LoginClient) (model.User, string, error) {
+	requestId, err := u.SAMLUtil.RequestID(samlResponse)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_SAML_RESPONSE", "Invalid Or Expired Login Request.")
+	}
+
+	request, err := u.IdentityRepository.ConsumeSAMLAuthRequest(ctx, requestId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_SAML_RESPONSE", "Invalid Or Expired Login Request.")
+		}
+
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if request.Provider != provider || time.Now().After(request.ExpiresAt) {
+		err = fmt.Errorf("saml auth request %d is expired or not for provider %s", request.Id, provider)
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_SAML_RESPONSE", "Invalid Or Expired Login Request.")
+	}
+
+	assertion, err := u.SAMLUtil.ParseResponse(provider, samlResponse, request.RequestId)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "INVALID_SAML_ASSERTION", "Could Not Verify The Identity Provider's Response.")
+	}
+
+	identity, err := u.IdentityRepository.GetIdentity(ctx, provider, assertion.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		user := model.User{
+			FullName:    assertion.FullName,
+			PhoneNumber: assertion.PhoneNumber,
+			Email:       null.StringFrom(assertion.Email),
+		}
+
+		identity, err = provisionUser(ctx, u.UserRepository, u.IdentityRepository, u.PhoneUtil, user,
+			model.UserIdentity{Provider: provider, Subject: assertion.Subject})
+		if err != nil {
+			return model.User{}, "", err
+		}
+	} else if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return u.loginIdentity(ctx, identity, client)
+}
+
+func (u IdentityUsecase) ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error) {
+	identities, err := u.IdentityRepository.ListIdentities(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity removes the user's identity at an external provider. Users
+// provisioned from the LDAP directory or a SAML provider have no password and
+// keep their identities, they have no other way to log in.
+func (u IdentityUsecase) UnlinkIdentity(ctx context.Context, userId int64, provider string) error {
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if user.Password == "" {
+		err = fmt.Errorf("user %d has no password and can not unlink identities", userId)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "MANAGED_ACCOUNT",
+			"Accounts Provisioned By An Identity Provider Can Not Unlink Identities.")
+	}
+
+	if err = u.IdentityRepository.DeleteIdentity(ctx, userId, provider); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// startAuthRequest stores the state, browser binding, nonce and PKCE code
+// verifier of a new redirect to provider. userId is set when the identity is
+// to be linked.
+func (u IdentityUsecase) startAuthRequest(ctx context.Context, provider string, userId null.Int) (string, string, error) {
+	state, stateHash, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	binding, bindingHash, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	nonce, _, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	codeVerifier, _, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	authURL, err := u.OIDCUtil.AuthCodeURL(provider, state, nonce, codeVerifier)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, utils.ErrUnknownOIDCProvider) {
+			return "", "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusNotFound), "UNKNOWN_IDENTITY_PROVIDER", "Unknown Identity Provider.")
+		}
+
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if _, err = u.IdentityRepository.DeleteExpiredOIDCAuthRequests(ctx); err != nil {
+		log.Warn(err)
+	}
+
+	request := model.OIDCAuthRequest{
+		StateHash:    stateHash,
+		BindingHash:  bindingHash,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		UserId:       userId,
+		ExpiresAt:    time.Now().Add(u.AuthRequestDuration),
+	}
+
+	if err = u.IdentityRepository.CreateOIDCAuthRequest(ctx, request); err != nil {
+		log.Error(err)
+		return "", "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return authURL, binding, nil
+}
+
+// linkIdentity links the identity to the user unless it already belongs to
+// another account or the user has linked another identity at the provider.
+func (u IdentityUsecase) linkIdentity(ctx context.Context, userId int64, provider string, claims utils.OIDCIdentity) (model.UserIdentity, error) {
+	identity, err := u.IdentityRepository.GetIdentity(ctx, provider, claims.Subject)
+	switch {
+	case err == nil && identity.UserId == userId:
+		return identity, nil
+	case err == nil:
+		err = fmt.Errorf("identity %d is linked to user %d", identity.Id, identity.UserId)
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "IDENTITY_ALREADY_LINKED",
+			"Identity Is Already Linked To An Account.")
+	case !errors.Is(err, sql.ErrNoRows):
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	identities, err := u.IdentityRepository.ListIdentities(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	for _, linked := range identities {
+		if linked.Provider == provider {
+			err = fmt.Errorf("user %d already linked identity %d at %s", userId, linked.Id, provider)
+			log.Error(err)
+			return model.UserIdentity{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "IDENTITY_ALREADY_LINKED",
+				"Identity Is Already Linked To An Account.")
+		}
+	}
+
+	identity = model.UserIdentity{
+		UserId:   userId,
+		Provider: provider,
+		Subject:  claims.Subject,
+	}
+
+	if claims.Email != "" {
+		identity.Email = null.StringFrom(utils.NormalizeEmail(claims.Email))
+	}
+
+	identity, err = u.IdentityRepository.CreateIdentity(ctx, identity)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return identity, nil
+}
+
+// loginIdentity issues a token for the user of an identity that has just been
+// verified by its provider, tied to the device of client. The provider
+// redirects the browser back, which can not send a DPoP proof, so the token is
+// a bearer token.
+func (u IdentityUsecase) loginIdentity(ctx context.Context, identity model.UserIdentity, client model.LoginClient) (model.User, string, error) {
+	user, err := u.UserRepository.GetUserById(ctx, identity.UserId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return model.User{}, "", err
+	}
+
+	user.Roles, err = u.RoleRepository.GetUserRoles(ctx, user.Id)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	device, err := u.DeviceRecognizer.RecognizeDevice(ctx, user, client)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	jwt, err := u.AuthUtil.GenerateJWTToken(user, []string{utils.AuthMethodFederated}, "", device.Id)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.IdentityRepository.TouchIdentity(ctx, identity.Id); err != nil {
+		log.Warn(err)
+	}
+
+	if err = u.UserRepository.IncrementUserLoginCount(ctx, user.Id); err != nil {
+		log.Warn(err)
+	}
+
+	return user, jwt, nil
+}
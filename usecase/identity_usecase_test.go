@@ -0,0 +1,674 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestIdentityUsecase_StartOIDCLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockOIDCUtil := mockUtils.NewMockOIDCInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		IdentityRepository:  mockIdentityRepo,
+		OIDCUtil:            mockOIDCUtil,
+		AuthRequestDuration: 10 * time.Minute,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		var state, nonce, codeVerifier, bindingHash string
+		mockOIDCUtil.EXPECT().AuthCodeURL("google", gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_, s, n, v string) (string, error) {
+				state, nonce, codeVerifier = s, n, v
+				return "https://accounts.example.com/authorize?state=" + s, nil
+			})
+		mockIdentityRepo.EXPECT().DeleteExpiredOIDCAuthRequests(ctx).Times(1).Return(int64(0), nil)
+		mockIdentityRepo.EXPECT().CreateOIDCAuthRequest(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, request model.OIDCAuthRequest) error {
+				require.Equal(t, utils.HashToken(state), request.StateHash)
+				bindingHash = request.BindingHash
+				require.Equal(t, "google", request.Provider)
+				require.Equal(t, nonce, request.Nonce)
+				require.Equal(t, codeVerifier, request.CodeVerifier)
+				require.False(t, request.UserId.Valid)
+				require.WithinDuration(t, time.Now().Add(10*time.Minute), request.ExpiresAt, time.Minute)
+				return nil
+			})
+
+		authURL, binding, err := identityUsecase.StartOIDCLogin(ctx, "google")
+		require.NoError(t, err)
+		require.Equal(t, "https://accounts.example.com/authorize?state="+state, authURL)
+		require.Equal(t, utils.HashToken(binding), bindingHash)
+		require.NotEqual(t, state, nonce)
+		require.NotEqual(t, state, binding)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		mockOIDCUtil.EXPECT().AuthCodeURL("unknown", gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("", utils.ErrUnknownOIDCProvider)
+
+		_, _, err := identityUsecase.StartOIDCLogin(ctx, "unknown")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+		require.Equal(t, "UNKNOWN_IDENTITY_PROVIDER", utils.GetKey(err))
+	})
+
+	t.Run("failed - create auth request return error", func(t *testing.T) {
+		mockOIDCUtil.EXPECT().AuthCodeURL("google", gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("https://accounts.example.com/authorize", nil)
+		mockIdentityRepo.EXPECT().DeleteExpiredOIDCAuthRequests(ctx).Times(1).Return(int64(0), nil)
+		mockIdentityRepo.EXPECT().CreateOIDCAuthRequest(ctx, gomock.Any()).Times(1).Return(errors.New("db error"))
+
+		_, _, err := identityUsecase.StartOIDCLogin(ctx, "google")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestIdentityUsecase_StartIdentityLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockOIDCUtil := mockUtils.NewMockOIDCInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		IdentityRepository:     mockIdentityRepo,
+		OIDCUtil:               mockOIDCUtil,
+		AuthRequestDuration:    10 * time.Minute,
+		ReauthenticationWindow: 10 * time.Minute,
+	})
+
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		mockOIDCUtil.EXPECT().AuthCodeURL("google", gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return("https://accounts.example.com/authorize", nil)
+		mockIdentityRepo.EXPECT().DeleteExpiredOIDCAuthRequests(ctx).Times(1).Return(int64(0), nil)
+		mockIdentityRepo.EXPECT().CreateOIDCAuthRequest(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, request model.OIDCAuthRequest) error {
+				require.Equal(t, null.IntFrom(userId), request.UserId)
+				require.NotEmpty(t, request.BindingHash)
+				return nil
+			})
+
+		authURL, binding, err := identityUsecase.StartIdentityLink(ctx, userId, time.Now(), "google")
+		require.NoError(t, err)
+		require.Equal(t, "https://accounts.example.com/authorize", authURL)
+		require.NotEmpty(t, binding)
+	})
+
+	t.Run("failed - authentication is not recent", func(t *testing.T) {
+		_, _, err := identityUsecase.StartIdentityLink(ctx, userId, time.Now().Add(-time.Hour), "google")
+		require.Error(t, err)
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+}
+
+func TestIdentityUsecase_HandleOIDCCallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+	mockOIDCUtil := mockUtils.NewMockOIDCInterface(ctrl)
+	mockDeviceRecognizer := mocks.NewMockDeviceRecognizerInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		UserRepository:     mockUserRepo,
+		RoleRepository:     mockRoleRepo,
+		IdentityRepository: mockIdentityRepo,
+		AuthUtil:           mockAuthUtil,
+		OIDCUtil:           mockOIDCUtil,
+		DeviceRecognizer:   mockDeviceRecognizer,
+	})
+
+	userId := int64(10)
+	client := model.LoginClient{UserAgent: "Mozilla/5.0", IPAddress: "192.0.2.1"}
+	device := model.Device{Id: 7, UserId: userId}
+	state := "state"
+	binding := "binding"
+	code := "code"
+	request := model.OIDCAuthRequest{
+		Id:           1,
+		StateHash:    utils.HashToken(state),
+		BindingHash:  utils.HashToken(binding),
+		Provider:     "google",
+		Nonce:        "nonce",
+		CodeVerifier: "verifier",
+		ExpiresAt:    time.Now().Add(5 * time.Minute),
+	}
+	linkRequest := request
+	linkRequest.UserId = null.IntFrom(userId)
+	claims := utils.OIDCIdentity{Subject: "1234", Email: "John@Example.com", EmailVerified: true, Nonce: "nonce"}
+	identity := model.UserIdentity{Id: 4, UserId: userId, Provider: "google", Subject: "1234"}
+	user := model.User{Id: userId, FullName: "John Doe", Status: model.UserStatusActive}
+	roles := []string{model.RoleUser}
+
+	t.Run("success - login", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), []string{utils.AuthMethodFederated}, "", device.Id).Times(1).Return("jwt", nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, identity.Id).Times(1).Return(nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, userId).Times(1).Return(nil)
+
+		loggedIn, jwt, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+		require.Equal(t, userId, loggedIn.Id)
+		require.Equal(t, roles, loggedIn.Roles)
+	})
+
+	t.Run("success - link", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(linkRequest, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockIdentityRepo.EXPECT().ListIdentities(ctx, userId).Times(1).Return([]model.UserIdentity{}, nil)
+		mockIdentityRepo.EXPECT().CreateIdentity(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, created model.UserIdentity) (model.UserIdentity, error) {
+				require.Equal(t, userId, created.UserId)
+				require.Equal(t, "google", created.Provider)
+				require.Equal(t, claims.Subject, created.Subject)
+				require.Equal(t, null.StringFrom("john@example.com"), created.Email)
+				created.Id = identity.Id
+				return created, nil
+			})
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), []string{utils.AuthMethodFederated}, "", device.Id).Times(1).Return("jwt", nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, identity.Id).Times(1).Return(nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, userId).Times(1).Return(nil)
+
+		_, jwt, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - unknown state", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(model.OIDCAuthRequest{}, sql.ErrNoRows)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_OIDC_STATE", utils.GetKey(err))
+	})
+
+	t.Run("failed - state of another provider", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "microsoft", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_OIDC_STATE", utils.GetKey(err))
+	})
+
+	t.Run("failed - started in another browser", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, "other", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_OIDC_STATE", utils.GetKey(err))
+	})
+
+	t.Run("failed - no binding", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, "", client)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_OIDC_STATE", utils.GetKey(err))
+	})
+
+	t.Run("failed - state expired", func(t *testing.T) {
+		expired := request
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(expired, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_OIDC_STATE", utils.GetKey(err))
+	})
+
+	t.Run("failed - id token verification failed", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(utils.OIDCIdentity{}, errors.New("invalid signature"))
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_ID_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - nonce mismatch", func(t *testing.T) {
+		replayed := claims
+		replayed.Nonce = "other"
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(replayed, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_ID_TOKEN", utils.GetKey(err))
+	})
+
+	t.Run("failed - identity not linked", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "IDENTITY_NOT_LINKED", utils.GetKey(err))
+	})
+
+	t.Run("failed - identity linked to another user", func(t *testing.T) {
+		other := identity
+		other.UserId = 11
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(linkRequest, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(other, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "IDENTITY_ALREADY_LINKED", utils.GetKey(err))
+	})
+
+	t.Run("failed - user already linked another identity at the provider", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(linkRequest, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockIdentityRepo.EXPECT().ListIdentities(ctx, userId).Times(1).
+			Return([]model.UserIdentity{{Id: 5, UserId: userId, Provider: "google", Subject: "5678"}}, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, "IDENTITY_ALREADY_LINKED", utils.GetKey(err))
+	})
+
+	t.Run("failed - user is suspended", func(t *testing.T) {
+		suspended := user
+		suspended.Status = model.UserStatusSuspended
+		mockIdentityRepo.EXPECT().ConsumeOIDCAuthRequest(ctx, utils.HashToken(state)).Times(1).Return(request, nil)
+		mockOIDCUtil.EXPECT().Exchange(ctx, "google", code, request.CodeVerifier).Times(1).Return(claims, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "google", claims.Subject).Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(suspended, nil)
+
+		_, _, err := identityUsecase.HandleOIDCCallback(ctx, "google", code, state, binding, client)
+		require.Error(t, err)
+		require.Equal(t, "ACCOUNT_SUSPENDED", utils.GetKey(err))
+	})
+}
+
+func TestIdentityUsecase_ListIdentities(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		IdentityRepository: mockIdentityRepo,
+	})
+
+	userId := int64(10)
+
+	t.Run("success", func(t *testing.T) {
+		identities := []model.UserIdentity{{Id: 4, UserId: userId, Provider: "google", Subject: "1234"}}
+		mockIdentityRepo.EXPECT().ListIdentities(ctx, userId).Times(1).Return(identities, nil)
+
+		result, err := identityUsecase.ListIdentities(ctx, userId)
+		require.NoError(t, err)
+		require.Equal(t, identities, result)
+	})
+
+	t.Run("failed - list identities return error", func(t *testing.T) {
+		mockIdentityRepo.EXPECT().ListIdentities(ctx, userId).Times(1).Return(nil, errors.New("db error"))
+
+		_, err := identityUsecase.ListIdentities(ctx, userId)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestIdentityUsecase_UnlinkIdentity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		UserRepository:     mockUserRepo,
+		IdentityRepository: mockIdentityRepo,
+	})
+
+	userId := int64(10)
+	user := model.User{Id: userId, Password: "hashed"}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockIdentityRepo.EXPECT().DeleteIdentity(ctx, userId, "google").Times(1).Return(nil)
+
+		err := identityUsecase.UnlinkIdentity(ctx, userId, "google")
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - identity not linked", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockIdentityRepo.EXPECT().DeleteIdentity(ctx, userId, "google").Times(1).Return(sql.ErrNoRows)
+
+		err := identityUsecase.UnlinkIdentity(ctx, userId, "google")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - provisioned user", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{Id: userId}, nil)
+
+		err := identityUsecase.UnlinkIdentity(ctx, userId, model.IdentityProviderLDAP)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "MANAGED_ACCOUNT", utils.GetKey(err))
+	})
+
+	t.Run("failed - db error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{}, errors.New("db error"))
+
+		err := identityUsecase.UnlinkIdentity(ctx, userId, "google")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestIdentityUsecase_SAMLMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockSAMLUtil := mockUtils.NewMockSAMLInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		SAMLUtil: mockSAMLUtil,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().Metadata("acme").Times(1).Return([]byte("<EntityDescriptor/>"), nil)
+
+		metadata, err := identityUsecase.SAMLMetadata(ctx, "acme")
+		require.NoError(t, err)
+		require.Equal(t, []byte("<EntityDescriptor/>"), metadata)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().Metadata("unknown").Times(1).Return(nil, utils.ErrUnknownSAMLProvider)
+
+		_, err := identityUsecase.SAMLMetadata(ctx, "unknown")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+}
+
+func TestIdentityUsecase_StartSAMLLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockSAMLUtil := mockUtils.NewMockSAMLInterface(ctrl)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		IdentityRepository:  mockIdentityRepo,
+		SAMLUtil:            mockSAMLUtil,
+		AuthRequestDuration: 10 * time.Minute,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().AuthnRequest("acme").Times(1).Return("id-123", "https://idp.example.com/sso?SAMLRequest=request", nil)
+		mockIdentityRepo.EXPECT().DeleteExpiredSAMLAuthRequests(ctx).Times(1).Return(int64(0), errors.New("db error"))
+		mockIdentityRepo.EXPECT().CreateSAMLAuthRequest(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, request model.SAMLAuthRequest) error {
+				require.Equal(t, "id-123", request.RequestId)
+				require.Equal(t, "acme", request.Provider)
+				require.WithinDuration(t, time.Now().Add(10*time.Minute), request.ExpiresAt, time.Minute)
+				return nil
+			})
+
+		redirectURL, err := identityUsecase.StartSAMLLogin(ctx, "acme")
+		require.NoError(t, err)
+		require.Equal(t, "https://idp.example.com/sso?SAMLRequest=request", redirectURL)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().AuthnRequest("unknown").Times(1).Return("", "", utils.ErrUnknownSAMLProvider)
+
+		_, err := identityUsecase.StartSAMLLogin(ctx, "unknown")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+		require.Equal(t, "UNKNOWN_IDENTITY_PROVIDER", utils.GetKey(err))
+	})
+
+	t.Run("failed - create auth request return error", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().AuthnRequest("acme").Times(1).Return("id-123", "https://idp.example.com/sso", nil)
+		mockIdentityRepo.EXPECT().DeleteExpiredSAMLAuthRequests(ctx).Times(1).Return(int64(0), nil)
+		mockIdentityRepo.EXPECT().CreateSAMLAuthRequest(ctx, gomock.Any()).Times(1).Return(errors.New("db error"))
+
+		_, err := identityUsecase.StartSAMLLogin(ctx, "acme")
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestIdentityUsecase_HandleSAMLResponse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockIdentityRepo := mocks.NewMockIdentityRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+	mockSAMLUtil := mockUtils.NewMockSAMLInterface(ctrl)
+	mockDeviceRecognizer := mocks.NewMockDeviceRecognizerInterface(ctrl)
+
+	phone, err := utils.InitPhone(utils.PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	identityUsecase := NewIdentityUsecase(IdentityUsecaseOptions{
+		UserRepository:     mockUserRepo,
+		RoleRepository:     mockRoleRepo,
+		IdentityRepository: mockIdentityRepo,
+		AuthUtil:           mockAuthUtil,
+		SAMLUtil:           mockSAMLUtil,
+		PhoneUtil:          phone,
+		DeviceRecognizer:   mockDeviceRecognizer,
+	})
+
+	client := model.LoginClient{UserAgent: "Mozilla/5.0", IPAddress: "192.0.2.1"}
+	device := model.Device{Id: 7, UserId: 10}
+
+	request := model.SAMLAuthRequest{Id: 1, RequestId: "id-123", Provider: "acme", ExpiresAt: time.Now().Add(5 * time.Minute)}
+	assertion := utils.SAMLIdentity{Subject: "00u1a2b3c4", FullName: "John Doe", Email: "John@Example.com", PhoneNumber: "+6281234567890"}
+	identity := model.UserIdentity{Id: 5, UserId: 10, Provider: "acme", Subject: "00u1a2b3c4"}
+	user := model.User{Id: 10, FullName: "John Doe", PhoneNumber: "+6281234567890", Status: model.UserStatusActive}
+
+	t.Run("success", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(request, nil)
+		mockSAMLUtil.EXPECT().ParseResponse("acme", "response", "id-123").Times(1).Return(assertion, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "acme", "00u1a2b3c4").Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, int64(10)).Times(1).Return([]string{model.RoleUser}, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), []string{utils.AuthMethodFederated}, "", device.Id).Times(1).Return("jwt", nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, int64(10)).Times(1).Return(nil)
+
+		resUser, jwt, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), resUser.Id)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("success - provisioned on first login", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(request, nil)
+		mockSAMLUtil.EXPECT().ParseResponse("acme", "response", "id-123").Times(1).Return(assertion, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "acme", "00u1a2b3c4").Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, "+6281234567890").Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, "john@example.com").Times(1).Return(false, nil)
+		mockIdentityRepo.EXPECT().ProvisionUser(ctx, gomock.Any(), model.RoleUser, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, newUser model.User, _ string, newIdentity model.UserIdentity) (model.UserIdentity, error) {
+				require.Equal(t, "John Doe", newUser.FullName)
+				require.Equal(t, null.StringFrom("john@example.com"), newUser.Email)
+				require.Equal(t, "acme", newIdentity.Provider)
+				require.Equal(t, "00u1a2b3c4", newIdentity.Subject)
+
+				return identity, nil
+			})
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, int64(10)).Times(1).Return([]string{model.RoleUser}, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), []string{utils.AuthMethodFederated}, "", device.Id).Times(1).Return("jwt", nil)
+		mockIdentityRepo.EXPECT().TouchIdentity(ctx, int64(5)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, int64(10)).Times(1).Return(errors.New("db error"))
+
+		_, jwt, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - malformed response", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("", errors.New("not base64"))
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_SAML_RESPONSE", utils.GetKey(err))
+	})
+
+	t.Run("failed - replayed response", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(model.SAMLAuthRequest{}, sql.ErrNoRows)
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_SAML_RESPONSE", utils.GetKey(err))
+	})
+
+	t.Run("failed - request for another provider", func(t *testing.T) {
+		other := request
+		other.Provider = "other"
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(other, nil)
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("failed - expired request", func(t *testing.T) {
+		expired := request
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(expired, nil)
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("failed - invalid assertion", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(request, nil)
+		mockSAMLUtil.EXPECT().ParseResponse("acme", "response", "id-123").Times(1).Return(utils.SAMLIdentity{}, errors.New("signature mismatch"))
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_SAML_ASSERTION", utils.GetKey(err))
+	})
+
+	t.Run("failed - phone number of a local user", func(t *testing.T) {
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(request, nil)
+		mockSAMLUtil.EXPECT().ParseResponse("acme", "response", "id-123").Times(1).Return(assertion, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "acme", "00u1a2b3c4").Times(1).Return(model.UserIdentity{}, sql.ErrNoRows)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, "+6281234567890").Times(1).Return(true, nil)
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "ACCOUNT_CONFLICT", utils.GetKey(err))
+	})
+
+	t.Run("failed - user is suspended", func(t *testing.T) {
+		suspended := user
+		suspended.Status = model.UserStatusSuspended
+		mockSAMLUtil.EXPECT().RequestID("response").Times(1).Return("id-123", nil)
+		mockIdentityRepo.EXPECT().ConsumeSAMLAuthRequest(ctx, "id-123").Times(1).Return(request, nil)
+		mockSAMLUtil.EXPECT().ParseResponse("acme", "response", "id-123").Times(1).Return(assertion, nil)
+		mockIdentityRepo.EXPECT().GetIdentity(ctx, "acme", "00u1a2b3c4").Times(1).Return(identity, nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, int64(10)).Times(1).Return(suspended, nil)
+
+		_, _, err := identityUsecase.HandleSAMLResponse(ctx, "acme", "response", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+type ImpersonationUsecase struct {
+	UserRepository          repository.UserRepositoryInterface
+	RoleRepository          repository.RoleRepositoryInterface
+	ImpersonationRepository repository.ImpersonationRepositoryInterface
+	AuthUtil                utils.AuthInterface
+	TokenDuration           time.Duration
+}
+
+type ImpersonationUsecaseOptions struct {
+	UserRepository          repository.UserRepositoryInterface
+	RoleRepository          repository.RoleRepositoryInterface
+	ImpersonationRepository repository.ImpersonationRepositoryInterface
+	AuthUtil                utils.AuthInterface
+	TokenDuration           time.Duration
+}
+
+func NewImpersonationUsecase(opts ImpersonationUsecaseOptions) *ImpersonationUsecase {
+	u := &ImpersonationUsecase{
+		UserRepository:          opts.UserRepository,
+		RoleRepository:          opts.RoleRepository,
+		ImpersonationRepository: opts.ImpersonationRepository,
+		AuthUtil:                opts.AuthUtil,
+		TokenDuration:           opts.TokenDuration,
+	}
+
+	return u
+}
+
+// StartImpersonation opens a session in which the admin identified by
+// actorId acts as userId and returns the token of that session. Admins
+// cannot be impersonated.
+func (u ImpersonationUsecase) StartImpersonation(ctx context.Context, actorId, userId int64, payload generated.ImpersonateUserJSONRequestBody) (string, model.Impersonation, error) {
+	if actorId == userId {
+		err := fmt.Errorf("admin cannot impersonate themselves")
+		log.Error(err)
+		return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusBadRequest), "")
+	}
+
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return "", model.Impersonation{}, err
+	}
+
+	user.Roles, err = u.RoleRepository.GetUserRoles(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	for _, role := range user.Roles {
+		if role == model.RoleAdmin {
+			err = fmt.Errorf("user %d is an admin and cannot be impersonated", userId)
+			log.Error(err)
+			return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "Admins Cannot Be Impersonated.")
+		}
+	}
+
+	impersonation := model.Impersonation{
+		ActorId:   null.IntFrom(actorId),
+		UserId:    userId,
+		Reason:    payload.Reason,
+		ExpiresAt: time.Now().Add(u.TokenDuration),
+	}
+
+	impersonation.Id, err = u.ImpersonationRepository.CreateImpersonation(ctx, impersonation)
+	if err != nil {
+		log.Error(err)
+		return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	jwt, err := u.AuthUtil.GenerateImpersonationToken(user, impersonation)
+	if err != nil {
+		log.Error(err)
+		return "", model.Impersonation{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return jwt, impersonation, nil
+}
+
+// RecordImpersonatedRequest appends a request made with an impersonation
+// token to the audit log.
+func (u ImpersonationUsecase) RecordImpersonatedRequest(ctx context.Context, auditLog model.ImpersonationAuditLog) error {
+	if err := u.ImpersonationRepository.CreateAuditLog(ctx, auditLog); err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestImpersonationUsecase_StartImpersonation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockImpersonationRepo := mocks.NewMockImpersonationRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+
+	impersonationUsecase := NewImpersonationUsecase(ImpersonationUsecaseOptions{
+		UserRepository:          mockUserRepo,
+		RoleRepository:          mockRoleRepo,
+		ImpersonationRepository: mockImpersonationRepo,
+		AuthUtil:                mockAuthUtil,
+		TokenDuration:           15 * time.Minute,
+	})
+
+	actorId := int64(1)
+	userId := int64(10)
+	jwtToken := "thisisjwt"
+	payload := generated.ImpersonateUserJSONRequestBody{Reason: "debugging checkout"}
+	user := model.User{Id: userId, Status: model.UserStatusActive}
+	roles := []string{model.RoleUser}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockImpersonationRepo.EXPECT().CreateImpersonation(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, impersonation model.Impersonation) (int64, error) {
+				require.Equal(t, null.IntFrom(actorId), impersonation.ActorId)
+				require.Equal(t, userId, impersonation.UserId)
+				require.Equal(t, payload.Reason, impersonation.Reason)
+				require.WithinDuration(t, time.Now().Add(15*time.Minute), impersonation.ExpiresAt, time.Minute)
+				return 5, nil
+			})
+		mockAuthUtil.EXPECT().GenerateImpersonationToken(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(target model.User, impersonation model.Impersonation) (string, error) {
+				require.Equal(t, roles, target.Roles)
+				require.Equal(t, int64(5), impersonation.Id)
+				return jwtToken, nil
+			})
+
+		jwt, impersonation, err := impersonationUsecase.StartImpersonation(ctx, actorId, userId, payload)
+		require.NoError(t, err)
+		require.Equal(t, jwtToken, jwt)
+		require.Equal(t, int64(5), impersonation.Id)
+	})
+
+	t.Run("failed - impersonate themselves", func(t *testing.T) {
+		_, _, err := impersonationUsecase.StartImpersonation(ctx, actorId, actorId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, _, err := impersonationUsecase.StartImpersonation(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - user is suspended", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{Id: userId, Status: model.UserStatusSuspended}, nil)
+
+		_, _, err := impersonationUsecase.StartImpersonation(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, "ACCOUNT_SUSPENDED", utils.GetKey(err))
+	})
+
+	t.Run("failed - user is an admin", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return([]string{model.RoleAdmin, model.RoleUser}, nil)
+
+		_, _, err := impersonationUsecase.StartImpersonation(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+	})
+
+	t.Run("failed - create impersonation return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockImpersonationRepo.EXPECT().CreateImpersonation(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+
+		_, _, err := impersonationUsecase.StartImpersonation(ctx, actorId, userId, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestImpersonationUsecase_RecordImpersonatedRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockImpersonationRepo := mocks.NewMockImpersonationRepositoryInterface(ctrl)
+
+	impersonationUsecase := NewImpersonationUsecase(ImpersonationUsecaseOptions{
+		ImpersonationRepository: mockImpersonationRepo,
+	})
+
+	auditLog := model.ImpersonationAuditLog{ImpersonationId: 5, Method: http.MethodGet, Path: "/v1/users/profile", StatusCode: http.StatusOK}
+
+	t.Run("success", func(t *testing.T) {
+		mockImpersonationRepo.EXPECT().CreateAuditLog(ctx, auditLog).Times(1).Return(nil)
+
+		err := impersonationUsecase.RecordImpersonatedRequest(ctx, auditLog)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mockImpersonationRepo.EXPECT().CreateAuditLog(ctx, auditLog).Times(1).Return(errors.New("db error"))
+
+		err := impersonationUsecase.RecordImpersonatedRequest(ctx, auditLog)
+		require.Error(t, err)
+	})
+}
@@ -2,17 +2,107 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
 )
 
 type AuthUsecaseInterface interface {
-	LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, string, error)
+	LoginUser(ctx context.Context, payload generated.AuthLoginJSONRequestBody, keyThumbprint string, client model.LoginClient) (model.User, string, error)
+	AuthenticateAPIKey(ctx context.Context, key string, permissions []string) (model.User, error)
+	AuthorizeRoles(ctx context.Context, roles []string, permissions []string) error
+	CheckDevice(ctx context.Context, userId, deviceId int64) error
+	CheckUserStatus(ctx context.Context, userId int64) (model.User, error)
+	Reauthenticate(ctx context.Context, userId int64, payload generated.ReauthenticateJSONRequestBody, keyThumbprint string, deviceId int64) (string, error)
+	RevokeDevice(ctx context.Context, token string) error
+}
+
+// AuthenticatorInterface verifies the credentials of a login. It returns
+// sql.ErrNoRows when it does not know the user, any other error rejects the
+// login.
+type AuthenticatorInterface interface {
+	Authenticate(ctx context.Context, payload generated.AuthLoginJSONRequestBody) (model.User, error)
+}
+
+// SecurityNotifierInterface delivers security events to the user they
+// concern, such as a login from a new device.
+type SecurityNotifierInterface interface {
+	NotifySecurityEvent(ctx context.Context, event model.SecurityEvent) error
+}
+
+// DeviceRecognizerInterface remembers the device of each login and reports
+// new ones, for every way of logging in.
+type DeviceRecognizerInterface interface {
+	RecognizeDevice(ctx context.Context, user model.User, client model.LoginClient) (model.Device, error)
+}
+
+type CaptchaUsecaseInterface interface {
+	Challenge(ctx context.Context, attempt model.AuthAttempt, token string) error
+	RecordFailure(ctx context.Context, attempt model.AuthAttempt)
+	ResetFailures(ctx context.Context, attempt model.AuthAttempt)
 }
 
 type UserUsecaseInterface interface {
+	BootstrapAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error)
+	ChangePassword(ctx context.Context, userId int64, authTime time.Time, payload generated.ChangeUserPasswordJSONRequestBody) error
 	CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error)
+	DeleteUser(ctx context.Context, userId int64, authTime time.Time) error
 	GetUserProfile(ctx context.Context, userId int64) (model.User, error)
-	UpdateUserProfile(ctx context.Context, userId int64, payload generated.UpdateUserProfileJSONRequestBody) error
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+	UpdateUserProfile(ctx context.Context, userId int64, authTime time.Time, payload generated.UpdateUserProfileJSONRequestBody) error
+}
+
+type AdminUsecaseInterface interface {
+	ChangeUserStatus(ctx context.Context, actorId, userId int64, payload generated.ChangeUserStatusJSONRequestBody) error
+	GetUser(ctx context.Context, userId int64) (model.User, error)
+	ListUsers(ctx context.Context, filter model.UserFilter) ([]model.User, string, error)
+	UpdateUser(ctx context.Context, userId int64, payload generated.UpdateUserJSONRequestBody) error
+}
+
+type APIKeyUsecaseInterface interface {
+	CreateAPIKey(ctx context.Context, userId int64, payload generated.CreateApiKeyJSONRequestBody) (model.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userId int64) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userId, id int64) error
+}
+
+type EmailUsecaseInterface interface {
+	NotifySecurityEvent(ctx context.Context, event model.SecurityEvent) error
+	SendSecurityAlert(ctx context.Context, user model.User, event string) error
+	SendVerificationEmail(ctx context.Context, userId int64) error
+	VerifyEmail(ctx context.Context, token string) error
+}
+
+type ExportUsecaseInterface interface {
+	DownloadExport(ctx context.Context, exportId int64, expiresAt time.Time, signature string) ([]byte, error)
+	GetExport(ctx context.Context, userId, exportId int64) (model.UserExport, string, error)
+	ProcessPendingExport(ctx context.Context) (bool, error)
+	PurgeExpiredExports(ctx context.Context) (int64, error)
+	RequestExport(ctx context.Context, userId int64) (model.UserExport, error)
+}
+
+type IdentityUsecaseInterface interface {
+	HandleOIDCCallback(ctx context.Context, provider, code, state, binding string, client model.LoginClient) (model.User, string, error)
+	HandleSAMLResponse(ctx context.Context, provider, samlResponse string, client model.LoginClient) (model.User, string, error)
+	ListIdentities(ctx context.Context, userId int64) ([]model.UserIdentity, error)
+	SAMLMetadata(ctx context.Context, provider string) ([]byte, error)
+	StartIdentityLink(ctx context.Context, userId int64, authTime time.Time, provider string) (string, string, error)
+	StartOIDCLogin(ctx context.Context, provider string) (string, string, error)
+	StartSAMLLogin(ctx context.Context, provider string) (string, error)
+	UnlinkIdentity(ctx context.Context, userId int64, provider string) error
+}
+
+type ImpersonationUsecaseInterface interface {
+	RecordImpersonatedRequest(ctx context.Context, auditLog model.ImpersonationAuditLog) error
+	StartImpersonation(ctx context.Context, actorId, userId int64, payload generated.ImpersonateUserJSONRequestBody) (string, model.Impersonation, error)
+}
+
+type WebAuthnUsecaseInterface interface {
+	BeginLogin(ctx context.Context) (utils.WebAuthnRequestOptions, error)
+	BeginRegistration(ctx context.Context, userId int64, authTime time.Time) (utils.WebAuthnCreationOptions, error)
+	DeleteCredential(ctx context.Context, userId, id int64) error
+	FinishLogin(ctx context.Context, credentialId string, response utils.WebAuthnAssertionResponse, keyThumbprint string, client model.LoginClient) (model.User, string, error)
+	FinishRegistration(ctx context.Context, userId int64, name string, response utils.WebAuthnAttestationResponse) (model.WebAuthnCredential, error)
+	ListCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error)
 }
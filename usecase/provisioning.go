@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+// provisionUser creates the user of an identity logging in for the first time
+// from a source that vouches for its attributes, such as the LDAP directory.
+// Identities whose phone number or email already belong to a user are
+// rejected rather than taking that account over. The phone number is stored
+// in its E.164 form, identities without a valid one are rejected.
+func provisionUser(ctx context.Context, userRepo repository.UserRepositoryInterface, identityRepo repository.IdentityRepositoryInterface,
+	phone utils.PhoneInterface, user model.User, identity model.UserIdentity) (model.UserIdentity, error) {
+	phoneNumber, err := phone.Normalize(user.PhoneNumber)
+	if user.FullName == "" || err != nil {
+		err = fmt.Errorf("%s identity %s has no full name or valid phone number", identity.Provider, identity.Subject)
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "INCOMPLETE_IDENTITY",
+			"Your Account Lacks A Name Or Valid Phone Number.")
+	}
+	user.PhoneNumber = phoneNumber
+
+	exists, err := userRepo.PhoneNumberExists(ctx, user.PhoneNumber)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if !exists && user.Email.Valid && user.Email.String != "" {
+		// The source vouches for the address, there is nothing to verify.
+		user.Email = null.StringFrom(utils.NormalizeEmail(user.Email.String))
+		user.EmailVerifiedAt = null.TimeFrom(time.Now())
+
+		exists, err = userRepo.EmailExists(ctx, user.Email.String)
+		if err != nil {
+			log.Error(err)
+			return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+	} else {
+		user.Email = null.String{}
+	}
+
+	if exists {
+		err = fmt.Errorf("%s identity %s conflicts with an existing user", identity.Provider, identity.Subject)
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "ACCOUNT_CONFLICT",
+			"Your Phone Number Or Email Is Already Used By Another Account.")
+	}
+
+	identity.Email = user.Email
+	identity, err = identityRepo.ProvisionUser(ctx, user, model.RoleUser, identity)
+	if err != nil {
+		log.Error(err)
+		return model.UserIdentity{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return identity, nil
+}
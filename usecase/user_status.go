@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+)
+
+// userStatusTransitions lists, for every account status, the statuses it may
+// be moved to.
+var userStatusTransitions = map[string][]string{
+	model.UserStatusPending:   {model.UserStatusActive, model.UserStatusDeleted},
+	model.UserStatusActive:    {model.UserStatusSuspended, model.UserStatusLocked, model.UserStatusDeleted},
+	model.UserStatusSuspended: {model.UserStatusActive, model.UserStatusLocked, model.UserStatusDeleted},
+	model.UserStatusLocked:    {model.UserStatusActive, model.UserStatusDeleted},
+	model.UserStatusDeleted:   {model.UserStatusActive},
+}
+
+func canTransitionUserStatus(from, to string) bool {
+	for _, status := range userStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkUserStatus returns an error carrying a distinct key for every status
+// that does not allow the user to authenticate.
+func checkUserStatus(status string) error {
+	switch status {
+	case model.UserStatusActive:
+		return nil
+	case model.UserStatusPending:
+		return utils.WrapWithKey(errors.New("account is pending"), utils.ErrorCode(http.StatusForbidden),
+			"ACCOUNT_PENDING", "Account Is Pending Activation.")
+	case model.UserStatusSuspended:
+		return utils.WrapWithKey(errors.New("account is suspended"), utils.ErrorCode(http.StatusForbidden),
+			"ACCOUNT_SUSPENDED", "Account Is Suspended. Please Contact Administrator.")
+	case model.UserStatusLocked:
+		return utils.WrapWithKey(errors.New("account is locked"), utils.ErrorCode(http.StatusLocked),
+			"ACCOUNT_LOCKED", "Account Is Locked. Please Contact Administrator.")
+	case model.UserStatusDeleted:
+		return utils.WrapWithKey(errors.New("account is deleted"), utils.ErrorCode(http.StatusForbidden),
+			"ACCOUNT_DELETED", "Account Is Deleted.")
+	default:
+		return utils.WrapWithKey(errors.New("account status is unknown"), utils.ErrorCode(http.StatusForbidden),
+			"ACCOUNT_INACTIVE", "")
+	}
+}
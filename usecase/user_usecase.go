@@ -3,70 +3,147 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/model"
 	"github.com/SawitProRecruitment/UserService/repository"
 	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
 	"github.com/labstack/gommon/log"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserUsecase struct {
-	UserRepository repository.UserRepositoryInterface
-	CryptUtil      utils.CryptInterface
+	UserRepository         repository.UserRepositoryInterface
+	EmailUsecase           EmailUsecaseInterface
+	CryptUtil              utils.CryptInterface
+	DeletionGracePeriod    time.Duration
+	ReauthenticationWindow time.Duration
 }
 
 type UserUsecaseOptions struct {
-	UserRepository repository.UserRepositoryInterface
-	CryptUtil      utils.CryptInterface
+	UserRepository         repository.UserRepositoryInterface
+	EmailUsecase           EmailUsecaseInterface
+	CryptUtil              utils.CryptInterface
+	DeletionGracePeriod    time.Duration
+	ReauthenticationWindow time.Duration
 }
 
 func NewUserUsecase(opts UserUsecaseOptions) *UserUsecase {
 	u := &UserUsecase{
-		UserRepository: opts.UserRepository,
-		CryptUtil:      opts.CryptUtil,
+		UserRepository:         opts.UserRepository,
+		EmailUsecase:           opts.EmailUsecase,
+		CryptUtil:              opts.CryptUtil,
+		DeletionGracePeriod:    opts.DeletionGracePeriod,
+		ReauthenticationWindow: opts.ReauthenticationWindow,
 	}
 
 	return u
 }
 
 func (u UserUsecase) CreateUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error) {
-	existingUser, err := u.UserRepository.GetUserByPhoneNumber(ctx, payload.PhoneNumber)
-	if err != nil && err != sql.ErrNoRows {
+	payload, err := u.prepareNewUser(ctx, payload)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	roles := []string{model.RoleUser}
+	userId, err := u.UserRepository.CreateUser(ctx, payload, roles)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return u.newUserCreated(ctx, userId, payload, roles), nil
+}
+
+// BootstrapAdmin creates the first admin. The check for an existing admin and
+// the creation happen in one transaction, so running it twice at once does
+// not create two admins.
+func (u UserUsecase) BootstrapAdmin(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (model.User, error) {
+	payload, err := u.prepareNewUser(ctx, payload)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	userId, err := u.UserRepository.CreateFirstAdmin(ctx, payload)
+	if err != nil {
 		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("admin user already exist")
+			return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
+
 		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
-	if existingUser.Id > 0 {
+	return u.newUserCreated(ctx, userId, payload, []string{model.RoleUser, model.RoleAdmin}), nil
+}
+
+// prepareNewUser checks that the phone number and email of a new user are
+// not taken and hashes their password.
+func (u UserUsecase) prepareNewUser(ctx context.Context, payload generated.RegisterUserJSONRequestBody) (generated.RegisterUserJSONRequestBody, error) {
+	exists, err := u.UserRepository.PhoneNumberExists(ctx, payload.PhoneNumber)
+	if err != nil {
+		log.Error(err)
+		return payload, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if exists {
 		err = fmt.Errorf("user with phone number %s already exist", payload.PhoneNumber)
 		log.Error(err)
-		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		return payload, utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+	}
+
+	if payload.Email != nil {
+		email := utils.NormalizeEmail(*payload.Email)
+		payload.Email = &email
+
+		exists, err := u.UserRepository.EmailExists(ctx, email)
+		if err != nil {
+			log.Error(err)
+			return payload, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		}
+
+		if exists {
+			err = fmt.Errorf("user with email %s already exist", email)
+			log.Error(err)
+			return payload, utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
 	}
 
 	hashedPassword, err := u.CryptUtil.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Error(err)
-		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+		return payload, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
 	payload.Password = string(hashedPassword)
-	userId, err := u.UserRepository.CreateUser(ctx, payload)
-	if err != nil {
-		log.Error(err)
-		return model.User{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+
+	return payload, nil
+}
+
+// newUserCreated sends a new user with an email the link to verify it and
+// returns the user.
+func (u UserUsecase) newUserCreated(ctx context.Context, userId int64, payload generated.RegisterUserJSONRequestBody, roles []string) model.User {
+	if payload.Email != nil {
+		if err := u.EmailUsecase.SendVerificationEmail(ctx, userId); err != nil {
+			log.Warn(err)
+		}
 	}
 
-	user := model.User{
+	return model.User{
 		Id:          userId,
 		FullName:    payload.FullName,
 		PhoneNumber: payload.PhoneNumber,
+		Email:       null.StringFromPtr(payload.Email),
+		Roles:       roles,
 	}
-
-	return user, nil
 }
 
 func (u UserUsecase) GetUserProfile(ctx context.Context, userId int64) (model.User, error) {
@@ -79,34 +156,193 @@ func (u UserUsecase) GetUserProfile(ctx context.Context, userId int64) (model.Us
 	return user, nil
 }
 
-func (u UserUsecase) UpdateUserProfile(ctx context.Context, userId int64, payload generated.UpdateUserProfileJSONRequestBody) error {
+// UpdateUserProfile updates the user's own profile. Changing the phone number
+// or email, which are login identifiers, requires a recent authentication and
+// is reported to the user's verified email. A new email has to be verified
+// again.
+func (u UserUsecase) UpdateUserProfile(ctx context.Context, userId int64, authTime time.Time, payload generated.UpdateUserProfileJSONRequestBody) error {
 	user, err := u.UserRepository.GetUserById(ctx, userId)
 	if err != nil {
 		log.Error(err)
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
 		}
 
 		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
-	if payload.PhoneNumber != "" && payload.PhoneNumber != user.PhoneNumber {
-		existingUser, err := u.UserRepository.GetUserByPhoneNumber(ctx, payload.PhoneNumber)
-		if err != nil && err != sql.ErrNoRows {
+	phoneChanged := payload.PhoneNumber != "" && payload.PhoneNumber != user.PhoneNumber
+	if phoneChanged {
+		if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+			return err
+		}
+
+		exists, err := u.UserRepository.PhoneNumberExists(ctx, payload.PhoneNumber)
+		if err != nil {
 			log.Error(err)
 			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 		}
 
-		if existingUser.Id > 0 {
+		if exists {
 			err = fmt.Errorf("phone number is already used by another user")
 			log.Error(err)
 			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
 		}
 	}
 
+	emailChanged := false
+	if payload.Email != nil {
+		email := utils.NormalizeEmail(*payload.Email)
+		payload.Email = &email
+
+		emailChanged = email != user.Email.String
+		if emailChanged {
+			if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+				return err
+			}
+
+			exists, err := u.UserRepository.EmailExists(ctx, email)
+			if err != nil {
+				log.Error(err)
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+			}
+
+			if exists {
+				err = fmt.Errorf("email is already used by another user")
+				log.Error(err)
+				return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+			}
+		}
+	}
+
 	if err := u.UserRepository.UpdateUserProfile(ctx, userId, payload); err != nil {
 		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
 	}
 
+	if phoneChanged {
+		if err := u.EmailUsecase.SendSecurityAlert(ctx, user, SecurityEventPhoneNumberChanged); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	if emailChanged {
+		if err := u.EmailUsecase.SendSecurityAlert(ctx, user, SecurityEventEmailChanged); err != nil {
+			log.Warn(err)
+		}
+
+		if err := u.EmailUsecase.SendVerificationEmail(ctx, userId); err != nil {
+			log.Warn(err)
+		}
+	}
+
 	return nil
 }
+
+// ChangePassword replaces the user's own password. It requires a recent
+// authentication and is reported to the user's verified email.
+func (u UserUsecase) ChangePassword(ctx context.Context, userId int64, authTime time.Time, payload generated.ChangeUserPasswordJSONRequestBody) error {
+	if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+		return err
+	}
+
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	hashedPassword, err := u.CryptUtil.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error(err)
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err := u.UserRepository.UpdateUserPassword(ctx, userId, string(hashedPassword)); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err := u.EmailUsecase.SendSecurityAlert(ctx, user, SecurityEventPasswordChanged); err != nil {
+		log.Warn(err)
+	}
+
+	return nil
+}
+
+// DeleteUser soft-deletes the user's own account. The account can be
+// reactivated by logging in until it is purged after DeletionGracePeriod.
+// It requires a recent authentication.
+func (u UserUsecase) DeleteUser(ctx context.Context, userId int64, authTime time.Time) error {
+	if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+		return err
+	}
+
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if !canTransitionUserStatus(user.Status, model.UserStatusDeleted) {
+		err = fmt.Errorf("cannot delete user with status %s", user.Status)
+		log.Error(err)
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "INVALID_STATUS_TRANSITION", "")
+	}
+
+	change := model.UserStatusChange{
+		UserId:     userId,
+		FromStatus: user.Status,
+		ToStatus:   model.UserStatusDeleted,
+		Reason:     "deleted by user",
+		ActorId:    null.IntFrom(userId),
+	}
+
+	if err := u.UserRepository.UpdateUserStatus(ctx, change); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusConflict), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers hard-deletes the users whose grace period has passed and
+// returns how many were removed.
+func (u UserUsecase) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	purged, err := u.UserRepository.PurgeDeletedUsers(ctx, time.Now().Add(-u.DeletionGracePeriod))
+	if err != nil {
+		log.Error(err)
+		return 0, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return purged, nil
+}
+
+// requireRecentAuth rejects sensitive operations unless the user authenticated
+// within window. authTime is zero for credentials that do not carry one, which
+// are never recent enough.
+func requireRecentAuth(authTime time.Time, window time.Duration) error {
+	if !authTime.IsZero() && time.Since(authTime) <= window {
+		return nil
+	}
+
+	err := fmt.Errorf("authentication at %s is not recent enough", authTime)
+	log.Error(err)
+	return utils.WrapWithKey(err, utils.ErrorCode(http.StatusForbidden), "REAUTHENTICATION_REQUIRED",
+		"Please Confirm Your Password To Continue.")
+}
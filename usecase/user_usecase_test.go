@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 	"github.com/SawitProRecruitment/UserService/mocks"
 	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
 	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"golang.org/x/crypto/bcrypt"
@@ -25,10 +29,12 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 	}()
 
 	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
 	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
 
 	userUsecase := NewUserUsecase(UserUsecaseOptions{
 		UserRepository: mockUserRepo,
+		EmailUsecase:   mockEmailUsecase,
 		CryptUtil:      mockCryptUtil,
 	})
 
@@ -43,26 +49,56 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 		Password:    password,
 	}
 
-	user := model.User{
-		Id:          id,
-		FullName:    fullName,
-		PhoneNumber: phoneNumber,
-		Password:    password,
-	}
-
 	t.Run("success", func(t *testing.T) {
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
 		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
 			Times(1).Return([]byte(password), nil)
-		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Times(1).Return(id, nil)
+		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any(), []string{model.RoleUser}).Times(1).Return(id, nil)
 
 		res, err := userUsecase.CreateUser(ctx, payload)
 		require.NoError(t, err)
 		require.NotEmpty(t, res)
+		require.Equal(t, []string{model.RoleUser}, res.Roles)
+	})
+
+	t.Run("success - with email", func(t *testing.T) {
+		email := "John@Example.com "
+		emailPayload := payload
+		emailPayload.Email = &email
+
+		normalized := "john@example.com"
+		expectedPayload := payload
+		expectedPayload.Email = &normalized
+		expectedPayload.Password = password
+
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, normalized).Times(1).Return(false, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
+			Times(1).Return([]byte(password), nil)
+		mockUserRepo.EXPECT().CreateUser(ctx, expectedPayload, []string{model.RoleUser}).Times(1).Return(id, nil)
+		mockEmailUsecase.EXPECT().SendVerificationEmail(ctx, id).Times(1).Return(errors.New("smtp error"))
+
+		res, err := userUsecase.CreateUser(ctx, emailPayload)
+		require.NoError(t, err)
+		require.Equal(t, normalized, res.Email.String)
+	})
+
+	t.Run("failed - email is already used", func(t *testing.T) {
+		email := "john@example.com"
+		emailPayload := payload
+		emailPayload.Email = &email
+
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, email).Times(1).Return(true, nil)
+
+		res, err := userUsecase.CreateUser(ctx, emailPayload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Empty(t, res)
 	})
 
 	t.Run("failed - get user by phone number return error", func(t *testing.T) {
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, errors.New("repo error"))
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, errors.New("repo error"))
 
 		res, err := userUsecase.CreateUser(ctx, payload)
 		require.Error(t, err)
@@ -70,7 +106,7 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 	})
 
 	t.Run("failed - phone number is already used", func(t *testing.T) {
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(true, nil)
 
 		res, err := userUsecase.CreateUser(ctx, payload)
 		require.Error(t, err)
@@ -78,7 +114,7 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 	})
 
 	t.Run("failed - generate hashed password failed", func(t *testing.T) {
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
 		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
 			Times(1).Return([]byte{}, errors.New("password doesnt match"))
 
@@ -88,10 +124,10 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 	})
 
 	t.Run("failed - create user return error", func(t *testing.T) {
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumber).Times(1).Return(model.User{}, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
 		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
 			Times(1).Return([]byte(password), nil)
-		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+		mockUserRepo.EXPECT().CreateUser(ctx, gomock.Any(), []string{model.RoleUser}).Times(1).Return(int64(0), errors.New("db error"))
 
 		res, err := userUsecase.CreateUser(ctx, payload)
 		require.Error(t, err)
@@ -99,6 +135,83 @@ func TestUserUsecase_CreateUser(t *testing.T) {
 	})
 }
 
+func TestUserUsecase_BootstrapAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
+
+	userUsecase := NewUserUsecase(UserUsecaseOptions{
+		UserRepository: mockUserRepo,
+		CryptUtil:      mockCryptUtil,
+	})
+
+	id := int64(1)
+	phoneNumber := "+6285912345678"
+	password := "password"
+
+	payload := generated.RegisterUserJSONRequestBody{
+		FullName:    "John Doe",
+		PhoneNumber: phoneNumber,
+		Password:    password,
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
+			Times(1).Return([]byte("hashed"), nil)
+		mockUserRepo.EXPECT().CreateFirstAdmin(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, payload generated.RegisterUserJSONRequestBody) (int64, error) {
+				require.Equal(t, "hashed", payload.Password)
+				return id, nil
+			})
+
+		res, err := userUsecase.BootstrapAdmin(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, id, res.Id)
+		require.Equal(t, []string{model.RoleUser, model.RoleAdmin}, res.Roles)
+	})
+
+	t.Run("failed - admin already exist", func(t *testing.T) {
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
+			Times(1).Return([]byte("hashed"), nil)
+		mockUserRepo.EXPECT().CreateFirstAdmin(ctx, gomock.Any()).Times(1).Return(int64(0), sql.ErrNoRows)
+
+		res, err := userUsecase.BootstrapAdmin(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Empty(t, res)
+	})
+
+	t.Run("failed - phone number is already used", func(t *testing.T) {
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(true, nil)
+
+		res, err := userUsecase.BootstrapAdmin(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Empty(t, res)
+	})
+
+	t.Run("failed - create first admin return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumber).Times(1).Return(false, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(password), bcrypt.DefaultCost).
+			Times(1).Return([]byte("hashed"), nil)
+		mockUserRepo.EXPECT().CreateFirstAdmin(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+
+		res, err := userUsecase.BootstrapAdmin(ctx, payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+		require.Empty(t, res)
+	})
+}
+
 func TestUserUsecase_GetUserProfile(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	ctx := context.Background()
@@ -153,17 +266,21 @@ func TestUserUsecase_UpdateUserProfile(t *testing.T) {
 	}()
 
 	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
 	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
 
 	userUsecase := NewUserUsecase(UserUsecaseOptions{
-		UserRepository: mockUserRepo,
-		CryptUtil:      mockCryptUtil,
+		UserRepository:         mockUserRepo,
+		EmailUsecase:           mockEmailUsecase,
+		CryptUtil:              mockCryptUtil,
+		ReauthenticationWindow: 10 * time.Minute,
 	})
 
 	id := int64(1)
 	fullName := "John Doe"
 	phoneNumber := "+6285912345678"
 	phoneNumberToUpdate := "+6285912345679"
+	authTime := time.Now()
 
 	payload := generated.UpdateUserProfileJSONRequestBody{
 		FullName:    fullName,
@@ -176,54 +293,264 @@ func TestUserUsecase_UpdateUserProfile(t *testing.T) {
 		PhoneNumber: phoneNumber,
 	}
 
-	otherUser := model.User{
-		Id:          int64(2),
-		PhoneNumber: phoneNumberToUpdate,
-	}
-
 	t.Run("success", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumberToUpdate).Times(1).Return(model.User{}, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, nil)
 		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, payload).Times(1).Return(nil)
+		mockEmailUsecase.EXPECT().SendSecurityAlert(ctx, user, SecurityEventPhoneNumberChanged).Times(1).Return(nil)
 
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
 		require.NoError(t, err)
 	})
 
 	t.Run("failed - get user by id return error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, errors.New("db error"))
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
 		require.Error(t, err)
 	})
 
 	t.Run("failed - get user by id return no user", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
 		require.Error(t, err)
 	})
 
 	t.Run("failed - get user by phone number return error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumberToUpdate).Times(1).Return(model.User{}, errors.New("db error"))
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, errors.New("db error"))
 
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
 		require.Error(t, err)
 	})
 
 	t.Run("failed - get user by phone number return error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumberToUpdate).Times(1).Return(otherUser, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(true, nil)
 
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
 		require.Error(t, err)
 	})
 
 	t.Run("failed - update user profile return error", func(t *testing.T) {
 		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
-		mockUserRepo.EXPECT().GetUserByPhoneNumber(ctx, phoneNumberToUpdate).Times(1).Return(model.User{}, nil)
+		mockUserRepo.EXPECT().PhoneNumberExists(ctx, phoneNumberToUpdate).Times(1).Return(false, nil)
 		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, payload).Times(1).Return(errors.New("db error"))
 
-		err := userUsecase.UpdateUserProfile(ctx, id, payload)
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, payload)
+		require.Error(t, err)
+	})
+
+	t.Run("success - name change without recent authentication", func(t *testing.T) {
+		namePayload := generated.UpdateUserProfileJSONRequestBody{FullName: "Jane Doe", PhoneNumber: phoneNumber}
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, namePayload).Times(1).Return(nil)
+
+		err := userUsecase.UpdateUserProfile(ctx, id, time.Time{}, namePayload)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - email change", func(t *testing.T) {
+		email := "John@Example.com"
+		normalized := "john@example.com"
+		emailPayload := generated.UpdateUserProfileJSONRequestBody{Email: &email}
+		expectedPayload := generated.UpdateUserProfileJSONRequestBody{Email: &normalized}
+
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, normalized).Times(1).Return(false, nil)
+		mockUserRepo.EXPECT().UpdateUserProfile(ctx, id, expectedPayload).Times(1).Return(nil)
+		mockEmailUsecase.EXPECT().SendSecurityAlert(ctx, user, SecurityEventEmailChanged).Times(1).Return(nil)
+		mockEmailUsecase.EXPECT().SendVerificationEmail(ctx, id).Times(1).Return(nil)
+
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, emailPayload)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - email is already used", func(t *testing.T) {
+		email := "john@example.com"
+		emailPayload := generated.UpdateUserProfileJSONRequestBody{Email: &email}
+
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().EmailExists(ctx, email).Times(1).Return(true, nil)
+
+		err := userUsecase.UpdateUserProfile(ctx, id, authTime, emailPayload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+	})
+
+	t.Run("failed - email change without recent authentication", func(t *testing.T) {
+		email := "john@example.com"
+		emailPayload := generated.UpdateUserProfileJSONRequestBody{Email: &email}
+
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+
+		err := userUsecase.UpdateUserProfile(ctx, id, time.Time{}, emailPayload)
+		require.Error(t, err)
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - phone change without recent authentication", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+
+		err := userUsecase.UpdateUserProfile(ctx, id, time.Now().Add(-time.Hour), payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+}
+
+func TestUserUsecase_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockEmailUsecase := mocks.NewMockEmailUsecaseInterface(ctrl)
+	mockCryptUtil := mockUtils.NewMockCryptInterface(ctrl)
+
+	userUsecase := NewUserUsecase(UserUsecaseOptions{
+		UserRepository:         mockUserRepo,
+		EmailUsecase:           mockEmailUsecase,
+		CryptUtil:              mockCryptUtil,
+		ReauthenticationWindow: 10 * time.Minute,
+	})
+
+	id := int64(1)
+	payload := generated.ChangeUserPasswordJSONRequestBody{Password: "N3wPassword!"}
+	user := model.User{Id: id, Status: model.UserStatusActive}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(payload.Password), gomock.Any()).Times(1).Return([]byte("hashed"), nil)
+		mockUserRepo.EXPECT().UpdateUserPassword(ctx, id, "hashed").Times(1).Return(nil)
+		mockEmailUsecase.EXPECT().SendSecurityAlert(ctx, user, SecurityEventPasswordChanged).Times(1).Return(nil)
+
+		err := userUsecase.ChangePassword(ctx, id, time.Now(), payload)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - without recent authentication", func(t *testing.T) {
+		err := userUsecase.ChangePassword(ctx, id, time.Now().Add(-time.Hour), payload)
+		require.Error(t, err)
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		err := userUsecase.ChangePassword(ctx, id, time.Now(), payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - update user password return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockCryptUtil.EXPECT().GenerateFromPassword([]byte(payload.Password), gomock.Any()).Times(1).Return([]byte("hashed"), nil)
+		mockUserRepo.EXPECT().UpdateUserPassword(ctx, id, "hashed").Times(1).Return(errors.New("db error"))
+
+		err := userUsecase.ChangePassword(ctx, id, time.Now(), payload)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestUserUsecase_DeleteUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	userUsecase := NewUserUsecase(UserUsecaseOptions{
+		UserRepository:         mockUserRepo,
+		ReauthenticationWindow: 10 * time.Minute,
+	})
+
+	id := int64(1)
+	user := model.User{Id: id, Status: model.UserStatusActive}
+	authTime := time.Now()
+
+	change := model.UserStatusChange{
+		UserId:     id,
+		FromStatus: model.UserStatusActive,
+		ToStatus:   model.UserStatusDeleted,
+		Reason:     "deleted by user",
+		ActorId:    null.IntFrom(id),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(nil)
+
+		err := userUsecase.DeleteUser(ctx, id, authTime)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		err := userUsecase.DeleteUser(ctx, id, authTime)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - update user status return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, id).Times(1).Return(user, nil)
+		mockUserRepo.EXPECT().UpdateUserStatus(ctx, change).Times(1).Return(errors.New("db error"))
+
+		err := userUsecase.DeleteUser(ctx, id, authTime)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - without recent authentication", func(t *testing.T) {
+		err := userUsecase.DeleteUser(ctx, id, time.Time{})
+		require.Error(t, err)
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+}
+
+func TestUserUsecase_PurgeDeletedUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+
+	gracePeriod := 24 * time.Hour
+	userUsecase := NewUserUsecase(UserUsecaseOptions{
+		UserRepository:      mockUserRepo,
+		DeletionGracePeriod: gracePeriod,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockUserRepo.EXPECT().PurgeDeletedUsers(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, deletedBefore time.Time) (int64, error) {
+				require.WithinDuration(t, time.Now().Add(-gracePeriod), deletedBefore, time.Minute)
+				return 3, nil
+			})
+
+		purged, err := userUsecase.PurgeDeletedUsers(ctx)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), purged)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		mockUserRepo.EXPECT().PurgeDeletedUsers(ctx, gomock.Any()).Times(1).Return(int64(0), errors.New("db error"))
+
+		purged, err := userUsecase.PurgeDeletedUsers(ctx)
 		require.Error(t, err)
+		require.Zero(t, purged)
 	})
 }
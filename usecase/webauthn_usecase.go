@@ -0,0 +1,334 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/repository"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+
+	"github.com/labstack/gommon/log"
+)
+
+// defaultWebAuthnCredentialName names passkeys registered without a name.
+const defaultWebAuthnCredentialName = "Passkey"
+
+type WebAuthnUsecase struct {
+	UserRepository         repository.UserRepositoryInterface
+	RoleRepository         repository.RoleRepositoryInterface
+	WebAuthnRepository     repository.WebAuthnRepositoryInterface
+	AuthUtil               utils.AuthInterface
+	WebAuthnUtil           utils.WebAuthnInterface
+	DeviceRecognizer       DeviceRecognizerInterface
+	ChallengeDuration      time.Duration
+	ReauthenticationWindow time.Duration
+}
+
+type WebAuthnUsecaseOptions struct {
+	UserRepository         repository.UserRepositoryInterface
+	RoleRepository         repository.RoleRepositoryInterface
+	WebAuthnRepository     repository.WebAuthnRepositoryInterface
+	AuthUtil               utils.AuthInterface
+	WebAuthnUtil           utils.WebAuthnInterface
+	DeviceRecognizer       DeviceRecognizerInterface
+	ChallengeDuration      time.Duration
+	ReauthenticationWindow time.Duration
+}
+
+func NewWebAuthnUsecase(opts WebAuthnUsecaseOptions) *WebAuthnUsecase {
+	u := &WebAuthnUsecase{
+		UserRepository:         opts.UserRepository,
+		RoleRepository:         opts.RoleRepository,
+		WebAuthnRepository:     opts.WebAuthnRepository,
+		AuthUtil:               opts.AuthUtil,
+		WebAuthnUtil:           opts.WebAuthnUtil,
+		DeviceRecognizer:       opts.DeviceRecognizer,
+		ChallengeDuration:      opts.ChallengeDuration,
+		ReauthenticationWindow: opts.ReauthenticationWindow,
+	}
+
+	return u
+}
+
+// BeginRegistration starts registering a passkey for the user. It requires a
+// recent authentication and returns the options for the authenticator, whose
+// challenge is stored until the response comes back.
+func (u WebAuthnUsecase) BeginRegistration(ctx context.Context, userId int64, authTime time.Time) (utils.WebAuthnCreationOptions, error) {
+	if err := requireRecentAuth(authTime, u.ReauthenticationWindow); err != nil {
+		return utils.WebAuthnCreationOptions{}, err
+	}
+
+	user, err := u.UserRepository.GetUserById(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WebAuthnCreationOptions{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WebAuthnCreationOptions{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	credentials, err := u.WebAuthnRepository.ListWebAuthnCredentials(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return utils.WebAuthnCreationOptions{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	exclude := make([]string, 0, len(credentials))
+	for _, credential := range credentials {
+		exclude = append(exclude, credential.CredentialId)
+	}
+
+	challenge, err := u.createChallenge(ctx, model.WebAuthnCeremonyRegistration, null.IntFrom(userId))
+	if err != nil {
+		return utils.WebAuthnCreationOptions{}, err
+	}
+
+	options, err := u.WebAuthnUtil.CreationOptions(user, challenge, exclude)
+	if err != nil {
+		return utils.WebAuthnCreationOptions{}, wrapWebAuthnOptionsError(err)
+	}
+
+	return options, nil
+}
+
+// FinishRegistration verifies the authenticator's response to the challenge
+// of BeginRegistration, which is consumed, and stores the new passkey.
+func (u WebAuthnUsecase) FinishRegistration(ctx context.Context, userId int64, name string, response utils.WebAuthnAttestationResponse) (model.WebAuthnCredential, error) {
+	pending, challenge, err := u.consumeChallenge(ctx, response.ClientDataJSON, model.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return model.WebAuthnCredential{}, err
+	}
+
+	if !pending.UserId.Valid || pending.UserId.Int64 != userId {
+		err = fmt.Errorf("webauthn challenge %d was not issued to user %d", pending.Id, userId)
+		log.Error(err)
+		return model.WebAuthnCredential{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_WEBAUTHN_CHALLENGE", "Invalid Or Expired Challenge.")
+	}
+
+	verified, err := u.WebAuthnUtil.VerifyRegistration(challenge, response)
+	if err != nil {
+		log.Error(err)
+		return model.WebAuthnCredential{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_WEBAUTHN_RESPONSE", "Could Not Verify The Authenticator's Response.")
+	}
+
+	existing, err := u.WebAuthnRepository.GetWebAuthnCredential(ctx, verified.ID)
+	if err == nil {
+		err = fmt.Errorf("webauthn credential is already registered as %d", existing.Id)
+		log.Error(err)
+		return model.WebAuthnCredential{}, utils.WrapWithKey(err, utils.ErrorCode(http.StatusConflict), "WEBAUTHN_CREDENTIAL_EXISTS", "Passkey Is Already Registered.")
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Error(err)
+		return model.WebAuthnCredential{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if name == "" {
+		name = defaultWebAuthnCredentialName
+	}
+
+	credential := model.WebAuthnCredential{
+		UserId:       userId,
+		CredentialId: verified.ID,
+		PublicKey:    verified.PublicKey,
+		SignCount:    int64(verified.SignCount),
+		AAGUID:       verified.AAGUID,
+		Name:         name,
+	}
+
+	credential, err = u.WebAuthnRepository.CreateWebAuthnCredential(ctx, credential)
+	if err != nil {
+		log.Error(err)
+		return model.WebAuthnCredential{}, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return credential, nil
+}
+
+// BeginLogin starts a passkey login and returns the options for the
+// authenticator, whose challenge is stored until the response comes back.
+func (u WebAuthnUsecase) BeginLogin(ctx context.Context) (utils.WebAuthnRequestOptions, error) {
+	challenge, err := u.createChallenge(ctx, model.WebAuthnCeremonyLogin, null.Int{})
+	if err != nil {
+		return utils.WebAuthnRequestOptions{}, err
+	}
+
+	options, err := u.WebAuthnUtil.RequestOptions(challenge)
+	if err != nil {
+		return utils.WebAuthnRequestOptions{}, wrapWebAuthnOptionsError(err)
+	}
+
+	return options, nil
+}
+
+// FinishLogin verifies the assertion of the passkey credentialId to the
+// challenge of BeginLogin, which is consumed, and logs its user in. The
+// authenticator's signature counter must have increased since the last login,
+// otherwise the authenticator may have been cloned. The token is bound to the
+// client's DPoP key when keyThumbprint is set and tied to its device.
+func (u WebAuthnUsecase) FinishLogin(ctx context.Context, credentialId string, response utils.WebAuthnAssertionResponse, keyThumbprint string, client model.LoginClient) (model.User, string, error) {
+	_, challenge, err := u.consumeChallenge(ctx, response.ClientDataJSON, model.WebAuthnCeremonyLogin)
+	if err != nil {
+		return model.User{}, "", err
+	}
+
+	credential, err := u.WebAuthnRepository.GetWebAuthnCredential(ctx, credentialId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "INVALID_WEBAUTHN_ASSERTION", "Could Not Verify The Passkey.")
+		}
+
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	signCount, err := u.WebAuthnUtil.VerifyAssertion(challenge, credential, response)
+	if err == nil && (signCount != 0 || credential.SignCount != 0) && int64(signCount) <= credential.SignCount {
+		err = fmt.Errorf("webauthn credential %d signature counter went from %d to %d, the authenticator may be cloned", credential.Id, credential.SignCount, signCount)
+	}
+
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusUnauthorized), "INVALID_WEBAUTHN_ASSERTION", "Could Not Verify The Passkey.")
+	}
+
+	if err = u.WebAuthnRepository.UpdateWebAuthnCredentialSignCount(ctx, credential.Id, int64(signCount)); err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	user, err := u.UserRepository.GetUserById(ctx, credential.UserId)
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusForbidden), "")
+		}
+
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = checkUserStatus(user.Status); err != nil {
+		log.Error(err)
+		return model.User{}, "", err
+	}
+
+	user.Roles, err = u.RoleRepository.GetUserRoles(ctx, user.Id)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	device, err := u.DeviceRecognizer.RecognizeDevice(ctx, user, client)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	jwt, err := u.AuthUtil.GenerateJWTToken(user, []string{utils.AuthMethodHardwareKey, utils.AuthMethodMultiFactor}, keyThumbprint, device.Id)
+	if err != nil {
+		log.Error(err)
+		return model.User{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if err = u.UserRepository.IncrementUserLoginCount(ctx, user.Id); err != nil {
+		log.Warn(err)
+	}
+
+	return user, jwt, nil
+}
+
+func (u WebAuthnUsecase) ListCredentials(ctx context.Context, userId int64) ([]model.WebAuthnCredential, error) {
+	credentials, err := u.WebAuthnRepository.ListWebAuthnCredentials(ctx, userId)
+	if err != nil {
+		log.Error(err)
+		return nil, utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return credentials, nil
+}
+
+func (u WebAuthnUsecase) DeleteCredential(ctx context.Context, userId, id int64) error {
+	if err := u.WebAuthnRepository.DeleteWebAuthnCredential(ctx, userId, id); err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.WrapWithCode(err, utils.ErrorCode(http.StatusNotFound), "")
+		}
+
+		return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return nil
+}
+
+// createChallenge stores a new challenge for ceremony. userId is set when an
+// authenticated user registers a passkey.
+func (u WebAuthnUsecase) createChallenge(ctx context.Context, ceremony string, userId null.Int) (string, error) {
+	challenge, challengeHash, err := utils.GenerateToken()
+	if err != nil {
+		log.Error(err)
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if _, err = u.WebAuthnRepository.DeleteExpiredWebAuthnChallenges(ctx); err != nil {
+		log.Warn(err)
+	}
+
+	pending := model.WebAuthnChallenge{
+		ChallengeHash: challengeHash,
+		Ceremony:      ceremony,
+		UserId:        userId,
+		ExpiresAt:     time.Now().Add(u.ChallengeDuration),
+	}
+
+	if err = u.WebAuthnRepository.CreateWebAuthnChallenge(ctx, pending); err != nil {
+		log.Error(err)
+		return "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge looks up and consumes the challenge the client data
+// answers. It must have been issued for ceremony and not be expired. The
+// challenge itself is returned along with it, only its hash is stored.
+func (u WebAuthnUsecase) consumeChallenge(ctx context.Context, clientDataJSON, ceremony string) (model.WebAuthnChallenge, string, error) {
+	challenge, err := u.WebAuthnUtil.Challenge(clientDataJSON)
+	if err != nil {
+		log.Error(err)
+		return model.WebAuthnChallenge{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_WEBAUTHN_CHALLENGE", "Invalid Or Expired Challenge.")
+	}
+
+	pending, err := u.WebAuthnRepository.ConsumeWebAuthnChallenge(ctx, utils.HashToken(challenge))
+	if err != nil {
+		log.Error(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.WebAuthnChallenge{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_WEBAUTHN_CHALLENGE", "Invalid Or Expired Challenge.")
+		}
+
+		return model.WebAuthnChallenge{}, "", utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+	}
+
+	if pending.Ceremony != ceremony || time.Now().After(pending.ExpiresAt) {
+		err = fmt.Errorf("webauthn challenge %d is expired or not for %s", pending.Id, ceremony)
+		log.Error(err)
+		return model.WebAuthnChallenge{}, "", utils.WrapWithKey(err, utils.ErrorCode(http.StatusBadRequest), "INVALID_WEBAUTHN_CHALLENGE", "Invalid Or Expired Challenge.")
+	}
+
+	return pending, challenge, nil
+}
+
+// wrapWebAuthnOptionsError reports passkeys as not found when WebAuthn is
+// not configured.
+func wrapWebAuthnOptionsError(err error) error {
+	log.Error(err)
+	if errors.Is(err, utils.ErrWebAuthnDisabled) {
+		return utils.WrapWithKey(err, utils.ErrorCode(http.StatusNotFound), "WEBAUTHN_DISABLED", "Passkeys Are Not Enabled.")
+	}
+
+	return utils.WrapWithCode(err, utils.ErrorCode(http.StatusInternalServerError), "")
+}
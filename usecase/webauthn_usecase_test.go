@@ -0,0 +1,505 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/mocks"
+	mockUtils "github.com/SawitProRecruitment/UserService/mocks/utils"
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/SawitProRecruitment/UserService/utils"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestWebAuthnUsecase_BeginRegistration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+	mockWebAuthnUtil := mockUtils.NewMockWebAuthnInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		UserRepository:         mockUserRepo,
+		WebAuthnRepository:     mockWebAuthnRepo,
+		WebAuthnUtil:           mockWebAuthnUtil,
+		ChallengeDuration:      5 * time.Minute,
+		ReauthenticationWindow: 5 * time.Minute,
+	})
+
+	userId := int64(10)
+	user := model.User{Id: userId, FullName: "John Doe", Status: model.UserStatusActive}
+	credentials := []model.WebAuthnCredential{{Id: 1, UserId: userId, CredentialId: "AQID"}}
+
+	t.Run("success", func(t *testing.T) {
+		var challenge string
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, userId).Times(1).Return(credentials, nil)
+		mockWebAuthnRepo.EXPECT().DeleteExpiredWebAuthnChallenges(ctx).Times(1).Return(int64(0), nil)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnChallenge(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, pending model.WebAuthnChallenge) error {
+				require.Equal(t, model.WebAuthnCeremonyRegistration, pending.Ceremony)
+				require.Equal(t, null.IntFrom(userId), pending.UserId)
+				require.WithinDuration(t, time.Now().Add(5*time.Minute), pending.ExpiresAt, time.Minute)
+				return nil
+			})
+		mockWebAuthnUtil.EXPECT().CreationOptions(user, gomock.Any(), []string{"AQID"}).Times(1).
+			DoAndReturn(func(_ model.User, c string, _ []string) (utils.WebAuthnCreationOptions, error) {
+				challenge = c
+				return utils.WebAuthnCreationOptions{Challenge: c}, nil
+			})
+
+		options, err := webAuthnUsecase.BeginRegistration(ctx, userId, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, challenge, options.Challenge)
+	})
+
+	t.Run("failed - authentication is not recent", func(t *testing.T) {
+		_, err := webAuthnUsecase.BeginRegistration(ctx, userId, time.Now().Add(-time.Hour))
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "REAUTHENTICATION_REQUIRED", utils.GetKey(err))
+	})
+
+	t.Run("failed - user not found", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(model.User{}, sql.ErrNoRows)
+
+		_, err := webAuthnUsecase.BeginRegistration(ctx, userId, time.Now())
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+
+	t.Run("failed - create challenge return error", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, userId).Times(1).Return(credentials, nil)
+		mockWebAuthnRepo.EXPECT().DeleteExpiredWebAuthnChallenges(ctx).Times(1).Return(int64(0), errors.New("db error"))
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnChallenge(ctx, gomock.Any()).Times(1).Return(errors.New("db error"))
+
+		_, err := webAuthnUsecase.BeginRegistration(ctx, userId, time.Now())
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+
+	t.Run("failed - webauthn disabled", func(t *testing.T) {
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, userId).Times(1).Return(nil, nil)
+		mockWebAuthnRepo.EXPECT().DeleteExpiredWebAuthnChallenges(ctx).Times(1).Return(int64(0), nil)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnChallenge(ctx, gomock.Any()).Times(1).Return(nil)
+		mockWebAuthnUtil.EXPECT().CreationOptions(user, gomock.Any(), []string{}).Times(1).Return(utils.WebAuthnCreationOptions{}, utils.ErrWebAuthnDisabled)
+
+		_, err := webAuthnUsecase.BeginRegistration(ctx, userId, time.Now())
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+		require.Equal(t, "WEBAUTHN_DISABLED", utils.GetKey(err))
+	})
+}
+
+func TestWebAuthnUsecase_FinishRegistration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+	mockWebAuthnUtil := mockUtils.NewMockWebAuthnInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		WebAuthnRepository: mockWebAuthnRepo,
+		WebAuthnUtil:       mockWebAuthnUtil,
+	})
+
+	userId := int64(10)
+	response := utils.WebAuthnAttestationResponse{ClientDataJSON: "client-data", AttestationObject: "attestation"}
+	pending := model.WebAuthnChallenge{
+		Id:        1,
+		Ceremony:  model.WebAuthnCeremonyRegistration,
+		UserId:    null.IntFrom(userId),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	verified := utils.WebAuthnCredential{ID: "AQID", PublicKey: []byte("key"), AAGUID: "adce0002-35bc-c60a-648b-0b25f1f05503"}
+
+	t.Run("success", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnUtil.EXPECT().VerifyRegistration("challenge", response).Times(1).Return(verified, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(model.WebAuthnCredential{}, sql.ErrNoRows)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnCredential(ctx, model.WebAuthnCredential{
+			UserId:       userId,
+			CredentialId: "AQID",
+			PublicKey:    []byte("key"),
+			AAGUID:       "adce0002-35bc-c60a-648b-0b25f1f05503",
+			Name:         "Passkey",
+		}).Times(1).DoAndReturn(func(_ context.Context, credential model.WebAuthnCredential) (model.WebAuthnCredential, error) {
+			credential.Id = 3
+			return credential, nil
+		})
+
+		credential, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), credential.Id)
+	})
+
+	t.Run("failed - challenge not found", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(model.WebAuthnChallenge{}, sql.ErrNoRows)
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - challenge expired", func(t *testing.T) {
+		expired := pending
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(expired, nil)
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - challenge for login", func(t *testing.T) {
+		login := pending
+		login.Ceremony = model.WebAuthnCeremonyLogin
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(login, nil)
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - challenge of another user", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, 11, "", response)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - response not verified", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnUtil.EXPECT().VerifyRegistration("challenge", response).Times(1).Return(utils.WebAuthnCredential{}, errors.New("bad signature"))
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_RESPONSE", utils.GetKey(err))
+	})
+
+	t.Run("failed - credential already registered", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnUtil.EXPECT().VerifyRegistration("challenge", response).Times(1).Return(verified, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(model.WebAuthnCredential{Id: 3}, nil)
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "", response)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusConflict), utils.GetCode(err))
+		require.Equal(t, "WEBAUTHN_CREDENTIAL_EXISTS", utils.GetKey(err))
+	})
+
+	t.Run("failed - create credential return error", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnUtil.EXPECT().VerifyRegistration("challenge", response).Times(1).Return(verified, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(model.WebAuthnCredential{}, sql.ErrNoRows)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnCredential(ctx, gomock.Any()).Times(1).Return(model.WebAuthnCredential{}, errors.New("db error"))
+
+		_, err := webAuthnUsecase.FinishRegistration(ctx, userId, "YubiKey", response)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestWebAuthnUsecase_BeginLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+	mockWebAuthnUtil := mockUtils.NewMockWebAuthnInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		WebAuthnRepository: mockWebAuthnRepo,
+		WebAuthnUtil:       mockWebAuthnUtil,
+		ChallengeDuration:  5 * time.Minute,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		var challengeHash string
+		mockWebAuthnRepo.EXPECT().DeleteExpiredWebAuthnChallenges(ctx).Times(1).Return(int64(1), nil)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnChallenge(ctx, gomock.Any()).Times(1).
+			DoAndReturn(func(_ context.Context, pending model.WebAuthnChallenge) error {
+				require.Equal(t, model.WebAuthnCeremonyLogin, pending.Ceremony)
+				require.False(t, pending.UserId.Valid)
+				challengeHash = pending.ChallengeHash
+				return nil
+			})
+		mockWebAuthnUtil.EXPECT().RequestOptions(gomock.Any()).Times(1).
+			DoAndReturn(func(challenge string) (utils.WebAuthnRequestOptions, error) {
+				return utils.WebAuthnRequestOptions{Challenge: challenge}, nil
+			})
+
+		options, err := webAuthnUsecase.BeginLogin(ctx)
+		require.NoError(t, err)
+		require.Equal(t, challengeHash, utils.HashToken(options.Challenge))
+	})
+
+	t.Run("failed - webauthn disabled", func(t *testing.T) {
+		mockWebAuthnRepo.EXPECT().DeleteExpiredWebAuthnChallenges(ctx).Times(1).Return(int64(0), nil)
+		mockWebAuthnRepo.EXPECT().CreateWebAuthnChallenge(ctx, gomock.Any()).Times(1).Return(nil)
+		mockWebAuthnUtil.EXPECT().RequestOptions(gomock.Any()).Times(1).Return(utils.WebAuthnRequestOptions{}, utils.ErrWebAuthnDisabled)
+
+		_, err := webAuthnUsecase.BeginLogin(ctx)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+		require.Equal(t, "WEBAUTHN_DISABLED", utils.GetKey(err))
+	})
+}
+
+func TestWebAuthnUsecase_FinishLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockUserRepo := mocks.NewMockUserRepositoryInterface(ctrl)
+	mockRoleRepo := mocks.NewMockRoleRepositoryInterface(ctrl)
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+	mockAuthUtil := mockUtils.NewMockAuthInterface(ctrl)
+	mockWebAuthnUtil := mockUtils.NewMockWebAuthnInterface(ctrl)
+	mockDeviceRecognizer := mocks.NewMockDeviceRecognizerInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		UserRepository:     mockUserRepo,
+		RoleRepository:     mockRoleRepo,
+		WebAuthnRepository: mockWebAuthnRepo,
+		AuthUtil:           mockAuthUtil,
+		WebAuthnUtil:       mockWebAuthnUtil,
+		DeviceRecognizer:   mockDeviceRecognizer,
+	})
+
+	userId := int64(10)
+	user := model.User{Id: userId, FullName: "John Doe", Status: model.UserStatusActive}
+	roles := []string{"user"}
+	client := model.LoginClient{UserAgent: "Mozilla/5.0", IPAddress: "192.0.2.1"}
+	device := model.Device{Id: 7, UserId: userId}
+	response := utils.WebAuthnAssertionResponse{ClientDataJSON: "client-data", AuthenticatorData: "auth-data", Signature: "signature"}
+	pending := model.WebAuthnChallenge{Id: 1, Ceremony: model.WebAuthnCeremonyLogin, ExpiresAt: time.Now().Add(time.Minute)}
+	credential := model.WebAuthnCredential{Id: 3, UserId: userId, CredentialId: "AQID", SignCount: 4}
+	methods := []string{utils.AuthMethodHardwareKey, utils.AuthMethodMultiFactor}
+
+	t.Run("success", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(credential, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", credential, response).Times(1).Return(uint32(5), nil)
+		mockWebAuthnRepo.EXPECT().UpdateWebAuthnCredentialSignCount(ctx, int64(3), int64(5)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), methods, "", device.Id).Times(1).Return("jwt", nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, userId).Times(1).Return(nil)
+
+		loggedIn, jwt, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+		require.Equal(t, roles, loggedIn.Roles)
+	})
+
+	t.Run("success - authenticator without counter", func(t *testing.T) {
+		counterless := credential
+		counterless.SignCount = 0
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(counterless, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", counterless, response).Times(1).Return(uint32(0), nil)
+		mockWebAuthnRepo.EXPECT().UpdateWebAuthnCredentialSignCount(ctx, int64(3), int64(0)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(device, nil)
+		mockAuthUtil.EXPECT().GenerateJWTToken(gomock.Any(), methods, "", device.Id).Times(1).Return("jwt", nil)
+		mockUserRepo.EXPECT().IncrementUserLoginCount(ctx, userId).Times(1).Return(errors.New("db error"))
+
+		_, jwt, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.NoError(t, err)
+		require.Equal(t, "jwt", jwt)
+	})
+
+	t.Run("failed - invalid client data", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("", errors.New("invalid client data"))
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusBadRequest), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - challenge for registration", func(t *testing.T) {
+		registration := pending
+		registration.Ceremony = model.WebAuthnCeremonyRegistration
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(registration, nil)
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, "INVALID_WEBAUTHN_CHALLENGE", utils.GetKey(err))
+	})
+
+	t.Run("failed - credential not found", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(model.WebAuthnCredential{}, sql.ErrNoRows)
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_ASSERTION", utils.GetKey(err))
+	})
+
+	t.Run("failed - assertion not verified", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(credential, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", credential, response).Times(1).Return(uint32(0), errors.New("bad signature"))
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_ASSERTION", utils.GetKey(err))
+	})
+
+	t.Run("failed - signature counter did not increase", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(credential, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", credential, response).Times(1).Return(uint32(4), nil)
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusUnauthorized), utils.GetCode(err))
+		require.Equal(t, "INVALID_WEBAUTHN_ASSERTION", utils.GetKey(err))
+	})
+
+	t.Run("failed - user suspended", func(t *testing.T) {
+		suspended := user
+		suspended.Status = model.UserStatusSuspended
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(credential, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", credential, response).Times(1).Return(uint32(5), nil)
+		mockWebAuthnRepo.EXPECT().UpdateWebAuthnCredentialSignCount(ctx, int64(3), int64(5)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(suspended, nil)
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusForbidden), utils.GetCode(err))
+		require.Equal(t, "ACCOUNT_SUSPENDED", utils.GetKey(err))
+	})
+
+	t.Run("failed - recognize device", func(t *testing.T) {
+		mockWebAuthnUtil.EXPECT().Challenge("client-data").Times(1).Return("challenge", nil)
+		mockWebAuthnRepo.EXPECT().ConsumeWebAuthnChallenge(ctx, utils.HashToken("challenge")).Times(1).Return(pending, nil)
+		mockWebAuthnRepo.EXPECT().GetWebAuthnCredential(ctx, "AQID").Times(1).Return(credential, nil)
+		mockWebAuthnUtil.EXPECT().VerifyAssertion("challenge", credential, response).Times(1).Return(uint32(5), nil)
+		mockWebAuthnRepo.EXPECT().UpdateWebAuthnCredentialSignCount(ctx, int64(3), int64(5)).Times(1).Return(nil)
+		mockUserRepo.EXPECT().GetUserById(ctx, userId).Times(1).Return(user, nil)
+		mockRoleRepo.EXPECT().GetUserRoles(ctx, userId).Times(1).Return(roles, nil)
+		mockDeviceRecognizer.EXPECT().RecognizeDevice(ctx, gomock.Any(), client).Times(1).Return(model.Device{}, errors.New("db error"))
+
+		_, _, err := webAuthnUsecase.FinishLogin(ctx, "AQID", response, "", client)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestWebAuthnUsecase_ListCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		WebAuthnRepository: mockWebAuthnRepo,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		credentials := []model.WebAuthnCredential{{Id: 3, UserId: 10, Name: "Passkey"}}
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, int64(10)).Times(1).Return(credentials, nil)
+
+		result, err := webAuthnUsecase.ListCredentials(ctx, 10)
+		require.NoError(t, err)
+		require.Equal(t, credentials, result)
+	})
+
+	t.Run("failed - list credentials return error", func(t *testing.T) {
+		mockWebAuthnRepo.EXPECT().ListWebAuthnCredentials(ctx, int64(10)).Times(1).Return(nil, errors.New("db error"))
+
+		_, err := webAuthnUsecase.ListCredentials(ctx, 10)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusInternalServerError), utils.GetCode(err))
+	})
+}
+
+func TestWebAuthnUsecase_DeleteCredential(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+
+	defer func() {
+		ctx.Done()
+		ctrl.Finish()
+	}()
+
+	mockWebAuthnRepo := mocks.NewMockWebAuthnRepositoryInterface(ctrl)
+
+	webAuthnUsecase := NewWebAuthnUsecase(WebAuthnUsecaseOptions{
+		WebAuthnRepository: mockWebAuthnRepo,
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockWebAuthnRepo.EXPECT().DeleteWebAuthnCredential(ctx, int64(10), int64(3)).Times(1).Return(nil)
+
+		err := webAuthnUsecase.DeleteCredential(ctx, 10, 3)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - credential not found", func(t *testing.T) {
+		mockWebAuthnRepo.EXPECT().DeleteWebAuthnCredential(ctx, int64(10), int64(3)).Times(1).Return(sql.ErrNoRows)
+
+		err := webAuthnUsecase.DeleteCredential(ctx, 10, 3)
+		require.Error(t, err)
+		require.Equal(t, utils.ErrorCode(http.StatusNotFound), utils.GetCode(err))
+	})
+}
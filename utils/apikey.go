@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix marks a bearer token as an API key rather than a JWT.
+	APIKeyPrefix = "usk_"
+
+	apiKeySecretLen  = 32
+	apiKeyVisibleLen = len(APIKeyPrefix) + 8
+)
+
+// GenerateAPIKey returns a new random API key along with the prefix shown to
+// its owner and the hash stored in place of the key.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	secret := make([]byte, apiKeySecretLen)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + hex.EncodeToString(secret)
+
+	return key, key[:apiKeyVisibleLen], HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes an API key for lookup. The key carries enough entropy that
+// a plain SHA-256 is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCaptcha(t *testing.T) {
+	t.Run("success - http", func(t *testing.T) {
+		verifier, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderHTTP, VerifyURL: "https://example.com/siteverify", Secret: "secret", Timeout: time.Second})
+		require.NoError(t, err)
+		require.IsType(t, &HTTPCaptchaVerifier{}, verifier)
+	})
+
+	t.Run("success - fake", func(t *testing.T) {
+		verifier, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderFake, FakeToken: "pass", AllowFake: true})
+		require.NoError(t, err)
+		require.Equal(t, FakeCaptchaVerifier{Token: "pass"}, verifier)
+	})
+
+	t.Run("failed - http without secret", func(t *testing.T) {
+		_, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderHTTP, VerifyURL: "https://example.com/siteverify", Timeout: time.Second})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - fake without token", func(t *testing.T) {
+		_, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderFake, AllowFake: true})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - fake not allowed", func(t *testing.T) {
+		_, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderFake, FakeToken: "pass"})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, err := InitCaptcha(CaptchaOptions{Provider: "other"})
+		require.Error(t, err)
+	})
+}
+
+func TestHTTPCaptchaVerifier_Verify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.PostFormValue("response") {
+		case "pass":
+			if r.PostFormValue("remoteip") != "192.0.2.1" {
+				io.WriteString(w, `{"success": false, "error-codes": ["bad-remoteip"]}`)
+				return
+			}
+
+			io.WriteString(w, `{"success": true}`)
+		case "broken":
+			io.WriteString(w, `not json`)
+		default:
+			io.WriteString(w, `{"success": false, "error-codes": ["invalid-input-response"]}`)
+		}
+	}))
+	defer server.Close()
+
+	newVerifier := func(secret string) CaptchaVerifierInterface {
+		verifier, err := InitCaptcha(CaptchaOptions{Provider: CaptchaProviderHTTP, VerifyURL: server.URL, Secret: secret, Timeout: time.Second})
+		require.NoError(t, err)
+		return verifier
+	}
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, newVerifier("secret").Verify(context.Background(), "pass", "192.0.2.1"))
+	})
+
+	t.Run("failed - rejected token", func(t *testing.T) {
+		err := newVerifier("secret").Verify(context.Background(), "fail", "192.0.2.1")
+		require.True(t, errors.Is(err, ErrCaptchaRejected))
+	})
+
+	t.Run("failed - invalid response", func(t *testing.T) {
+		err := newVerifier("secret").Verify(context.Background(), "broken", "192.0.2.1")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrCaptchaRejected))
+	})
+
+	t.Run("failed - provider error", func(t *testing.T) {
+		err := newVerifier("wrong").Verify(context.Background(), "pass", "192.0.2.1")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrCaptchaRejected))
+	})
+}
+
+func TestFakeCaptchaVerifier_Verify(t *testing.T) {
+	verifier := FakeCaptchaVerifier{Token: "pass"}
+
+	t.Run("success", func(t *testing.T) {
+		require.NoError(t, verifier.Verify(context.Background(), "pass", ""))
+	})
+
+	t.Run("failed - other token", func(t *testing.T) {
+		require.Equal(t, ErrCaptchaRejected, verifier.Verify(context.Background(), "fail", ""))
+	})
+}
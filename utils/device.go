@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// DeviceFingerprint identifies a device by the device id its client sent and
+// its User-Agent. Clients that send no device id are told apart by their
+// User-Agent alone.
+func DeviceFingerprint(deviceId, userAgent string) string {
+	sum := sha256.Sum256([]byte(deviceId + "\x00" + userAgent))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	DPoPHeader     = "DPoP"
+	DPoPAuthScheme = "DPoP"
+
+	dpopProofType = "dpop+jwt"
+)
+
+// dpopAlgorithms are the signature algorithms accepted for DPoP proofs, see
+// RFC 9449 section 4.3.
+var dpopAlgorithms = []string{
+	jwt.SigningMethodES256.Alg(),
+	jwt.SigningMethodRS256.Alg(),
+	jwt.SigningMethodPS256.Alg(),
+	jwt.SigningMethodEdDSA.Alg(),
+}
+
+// DPoPInterface verifies DPoP proofs (RFC 9449), which prove that the client
+// holds the private key a token is bound to.
+type DPoPInterface interface {
+	VerifyProof(proof, method, requestURL, accessToken string) (string, error)
+}
+
+type DPoPOptions struct {
+	// ProofLifetime is how far the `iat` of a proof may be from now, in
+	// either direction. Proofs are remembered for as long to refuse replays.
+	ProofLifetime time.Duration
+}
+
+// DPoP keeps the identifiers of the proofs it accepted in memory. Replays are
+// only detected by the instance that accepted the proof.
+type DPoP struct {
+	opt       DPoPOptions
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+type dpopClaims struct {
+	JTI             string  `json:"jti"`
+	HTM             string  `json:"htm"`
+	HTU             string  `json:"htu"`
+	IAT             float64 `json:"iat"`
+	AccessTokenHash string  `json:"ath"`
+}
+
+// Valid is left to VerifyProof, which checks `iat` against the proof
+// lifetime rather than the issued-at rules of registered claims.
+func (c dpopClaims) Valid() error {
+	return nil
+}
+
+type dpopJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d"`
+}
+
+func InitDPoP(opt DPoPOptions) (DPoPInterface, error) {
+	if opt.ProofLifetime <= 0 {
+		return nil, errors.New("dpop proof lifetime must be positive")
+	}
+
+	return &DPoP{opt: opt, seen: make(map[string]time.Time)}, nil
+}
+
+// VerifyProof verifies the DPoP proof sent with a request of method to
+// requestURL and returns the JWK SHA-256 thumbprint (RFC 7638) of its key.
+// accessToken is the token the request is authorized with, whose hash the
+// proof must carry, or empty when the proof is sent to obtain a token.
+func (d *DPoP) VerifyProof(proof, method, requestURL, accessToken string) (string, error) {
+	var thumbprint string
+	var claims dpopClaims
+	parser := jwt.Parser{ValidMethods: dpopAlgorithms}
+	_, err := parser.ParseWithClaims(proof, &claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != dpopProofType {
+			return nil, fmt.Errorf("dpop proof has type %q", typ)
+		}
+
+		key, jkt, err := parseDPoPJWK(token.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+
+		thumbprint = jkt
+		return key, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if claims.JTI == "" {
+		return "", errors.New("dpop proof has no jti")
+	}
+
+	if claims.HTM != method {
+		return "", fmt.Errorf("dpop proof is for method %q, not %q", claims.HTM, method)
+	}
+
+	if !isSameDPoPTarget(claims.HTU, requestURL) {
+		return "", fmt.Errorf("dpop proof is for %q, not %q", claims.HTU, requestURL)
+	}
+
+	issuedAt := time.Unix(int64(claims.IAT), 0)
+	if age := time.Since(issuedAt); age > d.opt.ProofLifetime || age < -d.opt.ProofLifetime {
+		return "", fmt.Errorf("dpop proof was issued at %s", issuedAt)
+	}
+
+	if accessToken != "" {
+		hash := sha256.Sum256([]byte(accessToken))
+		if subtle.ConstantTimeCompare([]byte(claims.AccessTokenHash), []byte(base64.RawURLEncoding.EncodeToString(hash[:]))) != 1 {
+			return "", errors.New("dpop proof is for another access token")
+		}
+	}
+
+	if !d.remember(thumbprint+" "+claims.JTI, issuedAt.Add(d.opt.ProofLifetime)) {
+		return "", errors.New("dpop proof was already used")
+	}
+
+	return thumbprint, nil
+}
+
+// remember records a proof until it expires and reports whether it was
+// not recorded yet.
+func (d *DPoP) remember(id string, expiresAt time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(d.lastSweep) > d.opt.ProofLifetime {
+		for seenId, seenExpiresAt := range d.seen {
+			if now.After(seenExpiresAt) {
+				delete(d.seen, seenId)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	if seenExpiresAt, ok := d.seen[id]; ok && !now.After(seenExpiresAt) {
+		return false
+	}
+
+	d.seen[id] = expiresAt
+	return true
+}
+
+// parseDPoPJWK returns the public key of a proof's `jwk` header and its
+// thumbprint. Private keys are refused.
+func parseDPoPJWK(header interface{}) (interface{}, string, error) {
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var jwk dpopJWK
+	if err = json.Unmarshal(raw, &jwk); err != nil {
+		return nil, "", err
+	}
+
+	if jwk.D != "" {
+		return nil, "", errors.New("dpop proof jwk is a private key")
+	}
+
+	var key interface{}
+	var members map[string]string
+	switch jwk.Kty {
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, "", fmt.Errorf("unsupported dpop proof jwk curve %q", jwk.Crv)
+		}
+
+		x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return nil, "", errors.New("invalid dpop proof jwk coordinates")
+		}
+
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, "", errors.New("dpop proof jwk is not on its curve")
+		}
+
+		key = publicKey
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X, "y": jwk.Y}
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, "", errors.New("invalid dpop proof jwk modulus or exponent")
+		}
+
+		publicKey := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if publicKey.N.BitLen() < 2048 {
+			return nil, "", errors.New("dpop proof jwk is shorter than 2048 bits")
+		}
+
+		key = publicKey
+		members = map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N}
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if jwk.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, "", errors.New("invalid dpop proof jwk, only Ed25519 octet key pairs are supported")
+		}
+
+		key = ed25519.PublicKey(x)
+		members = map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X}
+	default:
+		return nil, "", fmt.Errorf("unsupported dpop proof jwk type %q", jwk.Kty)
+	}
+
+	// encoding/json sorts map keys, which gives the canonical form of the
+	// required members.
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return nil, "", err
+	}
+
+	thumbprint := sha256.Sum256(canonical)
+
+	return key, base64.RawURLEncoding.EncodeToString(thumbprint[:]), nil
+}
+
+// isSameDPoPTarget compares the `htu` of a proof with the request URL,
+// ignoring query and fragment.
+func isSameDPoPTarget(htu, requestURL string) bool {
+	target, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+
+	request, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(target.Scheme, request.Scheme) &&
+		strings.EqualFold(target.Host, request.Host) &&
+		target.EscapedPath() == request.EscapedPath()
+}
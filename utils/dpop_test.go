@@ -0,0 +1,225 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+const testDPoPURL = "https://users.example.com/v1/users/profile"
+
+// testDPoPClient signs DPoP proofs with a P-256 key.
+type testDPoPClient struct {
+	t   *testing.T
+	key *ecdsa.PrivateKey
+}
+
+func newTestDPoPClient(t *testing.T) testDPoPClient {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return testDPoPClient{t: t, key: key}
+}
+
+func (c testDPoPClient) jwk() map[string]interface{} {
+	return map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(c.key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(c.key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func (c testDPoPClient) thumbprint() string {
+	jwk := c.jwk()
+	canonical := fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, jwk["x"], jwk["y"])
+	hash := sha256.Sum256([]byte(canonical))
+
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// proof signs a proof for a request, the claims and header override the
+// defaults.
+func (c testDPoPClient) proof(method, url string, claims jwt.MapClaims, header map[string]interface{}) string {
+	jti, _, err := GenerateToken()
+	require.NoError(c.t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"jti": jti,
+		"htm": method,
+		"htu": url,
+		"iat": time.Now().Unix(),
+	})
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = c.jwk()
+
+	for name, value := range claims {
+		token.Claims.(jwt.MapClaims)[name] = value
+	}
+
+	for name, value := range header {
+		token.Header[name] = value
+	}
+
+	proof, err := token.SignedString(c.key)
+	require.NoError(c.t, err)
+
+	return proof
+}
+
+func testAccessTokenHash(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func TestInitDPoP(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		_, err := InitDPoP(DPoPOptions{ProofLifetime: time.Minute})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - no proof lifetime", func(t *testing.T) {
+		_, err := InitDPoP(DPoPOptions{})
+		require.Error(t, err)
+	})
+}
+
+func TestDPoP_VerifyProof(t *testing.T) {
+	dpop, err := InitDPoP(DPoPOptions{ProofLifetime: time.Minute})
+	require.NoError(t, err)
+
+	client := newTestDPoPClient(t)
+
+	t.Run("success", func(t *testing.T) {
+		thumbprint, err := dpop.VerifyProof(client.proof("POST", testDPoPURL, nil, nil), "POST", testDPoPURL, "")
+		require.NoError(t, err)
+		require.Equal(t, client.thumbprint(), thumbprint)
+	})
+
+	t.Run("success - access token", func(t *testing.T) {
+		proof := client.proof("GET", testDPoPURL, jwt.MapClaims{"ath": testAccessTokenHash("token")}, nil)
+
+		thumbprint, err := dpop.VerifyProof(proof, "GET", testDPoPURL, "token")
+		require.NoError(t, err)
+		require.Equal(t, client.thumbprint(), thumbprint)
+	})
+
+	t.Run("success - query and fragment are ignored", func(t *testing.T) {
+		proof := client.proof("GET", "https://USERS.example.com/v1/users/profile?fields=id#top", nil, nil)
+
+		_, err := dpop.VerifyProof(proof, "GET", testDPoPURL, "")
+		require.NoError(t, err)
+	})
+
+	t.Run("success - ed25519 key", func(t *testing.T) {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		x := base64.RawURLEncoding.EncodeToString(publicKey)
+		token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"jti": "ed25519", "htm": "POST", "htu": testDPoPURL, "iat": time.Now().Unix()})
+		token.Header["typ"] = "dpop+jwt"
+		token.Header["jwk"] = map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": x}
+		proof, err := token.SignedString(privateKey)
+		require.NoError(t, err)
+
+		thumbprint, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.NoError(t, err)
+
+		hash := sha256.Sum256([]byte(`{"crv":"Ed25519","kty":"OKP","x":"` + x + `"}`))
+		require.Equal(t, base64.RawURLEncoding.EncodeToString(hash[:]), thumbprint)
+	})
+
+	t.Run("failed - replayed proof", func(t *testing.T) {
+		proof := client.proof("POST", testDPoPURL, jwt.MapClaims{"jti": "replayed"}, nil)
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.NoError(t, err)
+
+		_, err = dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another method", func(t *testing.T) {
+		_, err := dpop.VerifyProof(client.proof("GET", testDPoPURL, nil, nil), "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another url", func(t *testing.T) {
+		_, err := dpop.VerifyProof(client.proof("POST", "https://users.example.com/v1/auth/login", nil, nil), "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - stale proof", func(t *testing.T) {
+		proof := client.proof("POST", testDPoPURL, jwt.MapClaims{"iat": time.Now().Add(-2 * time.Minute).Unix()}, nil)
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - proof from the future", func(t *testing.T) {
+		proof := client.proof("POST", testDPoPURL, jwt.MapClaims{"iat": time.Now().Add(2 * time.Minute).Unix()}, nil)
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - no jti", func(t *testing.T) {
+		_, err := dpop.VerifyProof(client.proof("POST", testDPoPURL, jwt.MapClaims{"jti": ""}, nil), "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another access token", func(t *testing.T) {
+		proof := client.proof("GET", testDPoPURL, jwt.MapClaims{"ath": testAccessTokenHash("other")}, nil)
+
+		_, err := dpop.VerifyProof(proof, "GET", testDPoPURL, "token")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - no access token hash", func(t *testing.T) {
+		_, err := dpop.VerifyProof(client.proof("GET", testDPoPURL, nil, nil), "GET", testDPoPURL, "token")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - not a dpop proof", func(t *testing.T) {
+		proof := client.proof("POST", testDPoPURL, nil, map[string]interface{}{"typ": "JWT"})
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - private key", func(t *testing.T) {
+		jwk := client.jwk()
+		jwk["d"] = base64.RawURLEncoding.EncodeToString(client.key.D.Bytes())
+		proof := client.proof("POST", testDPoPURL, nil, map[string]interface{}{"jwk": jwk})
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - signed by another key", func(t *testing.T) {
+		proof := client.proof("POST", testDPoPURL, nil, map[string]interface{}{"jwk": newTestDPoPClient(t).jwk()})
+
+		_, err := dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - symmetric algorithm", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"jti": "hmac", "htm": "POST", "htu": testDPoPURL, "iat": time.Now().Unix()})
+		token.Header["typ"] = "dpop+jwt"
+		token.Header["jwk"] = map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}
+		proof, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = dpop.VerifyProof(proof, "POST", testDPoPURL, "")
+		require.Error(t, err)
+	})
+}
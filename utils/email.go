@@ -0,0 +1,9 @@
+package utils
+
+import "strings"
+
+// NormalizeEmail returns the canonical form an email address is stored and
+// looked up in. Addresses are compared case-insensitively.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
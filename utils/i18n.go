@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Languages messages are available in, as ISO 639-1 codes.
+const (
+	LanguageEnglish    = "en"
+	LanguageIndonesian = "id"
+)
+
+// SupportedLanguages lists the languages messages are available in, the first
+// is used when a client accepts none of them.
+var SupportedLanguages = []string{LanguageEnglish, LanguageIndonesian}
+
+var languageMatcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+// messageCatalog holds the translations of messages shown to users, keyed by
+// their English format. English needs no entry, messages without a
+// translation are shown in English.
+var messageCatalog = map[string]map[string]string{
+	LanguageIndonesian: {
+		// Default messages of error codes.
+		`Invalid Input. Please Validate Your Input.`:                                                  `Masukan Tidak Valid. Silakan Periksa Masukan Anda.`,
+		`Unauthorized Access. You are not authorized to access this resource.`:                        `Akses Tidak Sah. Anda tidak berwenang mengakses sumber daya ini.`,
+		`Forbidden Access. You are forbidden to access this resource`:                                 `Akses Ditolak. Anda dilarang mengakses sumber daya ini`,
+		`Record Does Not Exist. Please Validate Your Input Or Contact Administrator.`:                 `Data Tidak Ditemukan. Silakan Periksa Masukan Anda Atau Hubungi Administrator.`,
+		`Record Has Existed and Must Be Unique. Please Validate Your Input Or Contact Administrator.`: `Data Sudah Ada dan Harus Unik. Silakan Periksa Masukan Anda Atau Hubungi Administrator.`,
+		`Unprocessable Entity. This entity can not be processed.`:                                     `Entitas Tidak Dapat Diproses. Entitas ini tidak dapat diproses.`,
+		`Internal Server Error. Please Call Administrator.`:                                           `Terjadi Kesalahan Pada Server. Silakan Hubungi Administrator.`,
+
+		// Errors.
+		`API Key Is Expired.`:                                                     `Kunci API Sudah Kedaluwarsa.`,
+		`Account Is Deleted.`:                                                     `Akun Sudah Dihapus.`,
+		`Account Is Locked. Please Contact Administrator.`:                        `Akun Terkunci. Silakan Hubungi Administrator.`,
+		`Account Is Pending Activation.`:                                          `Akun Menunggu Aktivasi.`,
+		`Account Is Suspended. Please Contact Administrator.`:                     `Akun Ditangguhkan. Silakan Hubungi Administrator.`,
+		`Accounts Provisioned By An Identity Provider Can Not Unlink Identities.`: `Akun Yang Dibuat Oleh Penyedia Identitas Tidak Dapat Melepas Identitas.`,
+		`Admins Cannot Be Impersonated.`:                                          `Admin Tidak Dapat Ditiru.`,
+		`Captcha Required.`:                                                       `Captcha Diperlukan.`,
+		`Client Certificate Of A Known Service Required.`:                         `Sertifikat Klien Dari Layanan Yang Dikenal Diperlukan.`,
+		`Could Not Verify The Authenticator's Response.`:                          `Tidak Dapat Memverifikasi Respons Autentikator.`,
+		`Could Not Verify The Identity Provider's Response.`:                      `Tidak Dapat Memverifikasi Respons Penyedia Identitas.`,
+		`Could Not Verify The Passkey.`:                                           `Tidak Dapat Memverifikasi Passkey.`,
+		`Download Link Is Expired.`:                                               `Tautan Unduhan Sudah Kedaluwarsa.`,
+		`Download Link Is Invalid.`:                                               `Tautan Unduhan Tidak Valid.`,
+		`Email Address Is Already Verified.`:                                      `Alamat Email Sudah Terverifikasi.`,
+		`Export Is Already In Progress.`:                                          `Ekspor Sedang Berlangsung.`,
+		`Identity Is Already Linked To An Account.`:                               `Identitas Sudah Terhubung Ke Sebuah Akun.`,
+		`Identity Provider Did Not Authenticate The User. %s`:                     `Penyedia Identitas Tidak Mengautentikasi Pengguna. %s`,
+		`Identity Provider Did Not Authenticate The User.`:                        `Penyedia Identitas Tidak Mengautentikasi Pengguna.`,
+		`Invalid API Key.`:                                                        `Kunci API Tidak Valid.`,
+		`Invalid Captcha.`:                                                        `Captcha Tidak Valid.`,
+		`Invalid DPoP Proof.`:                                                     `Bukti DPoP Tidak Valid.`,
+		`Invalid Input.`:                                                          `Masukan Tidak Valid.`,
+		`Invalid JWT Token`:                                                       `Token JWT Tidak Valid`,
+		`Invalid Or Expired Challenge.`:                                           `Tantangan Tidak Valid Atau Sudah Kedaluwarsa.`,
+		`Invalid Or Expired Login Request.`:                                       `Permintaan Login Tidak Valid Atau Sudah Kedaluwarsa.`,
+		`Invalid Revoke Token.`:                                                   `Token Pencabutan Tidak Valid.`,
+		`Invalid Verification Token.`:                                             `Token Verifikasi Tidak Valid.`,
+		`Log In With Your Phone Number And Link This Account First.`:              `Masuk Dengan Nomor Telepon Anda Dan Hubungkan Akun Ini Terlebih Dahulu.`,
+		`Missing SAML Response.`:                                                  `Respons SAML Tidak Ada.`,
+		`Operation Is Not Allowed For This Service.`:                              `Operasi Tidak Diizinkan Untuk Layanan Ini.`,
+		`Operation Is Not Allowed While Impersonating.`:                           `Operasi Tidak Diizinkan Saat Meniru Pengguna.`,
+		`Operation Is Not Allowed With An API Key.`:                               `Operasi Tidak Diizinkan Dengan Kunci API.`,
+		`Passkey Is Already Registered.`:                                          `Passkey Sudah Terdaftar.`,
+		`Passkeys Are Not Enabled.`:                                               `Passkey Tidak Diaktifkan.`,
+		`Please Confirm Your Password To Continue.`:                               `Silakan Konfirmasi Kata Sandi Anda Untuk Melanjutkan.`,
+		`Response does not match the spec: %s`:                                    `Respons tidak sesuai dengan spesifikasi: %s`,
+		`Scope %s Is Not Granted To You.`:                                         `Cakupan %s Tidak Diberikan Kepada Anda.`,
+		`Session Has Been Revoked.`:                                               `Sesi Sudah Dicabut.`,
+		`Unknown Identity Provider.`:                                              `Penyedia Identitas Tidak Dikenal.`,
+		`User Has No Email Address.`:                                              `Pengguna Tidak Memiliki Alamat Email.`,
+		`Verification Token Has Expired.`:                                         `Token Verifikasi Sudah Kedaluwarsa.`,
+		`Your Account Lacks A Name Or Valid Phone Number.`:                        `Akun Anda Tidak Memiliki Nama Atau Nomor Telepon Yang Valid.`,
+		`Your Phone Number Or Email Is Already Used By Another Account.`:          `Nomor Telepon Atau Email Anda Sudah Digunakan Oleh Akun Lain.`,
+
+		// Validation errors.
+		`%s has an invalid format`:                                   `%s memiliki format yang tidak valid`,
+		`%s is invalid`:                                              `%s tidak valid`,
+		`%s is invalid: %s`:                                          `%s tidak valid: %s`,
+		`%s is required`:                                             `%s wajib diisi`,
+		`%s must be at least %d characters long`:                     `%s harus terdiri dari minimal %d karakter`,
+		`%s must be at least %v`:                                     `%s harus minimal %v`,
+		`%s must be at most %v`:                                      `%s harus maksimal %v`,
+		`%s must be before %s`:                                       `%s harus sebelum %s`,
+		`%s must be between %d to %d characters long`:                `%s harus terdiri dari %d sampai %d karakter`,
+		`%s must be between %v and %v`:                               `%s harus antara %v dan %v`,
+		`%s must be in the future`:                                   `%s harus di masa depan`,
+		`%s must be of type %s`:                                      `%s harus bertipe %s`,
+		`%s must be one of %s`:                                       `%s harus salah satu dari %s`,
+		`%s must contain %d number`:                                  `%s harus mengandung %d angka`,
+		`%s must contain %d special character`:                       `%s harus mengandung %d karakter khusus`,
+		`%s must contain %d upper case`:                              `%s harus mengandung %d huruf kapital`,
+		`%s must contain at least %d items`:                          `%s harus berisi minimal %d item`,
+		`%s must be a valid email address`:                           `%s harus berupa alamat email yang valid`,
+		`%s must be a mobile number in international format from %s`: `%s harus berupa nomor ponsel dalam format internasional dari %s`,
+		`%s or %s is required`:                                       `%s atau %s wajib diisi`,
+		`only one of %s or %s can be given`:                          `hanya salah satu dari %s atau %s yang boleh diisi`,
+	},
+}
+
+// Translate renders the message of format in language, falling back to
+// English when it has no translation.
+func Translate(lang, format string, args ...interface{}) string {
+	if translation, ok := messageCatalog[lang][format]; ok {
+		format = translation
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// IsLanguageSupported tells whether messages are available in lang.
+func IsLanguageSupported(lang string) bool {
+	for _, supported := range SupportedLanguages {
+		if lang == supported {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NegotiateLanguage picks the supported language best matching an
+// Accept-Language header, English when none matches.
+func NegotiateLanguage(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return LanguageEnglish
+	}
+
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return LanguageEnglish
+	}
+
+	return SupportedLanguages[index]
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		require.Equal(t, "Cakupan users:read Tidak Diberikan Kepada Anda.", Translate(LanguageIndonesian, "Scope %s Is Not Granted To You.", "users:read"))
+	})
+
+	t.Run("success - english", func(t *testing.T) {
+		require.Equal(t, "Scope users:read Is Not Granted To You.", Translate(LanguageEnglish, "Scope %s Is Not Granted To You.", "users:read"))
+	})
+
+	t.Run("success - no translation", func(t *testing.T) {
+		require.Equal(t, "Something Else.", Translate(LanguageIndonesian, "Something Else."))
+	})
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expected       string
+	}{
+		{name: "success - indonesian", acceptLanguage: "id-ID,id;q=0.9,en;q=0.8", expected: LanguageIndonesian},
+		{name: "success - english", acceptLanguage: "en-US,en;q=0.9", expected: LanguageEnglish},
+		{name: "success - preferred by weight", acceptLanguage: "en;q=0.5,id;q=0.9", expected: LanguageIndonesian},
+		{name: "success - unsupported language", acceptLanguage: "fr-FR", expected: LanguageEnglish},
+		{name: "success - empty", acceptLanguage: "", expected: LanguageEnglish},
+		{name: "success - malformed", acceptLanguage: "id;q=x", expected: LanguageEnglish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, NegotiateLanguage(tt.acceptLanguage))
+		})
+	}
+}
+
+func TestGetLocalizedMessage(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := WrapWithKey(errors.New("expired"), ErrorCode(http.StatusUnauthorized), "API_KEY_EXPIRED", "API Key Is Expired.")
+		require.Equal(t, "Kunci API Sudah Kedaluwarsa.", GetLocalizedMessage(err, LanguageIndonesian))
+		require.Equal(t, "API Key Is Expired.", GetLocalizedMessage(err, LanguageEnglish))
+	})
+
+	t.Run("success - default message of code", func(t *testing.T) {
+		err := WrapWithCode(errors.New("not found"), ErrorCode(http.StatusNotFound), "")
+		require.Equal(t, "Data Tidak Ditemukan. Silakan Periksa Masukan Anda Atau Hubungi Administrator.", GetLocalizedMessage(err, LanguageIndonesian))
+	})
+
+	t.Run("success - not a stacktrace", func(t *testing.T) {
+		require.Empty(t, GetLocalizedMessage(errors.New("plain"), LanguageIndonesian))
+	})
+}
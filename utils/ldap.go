@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	ErrLDAPUserNotFound       = errors.New("ldap user not found")
+	ErrLDAPInvalidCredentials = errors.New("invalid ldap credentials")
+)
+
+// LDAPInterface authenticates users against a directory server.
+type LDAPInterface interface {
+	Authenticate(login, password string) (LDAPEntry, error)
+}
+
+type LDAPOptions struct {
+	// URL of the directory server, ldap:// or ldaps://.
+	URL      string
+	StartTLS bool
+	// BindDN and BindPassword are used to search for the user's entry,
+	// anonymously when empty.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the entry of a login, every %s is replaced with the
+	// escaped login.
+	UserFilter string
+	Attributes LDAPAttributes
+	Timeout    time.Duration
+}
+
+// LDAPAttributes names the directory attributes mapped onto an LDAPEntry. An
+// empty ID uses the entry's DN.
+type LDAPAttributes struct {
+	ID          string
+	FullName    string
+	Email       string
+	PhoneNumber string
+}
+
+// LDAPEntry is the directory entry of an authenticated user. Missing
+// attributes are left empty.
+type LDAPEntry struct {
+	DN          string
+	ID          string
+	FullName    string
+	Email       string
+	PhoneNumber string
+}
+
+// LDAP authenticates with a search and bind: the user's entry is looked up
+// with the service account, then bound to with the user's password.
+type LDAP struct {
+	opt LDAPOptions
+}
+
+func InitLDAP(opt LDAPOptions) (LDAPInterface, error) {
+	if _, err := url.Parse(opt.URL); err != nil {
+		return nil, err
+	}
+
+	if opt.BaseDN == "" {
+		return nil, errors.New("ldap base dn is empty")
+	}
+
+	if !strings.Contains(opt.UserFilter, "%s") {
+		return nil, errors.New("ldap user filter does not contain %s")
+	}
+
+	return LDAP{opt: opt}, nil
+}
+
+func (l LDAP) Authenticate(login, password string) (LDAPEntry, error) {
+	// Most servers treat a bind without password as an anonymous bind, which
+	// succeeds for any DN.
+	if password == "" {
+		return LDAPEntry{}, ErrLDAPInvalidCredentials
+	}
+
+	conn, err := l.dial()
+	if err != nil {
+		return LDAPEntry{}, err
+	}
+	defer conn.Close()
+
+	if l.opt.BindDN != "" {
+		if err := conn.Bind(l.opt.BindDN, l.opt.BindPassword); err != nil {
+			return LDAPEntry{}, fmt.Errorf("ldap service bind: %w", err)
+		}
+	}
+
+	filter := strings.ReplaceAll(l.opt.UserFilter, "%s", ldap.EscapeFilter(login))
+	request := ldap.NewSearchRequest(l.opt.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(l.opt.Timeout.Seconds()), false, filter, l.attributes(), nil)
+
+	result, err := conn.Search(request)
+	if err != nil {
+		return LDAPEntry{}, err
+	}
+
+	switch len(result.Entries) {
+	case 0:
+		return LDAPEntry{}, ErrLDAPUserNotFound
+	case 1:
+	default:
+		return LDAPEntry{}, fmt.Errorf("ldap user filter matches %d entries", len(result.Entries))
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return LDAPEntry{}, ErrLDAPInvalidCredentials
+		}
+
+		return LDAPEntry{}, err
+	}
+
+	user := LDAPEntry{
+		DN:          entry.DN,
+		ID:          entry.DN,
+		FullName:    entry.GetAttributeValue(l.opt.Attributes.FullName),
+		Email:       entry.GetAttributeValue(l.opt.Attributes.Email),
+		PhoneNumber: entry.GetAttributeValue(l.opt.Attributes.PhoneNumber),
+	}
+
+	if l.opt.Attributes.ID != "" {
+		// Binary identifiers, such as Active Directory's objectGUID, are
+		// hex encoded.
+		id := entry.GetRawAttributeValue(l.opt.Attributes.ID)
+		if len(id) == 0 {
+			return LDAPEntry{}, fmt.Errorf("ldap entry %s has no %s", entry.DN, l.opt.Attributes.ID)
+		}
+
+		user.ID = string(id)
+		if !utf8.Valid(id) {
+			user.ID = hex.EncodeToString(id)
+		}
+	}
+
+	return user, nil
+}
+
+func (l LDAP) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(l.opt.URL, ldap.DialWithDialer(&net.Dialer{Timeout: l.opt.Timeout}))
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(l.opt.Timeout)
+	if l.opt.StartTLS {
+		u, _ := url.Parse(l.opt.URL)
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (l LDAP) attributes() []string {
+	attributes := make([]string, 0, 4)
+	for _, attribute := range []string{l.opt.Attributes.ID, l.opt.Attributes.FullName, l.opt.Attributes.Email, l.opt.Attributes.PhoneNumber} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+
+	return attributes
+}
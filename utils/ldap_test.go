@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLDAPServiceDN       = "cn=service,dc=example,dc=com"
+	testLDAPServicePassword = "service-secret"
+)
+
+// testLDAPEntry is returned for searches with exactly filter and accepts
+// binds with password.
+type testLDAPEntry struct {
+	dn         string
+	password   string
+	filter     string
+	attributes map[string]string
+}
+
+// testLDAPServer is a minimal in-process directory server speaking just
+// enough of the protocol for a search and bind: simple binds, searches by the
+// service account and unbinds.
+type testLDAPServer struct {
+	listener net.Listener
+	entries  []testLDAPEntry
+}
+
+func newTestLDAPServer(t *testing.T, entries ...testLDAPEntry) *testLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testLDAPServer{listener: listener, entries: entries}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testLDAPServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *testLDAPServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	boundDN := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if s.bind(dn, password) {
+				code, boundDN = ldap.LDAPResultSuccess, dn
+			}
+
+			conn.Write(testLDAPResult(id, ldap.ApplicationBindResponse, code).Bytes())
+		case ldap.ApplicationSearchRequest:
+			if boundDN != testLDAPServiceDN {
+				conn.Write(testLDAPResult(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights).Bytes())
+				continue
+			}
+
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				conn.Write(testLDAPResult(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError).Bytes())
+				continue
+			}
+
+			for _, entry := range s.entries {
+				if entry.filter == filter {
+					conn.Write(testLDAPSearchEntry(id, entry).Bytes())
+				}
+			}
+
+			conn.Write(testLDAPResult(id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		default:
+			return
+		}
+	}
+}
+
+func (s *testLDAPServer) bind(dn, password string) bool {
+	if dn == testLDAPServiceDN {
+		return password == testLDAPServicePassword
+	}
+
+	for _, entry := range s.entries {
+		if entry.dn == dn {
+			return password != "" && password == entry.password
+		}
+	}
+
+	return false
+}
+
+func testLDAPMessage(id int64, op *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+	message.AppendChild(op)
+
+	return message
+}
+
+func testLDAPResult(id int64, tag ber.Tag, code uint16) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+
+	return testLDAPMessage(id, op)
+}
+
+func testLDAPSearchEntry(id int64, entry testLDAPEntry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, ""))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+	for name, value := range entry.attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+		values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, ""))
+		attribute.AppendChild(values)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+
+	return testLDAPMessage(id, op)
+}
+
+func testLDAPOptions(url string) LDAPOptions {
+	return LDAPOptions{
+		URL:          url,
+		BindDN:       testLDAPServiceDN,
+		BindPassword: testLDAPServicePassword,
+		BaseDN:       "dc=example,dc=com",
+		UserFilter:   "(&(objectClass=person)(mail=%s))",
+		Attributes: LDAPAttributes{
+			ID:          "entryUUID",
+			FullName:    "cn",
+			Email:       "mail",
+			PhoneNumber: "telephoneNumber",
+		},
+		Timeout: time.Second,
+	}
+}
+
+func TestInitLDAP(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		_, err := InitLDAP(testLDAPOptions("ldap://localhost:389"))
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - no base dn", func(t *testing.T) {
+		opt := testLDAPOptions("ldap://localhost:389")
+		opt.BaseDN = ""
+
+		_, err := InitLDAP(opt)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - filter without login", func(t *testing.T) {
+		opt := testLDAPOptions("ldap://localhost:389")
+		opt.UserFilter = "(objectClass=person)"
+
+		_, err := InitLDAP(opt)
+		require.Error(t, err)
+	})
+}
+
+func TestLDAP_Authenticate(t *testing.T) {
+	john := testLDAPEntry{
+		dn:       "uid=john,ou=people,dc=example,dc=com",
+		password: "john-secret",
+		filter:   "(&(objectClass=person)(mail=john@example.com))",
+		attributes: map[string]string{
+			"entryUUID":       "6f1d0b2e-3c4a-4e0e-9d63-2a7f0e5b8c11",
+			"cn":              "John Doe",
+			"mail":            "john@example.com",
+			"telephoneNumber": "+6281234567890",
+		},
+	}
+	twin := testLDAPEntry{
+		dn:       "uid=twin,ou=people,dc=example,dc=com",
+		password: "twin-secret",
+		filter:   "(&(objectClass=person)(mail=twin@example.com))",
+	}
+
+	server := newTestLDAPServer(t, john, twin, twin)
+	defer server.Close()
+
+	t.Run("success", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		entry, err := l.Authenticate("john@example.com", "john-secret")
+		require.NoError(t, err)
+		require.Equal(t, LDAPEntry{
+			DN:          "uid=john,ou=people,dc=example,dc=com",
+			ID:          "6f1d0b2e-3c4a-4e0e-9d63-2a7f0e5b8c11",
+			FullName:    "John Doe",
+			Email:       "john@example.com",
+			PhoneNumber: "+6281234567890",
+		}, entry)
+	})
+
+	t.Run("success - dn as id", func(t *testing.T) {
+		opt := testLDAPOptions(server.URL())
+		opt.Attributes.ID = ""
+		l, err := InitLDAP(opt)
+		require.NoError(t, err)
+
+		entry, err := l.Authenticate("john@example.com", "john-secret")
+		require.NoError(t, err)
+		require.Equal(t, "uid=john,ou=people,dc=example,dc=com", entry.ID)
+	})
+
+	t.Run("failed - wrong password", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("john@example.com", "wrong")
+		require.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+	})
+
+	t.Run("failed - empty password", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("john@example.com", "")
+		require.ErrorIs(t, err, ErrLDAPInvalidCredentials)
+	})
+
+	t.Run("failed - unknown user", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("jane@example.com", "jane-secret")
+		require.ErrorIs(t, err, ErrLDAPUserNotFound)
+	})
+
+	t.Run("failed - login is escaped", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("*", "john-secret")
+		require.ErrorIs(t, err, ErrLDAPUserNotFound)
+	})
+
+	t.Run("failed - ambiguous filter", func(t *testing.T) {
+		l, err := InitLDAP(testLDAPOptions(server.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("twin@example.com", "twin-secret")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrLDAPUserNotFound)
+	})
+
+	t.Run("failed - missing id attribute", func(t *testing.T) {
+		single := newTestLDAPServer(t, twin)
+		defer single.Close()
+
+		l, err := InitLDAP(testLDAPOptions(single.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("twin@example.com", "twin-secret")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - wrong service password", func(t *testing.T) {
+		opt := testLDAPOptions(server.URL())
+		opt.BindPassword = "wrong"
+		l, err := InitLDAP(opt)
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("john@example.com", "john-secret")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrLDAPInvalidCredentials)
+	})
+
+	t.Run("failed - server unreachable", func(t *testing.T) {
+		closed := newTestLDAPServer(t)
+		closed.Close()
+
+		l, err := InitLDAP(testLDAPOptions(closed.URL()))
+		require.NoError(t, err)
+
+		_, err = l.Authenticate("john@example.com", "john-secret")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"embed"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+// Templates in utils/templates, each defines a "<name>.subject" and a
+// "<name>.body".
+const (
+	EmailTemplateVerification  = "verification"
+	EmailTemplateSecurityAlert = "security_alert"
+	EmailTemplateNewDevice     = "new_device"
+)
+
+const (
+	MailerProviderSMTP = "smtp"
+	MailerProviderLog  = "log"
+)
+
+//go:embed templates/*.tmpl
+var emailTemplateFS embed.FS
+
+var emailTemplates = template.Must(template.ParseFS(emailTemplateFS, "templates/*.tmpl"))
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// MailerInterface sends plain text emails.
+type MailerInterface interface {
+	Send(ctx context.Context, email Email) error
+}
+
+type MailerOptions struct {
+	// Provider is MailerProviderSMTP or MailerProviderLog, which writes
+	// emails to the log instead and is only meant for development.
+	Provider string
+	// Addr is the host:port of the SMTP server.
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer delivers emails through an SMTP server, upgrading the
+// connection with STARTTLS when the server offers it.
+type SMTPMailer struct {
+	opt       MailerOptions
+	host      string
+	tlsConfig *tls.Config
+}
+
+// LogMailer writes emails to the log instead of sending them.
+type LogMailer struct{}
+
+func InitMailer(opt MailerOptions) (MailerInterface, error) {
+	switch opt.Provider {
+	case MailerProviderSMTP:
+		if opt.Addr == "" {
+			return nil, errors.New("mailer smtp address is empty")
+		}
+
+		if opt.From == "" {
+			return nil, errors.New("mailer from address is empty")
+		}
+
+		host, _, err := net.SplitHostPort(opt.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return SMTPMailer{opt: opt, host: host, tlsConfig: &tls.Config{ServerName: host}}, nil
+	case MailerProviderLog:
+		return LogMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer provider %q", opt.Provider)
+	}
+}
+
+// RenderEmail renders the named template into an email to the given address.
+func RenderEmail(to, name string, data interface{}) (Email, error) {
+	var subject, body bytes.Buffer
+	if err := emailTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Email{}, err
+	}
+
+	if err := emailTemplates.ExecuteTemplate(&body, name+".body", data); err != nil {
+		return Email{}, err
+	}
+
+	return Email{To: to, Subject: strings.TrimSpace(subject.String()), Body: strings.TrimSpace(body.String()) + "\r\n"}, nil
+}
+
+func (m SMTPMailer) Send(ctx context.Context, email Email) error {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", m.opt.Addr)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if m.opt.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.opt.Username, m.opt.Password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.opt.From); err != nil {
+		return err
+	}
+
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(m.buildMessage(email)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m SMTPMailer) buildMessage(email Email) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.opt.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(strings.ReplaceAll(email.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return msg.Bytes()
+}
+
+func (m LogMailer) Send(ctx context.Context, email Email) error {
+	log.Infof("email to %s: %s\n%s", email.To, email.Subject, email.Body)
+	return nil
+}
@@ -0,0 +1,354 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSMTPMessage is a message accepted by testSMTPServer.
+type testSMTPMessage struct {
+	from string
+	to   []string
+	data string
+	tls  bool
+}
+
+// testSMTPServer is a minimal in-process mail server speaking just enough of
+// the protocol to deliver a message: EHLO, STARTTLS when it has a TLS config,
+// AUTH PLAIN when it has a username, MAIL, RCPT, DATA and QUIT.
+type testSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	username  string
+	password  string
+	messages  chan testSMTPMessage
+}
+
+func newTestSMTPServer(t *testing.T, tlsConfig *tls.Config, username, password string) *testSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testSMTPServer{
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		username:  username,
+		password:  password,
+		messages:  make(chan testSMTPMessage, 1),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *testSMTPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testSMTPServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testSMTPServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 test ESMTP")
+
+	var msg testSMTPMessage
+	authenticated := false
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"test"}
+			if s.tlsConfig != nil && !msg.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.username != "" {
+				lines = append(lines, "AUTH PLAIN")
+			}
+
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				text.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			text.PrintfLine("220 ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+
+			conn, text = tlsConn, textproto.NewConn(tlsConn)
+			msg = testSMTPMessage{tls: true}
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			if string(credentials) != "\x00"+s.username+"\x00"+s.password {
+				text.PrintfLine("535 invalid credentials")
+				continue
+			}
+
+			authenticated = true
+			text.PrintfLine("235 authenticated")
+		case "MAIL":
+			if s.username != "" && !authenticated {
+				text.PrintfLine("530 authentication required")
+				continue
+			}
+
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			text.PrintfLine("250 ok")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			text.PrintfLine("250 ok")
+		case "DATA":
+			text.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			msg.data = string(data)
+			s.messages <- msg
+			text.PrintfLine("250 queued")
+		case "QUIT":
+			text.PrintfLine("221 bye")
+			return
+		default:
+			text.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+func TestInitMailer(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mailer, err := InitMailer(MailerOptions{Provider: MailerProviderSMTP, Addr: "smtp.example.com:587", From: "no-reply@example.com"})
+		require.NoError(t, err)
+		require.Equal(t, "smtp.example.com", mailer.(SMTPMailer).host)
+		require.Equal(t, "smtp.example.com", mailer.(SMTPMailer).tlsConfig.ServerName)
+	})
+
+	t.Run("success - log", func(t *testing.T) {
+		mailer, err := InitMailer(MailerOptions{Provider: MailerProviderLog})
+		require.NoError(t, err)
+		require.Equal(t, LogMailer{}, mailer)
+	})
+
+	t.Run("failed - smtp without address", func(t *testing.T) {
+		_, err := InitMailer(MailerOptions{Provider: MailerProviderSMTP, From: "no-reply@example.com"})
+		require.EqualError(t, err, "mailer smtp address is empty")
+	})
+
+	t.Run("failed - smtp without from address", func(t *testing.T) {
+		_, err := InitMailer(MailerOptions{Provider: MailerProviderSMTP, Addr: "smtp.example.com:587"})
+		require.EqualError(t, err, "mailer from address is empty")
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, err := InitMailer(MailerOptions{Addr: "smtp.example.com:587", From: "no-reply@example.com"})
+		require.EqualError(t, err, `unknown mailer provider ""`)
+	})
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	ca := testCA(t, "ca")
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{
+		testCertificate(t, &x509.Certificate{IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}}, &ca),
+	}}
+
+	email := Email{To: "user@example.com", Subject: "Hello", Body: "Hi there\n"}
+	newMailer := func(t *testing.T, addr, username, password string) SMTPMailer {
+		mailer, err := InitMailer(MailerOptions{Provider: MailerProviderSMTP, Addr: addr, Username: username, Password: password, From: "no-reply@example.com"})
+		require.NoError(t, err)
+
+		smtpMailer := mailer.(SMTPMailer)
+		smtpMailer.tlsConfig.RootCAs = roots
+		return smtpMailer
+	}
+	send := func(mailer SMTPMailer) error {
+		ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+		defer cancel()
+
+		return mailer.Send(ctx, email)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		server := newTestSMTPServer(t, nil, "", "")
+		defer server.Close()
+
+		err := send(newMailer(t, server.Addr(), "", ""))
+		require.NoError(t, err)
+
+		msg := <-server.messages
+		require.False(t, msg.tls)
+		require.Equal(t, "no-reply@example.com", msg.from)
+		require.Equal(t, []string{"user@example.com"}, msg.to)
+		require.Contains(t, msg.data, "Subject: Hello\n")
+		require.True(t, strings.HasSuffix(msg.data, "\nHi there\n"))
+	})
+
+	t.Run("success - starttls and auth", func(t *testing.T) {
+		server := newTestSMTPServer(t, serverTLS, "mailer", "secret")
+		defer server.Close()
+
+		err := send(newMailer(t, server.Addr(), "mailer", "secret"))
+		require.NoError(t, err)
+
+		msg := <-server.messages
+		require.True(t, msg.tls)
+		require.Equal(t, []string{"user@example.com"}, msg.to)
+	})
+
+	t.Run("failed - untrusted certificate", func(t *testing.T) {
+		server := newTestSMTPServer(t, serverTLS, "", "")
+		defer server.Close()
+
+		mailer := newMailer(t, server.Addr(), "", "")
+		mailer.tlsConfig.RootCAs = x509.NewCertPool()
+
+		err := send(mailer)
+		require.Error(t, err)
+		require.Empty(t, server.messages)
+	})
+
+	t.Run("failed - invalid credentials", func(t *testing.T) {
+		server := newTestSMTPServer(t, serverTLS, "mailer", "secret")
+		defer server.Close()
+
+		err := send(newMailer(t, server.Addr(), "mailer", "wrong"))
+		require.Error(t, err)
+		require.Empty(t, server.messages)
+	})
+
+	t.Run("failed - connection refused", func(t *testing.T) {
+		server := newTestSMTPServer(t, nil, "", "")
+		server.Close()
+
+		err := send(newMailer(t, server.Addr(), "", ""))
+		require.Error(t, err)
+	})
+}
+
+func TestSMTPMailer_buildMessage(t *testing.T) {
+	mailer := SMTPMailer{opt: MailerOptions{From: "no-reply@example.com"}}
+
+	t.Run("success", func(t *testing.T) {
+		msg, err := textproto.NewReader(bufio.NewReader(strings.NewReader(string(mailer.buildMessage(Email{
+			To:      "user@example.com",
+			Subject: "Hello",
+			Body:    "line one\nline two\r\n",
+		}))))).ReadMIMEHeader()
+		require.NoError(t, err)
+		require.Equal(t, "no-reply@example.com", msg.Get("From"))
+		require.Equal(t, "user@example.com", msg.Get("To"))
+		require.Equal(t, "Hello", msg.Get("Subject"))
+		require.Equal(t, "1.0", msg.Get("MIME-Version"))
+		require.Equal(t, "text/plain; charset=UTF-8", msg.Get("Content-Type"))
+
+		_, err = time.Parse(time.RFC1123Z, msg.Get("Date"))
+		require.NoError(t, err)
+	})
+
+	t.Run("success - crlf body", func(t *testing.T) {
+		msg := string(mailer.buildMessage(Email{To: "user@example.com", Subject: "Hello", Body: "line one\nline two\r\n"}))
+		require.True(t, strings.HasSuffix(msg, "\r\n\r\nline one\r\nline two\r\n"))
+	})
+
+	t.Run("success - encoded subject", func(t *testing.T) {
+		msg := string(mailer.buildMessage(Email{To: "user@example.com", Subject: "Überprüfung", Body: "Hi\n"}))
+		require.Contains(t, msg, "Subject: =?utf-8?q?=C3=9Cberpr=C3=BCfung?=\r\n")
+	})
+}
+
+func TestRenderEmail(t *testing.T) {
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	t.Run("success - verification", func(t *testing.T) {
+		email, err := RenderEmail("user@example.com", EmailTemplateVerification, map[string]interface{}{
+			"FullName":  "Jane Doe",
+			"Email":     "user@example.com",
+			"Link":      "https://example.com/verify?token=abc",
+			"ExpiresIn": "24 hours",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "user@example.com", email.To)
+		require.Equal(t, "Verify your email address", email.Subject)
+		require.True(t, strings.HasPrefix(email.Body, "Hi Jane Doe,\n"))
+		require.Contains(t, email.Body, "\nhttps://example.com/verify?token=abc\n")
+		require.Contains(t, email.Body, "The link expires in 24 hours.")
+		require.True(t, strings.HasSuffix(email.Body, "ignore this email.\r\n"))
+	})
+
+	t.Run("success - security alert", func(t *testing.T) {
+		email, err := RenderEmail("user@example.com", EmailTemplateSecurityAlert, map[string]interface{}{
+			"FullName": "Jane Doe",
+			"Event":    "Your password was changed.",
+			"Time":     now,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Security alert for your account", email.Subject)
+		require.Contains(t, email.Body, "\nYour password was changed.\n")
+		require.Contains(t, email.Body, "This happened on Mon, 19 Oct 2026 08:30 UTC.")
+	})
+
+	t.Run("success - new device", func(t *testing.T) {
+		email, err := RenderEmail("user@example.com", EmailTemplateNewDevice, map[string]interface{}{
+			"FullName":  "Jane Doe",
+			"Time":      now,
+			"UserAgent": "Mozilla/5.0",
+			"IPAddress": "203.0.113.7",
+			"RevokeURL": "https://example.com/revoke?token=abc",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "New login to your account", email.Subject)
+		require.Contains(t, email.Body, "Device: Mozilla/5.0\nIP address: 203.0.113.7\n")
+		require.True(t, strings.HasSuffix(email.Body, "\nhttps://example.com/revoke?token=abc\r\n"))
+	})
+
+	t.Run("success - new device without details", func(t *testing.T) {
+		email, err := RenderEmail("user@example.com", EmailTemplateNewDevice, map[string]interface{}{
+			"FullName":  "Jane Doe",
+			"Time":      now,
+			"UserAgent": "",
+			"IPAddress": "",
+			"RevokeURL": "",
+		})
+		require.NoError(t, err)
+		require.Contains(t, email.Body, "Device: unknown\nIP address: unknown\n")
+		require.Contains(t, email.Body, "please reset your password and contact support.")
+	})
+
+	t.Run("failed - unknown template", func(t *testing.T) {
+		_, err := RenderEmail("user@example.com", "unknown", nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,341 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/guregu/null/v5"
+)
+
+// migrationLockId identifies the advisory lock held while migrating, so
+// instances started together do not apply the same migration twice.
+const migrationLockId int64 = 7236518204
+
+// migrationVersionLayout numbers migrations by their creation time, so
+// migrations written on different branches do not clash.
+const migrationVersionLayout = "20060102150405"
+
+var (
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	migrationNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+)
+
+// MigratorInterface applies and reverts the versioned schema migrations,
+// recording the applied ones in the schema_migrations table.
+type MigratorInterface interface {
+	Up(ctx context.Context, steps int) ([]Migration, error)
+	Down(ctx context.Context, steps int) ([]Migration, error)
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+// Migration is a pair of <version>_<name>.up.sql and .down.sql scripts.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt null.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+type MigratorOptions struct {
+	DB *sql.DB
+	FS fs.FS
+}
+
+func InitMigrator(opt MigratorOptions) (MigratorInterface, error) {
+	migrations, err := readMigrations(opt.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: opt.DB, migrations: migrations}, nil
+}
+
+// readMigrations lists the migrations of fsys by version. Every migration
+// must be revertible, so both scripts are required.
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	scripts := map[int64]int{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %q is not named <version>_<name>.up.sql or .down.sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %q: %w", entry.Name(), err)
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migrations %d_%s and %d_%s share a version", version, migration.Name, version, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+		scripts[version]++
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if scripts[migration.Version] != 2 {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies pending migrations in order, at most steps of them unless steps
+// is 0, and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if steps > 0 && len(done) == steps {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			query := "INSERT INTO schema_migrations(version, name) VALUES ($1, $2);"
+			if err := runMigration(ctx, conn, migration, migration.Up, query, migration.Version, migration.Name); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the latest applied migrations, at most steps of them unless
+// steps is 0, and returns the reverted ones.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, version := range versions {
+			if steps > 0 && len(done) == steps {
+				break
+			}
+
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("migration %d is applied but has no scripts", version)
+			}
+
+			query := "DELETE FROM schema_migrations WHERE version = $1;"
+			if err := runMigration(ctx, conn, migration, migration.Down, query, migration.Version); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status lists every migration with the time it was applied, if it was.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// Status only reads, so a database never migrated has nothing applied
+	// rather than getting the table created.
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL;").Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]time.Time{}
+	if exists {
+		if applied, err = appliedMigrations(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = null.TimeFrom(appliedAt)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock runs fn on a single connection holding the migration lock, as
+// advisory locks belong to the session that took them.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1);", migrationLockId); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockId)
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		"version" BIGINT PRIMARY KEY,
+		"name" VARCHAR(255) NOT NULL,
+		"applied_at" TIMESTAMP NOT NULL DEFAULT NOW()
+	);`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration runs script and records it with query in one transaction, so
+// a failing script leaves neither its changes nor a record behind.
+func runMigration(ctx context.Context, conn *sql.Conn, migration Migration, script, query string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateMigration writes up and down scripts to fill in for a new migration
+// into dir, versioned by now, and returns their paths.
+func CreateMigration(dir, name string, now time.Time) ([]string, error) {
+	if !migrationNameRegex.MatchString(name) {
+		return nil, errors.New("migration name must be lower case letters, digits and underscores")
+	}
+
+	prefix := fmt.Sprintf("%s_%s", now.UTC().Format(migrationVersionLayout), name)
+	paths := []string{
+		filepath.Join(dir, prefix+".up.sql"),
+		filepath.Join(dir, prefix+".down.sql"),
+	}
+
+	for i, direction := range []string{"up", "down"} {
+		file, err := os.OpenFile(paths[i], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = fmt.Fprintf(file, "/** %s %s. */\n", direction, name)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
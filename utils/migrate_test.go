@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/SawitProRecruitment/UserService/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitMigrator(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"2_add_nickname.up.sql":     {Data: []byte("ALTER TABLE users ADD nickname TEXT;")},
+			"2_add_nickname.down.sql":   {Data: []byte("ALTER TABLE users DROP nickname;")},
+			"1_initial_schema.up.sql":   {Data: []byte("CREATE TABLE users ();")},
+			"1_initial_schema.down.sql": {Data: []byte("DROP TABLE users;")},
+		}
+
+		migrator, err := InitMigrator(MigratorOptions{FS: fsys})
+		require.NoError(t, err)
+		require.Equal(t, []Migration{
+			{Version: 1, Name: "initial_schema", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+			{Version: 2, Name: "add_nickname", Up: "ALTER TABLE users ADD nickname TEXT;", Down: "ALTER TABLE users DROP nickname;"},
+		}, migrator.(*Migrator).migrations)
+	})
+
+	t.Run("success - embedded migrations", func(t *testing.T) {
+		migrator, err := InitMigrator(MigratorOptions{FS: migrations.FS})
+		require.NoError(t, err)
+
+		initial := migrator.(*Migrator).migrations[0]
+		require.Equal(t, "initial_schema", initial.Name)
+		require.NotContains(t, initial.Down, "DROP TABLE")
+	})
+
+	t.Run("failed - missing down script", func(t *testing.T) {
+		_, err := InitMigrator(MigratorOptions{FS: fstest.MapFS{"1_initial_schema.up.sql": {}}})
+		require.EqualError(t, err, "migration 1_initial_schema needs both an up and a down script")
+	})
+
+	t.Run("failed - shared version", func(t *testing.T) {
+		_, err := InitMigrator(MigratorOptions{FS: fstest.MapFS{
+			"1_initial_schema.up.sql": {},
+			"1_add_nickname.down.sql": {},
+		}})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - invalid name", func(t *testing.T) {
+		_, err := InitMigrator(MigratorOptions{FS: fstest.MapFS{"initial_schema.sql": {}}})
+		require.Error(t, err)
+	})
+}
+
+func TestMigrator_Up(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "initial_schema", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "add_nickname", Up: "ALTER TABLE users ADD nickname TEXT;", Down: "ALTER TABLE users DROP nickname;"},
+	}}
+
+	expectLock := func() {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1);")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations;")).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	}
+	insertQuery := "INSERT INTO schema_migrations(version, name) VALUES ($1, $2);"
+	unlockQuery := "SELECT pg_advisory_unlock($1);"
+
+	t.Run("success", func(t *testing.T) {
+		expectLock()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE users ADD nickname TEXT;")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(insertQuery)).WithArgs(int64(2), "add_nickname").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Up(ctx, 0)
+		require.NoError(t, err)
+		require.Equal(t, migrator.migrations[1:], done)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - script error", func(t *testing.T) {
+		expectLock()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE users ADD nickname TEXT;")).WillReturnError(errors.New("db error"))
+		mock.ExpectRollback()
+		mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Up(ctx, 0)
+		require.EqualError(t, err, "migration 2_add_nickname: db error")
+		require.Empty(t, done)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - lock error", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1);")).WithArgs(migrationLockId).WillReturnError(errors.New("db error"))
+
+		_, err := migrator.Up(ctx, 0)
+		require.Error(t, err)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "initial_schema", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "add_nickname", Up: "ALTER TABLE users ADD nickname TEXT;", Down: "ALTER TABLE users DROP nickname;"},
+	}}
+
+	expectLock := func(versions ...int64) {
+		rows := sqlmock.NewRows([]string{"version", "applied_at"})
+		for _, version := range versions {
+			rows.AddRow(version, time.Now())
+		}
+
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1);")).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations;")).WillReturnRows(rows)
+	}
+	deleteQuery := "DELETE FROM schema_migrations WHERE version = $1;"
+	unlockQuery := "SELECT pg_advisory_unlock($1);"
+
+	t.Run("success", func(t *testing.T) {
+		expectLock(1, 2)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE users DROP nickname;")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(deleteQuery)).WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Down(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, migrator.migrations[1:], done)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - unknown migration", func(t *testing.T) {
+		expectLock(3)
+		mock.ExpectExec(regexp.QuoteMeta(unlockQuery)).WithArgs(migrationLockId).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Down(ctx, 1)
+		require.EqualError(t, err, "migration 3 is applied but has no scripts")
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.TODO()
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "initial_schema"},
+		{Version: 2, Name: "add_nickname"},
+	}}
+	existsQuery := "SELECT to_regclass('schema_migrations') IS NOT NULL;"
+
+	t.Run("success", func(t *testing.T) {
+		appliedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations;")).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		require.Equal(t, appliedAt, statuses[0].AppliedAt.Time)
+		require.False(t, statuses[1].AppliedAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+
+	t.Run("success - never migrated", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(existsQuery)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		statuses, err := migrator.Status(ctx)
+		require.NoError(t, err)
+		require.False(t, statuses[0].AppliedAt.Valid)
+		require.False(t, statuses[1].AppliedAt.Valid)
+
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 8, 30, 0, 0, time.UTC)
+
+	t.Run("success", func(t *testing.T) {
+		paths, err := CreateMigration(dir, "add_nickname", now)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			filepath.Join(dir, "20261019083000_add_nickname.up.sql"),
+			filepath.Join(dir, "20261019083000_add_nickname.down.sql"),
+		}, paths)
+
+		_, err = InitMigrator(MigratorOptions{FS: os.DirFS(dir)})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - already exists", func(t *testing.T) {
+		_, err := CreateMigration(dir, "add_nickname", now)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - invalid name", func(t *testing.T) {
+		_, err := CreateMigration(dir, "Add Nickname", now)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var ErrUnknownOIDCProvider = errors.New("unknown oidc provider")
+
+type OIDCInterface interface {
+	AuthCodeURL(provider, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, provider, code, codeVerifier string) (OIDCIdentity, error)
+}
+
+// OIDCProviderOptions configures an upstream OpenID Connect provider. Name
+// identifies it in the API paths, RedirectURL must point at its callback.
+type OIDCProviderOptions struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// OIDCIdentity holds the claims of a verified ID token the service relies on.
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nonce         string
+}
+
+type OIDC struct {
+	providers map[string]oidcProvider
+}
+
+type oidcProvider struct {
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// InitOIDC discovers the endpoints and signing keys of every provider from
+// its issuer's `.well-known/openid-configuration`.
+func InitOIDC(ctx context.Context, opts []OIDCProviderOptions) (OIDCInterface, error) {
+	o := OIDC{providers: make(map[string]oidcProvider, len(opts))}
+	for _, opt := range opts {
+		provider, err := oidc.NewProvider(ctx, opt.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("oidc provider %s: %w", opt.Name, err)
+		}
+
+		o.providers[opt.Name] = oidcProvider{
+			config: oauth2.Config{
+				ClientID:     opt.ClientID,
+				ClientSecret: opt.ClientSecret,
+				RedirectURL:  opt.RedirectURL,
+				Endpoint:     provider.Endpoint(),
+				Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+			},
+			verifier: provider.Verifier(&oidc.Config{ClientID: opt.ClientID}),
+		}
+	}
+
+	return o, nil
+}
+
+// AuthCodeURL returns the provider's authorization URL the user is redirected
+// to. The code verifier is sent as an S256 PKCE challenge.
+func (o OIDC) AuthCodeURL(provider, state, nonce, codeVerifier string) (string, error) {
+	p, ok := o.providers[provider]
+	if !ok {
+		return "", ErrUnknownOIDCProvider
+	}
+
+	return p.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier)), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+// against the provider's keys, issuer and our client id. Checking the nonce
+// is left to the caller.
+func (o OIDC) Exchange(ctx context.Context, provider, code, codeVerifier string) (OIDCIdentity, error) {
+	p, ok := o.providers[provider]
+	if !ok {
+		return OIDCIdentity{}, ErrUnknownOIDCProvider
+	}
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return OIDCIdentity{}, errors.New("token response has no id_token")
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return OIDCIdentity{}, err
+	}
+
+	identity := OIDCIdentity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Nonce:         idToken.Nonce,
+	}
+
+	return identity, nil
+}
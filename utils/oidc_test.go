@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdP is a minimal OpenID Connect provider issuing ID tokens with claims,
+// signed with signingKey. Its JWKS only publishes the key it was created with.
+type mockIdP struct {
+	*httptest.Server
+	signingKey *rsa.PrivateKey
+	claims     jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{signingKey: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != "code" || r.PostFormValue("code_verifier") == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(idp.signingKey)
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+
+	idp.Server = httptest.NewServer(mux)
+	idp.claims = jwt.MapClaims{
+		"iss":            idp.URL,
+		"sub":            "1234",
+		"aud":            "user-service",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          "nonce",
+		"email":          "john@example.com",
+		"email_verified": true,
+		"name":           "John Doe",
+	}
+
+	return idp
+}
+
+func initMockOIDC(t *testing.T, idp *mockIdP) OIDCInterface {
+	oidc, err := InitOIDC(context.Background(), []OIDCProviderOptions{{
+		Name:         "mock",
+		IssuerURL:    idp.URL,
+		ClientID:     "user-service",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/v1/auth/oidc/mock/callback",
+	}})
+	require.NoError(t, err)
+
+	return oidc
+}
+
+func TestOIDC_AuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+	defer idp.Close()
+
+	oidc := initMockOIDC(t, idp)
+
+	t.Run("success", func(t *testing.T) {
+		authURL, err := oidc.AuthCodeURL("mock", "state", "nonce", "verifier")
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		require.Equal(t, idp.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+		query := parsed.Query()
+		require.Equal(t, "user-service", query.Get("client_id"))
+		require.Equal(t, "state", query.Get("state"))
+		require.Equal(t, "nonce", query.Get("nonce"))
+		require.Equal(t, "S256", query.Get("code_challenge_method"))
+		require.NotEmpty(t, query.Get("code_challenge"))
+		require.Equal(t, "openid email profile", query.Get("scope"))
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, err := oidc.AuthCodeURL("unknown", "state", "nonce", "verifier")
+		require.ErrorIs(t, err, ErrUnknownOIDCProvider)
+	})
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+
+		identity, err := initMockOIDC(t, idp).Exchange(ctx, "mock", "code", "verifier")
+		require.NoError(t, err)
+		require.Equal(t, "1234", identity.Subject)
+		require.Equal(t, "john@example.com", identity.Email)
+		require.True(t, identity.EmailVerified)
+		require.Equal(t, "John Doe", identity.Name)
+		require.Equal(t, "nonce", identity.Nonce)
+	})
+
+	t.Run("failed - invalid code", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+
+		_, err := initMockOIDC(t, idp).Exchange(ctx, "mock", "other", "verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - issued for another client", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+		idp.claims["aud"] = "other-service"
+
+		_, err := initMockOIDC(t, idp).Exchange(ctx, "mock", "code", "verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - expired", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+		idp.claims["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		_, err := initMockOIDC(t, idp).Exchange(ctx, "mock", "code", "verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - signed with an unpublished key", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		idp.signingKey = key
+
+		_, err = initMockOIDC(t, idp).Exchange(ctx, "mock", "code", "verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		idp := newMockIdP(t)
+		defer idp.Close()
+
+		_, err := initMockOIDC(t, idp).Exchange(ctx, "unknown", "code", "verifier")
+		require.ErrorIs(t, err, ErrUnknownOIDCProvider)
+	})
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// bodyField names the request body in validation errors not caused by one of
+// its fields, e.g. when it is missing or not JSON.
+const bodyField = "body"
+
+// ValidateSchema checks value, marshalled to JSON, against schema and lists
+// every failed rule, like requests are checked against api.yml.
+func ValidateSchema(schema *openapi3.Schema, value interface{}) ValidationErrors {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ValidationErrors{invalidError(bodyField, err.Error())}
+	}
+
+	var document interface{}
+	if err = json.Unmarshal(data, &document); err != nil {
+		return ValidationErrors{invalidError(bodyField, err.Error())}
+	}
+
+	if err = schema.VisitJSON(document, openapi3.MultiErrors()); err != nil {
+		return OpenAPIValidationErrors(err)
+	}
+
+	return nil
+}
+
+// OpenAPIValidationErrors turns the errors of validating a request against
+// api.yml into validation errors, named after the failing parameter or the
+// path of the failing body field.
+func OpenAPIValidationErrors(err error) ValidationErrors {
+	validationErrors := make(ValidationErrors, 0)
+
+	switch err := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range err {
+			validationErrors = append(validationErrors, OpenAPIValidationErrors(err)...)
+		}
+	case *openapi3filter.RequestError:
+		validationErrors = append(validationErrors, requestValidationErrors(err)...)
+	default:
+		validationErrors = append(validationErrors, schemaValidationErrors(err, nil)...)
+	}
+
+	return validationErrors
+}
+
+func requestValidationErrors(requestError *openapi3filter.RequestError) ValidationErrors {
+	field := bodyField
+	var path []string
+	if requestError.Parameter != nil {
+		field = requestError.Parameter.Name
+		path = []string{field}
+	}
+
+	switch err := requestError.Err.(type) {
+	case openapi3.MultiError, *openapi3.SchemaError:
+		return schemaValidationErrors(err, path)
+	case nil:
+		return ValidationErrors{invalidError(field, requestError.Reason)}
+	default:
+		if errors.Is(err, openapi3filter.ErrInvalidRequired) || errors.Is(err, openapi3filter.ErrInvalidEmptyValue) {
+			return ValidationErrors{requiredError(field)}
+		}
+
+		return ValidationErrors{invalidError(field, err.Error())}
+	}
+}
+
+func schemaValidationErrors(err error, path []string) ValidationErrors {
+	validationErrors := make(ValidationErrors, 0)
+
+	switch err := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range err {
+			validationErrors = append(validationErrors, schemaValidationErrors(err, path)...)
+		}
+	case *openapi3.SchemaError:
+		path = append(append([]string(nil), path...), err.JSONPointer()...)
+
+		// Errors of composed schemas, such as allOf, are reported by the
+		// member schema they came from.
+		switch err.Origin.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			return schemaValidationErrors(err.Origin, path)
+		}
+
+		field := bodyField
+		if len(path) > 0 {
+			field = strings.Join(path, ".")
+		}
+
+		validationErrors = append(validationErrors, schemaRuleError(field, err))
+	default:
+		validationErrors = append(validationErrors, invalidError(bodyField, err.Error()))
+	}
+
+	return validationErrors
+}
+
+func invalidError(field, reason string) ValidationError {
+	return newValidationError(field, ValidationCodeInvalidFormat, nil, "%s is invalid: %s", field, reason)
+}
+
+func schemaRuleError(field string, schemaError *openapi3.SchemaError) ValidationError {
+	schema := schemaError.Schema
+
+	switch schemaError.SchemaField {
+	case "required":
+		return requiredError(field)
+	case "minLength", "maxLength":
+		if schema.MaxLength == nil {
+			return newValidationError(field, ValidationCodeLength, map[string]interface{}{"min": int(schema.MinLength)},
+				"%s must be at least %d characters long", field, int(schema.MinLength))
+		}
+
+		return lengthError(field, int(schema.MinLength), int(*schema.MaxLength))
+	case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum":
+		switch {
+		case schema.Min != nil && schema.Max != nil:
+			return newValidationError(field, ValidationCodeRange, map[string]interface{}{"min": *schema.Min, "max": *schema.Max},
+				"%s must be between %v and %v", field, *schema.Min, *schema.Max)
+		case schema.Min != nil:
+			return newValidationError(field, ValidationCodeRange, map[string]interface{}{"min": *schema.Min},
+				"%s must be at least %v", field, *schema.Min)
+		default:
+			return newValidationError(field, ValidationCodeRange, map[string]interface{}{"max": *schema.Max},
+				"%s must be at most %v", field, *schema.Max)
+		}
+	case "enum":
+		allowed := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			allowed = append(allowed, fmt.Sprint(value))
+		}
+
+		return newValidationError(field, ValidationCodeNotAllowed, map[string]interface{}{"allowed": allowed},
+			"%s must be one of %s", field, strings.Join(allowed, ", "))
+	case "minItems":
+		return newValidationError(field, ValidationCodeTooFewItems, map[string]interface{}{"min": int(schema.MinItems)},
+			"%s must contain at least %d items", field, int(schema.MinItems))
+	case "type", "nullable":
+		return newValidationError(field, ValidationCodeInvalidType, map[string]interface{}{"type": schema.Type},
+			"%s must be of type %s", field, schema.Type)
+	case "pattern":
+		return newValidationError(field, ValidationCodeInvalidFormat, map[string]interface{}{"pattern": schema.Pattern},
+			"%s has an invalid format", field)
+	default:
+		return invalidError(field, schemaError.Reason)
+	}
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema(t *testing.T) {
+	swagger, err := generated.GetSwagger()
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		validationErrors := ValidateSchema(swagger.Components.Schemas["RegisterUserRequest"].Value, generated.RegisterUserJSONRequestBody{
+			FullName:    "John Doe",
+			PhoneNumber: "+62 812-3456-789",
+			Password:    "passworD!1",
+		})
+		require.Empty(t, validationErrors)
+	})
+
+	t.Run("failed - every rule", func(t *testing.T) {
+		validationErrors := ValidateSchema(swagger.Components.Schemas["RegisterUserRequest"].Value, generated.RegisterUserJSONRequestBody{
+			FullName:    "Jo",
+			PhoneNumber: "0812 3456 789",
+			Password:    "pass",
+		})
+		require.ElementsMatch(t, ValidationErrors{
+			{Field: "full_name", Code: ValidationCodeLength, Message: "full_name must be between 3 to 60 characters long", Params: map[string]interface{}{"min": 3, "max": 60}},
+			{Field: "phone_number", Code: ValidationCodeInvalidFormat, Message: "phone_number has an invalid format", Params: map[string]interface{}{"pattern": `^\+[0-9 ().-]+$`}},
+			{Field: "password", Code: ValidationCodeLength, Message: "password must be between 6 to 64 characters long", Params: map[string]interface{}{"min": 6, "max": 64}},
+		}, withoutFormats(validationErrors))
+	})
+
+	t.Run("failed - nested fields", func(t *testing.T) {
+		validationErrors := ValidateSchema(swagger.Components.Schemas["WebAuthnLoginRequest"].Value, map[string]interface{}{
+			"credential": map[string]interface{}{
+				"id":       "credential",
+				"type":     "password",
+				"response": map[string]interface{}{"clientDataJSON": "client-data", "signature": 1},
+			},
+		})
+		require.ElementsMatch(t, ValidationErrors{
+			{Field: "credential.type", Code: ValidationCodeNotAllowed, Message: "credential.type must be one of public-key", Params: map[string]interface{}{"allowed": []string{"public-key"}}},
+			{Field: "credential.response.authenticatorData", Code: ValidationCodeRequired, Message: "credential.response.authenticatorData is required"},
+			{Field: "credential.response.signature", Code: ValidationCodeInvalidType, Message: "credential.response.signature must be of type string", Params: map[string]interface{}{"type": "string"}},
+		}, withoutFormats(validationErrors))
+	})
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+)
+
+// EncodeCursor turns the id of the last item of a page into an opaque cursor.
+func EncodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func DecodeCursor(cursor string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := strconv.ParseInt(string(decoded), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid cursor")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidPhoneNumber = errors.New("phone number is not a mobile number of an allowed country")
+
+// PhoneInterface validates phone numbers and brings them into their E.164
+// form, e.g. `+62 (0)812-3456-789` into `+628123456789`, so that the same
+// number is always stored and looked up the same way.
+type PhoneInterface interface {
+	Normalize(phoneNumber string) (string, error)
+	AllowedCountries() []string
+}
+
+type PhoneOptions struct {
+	// AllowedCountries lists the ISO 3166-1 alpha-2 codes of the countries
+	// whose numbers are accepted, see phoneCountries.
+	AllowedCountries []string
+}
+
+// PhoneCountry describes the mobile numbers of a country. Lengths count the
+// digits after the calling code. TrunkPrefix is the digit dialled before
+// national numbers, which users often keep in international ones.
+type PhoneCountry struct {
+	CallingCode    string
+	TrunkPrefix    string
+	MinLength      int
+	MaxLength      int
+	MobilePrefixes []string
+}
+
+var phoneCountries = map[string]PhoneCountry{
+	"AU": {CallingCode: "61", TrunkPrefix: "0", MinLength: 9, MaxLength: 9, MobilePrefixes: []string{"4"}},
+	"CN": {CallingCode: "86", TrunkPrefix: "0", MinLength: 11, MaxLength: 11, MobilePrefixes: []string{"1"}},
+	"DE": {CallingCode: "49", TrunkPrefix: "0", MinLength: 10, MaxLength: 11, MobilePrefixes: []string{"15", "16", "17"}},
+	"GB": {CallingCode: "44", TrunkPrefix: "0", MinLength: 10, MaxLength: 10, MobilePrefixes: []string{"7"}},
+	"ID": {CallingCode: "62", TrunkPrefix: "0", MinLength: 9, MaxLength: 12, MobilePrefixes: []string{"8"}},
+	"IN": {CallingCode: "91", TrunkPrefix: "0", MinLength: 10, MaxLength: 10, MobilePrefixes: []string{"6", "7", "8", "9"}},
+	"JP": {CallingCode: "81", TrunkPrefix: "0", MinLength: 10, MaxLength: 10, MobilePrefixes: []string{"70", "80", "90"}},
+	"MY": {CallingCode: "60", TrunkPrefix: "0", MinLength: 9, MaxLength: 10, MobilePrefixes: []string{"1"}},
+	"NL": {CallingCode: "31", TrunkPrefix: "0", MinLength: 9, MaxLength: 9, MobilePrefixes: []string{"6"}},
+	"PH": {CallingCode: "63", TrunkPrefix: "0", MinLength: 10, MaxLength: 10, MobilePrefixes: []string{"9"}},
+	"SG": {CallingCode: "65", MinLength: 8, MaxLength: 8, MobilePrefixes: []string{"8", "9"}},
+	"TH": {CallingCode: "66", TrunkPrefix: "0", MinLength: 9, MaxLength: 9, MobilePrefixes: []string{"6", "8", "9"}},
+	// Numbers of the North American Numbering Plan do not tell mobile and
+	// fixed lines apart.
+	"US": {CallingCode: "1", MinLength: 10, MaxLength: 10, MobilePrefixes: []string{"2", "3", "4", "5", "6", "7", "8", "9"}},
+	"VN": {CallingCode: "84", TrunkPrefix: "0", MinLength: 9, MaxLength: 9, MobilePrefixes: []string{"3", "5", "7", "8", "9"}},
+}
+
+// maxE164Digits is the most digits an E.164 number may have, calling code
+// included.
+const maxE164Digits = 15
+
+type Phone struct {
+	countries []string
+}
+
+func InitPhone(opt PhoneOptions) (PhoneInterface, error) {
+	if len(opt.AllowedCountries) == 0 {
+		return nil, errors.New("at least one phone country must be allowed")
+	}
+
+	countries := make([]string, 0, len(opt.AllowedCountries))
+	for _, country := range opt.AllowedCountries {
+		country = strings.ToUpper(country)
+		if _, ok := phoneCountries[country]; !ok {
+			return nil, fmt.Errorf("unsupported phone country %q", country)
+		}
+
+		countries = append(countries, country)
+	}
+
+	return Phone{countries: countries}, nil
+}
+
+// Normalize returns the E.164 form of a mobile number given in international
+// format. Spaces, dashes, dots and parentheses are ignored, as is a trunk
+// prefix after the calling code.
+func (p Phone) Normalize(phoneNumber string) (string, error) {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if !strings.HasPrefix(phoneNumber, "+") {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	var digits strings.Builder
+	for _, r := range phoneNumber[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhoneNumber
+		}
+	}
+
+	for _, country := range p.countries {
+		metadata := phoneCountries[country]
+		if !strings.HasPrefix(digits.String(), metadata.CallingCode) {
+			continue
+		}
+
+		national := strings.TrimPrefix(digits.String(), metadata.CallingCode)
+		if metadata.TrunkPrefix != "" {
+			national = strings.TrimPrefix(national, metadata.TrunkPrefix)
+		}
+
+		if metadata.isMobile(national) && len(metadata.CallingCode)+len(national) <= maxE164Digits {
+			return "+" + metadata.CallingCode + national, nil
+		}
+	}
+
+	return "", ErrInvalidPhoneNumber
+}
+
+// AllowedCountries returns the codes of the countries whose numbers are
+// accepted.
+func (p Phone) AllowedCountries() []string {
+	return p.countries
+}
+
+func (c PhoneCountry) isMobile(national string) bool {
+	if len(national) < c.MinLength || len(national) > c.MaxLength {
+		return false
+	}
+
+	for _, prefix := range c.MobilePrefixes {
+		if strings.HasPrefix(national, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitPhone(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		phone, err := InitPhone(PhoneOptions{AllowedCountries: []string{"id", "SG"}})
+		require.NoError(t, err)
+		require.Equal(t, []string{"ID", "SG"}, phone.AllowedCountries())
+	})
+
+	t.Run("failed - no country", func(t *testing.T) {
+		_, err := InitPhone(PhoneOptions{})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unsupported country", func(t *testing.T) {
+		_, err := InitPhone(PhoneOptions{AllowedCountries: []string{"ID", "XX"}})
+		require.Error(t, err)
+	})
+}
+
+func TestPhone_Normalize(t *testing.T) {
+	phone, err := InitPhone(PhoneOptions{AllowedCountries: []string{"ID", "SG", "US"}})
+	require.NoError(t, err)
+
+	valid := map[string]string{
+		"+628123456789":       "+628123456789",
+		"+62 812-3456-789":    "+628123456789",
+		"+62 (0)812 3456 789": "+628123456789",
+		" +62.812.3456.789 ":  "+628123456789",
+		"+65 9123 4567":       "+6591234567",
+		"+1 (415) 555-0123":   "+14155550123",
+	}
+
+	for input, expected := range valid {
+		t.Run("success - "+input, func(t *testing.T) {
+			normalized, err := phone.Normalize(input)
+			require.NoError(t, err)
+			require.Equal(t, expected, normalized)
+		})
+	}
+
+	invalid := map[string]string{
+		"national format":     "08123456789",
+		"letters":             "+62 812 CALL ME",
+		"too short":           "+62812345",
+		"too long":            "+628123456789012",
+		"landline":            "+62215551234",
+		"country not allowed": "+60123456789",
+		"empty":               "",
+	}
+
+	for name, input := range invalid {
+		t.Run("failed - "+name, func(t *testing.T) {
+			_, err := phone.Normalize(input)
+			require.Equal(t, ErrInvalidPhoneNumber, err)
+		})
+	}
+}
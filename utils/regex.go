@@ -5,12 +5,6 @@ import (
 	"regexp"
 )
 
-func IsStartWithCountryCode(str string, countryCode string) bool {
-	regexSyntax := fmt.Sprintf(`^\%s`, countryCode)
-	regex := regexp.MustCompile(regexSyntax)
-	return regex.MatchString(str)
-}
-
 func IsLengthBetweenRange(str string, min, max int) bool {
 	regexSyntax := fmt.Sprintf(`^.{%d,%d}$`, min, max)
 	regex := regexp.MustCompile(regexSyntax)
@@ -31,3 +25,8 @@ func ContainsSpecialCharacter(str string) bool {
 	specialCharRegex := regexp.MustCompile(`[^a-zA-Z\d]`)
 	return specialCharRegex.MatchString(str)
 }
+
+func IsEmail(str string) bool {
+	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+	return len(str) <= 254 && emailRegex.MatchString(str)
+}
@@ -0,0 +1,342 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var ErrUnknownSAMLProvider = errors.New("unknown saml provider")
+
+type SAMLInterface interface {
+	Metadata(provider string) ([]byte, error)
+	AuthnRequest(provider string) (requestID, redirectURL string, err error)
+	RequestID(samlResponse string) (string, error)
+	ParseResponse(provider, samlResponse, requestID string) (SAMLIdentity, error)
+}
+
+// SAMLOptions configures the service provider. Key and Certificate are the
+// base64 encoded PKCS #8 private key and X.509 certificate, in DER form, used
+// to sign authentication requests and decrypt assertions.
+type SAMLOptions struct {
+	BaseURL     string
+	Key         string
+	Certificate string
+	Providers   []SAMLProviderOptions
+}
+
+// SAMLProviderOptions configures an upstream SAML identity provider. Name
+// identifies it in the API paths, IDPMetadata is the URL or file path of its
+// metadata.
+type SAMLProviderOptions struct {
+	Name        string
+	IDPMetadata string
+	Attributes  SAMLAttributes
+}
+
+// SAMLAttributes names the assertion attributes mapped onto a SAMLIdentity.
+// Attributes are matched by their name or friendly name.
+type SAMLAttributes struct {
+	FullName    string
+	Email       string
+	PhoneNumber string
+}
+
+// SAMLIdentity holds the subject of a verified assertion and the attributes
+// the service relies on. Missing attributes are left empty.
+type SAMLIdentity struct {
+	Subject     string
+	FullName    string
+	Email       string
+	PhoneNumber string
+}
+
+type SAML struct {
+	providers map[string]samlProvider
+}
+
+type samlProvider struct {
+	sp         *saml.ServiceProvider
+	attributes SAMLAttributes
+}
+
+// InitSAML loads the metadata of every identity provider. Each provider gets
+// its own service provider entity under BaseURL, identified by the URL of its
+// metadata.
+func InitSAML(ctx context.Context, opt SAMLOptions) (SAMLInterface, error) {
+	s := SAML{providers: make(map[string]samlProvider, len(opt.Providers))}
+	if len(opt.Providers) == 0 {
+		return s, nil
+	}
+
+	key, cert, err := parseSAMLKeyPair(opt.Key, opt.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL, err := url.Parse(strings.TrimSuffix(opt.BaseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range opt.Providers {
+		metadata, err := loadSAMLMetadata(ctx, p.IDPMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("saml provider %s: %w", p.Name, err)
+		}
+
+		metadataURL := baseURL.JoinPath("v1", "auth", "saml", p.Name, "metadata")
+		acsURL := baseURL.JoinPath("v1", "auth", "saml", p.Name, "acs")
+		s.providers[p.Name] = samlProvider{
+			sp: &saml.ServiceProvider{
+				EntityID:          metadataURL.String(),
+				Key:               key,
+				Certificate:       cert,
+				MetadataURL:       *metadataURL,
+				AcsURL:            *acsURL,
+				IDPMetadata:       metadata,
+				AuthnNameIDFormat: saml.PersistentNameIDFormat,
+				SignatureMethod:   dsig.RSASHA256SignatureMethod,
+			},
+			attributes: p.Attributes,
+		}
+	}
+
+	return s, nil
+}
+
+// Metadata returns the XML metadata of our service provider entity for
+// provider, to be registered there.
+func (s SAML) Metadata(provider string) ([]byte, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownSAMLProvider
+	}
+
+	// Responses are only accepted through the HTTP-POST binding.
+	metadata := p.sp.Metadata()
+	for i, descriptor := range metadata.SPSSODescriptors {
+		services := make([]saml.IndexedEndpoint, 0, 1)
+		for _, service := range descriptor.AssertionConsumerServices {
+			if service.Binding == saml.HTTPPostBinding {
+				services = append(services, service)
+			}
+		}
+		metadata.SPSSODescriptors[i].AssertionConsumerServices = services
+	}
+
+	return xml.MarshalIndent(metadata, "", "  ")
+}
+
+// AuthnRequest creates a signed authentication request for provider. It
+// returns the request's ID, which the response must refer to, and the URL the
+// user is redirected to with the HTTP-Redirect binding.
+func (s SAML) AuthnRequest(provider string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownSAMLProvider
+	}
+
+	ssoURL := p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	if ssoURL == "" {
+		return "", "", fmt.Errorf("saml provider %s has no HTTP-Redirect single sign-on service", provider)
+	}
+
+	request, err := p.sp.MakeAuthenticationRequest(ssoURL, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", "", err
+	}
+
+	redirectURL, err := request.Redirect("", p.sp)
+	if err != nil {
+		return "", "", err
+	}
+
+	return request.ID, redirectURL.String(), nil
+}
+
+// RequestID returns the ID of the request the base64 encoded response claims
+// to answer, without verifying anything. It is only meant to look up the
+// pending request before the response is parsed.
+func (s SAML) RequestID(samlResponse string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return "", err
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return "", err
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "Response" {
+		return "", errors.New("saml response has no Response element")
+	}
+
+	requestID := root.SelectAttrValue("InResponseTo", "")
+	if requestID == "" {
+		return "", errors.New("saml response is not in response to a request")
+	}
+
+	return requestID, nil
+}
+
+// ParseResponse verifies the base64 encoded response posted to the assertion
+// consumer service of provider: the signature with the identity provider's
+// keys, the destination, issuer, audience and validity period, and that it
+// answers the request with requestID. Replays have to be prevented by the
+// caller consuming the request.
+func (s SAML) ParseResponse(provider, samlResponse, requestID string) (SAMLIdentity, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return SAMLIdentity{}, ErrUnknownSAMLProvider
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return SAMLIdentity{}, err
+	}
+
+	assertion, err := p.sp.ParseXMLResponse(raw, []string{requestID})
+	if err != nil {
+		// The library hides the reason behind a generic message.
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) && invalid.PrivateErr != nil {
+			return SAMLIdentity{}, fmt.Errorf("invalid saml response: %w", invalid.PrivateErr)
+		}
+
+		return SAMLIdentity{}, err
+	}
+
+	// Assertions without an audience are valid for any service provider.
+	if assertion.Conditions == nil || len(assertion.Conditions.AudienceRestrictions) == 0 {
+		return SAMLIdentity{}, errors.New("saml assertion has no audience restriction")
+	}
+
+	if assertion.Subject == nil || assertion.Subject.NameID == nil || assertion.Subject.NameID.Value == "" {
+		return SAMLIdentity{}, errors.New("saml assertion has no subject")
+	}
+
+	// Transient identifiers change with every login and can not be linked to
+	// a user.
+	if assertion.Subject.NameID.Format == string(saml.TransientNameIDFormat) {
+		return SAMLIdentity{}, errors.New("saml assertion has a transient subject")
+	}
+
+	identity := SAMLIdentity{
+		Subject:     assertion.Subject.NameID.Value,
+		FullName:    samlAttributeValue(assertion, p.attributes.FullName),
+		Email:       samlAttributeValue(assertion, p.attributes.Email),
+		PhoneNumber: samlAttributeValue(assertion, p.attributes.PhoneNumber),
+	}
+
+	return identity, nil
+}
+
+func samlAttributeValue(assertion *saml.Assertion, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			if (attribute.Name == name || attribute.FriendlyName == name) && len(attribute.Values) > 0 {
+				return attribute.Values[0].Value
+			}
+		}
+	}
+
+	return ""
+}
+
+func parseSAMLKeyPair(encodedKey, encodedCert string) (*rsa.PrivateKey, *x509.Certificate, error) {
+	der, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml key: %w", err)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("saml key is not an RSA key")
+	}
+
+	der, err = base64.StdEncoding.DecodeString(encodedCert)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saml certificate: %w", err)
+	}
+
+	if !rsaKey.PublicKey.Equal(cert.PublicKey) {
+		return nil, nil, errors.New("saml certificate does not match the key")
+	}
+
+	return rsaKey, cert, nil
+}
+
+// loadSAMLMetadata reads identity provider metadata from an http(s) URL or a
+// file.
+func loadSAMLMetadata(ctx context.Context, location string) (*saml.EntityDescriptor, error) {
+	var data []byte
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetching metadata: %s", resp.Status)
+		}
+
+		data, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		data, err = os.ReadFile(location)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metadata := &saml.EntityDescriptor{}
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(metadata); err != nil {
+		return nil, err
+	}
+
+	if len(metadata.IDPSSODescriptors) == 0 {
+		return nil, errors.New("metadata has no IDPSSODescriptor")
+	}
+
+	return metadata, nil
+}
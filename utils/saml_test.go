@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	"github.com/stretchr/testify/require"
+)
+
+// testSAMLKeyPair generates an RSA key and a self-signed certificate for it.
+func testSAMLKeyPair(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "saml-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return key, cert
+}
+
+// newTestSAMLIdP starts an identity provider fixture serving its metadata at
+// /metadata.
+func newTestSAMLIdP(t *testing.T) (*saml.IdentityProvider, *httptest.Server) {
+	key, cert := testSAMLKeyPair(t)
+	idp := &saml.IdentityProvider{Key: key, Certificate: cert}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata, _ := xml.Marshal(idp.Metadata())
+		w.Header().Set("Content-Type", "application/samlmetadata+xml")
+		w.Write(metadata)
+	}))
+
+	metadataURL, _ := url.Parse(server.URL + "/metadata")
+	ssoURL, _ := url.Parse(server.URL + "/sso")
+	idp.MetadataURL = *metadataURL
+	idp.SSOURL = *ssoURL
+
+	return idp, server
+}
+
+func testSAMLOptions(t *testing.T, metadataURL string) SAMLOptions {
+	key, cert := testSAMLKeyPair(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return SAMLOptions{
+		BaseURL:     "https://users.example.com",
+		Key:         base64.StdEncoding.EncodeToString(der),
+		Certificate: base64.StdEncoding.EncodeToString(cert.Raw),
+		Providers: []SAMLProviderOptions{{
+			Name:        "acme",
+			IDPMetadata: metadataURL,
+			Attributes: SAMLAttributes{
+				FullName:    "cn",
+				Email:       "urn:oid:0.9.2342.19200300.100.1.3",
+				PhoneNumber: "telephoneNumber",
+			},
+		}},
+	}
+}
+
+// testSAMLResponse has idp answer requestID with a signed and encrypted
+// assertion for the service provider described by spMetadata. modify can
+// tamper with the assertion before it is signed.
+func testSAMLResponse(t *testing.T, idp *saml.IdentityProvider, spMetadata []byte, requestID string, modify func(*saml.Assertion)) string {
+	metadata := &saml.EntityDescriptor{}
+	require.NoError(t, xml.Unmarshal(spMetadata, metadata))
+
+	now := time.Now()
+	req := &saml.IdpAuthnRequest{
+		IDP:                     idp,
+		HTTPRequest:             httptest.NewRequest(http.MethodPost, "/sso", nil),
+		Request:                 saml.AuthnRequest{ID: requestID, IssueInstant: now},
+		ServiceProviderMetadata: metadata,
+		SPSSODescriptor:         &metadata.SPSSODescriptors[0],
+		ACSEndpoint:             &metadata.SPSSODescriptors[0].AssertionConsumerServices[0],
+		Now:                     now,
+	}
+
+	session := &saml.Session{
+		NameID:         "00u1a2b3c4",
+		NameIDFormat:   string(saml.PersistentNameIDFormat),
+		UserCommonName: "John Doe",
+		UserEmail:      "john@example.com",
+		CreateTime:     now,
+		CustomAttributes: []saml.Attribute{
+			{Name: "urn:oid:0.9.2342.19200300.100.1.3", FriendlyName: "mail", Values: []saml.AttributeValue{{Value: "John@Example.com"}}},
+			{Name: "telephoneNumber", Values: []saml.AttributeValue{{Value: "+6281234567890"}}},
+		},
+	}
+
+	require.NoError(t, saml.DefaultAssertionMaker{}.MakeAssertion(req, session))
+	if modify != nil {
+		modify(req.Assertion)
+	}
+	require.NoError(t, req.MakeResponse())
+
+	doc := etree.NewDocument()
+	doc.SetRoot(req.ResponseEl)
+	raw, err := doc.WriteToBytes()
+	require.NoError(t, err)
+
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestInitSAML(t *testing.T) {
+	_, server := newTestSAMLIdP(t)
+	defer server.Close()
+
+	t.Run("success", func(t *testing.T) {
+		_, err := InitSAML(context.Background(), testSAMLOptions(t, server.URL))
+		require.NoError(t, err)
+	})
+
+	t.Run("success - no providers", func(t *testing.T) {
+		_, err := InitSAML(context.Background(), SAMLOptions{})
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - certificate of another key", func(t *testing.T) {
+		opt := testSAMLOptions(t, server.URL)
+		opt.Certificate = testSAMLOptions(t, server.URL).Certificate
+
+		_, err := InitSAML(context.Background(), opt)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - metadata not found", func(t *testing.T) {
+		_, err := InitSAML(context.Background(), testSAMLOptions(t, "/nonexistent/metadata.xml"))
+		require.Error(t, err)
+	})
+}
+
+func TestSAML_Metadata(t *testing.T) {
+	_, server := newTestSAMLIdP(t)
+	defer server.Close()
+
+	s, err := InitSAML(context.Background(), testSAMLOptions(t, server.URL))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		raw, err := s.Metadata("acme")
+		require.NoError(t, err)
+
+		metadata := &saml.EntityDescriptor{}
+		require.NoError(t, xml.Unmarshal(raw, metadata))
+		require.Equal(t, "https://users.example.com/v1/auth/saml/acme/metadata", metadata.EntityID)
+		require.Equal(t, []saml.IndexedEndpoint{{
+			Binding:  saml.HTTPPostBinding,
+			Location: "https://users.example.com/v1/auth/saml/acme/acs",
+			Index:    1,
+		}}, metadata.SPSSODescriptors[0].AssertionConsumerServices)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, err := s.Metadata("unknown")
+		require.ErrorIs(t, err, ErrUnknownSAMLProvider)
+	})
+}
+
+func TestSAML_AuthnRequest(t *testing.T) {
+	_, server := newTestSAMLIdP(t)
+	defer server.Close()
+
+	s, err := InitSAML(context.Background(), testSAMLOptions(t, server.URL))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		requestID, redirectURL, err := s.AuthnRequest("acme")
+		require.NoError(t, err)
+		require.NotEmpty(t, requestID)
+
+		u, err := url.Parse(redirectURL)
+		require.NoError(t, err)
+		require.Equal(t, server.URL+"/sso", u.Scheme+"://"+u.Host+u.Path)
+		require.NotEmpty(t, u.Query().Get("SAMLRequest"))
+		require.NotEmpty(t, u.Query().Get("Signature"))
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, _, err := s.AuthnRequest("unknown")
+		require.ErrorIs(t, err, ErrUnknownSAMLProvider)
+	})
+}
+
+func TestSAML_ParseResponse(t *testing.T) {
+	idp, server := newTestSAMLIdP(t)
+	defer server.Close()
+
+	s, err := InitSAML(context.Background(), testSAMLOptions(t, server.URL))
+	require.NoError(t, err)
+
+	spMetadata, err := s.Metadata("acme")
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		requestID, _, err := s.AuthnRequest("acme")
+		require.NoError(t, err)
+
+		response := testSAMLResponse(t, idp, spMetadata, requestID, nil)
+
+		resRequestID, err := s.RequestID(response)
+		require.NoError(t, err)
+		require.Equal(t, requestID, resRequestID)
+
+		identity, err := s.ParseResponse("acme", response, requestID)
+		require.NoError(t, err)
+		require.Equal(t, SAMLIdentity{
+			Subject:     "00u1a2b3c4",
+			FullName:    "John Doe",
+			Email:       "John@Example.com",
+			PhoneNumber: "+6281234567890",
+		}, identity)
+	})
+
+	t.Run("failed - another request", func(t *testing.T) {
+		response := testSAMLResponse(t, idp, spMetadata, "id-other", nil)
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - wrong audience", func(t *testing.T) {
+		response := testSAMLResponse(t, idp, spMetadata, "id-pending", func(assertion *saml.Assertion) {
+			assertion.Conditions.AudienceRestrictions[0].Audience.Value = "https://other.example.com/metadata"
+		})
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - no audience", func(t *testing.T) {
+		response := testSAMLResponse(t, idp, spMetadata, "id-pending", func(assertion *saml.Assertion) {
+			assertion.Conditions.AudienceRestrictions = nil
+		})
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - expired", func(t *testing.T) {
+		response := testSAMLResponse(t, idp, spMetadata, "id-pending", func(assertion *saml.Assertion) {
+			assertion.Conditions.NotBefore = time.Now().Add(-time.Hour)
+			assertion.Conditions.NotOnOrAfter = time.Now().Add(-30 * time.Minute)
+		})
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - transient subject", func(t *testing.T) {
+		response := testSAMLResponse(t, idp, spMetadata, "id-pending", func(assertion *saml.Assertion) {
+			assertion.Subject.NameID.Format = string(saml.TransientNameIDFormat)
+		})
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - signed by another key", func(t *testing.T) {
+		key, cert := testSAMLKeyPair(t)
+		impostor := *idp
+		impostor.Key, impostor.Certificate = key, cert
+
+		response := testSAMLResponse(t, &impostor, spMetadata, "id-pending", nil)
+
+		_, err := s.ParseResponse("acme", response, "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - not base64", func(t *testing.T) {
+		_, err := s.ParseResponse("acme", "<Response/>", "id-pending")
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unknown provider", func(t *testing.T) {
+		_, err := s.ParseResponse("unknown", "", "id-pending")
+		require.ErrorIs(t, err, ErrUnknownSAMLProvider)
+	})
+}
+
+func TestSAML_RequestID(t *testing.T) {
+	s := SAML{}
+
+	t.Run("success", func(t *testing.T) {
+		response := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" InResponseTo="id-123"/>`))
+
+		requestID, err := s.RequestID(response)
+		require.NoError(t, err)
+		require.Equal(t, "id-123", requestID)
+	})
+
+	t.Run("failed - idp initiated", func(t *testing.T) {
+		response := base64.StdEncoding.EncodeToString([]byte(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol"/>`))
+
+		_, err := s.RequestID(response)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - not a response", func(t *testing.T) {
+		response := base64.StdEncoding.EncodeToString([]byte(`<samlp:AuthnRequest xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="id-123"/>`))
+
+		_, err := s.RequestID(response)
+		require.Error(t, err)
+	})
+}
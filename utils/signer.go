@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrSignatureInvalid = errors.New("signature is invalid")
+	ErrSignatureExpired = errors.New("signature is expired")
+)
+
+// SignerInterface signs payloads, such as download links, so that they can be
+// handed out without further authentication until they expire.
+type SignerInterface interface {
+	Sign(payload string, expiresAt time.Time) string
+	Verify(payload string, expiresAt time.Time, signature string) error
+}
+
+type Signer struct {
+	key []byte
+}
+
+type SignerOptions struct {
+	SecretKey string
+}
+
+func InitSigner(opt SignerOptions) (SignerInterface, error) {
+	if opt.SecretKey == "" {
+		return nil, errors.New("signer secret key is empty")
+	}
+
+	return Signer{key: []byte(opt.SecretKey)}, nil
+}
+
+func (s Signer) Sign(payload string, expiresAt time.Time) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload + "|" + strconv.FormatInt(expiresAt.Unix(), 10)))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (s Signer) Verify(payload string, expiresAt time.Time, signature string) error {
+	if !hmac.Equal([]byte(s.Sign(payload, expiresAt)), []byte(signature)) {
+		return ErrSignatureInvalid
+	}
+
+	if time.Now().After(expiresAt) {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"runtime"
@@ -25,12 +27,17 @@ var (
 
 const NoCode ErrorCode = math.MaxUint32
 
+// maxStackDepth bounds the frames recorded for an error.
+const maxStackDepth = 32
+
 type stacktrace struct {
 	message string
+	format  string
+	vals    []interface{}
 	cause   error
 	code    ErrorCode
-	file    string
-	line    int
+	key     string
+	stack   []uintptr
 }
 
 func Wrap(cause error, msg string, vals ...interface{}) error {
@@ -48,6 +55,15 @@ func NewErrorWithCode(code ErrorCode, msg string, vals ...interface{}) error {
 	return create(nil, code, msg, vals...)
 }
 
+// NewErrorWithKey is like NewErrorWithCode but also attaches a key, see
+// WrapWithKey.
+func NewErrorWithKey(code ErrorCode, key string, msg string, vals ...interface{}) error {
+	err := create(nil, code, msg, vals...)
+	err.(*stacktrace).key = key
+
+	return err
+}
+
 func WrapWithCode(cause error, code ErrorCode, msg string, vals ...interface{}) error {
 	if cause == nil {
 		return nil
@@ -55,6 +71,19 @@ func WrapWithCode(cause error, code ErrorCode, msg string, vals ...interface{})
 	return create(cause, code, msg, vals...)
 }
 
+// WrapWithKey is like WrapWithCode but also attaches a stable,
+// machine-readable key which API clients can branch on.
+func WrapWithKey(cause error, code ErrorCode, key string, msg string, vals ...interface{}) error {
+	if cause == nil {
+		return nil
+	}
+
+	err := create(cause, code, msg, vals...)
+	err.(*stacktrace).key = key
+
+	return err
+}
+
 func create(cause error, code ErrorCode, msg string, vals ...interface{}) error {
 	if code == 0 {
 		code = http.StatusInternalServerError
@@ -62,54 +91,204 @@ func create(cause error, code ErrorCode, msg string, vals ...interface{}) error
 
 	err := &stacktrace{
 		message: fmt.Sprintf(msg, vals...),
+		format:  msg,
+		vals:    vals,
 		cause:   cause,
 		code:    code,
 	}
 
-	pc, file, line, ok := runtime.Caller(2)
-	if !ok {
-		return err
-	}
-
-	err.file, err.line = file, line
-	f := runtime.FuncForPC(pc)
-	if f == nil {
-		return err
-	}
+	// Skip runtime.Callers, create and the constructor calling it.
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(3, pcs[:])
+	err.stack = pcs[:n]
 
 	return err
 }
 
+// coded finds the outermost error in the chain of err carrying a code, so
+// errors wrapped with Wrap keep the code they were created with.
+func coded(err error) *stacktrace {
+	for err != nil {
+		if st, ok := err.(*stacktrace); ok && st.code != NoCode {
+			return st
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
 func GetCode(err error) ErrorCode {
-	if err, ok := err.(*stacktrace); ok {
-		return err.code
+	if st := coded(err); st != nil {
+		return st.code
 	}
 	return NoCode
 }
 
+func GetKey(err error) string {
+	if st := coded(err); st != nil {
+		return st.key
+	}
+	return ""
+}
+
 func GetCause(err error) error {
-	if err, ok := err.(*stacktrace); ok {
-		return err.cause
+	var st *stacktrace
+	if errors.As(err, &st) {
+		return st.cause
 	}
 	return err
 }
 
 func GetMessage(err error) string {
-	errStacktrace, ok := err.(*stacktrace)
-	if !ok {
+	st := coded(err)
+	if st == nil {
+		return ""
+	}
+
+	if st.message == "" {
+		return errorMessages[st.code]
+	}
+
+	return st.message
+}
+
+// GetLocalizedMessage is like GetMessage but renders the message in lang.
+func GetLocalizedMessage(err error, lang string) string {
+	st := coded(err)
+	if st == nil {
 		return ""
 	}
 
-	if errStacktrace.message == "" {
-		return errorMessages[errStacktrace.code]
+	if st.message == "" {
+		return Translate(lang, errorMessages[st.code])
 	}
 
-	return errStacktrace.message
+	return Translate(lang, st.format, st.vals...)
 }
 
+// GetStackTrace returns the calls leading to the innermost error in the chain
+// of err created by this package, the one closest to where things went wrong.
+func GetStackTrace(err error) []runtime.Frame {
+	var innermost *stacktrace
+	for ; err != nil; err = errors.Unwrap(err) {
+		if st, ok := err.(*stacktrace); ok {
+			innermost = st
+		}
+	}
+
+	if innermost == nil || len(innermost.stack) == 0 {
+		return nil
+	}
+
+	var stack []runtime.Frame
+	frames := runtime.CallersFrames(innermost.stack)
+	for {
+		frame, more := frames.Next()
+		stack = append(stack, frame)
+		if !more {
+			return stack
+		}
+	}
+}
+
+// LogFields describes err for structured logs, e.g. log.Errorj(LogFields(err)):
+// its text, the code, key and message it is answered with and where it was
+// created.
+func LogFields(err error) map[string]interface{} {
+	fields := map[string]interface{}{"error": err.Error()}
+
+	if st := coded(err); st != nil {
+		fields["code"] = st.code
+		if st.key != "" {
+			fields["key"] = st.key
+		}
+		fields["message"] = GetMessage(st)
+	}
+
+	if stack := GetStackTrace(err); stack != nil {
+		calls := make([]string, 0, len(stack))
+		for _, frame := range stack {
+			calls = append(calls, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		fields["stack"] = calls
+	}
+
+	return fields
+}
+
+// text is the message st was created with, the default message of its code
+// when it has none.
+func (st *stacktrace) text() string {
+	if st.message == "" {
+		return errorMessages[st.code]
+	}
+	return st.message
+}
+
+// Error returns the text of the underlying cause, as messages are meant for
+// users. Errors without a cause return their message.
 func (st *stacktrace) Error() string {
-	if st != nil {
+	if st == nil {
+		return ""
+	}
+	if st.cause != nil {
 		return st.cause.Error()
 	}
-	return ""
+	return st.text()
+}
+
+// Unwrap returns the cause of st, so errors.Is and errors.As see through
+// wrapped errors.
+func (st *stacktrace) Unwrap() error {
+	return st.cause
+}
+
+// Format prints st like Error with %v and %s. %+v prints every error of the
+// chain with its message, code, key and the calls leading to it.
+func (st *stacktrace) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('+') {
+			st.writeChain(f)
+			return
+		}
+		_, _ = io.WriteString(f, st.Error())
+	case 's':
+		_, _ = io.WriteString(f, st.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", st.Error())
+	}
+}
+
+func (st *stacktrace) writeChain(w io.Writer) {
+	var err error = st
+	for i := 0; err != nil; i++ {
+		if i > 0 {
+			_, _ = io.WriteString(w, "\ncaused by: ")
+		}
+
+		current, ok := err.(*stacktrace)
+		if !ok {
+			_, _ = io.WriteString(w, err.Error())
+			return
+		}
+
+		_, _ = io.WriteString(w, current.text())
+		if current.code != NoCode {
+			_, _ = fmt.Fprintf(w, " [%d", current.code)
+			if current.key != "" {
+				_, _ = fmt.Fprintf(w, " %s", current.key)
+			}
+			_, _ = io.WriteString(w, "]")
+		}
+
+		frames := runtime.CallersFrames(current.stack)
+		for more := len(current.stack) > 0; more; {
+			var frame runtime.Frame
+			frame, more = frames.Next()
+			_, _ = fmt.Fprintf(w, "\n\t%s\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+
+		err = current.cause
+	}
 }
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStacktrace_Error(t *testing.T) {
+	t.Run("success - cause", func(t *testing.T) {
+		err := WrapWithCode(sql.ErrNoRows, ErrorCode(http.StatusNotFound), "")
+		require.Equal(t, sql.ErrNoRows.Error(), err.Error())
+	})
+
+	t.Run("success - no cause", func(t *testing.T) {
+		err := NewErrorWithCode(ErrorCode(http.StatusBadRequest), "Invalid Input.")
+		require.Equal(t, "Invalid Input.", err.Error())
+	})
+
+	t.Run("success - no cause nor message", func(t *testing.T) {
+		err := NewErrorWithCode(ErrorCode(http.StatusForbidden), "")
+		require.Equal(t, "Forbidden Access. You are forbidden to access this resource", err.Error())
+	})
+}
+
+func TestStacktrace_Unwrap(t *testing.T) {
+	t.Run("success - is", func(t *testing.T) {
+		err := fmt.Errorf("get user: %w", Wrap(WrapWithCode(sql.ErrNoRows, ErrorCode(http.StatusNotFound), ""), "loading profile"))
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+		require.False(t, errors.Is(err, sql.ErrTxDone))
+	})
+
+	t.Run("success - as", func(t *testing.T) {
+		cause := ValidationErrors{{Field: "cursor", Code: ValidationCodeInvalidFormat}}
+		err := WrapWithCode(cause, ErrorCode(http.StatusBadRequest), "")
+
+		var validationErrors ValidationErrors
+		require.True(t, errors.As(err, &validationErrors))
+		require.Equal(t, cause, validationErrors)
+	})
+
+	t.Run("success - code through wrappers", func(t *testing.T) {
+		err := fmt.Errorf("get user: %w", Wrap(WrapWithKey(sql.ErrNoRows, ErrorCode(http.StatusNotFound), "USER_NOT_FOUND", "User Not Found."), "loading profile"))
+		require.Equal(t, ErrorCode(http.StatusNotFound), GetCode(err))
+		require.Equal(t, "USER_NOT_FOUND", GetKey(err))
+		require.Equal(t, "User Not Found.", GetMessage(err))
+	})
+
+	t.Run("success - no code", func(t *testing.T) {
+		err := Wrap(sql.ErrNoRows, "loading profile")
+		require.Equal(t, NoCode, GetCode(err))
+		require.Empty(t, GetMessage(err))
+	})
+}
+
+func TestGetStackTrace(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := Wrap(NewErrorWithCode(ErrorCode(http.StatusBadRequest), "Invalid Input."), "outer")
+
+		stack := GetStackTrace(err)
+		require.NotEmpty(t, stack)
+		require.True(t, strings.HasSuffix(stack[0].Function, "TestGetStackTrace.func1"))
+		require.True(t, strings.HasSuffix(stack[0].File, "stacktrace_test.go"))
+	})
+
+	t.Run("success - not a stacktrace", func(t *testing.T) {
+		require.Nil(t, GetStackTrace(errors.New("plain")))
+	})
+}
+
+func TestStacktrace_Format(t *testing.T) {
+	err := Wrap(WrapWithKey(sql.ErrNoRows, ErrorCode(http.StatusNotFound), "USER_NOT_FOUND", "User Not Found."), "loading profile")
+
+	t.Run("success", func(t *testing.T) {
+		require.Equal(t, sql.ErrNoRows.Error(), fmt.Sprintf("%v", err))
+		require.Equal(t, sql.ErrNoRows.Error(), fmt.Sprintf("%s", err))
+	})
+
+	t.Run("success - detailed", func(t *testing.T) {
+		detailed := fmt.Sprintf("%+v", err)
+		require.True(t, strings.HasPrefix(detailed, "loading profile\n\t"))
+		require.Contains(t, detailed, "\ncaused by: User Not Found. [404 USER_NOT_FOUND]\n\t")
+		require.Contains(t, detailed, "stacktrace_test.go:")
+		require.True(t, strings.HasSuffix(detailed, "\ncaused by: "+sql.ErrNoRows.Error()))
+	})
+}
+
+func TestLogFields(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		err := WrapWithKey(sql.ErrNoRows, ErrorCode(http.StatusNotFound), "USER_NOT_FOUND", "User Not Found.")
+
+		fields := LogFields(err)
+		require.Equal(t, sql.ErrNoRows.Error(), fields["error"])
+		require.Equal(t, ErrorCode(http.StatusNotFound), fields["code"])
+		require.Equal(t, "USER_NOT_FOUND", fields["key"])
+		require.Equal(t, "User Not Found.", fields["message"])
+		require.NotEmpty(t, fields["stack"])
+	})
+
+	t.Run("success - not a stacktrace", func(t *testing.T) {
+		fields := LogFields(errors.New("plain"))
+		require.Equal(t, map[string]interface{}{"error": "plain"}, fields)
+	})
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+var (
+	ErrNoClientCertificate = errors.New("no verified client certificate")
+	ErrUnknownService      = errors.New("client certificate does not belong to a known service")
+)
+
+// TLSInterface provides the server's TLS configuration and maps verified
+// client certificates onto the services they were issued to.
+type TLSInterface interface {
+	Config() *tls.Config
+	ServiceIdentity(state *tls.ConnectionState) (string, error)
+}
+
+type TLSOptions struct {
+	// CertFile and KeyFile hold the PEM encoded certificate chain and private
+	// key, TLS is disabled when both are empty. They are reloaded when they
+	// change on disk, checked at most every ReloadInterval.
+	CertFile       string
+	KeyFile        string
+	ReloadInterval time.Duration
+	// MinVersion is "1.2" or "1.3".
+	MinVersion string
+	// CipherSuites names the TLS 1.2 cipher suites offered, as listed by
+	// tls.CipherSuites, Go's defaults when empty. TLS 1.3 suites are not
+	// configurable.
+	CipherSuites []string
+	// ClientCAFile holds the PEM encoded CAs client certificates are verified
+	// against. Without it no client certificates are requested. Clients
+	// without a certificate are still served unless RequireClientCert is set.
+	ClientCAFile      string
+	RequireClientCert bool
+	// Services maps the URI SAN, DNS SAN or subject common name of client
+	// certificates onto the service identity of their holder.
+	Services map[string]string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS serves a certificate that is reloaded from disk when renewed, so it can
+// be rotated without a restart.
+type TLS struct {
+	opt    TLSOptions
+	config *tls.Config
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func InitTLS(opt TLSOptions) (TLSInterface, error) {
+	t := &TLS{opt: opt}
+	if opt.CertFile == "" && opt.KeyFile == "" {
+		if opt.ClientCAFile != "" {
+			return nil, errors.New("client certificates need a server certificate")
+		}
+
+		return t, nil
+	}
+
+	if opt.CertFile == "" || opt.KeyFile == "" {
+		return nil, errors.New("tls needs both a certificate and a key file")
+	}
+
+	if opt.ReloadInterval <= 0 {
+		return nil, errors.New("tls reload interval must be positive")
+	}
+
+	minVersion, ok := tlsVersions[opt.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tls version %q", opt.MinVersion)
+	}
+
+	cipherSuites, err := parseCipherSuites(opt.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime, err := latestModTime(opt.CertFile, opt.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(opt.CertFile, opt.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	t.cert = &cert
+	t.modTime = modTime
+	t.checkedAt = time.Now()
+	t.config = &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return t.certificate(), nil
+		},
+	}
+
+	if opt.ClientCAFile != "" {
+		pem, err := os.ReadFile(opt.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client ca file does not contain any certificate")
+		}
+
+		t.config.ClientCAs = pool
+		t.config.ClientAuth = tls.VerifyClientCertIfGiven
+		if opt.RequireClientCert {
+			t.config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return t, nil
+}
+
+// Config returns the configuration to serve TLS with, or nil when TLS is
+// disabled.
+func (t *TLS) Config() *tls.Config {
+	return t.config
+}
+
+// ServiceIdentity returns the service the verified client certificate of a
+// connection was issued to. state is nil for plain HTTP requests.
+func (t *TLS) ServiceIdentity(state *tls.ConnectionState) (string, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", ErrNoClientCertificate
+	}
+
+	leaf := state.VerifiedChains[0][0]
+	names := make([]string, 0, len(leaf.URIs)+len(leaf.DNSNames)+1)
+	for _, uri := range leaf.URIs {
+		names = append(names, uri.String())
+	}
+	names = append(names, leaf.DNSNames...)
+	if leaf.Subject.CommonName != "" {
+		names = append(names, leaf.Subject.CommonName)
+	}
+
+	for _, name := range names {
+		if service, ok := t.opt.Services[name]; ok {
+			return service, nil
+		}
+	}
+
+	return "", ErrUnknownService
+}
+
+// certificate returns the current certificate, reloading it first when the
+// files changed since it was loaded. A renewal that fails to load is logged
+// and the previous certificate kept, so a half written file does not take
+// the server down.
+func (t *TLS) certificate() *tls.Certificate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(t.checkedAt) < t.opt.ReloadInterval {
+		return t.cert
+	}
+	t.checkedAt = now
+
+	modTime, err := latestModTime(t.opt.CertFile, t.opt.KeyFile)
+	if err != nil {
+		log.Error(err)
+		return t.cert
+	}
+
+	if !modTime.After(t.modTime) {
+		return t.cert
+	}
+
+	cert, err := tls.LoadX509KeyPair(t.opt.CertFile, t.opt.KeyFile)
+	if err != nil {
+		log.Error(err)
+		return t.cert
+	}
+
+	t.cert = &cert
+	t.modTime = modTime
+
+	return t.cert
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		ids[suite.Name] = suite.ID
+	}
+
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %s", name)
+		}
+
+		suites = append(suites, id)
+	}
+
+	return suites, nil
+}
+
+func latestModTime(paths ...string) (time.Time, error) {
+	var latest time.Time
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
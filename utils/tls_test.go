@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate issues a certificate for template, self-signed when parent
+// is nil.
+func testCertificate(t *testing.T, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	issuer, signer := template, interface{}(key)
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, signer)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func testCA(t *testing.T, name string) tls.Certificate {
+	return testCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+}
+
+// writeTestCertificate writes cert and its key as PEM files into dir.
+func writeTestCertificate(t *testing.T, dir, name string, cert tls.Certificate) (string, string) {
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestInitTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCA(t, "ca")
+	caFile, _ := writeTestCertificate(t, dir, "ca", ca)
+	certFile, keyFile := writeTestCertificate(t, dir, "server", testCertificate(t, &x509.Certificate{DNSNames: []string{"localhost"}}, &ca))
+
+	opt := TLSOptions{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Minute, MinVersion: "1.2"}
+
+	t.Run("success", func(t *testing.T) {
+		withCA := opt
+		withCA.ClientCAFile = caFile
+		withCA.CipherSuites = []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}
+
+		tlsUtil, err := InitTLS(withCA)
+		require.NoError(t, err)
+		require.Equal(t, uint16(tls.VersionTLS12), tlsUtil.Config().MinVersion)
+		require.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, tlsUtil.Config().CipherSuites)
+		require.Equal(t, tls.VerifyClientCertIfGiven, tlsUtil.Config().ClientAuth)
+	})
+
+	t.Run("success - client certificate required", func(t *testing.T) {
+		withCA := opt
+		withCA.ClientCAFile = caFile
+		withCA.RequireClientCert = true
+
+		tlsUtil, err := InitTLS(withCA)
+		require.NoError(t, err)
+		require.Equal(t, tls.RequireAndVerifyClientCert, tlsUtil.Config().ClientAuth)
+	})
+
+	t.Run("success - disabled", func(t *testing.T) {
+		tlsUtil, err := InitTLS(TLSOptions{})
+		require.NoError(t, err)
+		require.Nil(t, tlsUtil.Config())
+	})
+
+	t.Run("failed - no key file", func(t *testing.T) {
+		_, err := InitTLS(TLSOptions{CertFile: certFile, ReloadInterval: time.Minute, MinVersion: "1.2"})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - client ca without certificate", func(t *testing.T) {
+		_, err := InitTLS(TLSOptions{ClientCAFile: caFile})
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unsupported version", func(t *testing.T) {
+		old := opt
+		old.MinVersion = "1.0"
+
+		_, err := InitTLS(old)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - insecure cipher suite", func(t *testing.T) {
+		insecure := opt
+		insecure.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}
+
+		_, err := InitTLS(insecure)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - mismatched key", func(t *testing.T) {
+		_, otherKeyFile := writeTestCertificate(t, dir, "other", testCA(t, "other"))
+		mismatched := opt
+		mismatched.KeyFile = otherKeyFile
+
+		_, err := InitTLS(mismatched)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - empty client ca file", func(t *testing.T) {
+		emptyFile := filepath.Join(dir, "empty.crt")
+		require.NoError(t, os.WriteFile(emptyFile, nil, 0600))
+		withCA := opt
+		withCA.ClientCAFile = emptyFile
+
+		_, err := InitTLS(withCA)
+		require.Error(t, err)
+	})
+}
+
+func TestTLS_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCA(t, "ca")
+	first := testCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "first"}}, &ca)
+	certFile, keyFile := writeTestCertificate(t, dir, "server", first)
+
+	tlsUtil, err := InitTLS(TLSOptions{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond, MinVersion: "1.3"})
+	require.NoError(t, err)
+
+	getCertificate := tlsUtil.Config().GetCertificate
+	cert, err := getCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.Certificate, cert.Certificate)
+
+	t.Run("success - renewed certificate", func(t *testing.T) {
+		second := testCertificate(t, &x509.Certificate{Subject: pkix.Name{CommonName: "second"}}, &ca)
+		writeTestCertificate(t, dir, "server", second)
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(certFile, later, later))
+
+		cert, err := getCertificate(nil)
+		require.NoError(t, err)
+		require.Equal(t, second.Certificate, cert.Certificate)
+	})
+
+	t.Run("success - keeps certificate when renewal is broken", func(t *testing.T) {
+		before, err := getCertificate(nil)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(keyFile, []byte("broken"), 0600))
+		later := time.Now().Add(2 * time.Minute)
+		require.NoError(t, os.Chtimes(keyFile, later, later))
+
+		cert, err := getCertificate(nil)
+		require.NoError(t, err)
+		require.Equal(t, before.Certificate, cert.Certificate)
+	})
+}
+
+func TestTLS_ServiceIdentity(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCA(t, "ca")
+	caFile, _ := writeTestCertificate(t, dir, "ca", ca)
+	certFile, keyFile := writeTestCertificate(t, dir, "server", testCertificate(t, &x509.Certificate{
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, &ca))
+
+	tlsUtil, err := InitTLS(TLSOptions{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Minute,
+		MinVersion:     "1.2",
+		ClientCAFile:   caFile,
+		Services: map[string]string{
+			"spiffe://example.com/billing": "billing",
+			"reports":                      "reports",
+		},
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service, err := tlsUtil.ServiceIdentity(r.TLS)
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, err.Error())
+			return
+		}
+
+		io.WriteString(w, service)
+	}))
+	// StartTLS would serve httptest's own certificate.
+	server.Listener = tls.NewListener(server.Listener, tlsUtil.Config())
+	server.Start()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	// call sends certs whether or not they were issued by a CA the server
+	// asks for.
+	call := func(certs ...tls.Certificate) (int, string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: roots,
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				if len(certs) == 0 {
+					return &tls.Certificate{}, nil
+				}
+
+				return &certs[0], nil
+			},
+		}}}
+		resp, err := client.Get("https://" + server.Listener.Addr().String())
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	clientCertificate := func(template *x509.Certificate, issuer *tls.Certificate) tls.Certificate {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		return testCertificate(t, template, issuer)
+	}
+
+	t.Run("success - uri san", func(t *testing.T) {
+		uri, err := url.Parse("spiffe://example.com/billing")
+		require.NoError(t, err)
+
+		status, body, err := call(clientCertificate(&x509.Certificate{URIs: []*url.URL{uri}}, &ca))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "billing", body)
+	})
+
+	t.Run("success - common name", func(t *testing.T) {
+		status, body, err := call(clientCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "reports"}}, &ca))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, "reports", body)
+	})
+
+	t.Run("failed - no client certificate", func(t *testing.T) {
+		status, body, err := call()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, ErrNoClientCertificate.Error(), body)
+	})
+
+	t.Run("failed - unknown service", func(t *testing.T) {
+		status, body, err := call(clientCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}, &ca))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, status)
+		require.Equal(t, ErrUnknownService.Error(), body)
+	})
+
+	t.Run("failed - untrusted issuer", func(t *testing.T) {
+		other := testCA(t, "other")
+
+		_, _, err := call(clientCertificate(&x509.Certificate{Subject: pkix.Name{CommonName: "reports"}}, &other))
+		require.Error(t, err)
+	})
+}
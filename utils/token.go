@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const tokenLen = 32
+
+// GenerateToken returns a random URL-safe token, such as an email
+// verification token, along with the hash stored in its place.
+func GenerateToken() (token, hash string, err error) {
+	secret := make([]byte, tokenLen)
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(secret)
+
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes a token generated by GenerateToken for lookup.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
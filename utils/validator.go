@@ -3,81 +3,157 @@ package utils
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/SawitProRecruitment/UserService/generated"
 )
 
-func IsAuthLoginPayloadValid(payload generated.AuthLoginJSONRequestBody) (bool, string) {
-	isPayloadValid := true
-	errorMessages := make([]string, 0)
+// Validation error codes. They are part of the API, clients map them to their
+// own messages, so existing codes must not change.
+const (
+	ValidationCodeRequired          = "REQUIRED"
+	ValidationCodeMutuallyExclusive = "MUTUALLY_EXCLUSIVE"
+	ValidationCodeLength            = "LENGTH_OUT_OF_RANGE"
+	ValidationCodeRange             = "OUT_OF_RANGE"
+	ValidationCodeInvalidFormat     = "INVALID_FORMAT"
+	ValidationCodeInvalidType       = "INVALID_TYPE"
+	ValidationCodeInvalidEmail      = "INVALID_EMAIL"
+	ValidationCodeInvalidPhone      = "INVALID_PHONE_NUMBER"
+	ValidationCodeMissingUpperCase  = "MISSING_UPPER_CASE"
+	ValidationCodeMissingNumber     = "MISSING_NUMBER"
+	ValidationCodeMissingSpecial    = "MISSING_SPECIAL_CHARACTER"
+	ValidationCodeNotAllowed        = "NOT_ALLOWED"
+	ValidationCodeTooFewItems       = "TOO_FEW_ITEMS"
+	ValidationCodeNotInFuture       = "NOT_IN_FUTURE"
+	ValidationCodeNotBefore         = "NOT_BEFORE"
+)
 
-	if isValid := IsStartWithCountryCode(payload.PhoneNumber, "+62"); !isValid {
-		isPayloadValid = false
-		errorMessages = append(errorMessages, "phone_number field must start with +62")
-	}
+// ValidationError describes one failed rule of a request field. Params holds
+// the rule's arguments, e.g. `min` and `max` of a length, so clients can build
+// their own message.
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+	Params  map[string]interface{}
 
-	return isPayloadValid, strings.Join(errorMessages, ", ")
+	// format and args render Message, kept to render it in other languages.
+	format string
+	args   []interface{}
 }
 
-func IsRegisterUserPayloadValid(payload generated.RegisterUserJSONRequestBody) (bool, string) {
-	isPayloadValid := true
-	errorMessages := make([]string, 0)
+// ValidationErrors lists every failed rule of a request, its Error joins their
+// messages.
+type ValidationErrors []ValidationError
 
-	if isValid := IsStartWithCountryCode(payload.PhoneNumber, "+62"); !isValid {
-		isPayloadValid = false
-		errorMessages = append(errorMessages, "phone_number field must start with +62")
+func (e ValidationErrors) Error() string {
+	return e.LocalizedError(LanguageEnglish)
+}
+
+// LocalizedError is like Error but renders the messages in lang.
+func (e ValidationErrors) LocalizedError(lang string) string {
+	messages := make([]string, 0, len(e))
+	for _, validationError := range e {
+		messages = append(messages, validationError.LocalizedMessage(lang))
 	}
 
-	minPhoneLen, maxPhoneLen := 10, 16
-	if isValid := IsLengthBetweenRange(payload.PhoneNumber, minPhoneLen, maxPhoneLen); !isValid {
-		isPayloadValid = false
-		errorMessages = append(errorMessages, fmt.Sprintf("phone_number must be between %d to %d characters long", minPhoneLen, maxPhoneLen))
+	return strings.Join(messages, ", ")
+}
+
+// LocalizedMessage renders Message in lang.
+func (e ValidationError) LocalizedMessage(lang string) string {
+	if e.format == "" {
+		return e.Message
 	}
 
-	minNameLen, maxNameLen := 3, 60
-	if isValid := IsLengthBetweenRange(payload.FullName, minNameLen, maxNameLen); !isValid {
-		isPayloadValid = false
-		errorMessages = append(errorMessages, fmt.Sprintf("full_name must be between %d to %d characters long", minNameLen, maxNameLen))
+	return Translate(lang, e.format, e.args...)
+}
+
+func newValidationError(field, code string, params map[string]interface{}, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		Field:   field,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Params:  params,
+		format:  format,
+		args:    args,
 	}
+}
+
+// IsAuthLoginPayloadValid does not require the phone number to be valid, users
+// registered before numbers were checked log in with theirs as stored.
+func IsAuthLoginPayloadValid(payload generated.AuthLoginJSONRequestBody) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
 
-	minPasswordLen, maxPasswordLen := 6, 64
-	if isValid := IsLengthBetweenRange(payload.Password, minPasswordLen, maxPasswordLen); !isValid {
+	switch {
+	case payload.PhoneNumber == nil && payload.Email == nil:
+		isPayloadValid = false
+		validationErrors = append(validationErrors, newValidationError("phone_number", ValidationCodeRequired,
+			map[string]interface{}{"alternatives": []string{"email"}}, "%s or %s is required", "phone_number", "email"))
+	case payload.PhoneNumber != nil && payload.Email != nil:
 		isPayloadValid = false
-		errorMessages = append(errorMessages, fmt.Sprintf("password must be between %d to %d characters long", minPasswordLen, maxPasswordLen))
+		validationErrors = append(validationErrors, newValidationError("phone_number", ValidationCodeMutuallyExclusive,
+			map[string]interface{}{"fields": []string{"email"}}, "only one of %s or %s can be given", "phone_number", "email"))
+	case payload.PhoneNumber != nil:
+		if strings.TrimSpace(*payload.PhoneNumber) == "" {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, requiredError("phone_number"))
+		}
+	default:
+		if isValid := IsEmail(NormalizeEmail(*payload.Email)); !isValid {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, emailError())
+		}
 	}
 
-	if isValid := ContainsUpperCase(payload.Password); !isValid {
+	return isPayloadValid, validationErrors
+}
+
+func IsRegisterUserPayloadValid(payload generated.RegisterUserJSONRequestBody, phone PhoneInterface) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
+
+	if _, err := phone.Normalize(payload.PhoneNumber); err != nil {
 		isPayloadValid = false
-		errorMessages = append(errorMessages, "password must contain 1 upper case")
+		validationErrors = append(validationErrors, phoneNumberError(phone))
 	}
 
-	if isValid := ContainsNumber(payload.Password); !isValid {
+	if payload.Email != nil {
+		if isValid := IsEmail(NormalizeEmail(*payload.Email)); !isValid {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, emailError())
+		}
+	}
+
+	if passwordErrors := passwordErrors(payload.Password); len(passwordErrors) > 0 {
 		isPayloadValid = false
-		errorMessages = append(errorMessages, "password must contain 1 number")
+		validationErrors = append(validationErrors, passwordErrors...)
 	}
 
-	if isValid := ContainsSpecialCharacter(payload.Password); !isValid {
+	return isPayloadValid, validationErrors
+}
+
+func IsChangePasswordPayloadValid(payload generated.ChangeUserPasswordJSONRequestBody) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
+
+	if passwordErrors := passwordErrors(payload.Password); len(passwordErrors) > 0 {
 		isPayloadValid = false
-		errorMessages = append(errorMessages, "password must contain 1 special character")
+		validationErrors = append(validationErrors, passwordErrors...)
 	}
 
-	return isPayloadValid, strings.Join(errorMessages, ", ")
+	return isPayloadValid, validationErrors
 }
 
-func IsUpdateUserProfilePayloadValid(payload generated.UpdateUserProfileJSONRequestBody) (bool, string) {
+func IsUpdateUserProfilePayloadValid(payload generated.UpdateUserProfileJSONRequestBody, phone PhoneInterface) (bool, ValidationErrors) {
 	isPayloadValid := true
-	errorMessages := make([]string, 0)
+	validationErrors := make(ValidationErrors, 0)
 
 	if payload.PhoneNumber != "" {
-		if isValid := IsStartWithCountryCode(payload.PhoneNumber, "+62"); !isValid {
-			isPayloadValid = false
-			errorMessages = append(errorMessages, "phone_number field must start with +62")
-		}
-
-		minPhoneLen, maxPhoneLen := 10, 16
-		if isValid := IsLengthBetweenRange(payload.PhoneNumber, minPhoneLen, maxPhoneLen); !isValid {
+		if _, err := phone.Normalize(payload.PhoneNumber); err != nil {
 			isPayloadValid = false
-			errorMessages = append(errorMessages, fmt.Sprintf("phone_number must be between %d to %d characters long", minPhoneLen, maxPhoneLen))
+			validationErrors = append(validationErrors, phoneNumberError(phone))
 		}
 	}
 
@@ -85,9 +161,98 @@ func IsUpdateUserProfilePayloadValid(payload generated.UpdateUserProfileJSONRequ
 		minNameLen, maxNameLen := 3, 60
 		if isValid := IsLengthBetweenRange(payload.FullName, minNameLen, maxNameLen); !isValid {
 			isPayloadValid = false
-			errorMessages = append(errorMessages, fmt.Sprintf("full_name must be between %d to %d characters long", minNameLen, maxNameLen))
+			validationErrors = append(validationErrors, lengthError("full_name", minNameLen, maxNameLen))
+		}
+	}
+
+	if payload.Email != nil {
+		if isValid := IsEmail(NormalizeEmail(*payload.Email)); !isValid {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, emailError())
+		}
+	}
+
+	return isPayloadValid, validationErrors
+}
+
+func IsAdminUpdateUserPayloadValid(payload generated.UpdateUserJSONRequestBody, phone PhoneInterface) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
+
+	if payload.PhoneNumber != nil {
+		if _, err := phone.Normalize(*payload.PhoneNumber); err != nil {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, phoneNumberError(phone))
 		}
 	}
 
-	return isPayloadValid, strings.Join(errorMessages, ", ")
+	return isPayloadValid, validationErrors
+}
+
+func IsListUsersParamsValid(params generated.ListUsersParams) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
+
+	if params.Cursor != nil {
+		if _, err := DecodeCursor(*params.Cursor); err != nil {
+			isPayloadValid = false
+			validationErrors = append(validationErrors, newValidationError("cursor", ValidationCodeInvalidFormat, nil, "%s is invalid", "cursor"))
+		}
+	}
+
+	if params.CreatedFrom != nil && params.CreatedTo != nil && !params.CreatedFrom.Before(*params.CreatedTo) {
+		isPayloadValid = false
+		validationErrors = append(validationErrors, newValidationError("created_from", ValidationCodeNotBefore,
+			map[string]interface{}{"field": "created_to"}, "%s must be before %s", "created_from", "created_to"))
+	}
+
+	return isPayloadValid, validationErrors
+}
+
+func IsCreateAPIKeyPayloadValid(payload generated.CreateApiKeyJSONRequestBody) (bool, ValidationErrors) {
+	isPayloadValid := true
+	validationErrors := make(ValidationErrors, 0)
+
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		isPayloadValid = false
+		validationErrors = append(validationErrors, newValidationError("expires_at", ValidationCodeNotInFuture, nil, "%s must be in the future", "expires_at"))
+	}
+
+	return isPayloadValid, validationErrors
+}
+
+func passwordErrors(password string) ValidationErrors {
+	validationErrors := make(ValidationErrors, 0)
+
+	if isValid := ContainsUpperCase(password); !isValid {
+		validationErrors = append(validationErrors, newValidationError("password", ValidationCodeMissingUpperCase, map[string]interface{}{"min": 1}, "%s must contain %d upper case", "password", 1))
+	}
+
+	if isValid := ContainsNumber(password); !isValid {
+		validationErrors = append(validationErrors, newValidationError("password", ValidationCodeMissingNumber, map[string]interface{}{"min": 1}, "%s must contain %d number", "password", 1))
+	}
+
+	if isValid := ContainsSpecialCharacter(password); !isValid {
+		validationErrors = append(validationErrors, newValidationError("password", ValidationCodeMissingSpecial, map[string]interface{}{"min": 1}, "%s must contain %d special character", "password", 1))
+	}
+
+	return validationErrors
+}
+
+func lengthError(field string, min, max int) ValidationError {
+	return newValidationError(field, ValidationCodeLength, map[string]interface{}{"min": min, "max": max},
+		"%s must be between %d to %d characters long", field, min, max)
+}
+
+func emailError() ValidationError {
+	return newValidationError("email", ValidationCodeInvalidEmail, nil, "%s must be a valid email address", "email")
+}
+
+func phoneNumberError(phone PhoneInterface) ValidationError {
+	return newValidationError("phone_number", ValidationCodeInvalidPhone, map[string]interface{}{"countries": phone.AllowedCountries()},
+		"%s must be a mobile number in international format from %s", "phone_number", strings.Join(phone.AllowedCountries(), ", "))
+}
+
+func requiredError(field string) ValidationError {
+	return newValidationError(field, ValidationCodeRequired, nil, "%s is required", field)
 }
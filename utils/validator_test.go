@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/generated"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsAuthLoginPayloadValid(t *testing.T) {
+	t.Run("success - phone number that can not be normalized", func(t *testing.T) {
+		phoneNumber := "08123456789"
+		isValid, validationErrors := IsAuthLoginPayloadValid(generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"})
+		require.True(t, isValid)
+		require.Empty(t, validationErrors)
+	})
+
+	t.Run("failed - empty phone number", func(t *testing.T) {
+		phoneNumber := " "
+		isValid, validationErrors := IsAuthLoginPayloadValid(generated.AuthLoginJSONRequestBody{PhoneNumber: &phoneNumber, Password: "password"})
+		require.False(t, isValid)
+		require.Equal(t, ValidationErrors{
+			{Field: "phone_number", Code: ValidationCodeRequired, Message: "phone_number is required"},
+		}, withoutFormats(validationErrors))
+	})
+}
+
+func TestIsRegisterUserPayloadValid(t *testing.T) {
+	phone, err := InitPhone(PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		isValid, validationErrors := IsRegisterUserPayloadValid(generated.RegisterUserJSONRequestBody{
+			FullName:    "John Doe",
+			PhoneNumber: "+628123456789",
+			Password:    "passworD!1",
+		}, phone)
+		require.True(t, isValid)
+		require.Empty(t, validationErrors)
+	})
+
+	t.Run("failed - every rule", func(t *testing.T) {
+		email := "john"
+		isValid, validationErrors := IsRegisterUserPayloadValid(generated.RegisterUserJSONRequestBody{
+			FullName:    "Jo",
+			PhoneNumber: "08123456789",
+			Email:       &email,
+			Password:    "pass",
+		}, phone)
+		require.False(t, isValid)
+		require.Equal(t, ValidationErrors{
+			{Field: "phone_number", Code: ValidationCodeInvalidPhone, Message: "phone_number must be a mobile number in international format from ID", Params: map[string]interface{}{"countries": []string{"ID"}}},
+			{Field: "email", Code: ValidationCodeInvalidEmail, Message: "email must be a valid email address"},
+			{Field: "password", Code: ValidationCodeMissingUpperCase, Message: "password must contain 1 upper case", Params: map[string]interface{}{"min": 1}},
+			{Field: "password", Code: ValidationCodeMissingNumber, Message: "password must contain 1 number", Params: map[string]interface{}{"min": 1}},
+			{Field: "password", Code: ValidationCodeMissingSpecial, Message: "password must contain 1 special character", Params: map[string]interface{}{"min": 1}},
+		}, withoutFormats(validationErrors))
+	})
+}
+
+func TestIsCreateAPIKeyPayloadValid(t *testing.T) {
+	t.Run("failed - expired", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		isValid, validationErrors := IsCreateAPIKeyPayloadValid(generated.CreateApiKeyJSONRequestBody{Name: "reports", ExpiresAt: &expiresAt})
+		require.False(t, isValid)
+		require.Equal(t, "expires_at must be in the future", validationErrors.Error())
+		require.Equal(t, ValidationCodeNotInFuture, validationErrors[0].Code)
+	})
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	validationErrors := ValidationErrors{
+		{Field: "created_from", Code: ValidationCodeNotBefore, Message: "created_from must be before created_to"},
+		{Field: "cursor", Code: ValidationCodeInvalidFormat, Message: "cursor is invalid"},
+	}
+	require.Equal(t, "created_from must be before created_to, cursor is invalid", validationErrors.Error())
+}
+
+func TestValidationErrors_LocalizedError(t *testing.T) {
+	phone, err := InitPhone(PhoneOptions{AllowedCountries: []string{"ID"}})
+	require.NoError(t, err)
+
+	_, validationErrors := IsRegisterUserPayloadValid(generated.RegisterUserJSONRequestBody{
+		FullName:    "John Doe",
+		PhoneNumber: "08123456789",
+		Password:    "passworD1",
+	}, phone)
+
+	t.Run("success - indonesian", func(t *testing.T) {
+		require.Equal(t, "phone_number harus berupa nomor ponsel dalam format internasional dari ID, password harus mengandung 1 karakter khusus", validationErrors.LocalizedError(LanguageIndonesian))
+	})
+
+	t.Run("success - english", func(t *testing.T) {
+		require.Equal(t, validationErrors.Error(), validationErrors.LocalizedError(LanguageEnglish))
+	})
+
+	t.Run("success - message without format", func(t *testing.T) {
+		validationError := ValidationError{Field: "cursor", Code: ValidationCodeInvalidFormat, Message: "cursor is invalid"}
+		require.Equal(t, "cursor is invalid", validationError.LocalizedMessage(LanguageIndonesian))
+	})
+}
+
+// withoutFormats drops what validation errors keep to render their message in
+// other languages, so they can be compared with literals.
+func withoutFormats(validationErrors ValidationErrors) ValidationErrors {
+	stripped := make(ValidationErrors, 0, len(validationErrors))
+	for _, validationError := range validationErrors {
+		validationError.format, validationError.args = "", nil
+		stripped = append(stripped, validationError)
+	}
+
+	return stripped
+}
@@ -0,0 +1,649 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/fxamacker/cbor/v2"
+)
+
+var ErrWebAuthnDisabled = errors.New("webauthn is not configured")
+
+// COSE algorithms of the credential public keys we accept, in order of
+// preference.
+const (
+	COSEAlgorithmES256 int64 = -7
+	COSEAlgorithmEdDSA int64 = -8
+	COSEAlgorithmRS256 int64 = -257
+)
+
+// Attestation statement formats we can verify.
+const (
+	AttestationFormatNone   = "none"
+	AttestationFormatPacked = "packed"
+)
+
+// Flags of the authenticator data.
+const (
+	webAuthnFlagUserPresent   byte = 0x01
+	webAuthnFlagUserVerified  byte = 0x04
+	webAuthnFlagAttestedData  byte = 0x40
+	webAuthnFlagExtensionData byte = 0x80
+)
+
+// oidAAGUID is the certificate extension carrying the AAGUID of packed
+// attestation certificates.
+var oidAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+type WebAuthnInterface interface {
+	CreationOptions(user model.User, challenge string, excludeCredentials []string) (WebAuthnCreationOptions, error)
+	RequestOptions(challenge string) (WebAuthnRequestOptions, error)
+	Challenge(clientDataJSON string) (string, error)
+	VerifyRegistration(challenge string, response WebAuthnAttestationResponse) (WebAuthnCredential, error)
+	VerifyAssertion(challenge string, credential model.WebAuthnCredential, response WebAuthnAssertionResponse) (uint32, error)
+}
+
+// WebAuthnOptions configures the relying party. RPID is the domain the
+// credentials are scoped to, Origins the origins of the pages allowed to use
+// them. WebAuthn is disabled when RPID is empty.
+type WebAuthnOptions struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	Timeout time.Duration
+}
+
+// WebAuthnCreationOptions holds the PublicKeyCredentialCreationOptions passed
+// to navigator.credentials.create(). Binary values are base64url encoded.
+type WebAuthnCreationOptions struct {
+	RPID               string
+	RPName             string
+	UserID             string
+	UserName           string
+	UserDisplayName    string
+	Challenge          string
+	Algorithms         []int64
+	Timeout            time.Duration
+	ExcludeCredentials []string
+	ResidentKey        string
+	UserVerification   string
+	Attestation        string
+}
+
+// WebAuthnRequestOptions holds the PublicKeyCredentialRequestOptions passed to
+// navigator.credentials.get(). No credentials are listed, the authenticator
+// offers the discoverable credentials it holds for the relying party.
+type WebAuthnRequestOptions struct {
+	RPID             string
+	Challenge        string
+	Timeout          time.Duration
+	UserVerification string
+}
+
+// WebAuthnAttestationResponse is the AuthenticatorAttestationResponse of a new
+// credential, with base64url encoded fields.
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string
+	AttestationObject string
+}
+
+// WebAuthnAssertionResponse is the AuthenticatorAssertionResponse of a login,
+// with base64url encoded fields. UserHandle is empty when the authenticator
+// does not return it.
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string
+	AuthenticatorData string
+	Signature         string
+	UserHandle        string
+}
+
+// WebAuthnCredential is a verified new credential. ID is base64url encoded,
+// PublicKey is the COSE_Key to verify its assertions with.
+type WebAuthnCredential struct {
+	ID                string
+	PublicKey         []byte
+	SignCount         uint32
+	AAGUID            string
+	AttestationFormat string
+}
+
+type WebAuthn struct {
+	opt    WebAuthnOptions
+	rpHash [32]byte
+}
+
+type webAuthnClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type webAuthnAttestationObject struct {
+	Format    string          `cbor:"fmt"`
+	Statement cbor.RawMessage `cbor:"attStmt"`
+	AuthData  []byte          `cbor:"authData"`
+}
+
+type packedAttestationStatement struct {
+	Algorithm    int64    `cbor:"alg"`
+	Signature    []byte   `cbor:"sig"`
+	Certificates [][]byte `cbor:"x5c"`
+}
+
+type webAuthnAuthenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func InitWebAuthn(opt WebAuthnOptions) (WebAuthnInterface, error) {
+	w := WebAuthn{opt: opt}
+	if opt.RPID == "" {
+		return w, nil
+	}
+
+	if len(opt.Origins) == 0 {
+		return nil, errors.New("webauthn needs at least one origin")
+	}
+
+	if w.opt.RPName == "" {
+		w.opt.RPName = opt.RPID
+	}
+
+	w.rpHash = sha256.Sum256([]byte(opt.RPID))
+
+	return w, nil
+}
+
+// CreationOptions returns the options to register a new discoverable
+// credential for user. The user handle is the user's ID, the credentials the
+// user already has are excluded so that an authenticator is not registered
+// twice.
+func (w WebAuthn) CreationOptions(user model.User, challenge string, excludeCredentials []string) (WebAuthnCreationOptions, error) {
+	if w.opt.RPID == "" {
+		return WebAuthnCreationOptions{}, ErrWebAuthnDisabled
+	}
+
+	name := user.PhoneNumber
+	if user.Email.Valid {
+		name = user.Email.String
+	}
+
+	options := WebAuthnCreationOptions{
+		RPID:               w.opt.RPID,
+		RPName:             w.opt.RPName,
+		UserID:             webAuthnUserHandle(user.Id),
+		UserName:           name,
+		UserDisplayName:    user.FullName,
+		Challenge:          challenge,
+		Algorithms:         []int64{COSEAlgorithmES256, COSEAlgorithmEdDSA, COSEAlgorithmRS256},
+		Timeout:            w.opt.Timeout,
+		ExcludeCredentials: excludeCredentials,
+		ResidentKey:        "required",
+		UserVerification:   "required",
+		Attestation:        AttestationFormatNone,
+	}
+
+	return options, nil
+}
+
+// RequestOptions returns the options to log in with a discoverable
+// credential.
+func (w WebAuthn) RequestOptions(challenge string) (WebAuthnRequestOptions, error) {
+	if w.opt.RPID == "" {
+		return WebAuthnRequestOptions{}, ErrWebAuthnDisabled
+	}
+
+	options := WebAuthnRequestOptions{
+		RPID:             w.opt.RPID,
+		Challenge:        challenge,
+		Timeout:          w.opt.Timeout,
+		UserVerification: "required",
+	}
+
+	return options, nil
+}
+
+// Challenge returns the challenge the base64url encoded client data claims to
+// answer, without verifying anything. It is only meant to look up the pending
+// challenge before the response is verified.
+func (w WebAuthn) Challenge(clientDataJSON string) (string, error) {
+	clientData, _, err := parseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return "", err
+	}
+
+	if clientData.Challenge == "" {
+		return "", errors.New("webauthn client data has no challenge")
+	}
+
+	return clientData.Challenge, nil
+}
+
+// VerifyRegistration verifies the response of navigator.credentials.create()
+// to challenge: the client data, the authenticator data and the attestation
+// statement, which must be of the none or packed format. Packed attestation
+// certificates are not chained to a trusted root, authenticators are not
+// restricted to certified models.
+func (w WebAuthn) VerifyRegistration(challenge string, response WebAuthnAttestationResponse) (WebAuthnCredential, error) {
+	if w.opt.RPID == "" {
+		return WebAuthnCredential{}, ErrWebAuthnDisabled
+	}
+
+	clientDataHash, err := w.verifyClientData(response.ClientDataJSON, "webauthn.create", challenge)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	raw, err := decodeBase64URL(response.AttestationObject)
+	if err != nil {
+		return WebAuthnCredential{}, fmt.Errorf("webauthn attestation object: %w", err)
+	}
+
+	var attestation webAuthnAttestationObject
+	if err := cbor.Unmarshal(raw, &attestation); err != nil {
+		return WebAuthnCredential{}, fmt.Errorf("webauthn attestation object: %w", err)
+	}
+
+	authData, err := w.verifyAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	if authData.flags&webAuthnFlagAttestedData == 0 {
+		return WebAuthnCredential{}, errors.New("webauthn authenticator data has no attested credential")
+	}
+
+	publicKey, algorithm, err := parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return WebAuthnCredential{}, err
+	}
+
+	signed := append(append([]byte{}, attestation.AuthData...), clientDataHash[:]...)
+	switch attestation.Format {
+	case AttestationFormatNone:
+		var statement map[string]cbor.RawMessage
+		if err := cbor.Unmarshal(attestation.Statement, &statement); err != nil || len(statement) > 0 {
+			return WebAuthnCredential{}, errors.New("webauthn none attestation has a statement")
+		}
+	case AttestationFormatPacked:
+		if err := verifyPackedAttestation(attestation.Statement, signed, publicKey, algorithm, authData.aaguid); err != nil {
+			return WebAuthnCredential{}, err
+		}
+	default:
+		return WebAuthnCredential{}, fmt.Errorf("unsupported webauthn attestation format %q", attestation.Format)
+	}
+
+	credential := WebAuthnCredential{
+		ID:                base64.RawURLEncoding.EncodeToString(authData.credentialID),
+		PublicKey:         authData.publicKey,
+		SignCount:         authData.signCount,
+		AAGUID:            formatAAGUID(authData.aaguid),
+		AttestationFormat: attestation.Format,
+	}
+
+	return credential, nil
+}
+
+// VerifyAssertion verifies the response of navigator.credentials.get() to
+// challenge with the public key of credential and returns the signature
+// counter of the authenticator. Checking that the counter increased is left
+// to the caller.
+func (w WebAuthn) VerifyAssertion(challenge string, credential model.WebAuthnCredential, response WebAuthnAssertionResponse) (uint32, error) {
+	if w.opt.RPID == "" {
+		return 0, ErrWebAuthnDisabled
+	}
+
+	if response.UserHandle != "" && response.UserHandle != webAuthnUserHandle(credential.UserId) {
+		return 0, errors.New("webauthn user handle does not match the credential")
+	}
+
+	clientDataHash, err := w.verifyClientData(response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decodeBase64URL(response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("webauthn authenticator data: %w", err)
+	}
+
+	authData, err := w.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	signature, err := decodeBase64URL(response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("webauthn signature: %w", err)
+	}
+
+	publicKey, algorithm, err := parseCOSEKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifyCOSESignature(publicKey, algorithm, signed, signature); err != nil {
+		return 0, err
+	}
+
+	return authData.signCount, nil
+}
+
+// verifyClientData checks the type, challenge and origin of the base64url
+// encoded client data and returns its hash.
+func (w WebAuthn) verifyClientData(clientDataJSON, ceremony, challenge string) ([32]byte, error) {
+	clientData, raw, err := parseWebAuthnClientData(clientDataJSON)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	if clientData.Type != ceremony {
+		return [32]byte{}, fmt.Errorf("webauthn client data is of type %q", clientData.Type)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return [32]byte{}, errors.New("webauthn client data has another challenge")
+	}
+
+	if clientData.CrossOrigin {
+		return [32]byte{}, errors.New("webauthn client data is from a cross-origin frame")
+	}
+
+	allowed := false
+	for _, origin := range w.opt.Origins {
+		if clientData.Origin == origin {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return [32]byte{}, fmt.Errorf("webauthn origin %q is not allowed", clientData.Origin)
+	}
+
+	return sha256.Sum256(raw), nil
+}
+
+// verifyAuthenticatorData parses the authenticator data and checks that it is
+// scoped to our relying party and the user was present and verified.
+func (w WebAuthn) verifyAuthenticatorData(raw []byte) (webAuthnAuthenticatorData, error) {
+	authData, err := parseWebAuthnAuthenticatorData(raw)
+	if err != nil {
+		return webAuthnAuthenticatorData{}, err
+	}
+
+	if subtle.ConstantTimeCompare(authData.rpIDHash, w.rpHash[:]) != 1 {
+		return webAuthnAuthenticatorData{}, errors.New("webauthn authenticator data is for another relying party")
+	}
+
+	if authData.flags&webAuthnFlagUserPresent == 0 {
+		return webAuthnAuthenticatorData{}, errors.New("webauthn user was not present")
+	}
+
+	if authData.flags&webAuthnFlagUserVerified == 0 {
+		return webAuthnAuthenticatorData{}, errors.New("webauthn user was not verified")
+	}
+
+	return authData, nil
+}
+
+func parseWebAuthnClientData(clientDataJSON string) (webAuthnClientData, []byte, error) {
+	raw, err := decodeBase64URL(clientDataJSON)
+	if err != nil {
+		return webAuthnClientData{}, nil, fmt.Errorf("webauthn client data: %w", err)
+	}
+
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return webAuthnClientData{}, nil, fmt.Errorf("webauthn client data: %w", err)
+	}
+
+	return clientData, raw, nil
+}
+
+// parseWebAuthnAuthenticatorData splits the authenticator data into its
+// fields, see https://www.w3.org/TR/webauthn-2/#sctn-authenticator-data.
+func parseWebAuthnAuthenticatorData(raw []byte) (webAuthnAuthenticatorData, error) {
+	if len(raw) < 37 {
+		return webAuthnAuthenticatorData{}, errors.New("webauthn authenticator data is too short")
+	}
+
+	authData := webAuthnAuthenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	rest := raw[37:]
+	if authData.flags&webAuthnFlagAttestedData != 0 {
+		if len(rest) < 18 {
+			return webAuthnAuthenticatorData{}, errors.New("webauthn attested credential data is too short")
+		}
+
+		authData.aaguid = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen > 1023 || len(rest) < idLen {
+			return webAuthnAuthenticatorData{}, errors.New("webauthn credential id has an invalid length")
+		}
+
+		authData.credentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		var publicKey cbor.RawMessage
+		var err error
+		if rest, err = cbor.UnmarshalFirst(rest, &publicKey); err != nil {
+			return webAuthnAuthenticatorData{}, fmt.Errorf("webauthn credential public key: %w", err)
+		}
+
+		authData.publicKey = publicKey
+	}
+
+	if authData.flags&webAuthnFlagExtensionData != 0 {
+		var extensions map[string]cbor.RawMessage
+		var err error
+		if rest, err = cbor.UnmarshalFirst(rest, &extensions); err != nil {
+			return webAuthnAuthenticatorData{}, fmt.Errorf("webauthn extensions: %w", err)
+		}
+	}
+
+	if len(rest) > 0 {
+		return webAuthnAuthenticatorData{}, errors.New("webauthn authenticator data has trailing bytes")
+	}
+
+	return authData, nil
+}
+
+// verifyPackedAttestation verifies a packed attestation statement over
+// signed. Without a certificate chain it is a self attestation signed by the
+// credential itself.
+func verifyPackedAttestation(raw cbor.RawMessage, signed []byte, credentialKey crypto.PublicKey, credentialAlgorithm int64, aaguid []byte) error {
+	var statement packedAttestationStatement
+	if err := cbor.Unmarshal(raw, &statement); err != nil {
+		return fmt.Errorf("webauthn packed attestation: %w", err)
+	}
+
+	if len(statement.Certificates) == 0 {
+		if statement.Algorithm != credentialAlgorithm {
+			return errors.New("webauthn self attestation uses another algorithm than the credential")
+		}
+
+		return verifyCOSESignature(credentialKey, statement.Algorithm, signed, statement.Signature)
+	}
+
+	cert, err := x509.ParseCertificate(statement.Certificates[0])
+	if err != nil {
+		return fmt.Errorf("webauthn attestation certificate: %w", err)
+	}
+
+	if cert.IsCA {
+		return errors.New("webauthn attestation certificate is a CA certificate")
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidAAGUID) {
+			continue
+		}
+
+		var certAAGUID []byte
+		if _, err := asn1.Unmarshal(ext.Value, &certAAGUID); err != nil || ext.Critical || !bytes.Equal(certAAGUID, aaguid) {
+			return errors.New("webauthn attestation certificate is for another authenticator model")
+		}
+	}
+
+	return verifyCOSESignature(cert.PublicKey, statement.Algorithm, signed, statement.Signature)
+}
+
+// parseCOSEKey parses a credential public key in COSE_Key format, see RFC
+// 8152. It returns the key along with its algorithm.
+func parseCOSEKey(raw []byte) (crypto.PublicKey, int64, error) {
+	var params map[int64]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &params); err != nil {
+		return nil, 0, fmt.Errorf("webauthn public key: %w", err)
+	}
+
+	var keyType, algorithm int64
+	if err := coseParam(params, 1, &keyType); err != nil {
+		return nil, 0, err
+	}
+
+	if err := coseParam(params, 3, &algorithm); err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case keyType == 2 && algorithm == COSEAlgorithmES256:
+		var curve int64
+		var x, y []byte
+		if err := coseParams(params, map[int64]interface{}{-1: &curve, -2: &x, -3: &y}); err != nil {
+			return nil, 0, err
+		}
+
+		if curve != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("webauthn public key is not a P-256 key")
+		}
+
+		// Unmarshal checks that the point is on the curve.
+		px, py := elliptic.Unmarshal(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if px == nil {
+			return nil, 0, errors.New("webauthn public key is not on the P-256 curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: px, Y: py}, algorithm, nil
+	case keyType == 1 && algorithm == COSEAlgorithmEdDSA:
+		var curve int64
+		var x []byte
+		if err := coseParams(params, map[int64]interface{}{-1: &curve, -2: &x}); err != nil {
+			return nil, 0, err
+		}
+
+		if curve != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("webauthn public key is not an Ed25519 key")
+		}
+
+		return ed25519.PublicKey(x), algorithm, nil
+	case keyType == 3 && algorithm == COSEAlgorithmRS256:
+		var n, e []byte
+		if err := coseParams(params, map[int64]interface{}{-1: &n, -2: &e}); err != nil {
+			return nil, 0, err
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		modulus := new(big.Int).SetBytes(n)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 || modulus.BitLen() < 2048 {
+			return nil, 0, errors.New("webauthn public key is not a valid RSA key")
+		}
+
+		return &rsa.PublicKey{N: modulus, E: int(exponent.Int64())}, algorithm, nil
+	}
+
+	return nil, 0, fmt.Errorf("unsupported webauthn public key type %d with algorithm %d", keyType, algorithm)
+}
+
+func coseParam(params map[int64]cbor.RawMessage, label int64, v interface{}) error {
+	raw, ok := params[label]
+	if !ok {
+		return fmt.Errorf("webauthn public key has no parameter %d", label)
+	}
+
+	if err := cbor.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("webauthn public key parameter %d: %w", label, err)
+	}
+
+	return nil
+}
+
+func coseParams(params map[int64]cbor.RawMessage, values map[int64]interface{}) error {
+	for label, v := range values {
+		if err := coseParam(params, label, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyCOSESignature verifies signature over data made with the COSE
+// algorithm by the private key of publicKey.
+func verifyCOSESignature(publicKey crypto.PublicKey, algorithm int64, data, signature []byte) error {
+	digest := sha256.Sum256(data)
+	valid := false
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		valid = algorithm == COSEAlgorithmES256 && ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		valid = algorithm == COSEAlgorithmEdDSA && ed25519.Verify(key, data, signature)
+	case *rsa.PublicKey:
+		valid = algorithm == COSEAlgorithmRS256 && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	if !valid {
+		return errors.New("invalid webauthn signature")
+	}
+
+	return nil
+}
+
+// webAuthnUserHandle identifies the user to the authenticator. It carries
+// nothing but the user's ID.
+func webAuthnUserHandle(userId int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(userId, 10)))
+}
+
+// decodeBase64URL decodes base64url with or without padding, browsers
+// serialize credentials without it.
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func formatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", aaguid[0:4], aaguid[4:6], aaguid[6:8], aaguid[8:10], aaguid[10:16])
+}
@@ -0,0 +1,492 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/SawitProRecruitment/UserService/model"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/guregu/null/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testWebAuthnRPID   = "users.example.com"
+	testWebAuthnOrigin = "https://users.example.com"
+)
+
+var testWebAuthnAlgorithms = map[string]int64{
+	"ES256": COSEAlgorithmES256,
+	"EdDSA": COSEAlgorithmEdDSA,
+	"RS256": COSEAlgorithmRS256,
+}
+
+var testAAGUID = []byte{0xad, 0xce, 0x00, 0x02, 0x35, 0xbc, 0xc6, 0x0a, 0x64, 0x8b, 0x0b, 0x25, 0xf1, 0xf0, 0x55, 0x03}
+
+// testAuthenticator is a software authenticator holding a single discoverable
+// credential.
+type testAuthenticator struct {
+	t            *testing.T
+	key          crypto.Signer
+	algorithm    int64
+	credentialID []byte
+	signCount    uint32
+	rpID         string
+	flags        byte
+}
+
+func newTestAuthenticator(t *testing.T, algorithm int64) *testAuthenticator {
+	var key crypto.Signer
+	var err error
+	switch algorithm {
+	case COSEAlgorithmES256:
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case COSEAlgorithmEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	case COSEAlgorithmRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	require.NoError(t, err)
+
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+
+	return &testAuthenticator{
+		t:            t,
+		key:          key,
+		algorithm:    algorithm,
+		credentialID: credentialID,
+		rpID:         testWebAuthnRPID,
+		flags:        webAuthnFlagUserPresent | webAuthnFlagUserVerified,
+	}
+}
+
+func (a *testAuthenticator) id() string {
+	return base64.RawURLEncoding.EncodeToString(a.credentialID)
+}
+
+// publicKey returns the credential public key as COSE_Key.
+func (a *testAuthenticator) publicKey() []byte {
+	var params map[int]interface{}
+	switch key := a.key.Public().(type) {
+	case *ecdsa.PublicKey:
+		params = map[int]interface{}{1: 2, 3: a.algorithm, -1: 1, -2: key.X.FillBytes(make([]byte, 32)), -3: key.Y.FillBytes(make([]byte, 32))}
+	case ed25519.PublicKey:
+		params = map[int]interface{}{1: 1, 3: a.algorithm, -1: 6, -2: []byte(key)}
+	case *rsa.PublicKey:
+		params = map[int]interface{}{1: 3, 3: a.algorithm, -1: key.N.Bytes(), -2: big.NewInt(int64(key.E)).Bytes()}
+	}
+
+	encoder, err := cbor.CoreDetEncOptions().EncMode()
+	require.NoError(a.t, err)
+
+	raw, err := encoder.Marshal(params)
+	require.NoError(a.t, err)
+
+	return raw
+}
+
+func (a *testAuthenticator) authenticatorData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := a.flags
+	if attested {
+		flags |= webAuthnFlagAttestedData
+	}
+
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, testAAGUID...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.publicKey()...)
+	}
+
+	return data
+}
+
+func (a *testAuthenticator) sign(key crypto.Signer, data []byte) []byte {
+	digest := sha256.Sum256(data)
+	var signature []byte
+	var err error
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		signature, err = key.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	require.NoError(a.t, err)
+
+	return signature
+}
+
+// register answers challenge with a new credential attested in format. The
+// packed statement is signed by the credential itself unless an attestation
+// key is given, and is a self attestation unless a certificate is given too.
+func (a *testAuthenticator) register(challenge, format string, attestationKey crypto.Signer, attestationCert []byte) WebAuthnAttestationResponse {
+	clientData := testClientData(a.t, "webauthn.create", challenge, testWebAuthnOrigin)
+	authData := a.authenticatorData(true)
+
+	statement := map[string]interface{}{}
+	if format == AttestationFormatPacked {
+		clientDataHash := sha256.Sum256(clientData)
+		signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+		if attestationCert == nil {
+			if attestationKey == nil {
+				attestationKey = a.key
+			}
+			statement = map[string]interface{}{"alg": a.algorithm, "sig": a.sign(attestationKey, signed)}
+		} else {
+			statement = map[string]interface{}{"alg": COSEAlgorithmES256, "sig": a.sign(attestationKey, signed), "x5c": [][]byte{attestationCert}}
+		}
+	}
+
+	attestationObject, err := cbor.Marshal(map[string]interface{}{"fmt": format, "attStmt": statement, "authData": authData})
+	require.NoError(a.t, err)
+
+	return WebAuthnAttestationResponse{
+		ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+		AttestationObject: base64.RawURLEncoding.EncodeToString(attestationObject),
+	}
+}
+
+// assert answers challenge for the user with the given ID, incrementing the
+// signature counter.
+func (a *testAuthenticator) assert(challenge string, userId int64) WebAuthnAssertionResponse {
+	a.signCount++
+	clientData := testClientData(a.t, "webauthn.get", challenge, testWebAuthnOrigin)
+	authData := a.authenticatorData(false)
+	clientDataHash := sha256.Sum256(clientData)
+
+	return WebAuthnAssertionResponse{
+		ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+		AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+		Signature:         base64.RawURLEncoding.EncodeToString(a.sign(a.key, append(authData, clientDataHash[:]...))),
+		UserHandle:        webAuthnUserHandle(userId),
+	}
+}
+
+func testClientData(t *testing.T, ceremony, challenge, origin string) []byte {
+	raw, err := json.Marshal(webAuthnClientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	require.NoError(t, err)
+
+	return raw
+}
+
+// testAttestationCertificate creates a packed attestation certificate for
+// the authenticator model aaguid.
+func testAttestationCertificate(t *testing.T, aaguid []byte) (crypto.Signer, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ext, err := asn1.Marshal(aaguid)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		Subject:         pkix.Name{CommonName: "Test Authenticator", OrganizationalUnit: []string{"Authenticator Attestation"}},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: oidAAGUID, Value: ext}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return key, der
+}
+
+func testWebAuthn(t *testing.T) WebAuthnInterface {
+	w, err := InitWebAuthn(WebAuthnOptions{
+		RPID:    testWebAuthnRPID,
+		RPName:  "User Service",
+		Origins: []string{testWebAuthnOrigin},
+		Timeout: 5 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	return w
+}
+
+func TestInitWebAuthn(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		_, err := InitWebAuthn(WebAuthnOptions{RPID: testWebAuthnRPID, Origins: []string{testWebAuthnOrigin}})
+		require.NoError(t, err)
+	})
+
+	t.Run("success - disabled", func(t *testing.T) {
+		w, err := InitWebAuthn(WebAuthnOptions{})
+		require.NoError(t, err)
+
+		_, err = w.RequestOptions("challenge")
+		require.ErrorIs(t, err, ErrWebAuthnDisabled)
+	})
+
+	t.Run("failed - no origins", func(t *testing.T) {
+		_, err := InitWebAuthn(WebAuthnOptions{RPID: testWebAuthnRPID})
+		require.Error(t, err)
+	})
+}
+
+func TestWebAuthn_CreationOptions(t *testing.T) {
+	w := testWebAuthn(t)
+
+	t.Run("success", func(t *testing.T) {
+		user := model.User{Id: 10, FullName: "John Doe", PhoneNumber: "+6281234567890", Email: null.StringFrom("john@example.com")}
+
+		options, err := w.CreationOptions(user, "challenge", []string{"AQID"})
+		require.NoError(t, err)
+		require.Equal(t, WebAuthnCreationOptions{
+			RPID:               testWebAuthnRPID,
+			RPName:             "User Service",
+			UserID:             "MTA",
+			UserName:           "john@example.com",
+			UserDisplayName:    "John Doe",
+			Challenge:          "challenge",
+			Algorithms:         []int64{COSEAlgorithmES256, COSEAlgorithmEdDSA, COSEAlgorithmRS256},
+			Timeout:            5 * time.Minute,
+			ExcludeCredentials: []string{"AQID"},
+			ResidentKey:        "required",
+			UserVerification:   "required",
+			Attestation:        AttestationFormatNone,
+		}, options)
+	})
+
+	t.Run("success - no email", func(t *testing.T) {
+		options, err := w.CreationOptions(model.User{Id: 10, PhoneNumber: "+6281234567890"}, "challenge", nil)
+		require.NoError(t, err)
+		require.Equal(t, "+6281234567890", options.UserName)
+	})
+
+	t.Run("failed - disabled", func(t *testing.T) {
+		_, err := WebAuthn{}.CreationOptions(model.User{Id: 10}, "challenge", nil)
+		require.ErrorIs(t, err, ErrWebAuthnDisabled)
+	})
+}
+
+func TestWebAuthn_Challenge(t *testing.T) {
+	w := testWebAuthn(t)
+
+	t.Run("success", func(t *testing.T) {
+		clientData := testClientData(t, "webauthn.get", "challenge", testWebAuthnOrigin)
+
+		challenge, err := w.Challenge(base64.RawURLEncoding.EncodeToString(clientData))
+		require.NoError(t, err)
+		require.Equal(t, "challenge", challenge)
+	})
+
+	t.Run("failed - no challenge", func(t *testing.T) {
+		clientData := testClientData(t, "webauthn.get", "", testWebAuthnOrigin)
+
+		_, err := w.Challenge(base64.RawURLEncoding.EncodeToString(clientData))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - not base64url", func(t *testing.T) {
+		_, err := w.Challenge(`{"challenge":"challenge"}`)
+		require.Error(t, err)
+	})
+}
+
+func TestWebAuthn_VerifyRegistration(t *testing.T) {
+	w := testWebAuthn(t)
+
+	for name, algorithm := range testWebAuthnAlgorithms {
+		t.Run("success - none attestation "+name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t, algorithm)
+
+			credential, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatNone, nil, nil))
+			require.NoError(t, err)
+			require.Equal(t, WebAuthnCredential{
+				ID:                authenticator.id(),
+				PublicKey:         authenticator.publicKey(),
+				AAGUID:            "adce0002-35bc-c60a-648b-0b25f1f05503",
+				AttestationFormat: AttestationFormatNone,
+			}, credential)
+		})
+	}
+
+	t.Run("success - packed self attestation", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+
+		credential, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatPacked, nil, nil))
+		require.NoError(t, err)
+		require.Equal(t, authenticator.id(), credential.ID)
+		require.Equal(t, AttestationFormatPacked, credential.AttestationFormat)
+	})
+
+	t.Run("success - packed attestation certificate", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmEdDSA)
+		key, cert := testAttestationCertificate(t, testAAGUID)
+
+		credential, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatPacked, key, cert))
+		require.NoError(t, err)
+		require.Equal(t, authenticator.id(), credential.ID)
+	})
+
+	t.Run("failed - another challenge", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("other", AttestationFormatNone, nil, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another origin", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		response := authenticator.register("challenge", AttestationFormatNone, nil, nil)
+		response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(testClientData(t, "webauthn.create", "challenge", "https://evil.example.com"))
+
+		_, err := w.VerifyRegistration("challenge", response)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - assertion client data", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		response := authenticator.register("challenge", AttestationFormatNone, nil, nil)
+		response.ClientDataJSON = base64.RawURLEncoding.EncodeToString(testClientData(t, "webauthn.get", "challenge", testWebAuthnOrigin))
+
+		_, err := w.VerifyRegistration("challenge", response)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another relying party", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		authenticator.rpID = "evil.example.com"
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatNone, nil, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - user not verified", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		authenticator.flags = webAuthnFlagUserPresent
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatNone, nil, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - self attestation by another key", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		impostor := newTestAuthenticator(t, COSEAlgorithmES256)
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatPacked, impostor.key, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - attestation certificate of another model", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		key, cert := testAttestationCertificate(t, make([]byte, 16))
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatPacked, key, cert))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - unsupported attestation format", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+
+		_, err := w.VerifyRegistration("challenge", authenticator.register("challenge", "fido-u2f", nil, nil))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - disabled", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+
+		_, err := WebAuthn{}.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatNone, nil, nil))
+		require.ErrorIs(t, err, ErrWebAuthnDisabled)
+	})
+}
+
+func TestWebAuthn_VerifyAssertion(t *testing.T) {
+	w := testWebAuthn(t)
+
+	register := func(t *testing.T, authenticator *testAuthenticator) model.WebAuthnCredential {
+		credential, err := w.VerifyRegistration("challenge", authenticator.register("challenge", AttestationFormatNone, nil, nil))
+		require.NoError(t, err)
+
+		return model.WebAuthnCredential{Id: 3, UserId: 10, CredentialId: credential.ID, PublicKey: credential.PublicKey}
+	}
+
+	for name, algorithm := range testWebAuthnAlgorithms {
+		t.Run("success "+name, func(t *testing.T) {
+			authenticator := newTestAuthenticator(t, algorithm)
+			credential := register(t, authenticator)
+
+			signCount, err := w.VerifyAssertion("challenge", credential, authenticator.assert("challenge", 10))
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), signCount)
+
+			signCount, err = w.VerifyAssertion("next", credential, authenticator.assert("next", 10))
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), signCount)
+		})
+	}
+
+	t.Run("success - no user handle", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, authenticator)
+		response := authenticator.assert("challenge", 10)
+		response.UserHandle = ""
+
+		_, err := w.VerifyAssertion("challenge", credential, response)
+		require.NoError(t, err)
+	})
+
+	t.Run("failed - another challenge", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, authenticator)
+
+		_, err := w.VerifyAssertion("challenge", credential, authenticator.assert("other", 10))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - another user", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, authenticator)
+
+		_, err := w.VerifyAssertion("challenge", credential, authenticator.assert("challenge", 11))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - signed by another key", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, newTestAuthenticator(t, COSEAlgorithmES256))
+
+		_, err := w.VerifyAssertion("challenge", credential, authenticator.assert("challenge", 10))
+		require.Error(t, err)
+	})
+
+	t.Run("failed - tampered authenticator data", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, authenticator)
+		response := authenticator.assert("challenge", 10)
+		authenticator.signCount = 100
+		response.AuthenticatorData = base64.RawURLEncoding.EncodeToString(authenticator.authenticatorData(false))
+
+		_, err := w.VerifyAssertion("challenge", credential, response)
+		require.Error(t, err)
+	})
+
+	t.Run("failed - user not present", func(t *testing.T) {
+		authenticator := newTestAuthenticator(t, COSEAlgorithmES256)
+		credential := register(t, authenticator)
+		authenticator.flags = webAuthnFlagUserVerified
+
+		_, err := w.VerifyAssertion("challenge", credential, authenticator.assert("challenge", 10))
+		require.Error(t, err)
+	})
+}